@@ -1,17 +1,22 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"log/slog"
 	"os"
-	"os/user"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mikowitz/gotest-watch/internal"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -21,8 +26,53 @@ var (
 	runPattern  string
 	skipPattern string
 	count       int
-	clearScreen bool
-	color       bool
+	timeout     string
+	fuzzTime    string
+	clearScreen internal.ClearMode
+	color       colorMode
+	backend     colorBackend
+	quiet       bool
+	linePrefix  string
+	race        bool
+	failFast    bool
+	cover       bool
+	short       bool
+	autoShort   bool
+	pasteGuard  bool
+	singleKey   bool
+	noInput     bool
+	gitRoot     bool
+	summaryJSON bool
+	minFileSize int
+	slowest     int
+	altScreen   bool
+	skipCache   bool
+	deadlock    int
+	showPending bool
+	runnerName  string
+	exitOnPass  bool
+	quietPeriod int
+	reportFiles bool
+	outputFile  string
+	structured  bool
+	summary     bool
+	runBudget   time.Duration
+	webhookURL  string
+	junitPath   string
+	coverFile   string
+	failuresTo  string
+	eventsTo    string
+	controlPath string
+	httpAddr    string
+	ci          bool
+	runLogs     int
+	smartMode   bool
+	smartDeps   bool
+	since       string
+	vet         bool
+	vetStrict   bool
+	poll        string
+	exclude     []string
 )
 
 func setCmdFlags(cmd *cobra.Command) {
@@ -32,46 +82,289 @@ func setCmdFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&runPattern, "run", "r", "", "run tests that match this pattern")
 	cmd.Flags().StringVarP(&skipPattern, "skip", "s", "", "skip tests that match this pattern")
 	cmd.Flags().IntVarP(&count, "count", "n", 0, "number of times to run each test")
-	cmd.Flags().BoolVarP(&clearScreen, "cls", "l", false, "clear the screen before each test run")
-	cmd.Flags().BoolVarP(&color, "color", "c", false, "ANSI color output")
+	cmd.Flags().StringVar(&fuzzTime, "fuzztime", "", "how long the fuzz command fuzzes for (default: until interrupted)")
+	cmd.Flags().StringVar(&timeout, "timeout", "", "panic if a test binary runs longer than this duration (e.g. 30s)")
+	clearScreen = internal.ClearNever
+	cmd.Flags().VarP(&clearScreen, "cls", "l",
+		"clear the screen before each test run: always, never, or auto (only before runs started by file changes)")
+	cmd.Flags().Lookup("cls").NoOptDefVal = string(internal.ClearAlways)
+	color = colorAuto
+	cmd.Flags().VarP(&color, "color", "c",
+		"ANSI color output: auto (when writing to a terminal and NO_COLOR is unset), always or never")
+	cmd.Flags().Lookup("color").NoOptDefVal = colorAlways
+	backend = internal.ColorBackendInternal
+	cmd.Flags().Var(&backend, "color-backend",
+		"what colors test output: internal, richgo, gotest, or auto to use richgo when it is installed")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false,
+		"print only test output and the line ending each run, without the command, prompts or status messages")
+	cmd.Flags().BoolVar(&race, "race", false, "run tests with -race")
+	cmd.Flags().BoolVar(&failFast, "failfast", false, "run tests with -failfast")
+	cmd.Flags().BoolVar(&cover, "cover", false, "run tests with -cover")
+	cmd.Flags().BoolVar(&short, "short", false, "run tests with -short")
+	cmd.Flags().BoolVar(&autoShort, "auto-skip-long-tests", false, "add -short to runs triggered by file changes")
+	cmd.Flags().BoolVar(&pasteGuard, "run-on-paste-guard", false,
+		"ignore multi-line pastes unless they end with a blank line")
+	cmd.Flags().BoolVar(&singleKey, "single-key", false,
+		"act on single keypresses such as v, f and r without waiting for Enter")
+	cmd.Flags().BoolVar(&noInput, "no-input", false,
+		"never read commands or show the prompt, as when stdin is not a terminal, and keep watching")
+	cmd.Flags().BoolVar(&gitRoot, "test-path-relative-to-git-root", false,
+		"resolve the test path and watch root from the git repository root")
+	cmd.Flags().BoolVar(&summaryJSON, "summary-json", false, "print a compact JSON summary of each run to stderr")
+	cmd.Flags().IntVar(&minFileSize, "watch-min-file-size", 0, "ignore changes to files smaller than this many bytes")
+	cmd.Flags().IntVar(&slowest, "profile-summary", 0, "report the N slowest tests after each run")
+	cmd.Flags().BoolVar(&altScreen, "clear-screen-respect-scroll-region", false,
+		"use the alternate screen buffer so the terminal is restored on exit")
+	cmd.Flags().BoolVar(&skipCache, "first-run-skip-cache", false,
+		"run the initial tests with -count=1 to bypass the test cache")
+	cmd.Flags().IntVar(&deadlock, "deadlock-detector", 0,
+		"log a goroutine dump if a run makes no progress for this many seconds")
+	cmd.Flags().BoolVar(&showPending, "prompt-shows-pending-changes", false,
+		"mark the prompt with * while file changes are waiting to trigger a run")
+	cmd.Flags().StringVar(&runnerName, "runner", "go", "name of the registered test runner to use")
+	cmd.Flags().BoolVar(&exitOnPass, "exit-on-first-pass", false, "exit the first time a test run passes")
+	cmd.Flags().IntVar(&quietPeriod, "watch-quiet-period-before-run", 0,
+		"milliseconds without file changes before a run starts")
+	cmd.Flags().BoolVar(&reportFiles, "report-changed-files-in-summary", false,
+		"list the files that triggered each run after it finishes")
+	cmd.Flags().StringVar(&outputFile, "output-file", "", "also append test output to this file")
+	cmd.Flags().IntVar(&runLogs, "run-logs", 0,
+		"save the output of each run under .gotest-watch/runs, keeping the newest N runs")
+	cmd.Flags().BoolVar(&structured, "structured-summary", false,
+		"run tests with -json and print per-package results after each run")
+	cmd.Flags().BoolVar(&summary, "summary-line", false,
+		"print a count of passed, failed and skipped tests after each run")
+	cmd.Flags().StringVar(&webhookURL, "webhook-url", "", "post the result of each run to this URL as JSON")
+	cmd.Flags().StringVar(&coverFile, "coverage-file", "", "write a coverage profile of each run to this file")
+	cmd.Flags().StringVar(&failuresTo, "failure-locations", "",
+		"write each failure as file:line: message, for editors, to stderr or this file")
+	cmd.Flags().Lookup("failure-locations").NoOptDefVal = "stderr"
+	cmd.Flags().StringVar(&eventsTo, "json-events", "",
+		"write newline-delimited JSON events to stdout, moving the usual output to stderr, or to this file descriptor")
+	cmd.Flags().Lookup("json-events").NoOptDefVal = "stdout"
+	cmd.Flags().StringVar(&controlPath, "control-socket", "",
+		"also read commands sent with gotest-watch send from this unix socket")
+	cmd.Flags().Lookup("control-socket").NoOptDefVal = internal.DefaultControlSocket
+	cmd.Flags().StringVar(&httpAddr, "http", "",
+		"serve a JSON status endpoint with /status, /last-run and /run on this address")
+	cmd.Flags().Lookup("http").NoOptDefVal = internal.DefaultHTTPAddr
+	cmd.Flags().BoolVar(&ci, "ci", false,
+		"run the tests once without watching or reading commands, end with a summary line, and exit with their exit code")
+	cmd.Flags().StringVar(&junitPath, "junit", "",
+		"write a JUnit XML report of each run to this file, or to a new timestamped file in this directory")
+	cmd.Flags().DurationVar(&runBudget, "run-budget", 0, "warn when a run takes longer than this duration (e.g. 30s)")
+	cmd.Flags().BoolVar(&vet, "vet", false, "run go vet on the test path before each run")
+	cmd.Flags().BoolVar(&vetStrict, "vet-failure-skips-tests", false, "skip the test run when go vet reports problems")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil,
+		"directories to never watch, by name (e.g. vendor) or path from the project root; may be repeated")
+	cmd.Flags().StringVar(&poll, "poll", "",
+		"scan for file changes at this interval (e.g. 500ms) instead of relying on file system events")
+	cmd.Flags().BoolVar(&smartMode, "smart", false, "only test the packages containing changed files")
+	cmd.Flags().BoolVar(&smartDeps, "smart-include-dependents", false,
+		"in smart mode, also test packages that import the changed packages")
+	cmd.Flags().StringVar(&since, "since", "",
+		"test only the packages changed since this git ref, such as main, and the packages importing them")
+	cmd.Flags().StringVar(&linePrefix, "stdout-line-prefix", "", "string to prefix each line of test output with")
 }
 
 var gotestWatchCmd = func() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "gotest-watch",
+		Use:   "gotest-watch [packages]",
 		Short: "An interactive command line tool for running 'go test'",
 		Long: `An interactive command line tool for running 'go test'.
 It watches *.go files in your project for changes, and can be customized
-between runs to specify many of the flags that can be set for 'go test'`,
-		Args: cobra.NoArgs,
+between runs to specify many of the flags that can be set for 'go test'.
+Packages given as arguments, like those given to 'go test', are tested
+instead of the configured test path.`,
+		Args: packageArgs,
 		Run:  gotestWatch,
 	}
 
 	setCmdFlags(cmd)
+	cmd.AddCommand(onceCmd, precommitCmd, initCmd, sendCmd)
 	return cmd
 }()
 
-func gotestWatch(cmd *cobra.Command, args []string) {
-	internal.InitRegistry()
+var onceCmd = func() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "once [packages]",
+		Short: "Run the tests a single time, without watching, and exit with their exit code",
+		Long: `Run the tests a single time with the same settings, output and reports as
+each run while watching, then exit with the exit code of the test command.
+Nothing is watched and no commands are read.`,
+		Args: packageArgs,
+		Run:  runOnce,
+	}
 
-	// Create a cancellable context for graceful shutdown
-	ctx, _ := internal.SetupSignalHandler()
+	setCmdFlags(cmd)
+	return cmd
+}()
+
+var precommitCmd = func() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "precommit",
+		Short: "Test the packages staged for commit, for use in a git pre-commit hook",
+		Long: `Test the packages with Go files staged for commit, and the packages that
+import them, a single time with -failfast, showing only failures and a
+count of the results. Exits with the exit code of the test command, or 0
+when no Go files are staged, so that it can be run from
+.git/hooks/pre-commit. The same config file and flags apply as when
+watching.`,
+		Args: cobra.NoArgs,
+		Run:  runPrecommit,
+	}
+
+	setCmdFlags(cmd)
+	return cmd
+}()
+
+var initCmd = func() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write a .gotest-watch.yml with every setting and its default",
+		Long: `Write a commented .gotest-watch.yml to the current directory, with every
+supported setting set to its default. An existing file is left alone
+unless --force is given.`,
+		Args:          cobra.NoArgs,
+		RunE:          runInit,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().Bool("force", false, "overwrite an existing .gotest-watch.yml")
+	return cmd
+}()
+
+func runInit(cmd *cobra.Command, _ []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	force, _ := cmd.Flags().GetBool("force")
+	path, err := internal.WriteConfigTemplate(dir, force)
+	if err != nil {
+		return err
+	}
+	cmd.Printf("Wrote %s\n", path)
+	return nil
+}
+
+var sendCmd = func() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "send <command>",
+		Short: "Send a command to the gotest-watch running in this directory",
+		Long: `Send a command, such as "f" or "r TestFoo", to the gotest-watch running in
+this directory with --control-socket, as if it had been typed at its
+prompt. Several commands can be sent at once separated by ;.`,
+		Example:       `  gotest-watch send r TestFoo`,
+		Args:          cobra.MinimumNArgs(1),
+		RunE:          runSend,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().String("socket", "",
+		"control socket to send to (default: the controlSocket setting, or "+internal.DefaultControlSocket+")")
+	return cmd
+}()
+
+func runSend(cmd *cobra.Command, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	path, _ := cmd.Flags().GetString("socket")
+	if path == "" {
+		path = internal.LoadOrDefaultConfig(dir).GetControlSocket()
+	}
+	if path == "" {
+		path = internal.DefaultControlSocket
+	}
+	return internal.SendControl(controlSocketPath(path, dir), strings.Join(args, " "))
+}
+
+// controlSocketPath returns path, resolved from dir if it is relative.
+func controlSocketPath(path, dir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
 
+// packageArgs checks the packages given as arguments, which replace the
+// test path, so they can't be combined with --path.
+func packageArgs(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 && cmd.Flags().Lookup("path").Changed {
+		return errors.New("packages can't be given both as arguments and with --path")
+	}
+	return nil
+}
+
+// loadConfig returns the config for the working directory, with the
+// environment, flags and package arguments applied, along with the
+// directory it was loaded from and a snapshot of it from before they were.
+func loadConfig(cmd *cobra.Command, args []string) (*internal.TestConfig, string, *internal.TestConfig) {
 	// Get working directory for config lookup
-	root, err := os.Getwd()
+	configDir, err := os.Getwd()
 	if err != nil {
 		log.Println(err)
-		root = "."
+		configDir = "."
 	}
 
 	// Create test config from file or defaults
-	config := internal.LoadOrDefaultConfig(root)
+	config := internal.LoadOrDefaultConfig(configDir)
+	loaded := config.Snapshot()
+	setFlagsFromEnv(cmd)
+	if eventsTo != "" {
+		if err := openJSONEvents(eventsTo); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	overrideConfig(config, cmd)
+	overrideTestPath(config, args)
+	internal.EnableVirtualTerminal(os.Stdout)
+	if color == colorAuto {
+		internal.AdaptToOutput(config, os.Stdout)
+	}
+	return config, configDir, loaded
+}
+
+func gotestWatch(cmd *cobra.Command, args []string) {
+	// Create a cancellable context for graceful shutdown
+	ctx, _ := internal.SetupSignalHandler()
+
+	// CI mode can be set by the config files and the environment, so it
+	// is only known once the config is loaded
+	config, configDir, loaded := loadConfig(cmd, args)
+	if config.GetCI() {
+		os.Exit(runTestsOnce(ctx, prepareCI(config)))
+	}
+	internal.InitRegistry()
+
+	root := internal.ResolveRoot(config, configDir)
+
+	restoreScreen := internal.EnterAltScreen(os.Stdout, config)
+	defer restoreScreen()
+
+	restoreTerminal, isTerminal := func() {}, false
+	if !config.GetNoInput() {
+		restoreTerminal, isTerminal = internal.EnableTerminalInput(os.Stdin, os.Stderr, config)
+	}
+	defer restoreTerminal()
+	if !isTerminal {
+		// Without a terminal there is no one to type commands or read the
+		// prompt, so just keep watching
+		config.SetNoInput(true)
+	}
 
 	// Store config in context
 	ctx = internal.WithConfig(ctx, config)
 
 	logger := slog.New(slog.NewTextHandler(getLoggerDest(), nil))
+	ctx = internal.WithLogger(ctx, logger)
+	ctx = internal.WithWatchRequests(ctx)
+	ctx = internal.WithConfigReload(ctx, configDir, loaded)
 	logger.Log(ctx, slog.LevelInfo, "gotest-watch starting...")
 
 	cmdChan := make(chan internal.CommandMessage, 10)
@@ -84,14 +377,37 @@ func gotestWatch(cmd *cobra.Command, args []string) {
 
 	go internal.WatchFiles(ctx, root, fileChangeChan, startWatching)
 
+	if path := config.GetControlSocket(); path != "" {
+		err := internal.ListenControl(ctx, controlSocketPath(path, configDir), cmdChan, helpChan)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not listen for commands on the control socket: %v\n", err)
+		}
+	}
+	if addr := config.GetHTTPAddr(); addr != "" {
+		if err := internal.ServeStatus(ctx, addr, cmdChan); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not serve the status endpoint: %v\n", err)
+		}
+	}
+
 	// Start stdin reader in background
-	go internal.ReadStdin(ctx, os.Stdin, cmdChan, helpChan)
+	switch {
+	case config.GetNoInput():
+	case config.GetSingleKey():
+		go internal.ReadKeys(ctx, os.Stdin, os.Stdout, cmdChan, helpChan)
+	default:
+		go internal.ReadTerminal(ctx, os.Stdin, os.Stdout, cmdChan, helpChan)
+	}
 
-	fmt.Println("Running tests...")
-	internal.RunTests(ctx, testCompleteChan, nil, nil)
+	if !config.GetQuiet() {
+		fmt.Println("Running tests...")
+	}
+	internal.RunTests(internal.StartupContext(ctx), testCompleteChan, nil, nil)
 
 	select {
-	case <-testCompleteChan:
+	case result := <-testCompleteChan:
+		if result.Passed && config.GetExitOnFirstPass() {
+			return
+		}
 		close(startWatching)
 	case <-ctx.Done():
 		return
@@ -101,11 +417,86 @@ func gotestWatch(cmd *cobra.Command, args []string) {
 	internal.Dispatcher(ctx, fileChangeChan, cmdChan, helpChan, testCompleteChan)
 }
 
+// runOnce runs the tests a single time and exits with the test command's
+// exit code.
+func runOnce(cmd *cobra.Command, args []string) {
+	ctx, _ := internal.SetupSignalHandler()
+
+	config, _, _ := loadConfig(cmd, args)
+	if config.GetCI() {
+		prepareCI(config)
+	}
+	os.Exit(runTestsOnce(ctx, config))
+}
+
+// prepareCI sets config up for a run in CI and returns it. CI logs are read
+// from the top, and should end with the results.
+func prepareCI(config *internal.TestConfig) *internal.TestConfig {
+	config.SetClearScreen(internal.ClearNever)
+	config.SetSummaryLine(true)
+	return config
+}
+
+func runPrecommit(cmd *cobra.Command, _ []string) {
+	ctx, _ := internal.SetupSignalHandler()
+
+	config, _, _ := loadConfig(cmd, nil)
+	staged, err := preparePrecommit(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !staged {
+		if !config.GetQuiet() {
+			fmt.Println("No staged Go files to test")
+		}
+		return
+	}
+	os.Exit(runTestsOnce(ctx, config))
+}
+
+// preparePrecommit narrows config to the packages staged for commit, and
+// sets it up to stop at the first failure and report compactly. It reports
+// whether any packages are staged.
+func preparePrecommit(config *internal.TestConfig) (bool, error) {
+	packages, err := internal.StagedPackages(config)
+	if err != nil {
+		return false, fmt.Errorf("could not find staged packages: %w", err)
+	}
+	if len(packages) == 0 {
+		return false, nil
+	}
+	config.SetTestPath(strings.Join(packages, " "))
+	// The staged packages replace any other narrowing of the run
+	config.SetSince("")
+	config.SetFailFast(true)
+	config.SetQuietPass(true)
+	config.SetClearScreen(internal.ClearNever)
+	return true, nil
+}
+
+// runTestsOnce runs the tests a single time with config and returns the
+// exit code of the test command, once any reports have been delivered.
+func runTestsOnce(ctx context.Context, config *internal.TestConfig) int {
+	ctx = internal.WithConfig(ctx, config)
+
+	testCompleteChan := make(chan internal.TestCompleteMessage, 1)
+	go internal.RunTests(internal.StartupContext(ctx), testCompleteChan, nil, nil)
+	result := <-testCompleteChan
+
+	internal.WaitForDeliveries()
+	return result.ExitCode
+}
+
 func getLoggerDest() io.Writer {
-	usr, _ := user.Current()
-	logDir := filepath.Join(usr.HomeDir, ".local/state/gotest-watch")
+	stateHome, err := userStateHome(runtime.GOOS)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not find a directory for the log: %v\n", err)
+		return io.Discard
+	}
+	logDir := filepath.Join(stateHome, "gotest-watch")
 	if err := os.MkdirAll(logDir, 0o750); err != nil {
-		fmt.Printf("Could not find directory")
+		fmt.Fprintf(os.Stderr, "Warning: could not create the log directory: %v\n", err)
 		return io.Discard
 	}
 	if f, err := os.OpenFile(
@@ -119,6 +510,45 @@ func getLoggerDest() io.Writer {
 	}
 }
 
+// userStateHome returns the directory user state files, such as the log, go
+// in on goos: %LocalAppData% on Windows, and $XDG_STATE_HOME, or
+// ~/.local/state if it is not set, elsewhere.
+func userStateHome(goos string) (string, error) {
+	if goos == "windows" {
+		return os.UserCacheDir()
+	}
+	if stateHome := os.Getenv("XDG_STATE_HOME"); stateHome != "" {
+		return stateHome, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state"), nil
+}
+
+// openJSONEvents starts writing JSON events to target: stdout, in which case
+// everything else written to stdout goes to stderr instead so the events can
+// be read on their own, or the number of a file descriptor the caller left
+// open, such as 3.
+func openJSONEvents(target string) error {
+	if target == "stdout" {
+		internal.OpenJSONEvents(os.Stdout)
+		os.Stdout = os.Stderr
+		return nil
+	}
+	fd, err := strconv.Atoi(target)
+	if err != nil || fd < 0 {
+		return fmt.Errorf("invalid --json-events target %q (must be stdout or a file descriptor number)", target)
+	}
+	f := os.NewFile(uintptr(fd), "fd "+target)
+	if _, err := f.Stat(); err != nil {
+		return fmt.Errorf("file descriptor %d for --json-events is not open: %w", fd, err)
+	}
+	internal.OpenJSONEvents(f)
+	return nil
+}
+
 func Execute() {
 	if err := gotestWatchCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -130,6 +560,97 @@ func Run() {
 	Execute()
 }
 
+// envPrefix starts the names of the environment variables that set flags,
+// such as GOTEST_WATCH_VERBOSE for --verbose.
+const envPrefix = "GOTEST_WATCH_"
+
+// envVarName returns the environment variable that sets the named flag.
+func envVarName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// colorMode is the value of the --color flag. A bare --color means always,
+// and true and false, from when the flag was a boolean, mean always and
+// never.
+type colorMode string
+
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+func (m *colorMode) String() string {
+	return string(*m)
+}
+
+func (m *colorMode) Set(value string) error {
+	switch value {
+	case colorAuto, colorAlways, colorNever:
+		*m = colorMode(value)
+	case "true":
+		*m = colorAlways
+	case "false":
+		*m = colorNever
+	default:
+		return fmt.Errorf("must be auto, always or never")
+	}
+	return nil
+}
+
+func (m *colorMode) Type() string {
+	return "mode"
+}
+
+// colorBackend is the value of the --color-backend flag.
+type colorBackend string
+
+func (b *colorBackend) String() string {
+	return string(*b)
+}
+
+func (b *colorBackend) Set(value string) error {
+	switch value {
+	case internal.ColorBackendInternal, internal.ColorBackendRichgo, internal.ColorBackendGotest,
+		internal.ColorBackendAuto:
+		*b = colorBackend(value)
+	default:
+		return fmt.Errorf("must be internal, richgo, gotest or auto")
+	}
+	return nil
+}
+
+func (b *colorBackend) Type() string {
+	return "backend"
+}
+
+// setFlagsFromEnv sets each flag not given on the command line from its
+// environment variable, so that the environment overrides the config files
+// but not the command line. Invalid values are skipped with a warning.
+func setFlagsFromEnv(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed || flag.Name == "help" {
+			return
+		}
+		name := envVarName(flag.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		if err := cmd.Flags().Set(flag.Name, value); err != nil {
+			log.Printf("Warning: ignoring %s=%s: %v", name, value, err)
+		}
+	})
+}
+
+// overrideTestPath sets the test path to the packages given as arguments,
+// if there are any.
+func overrideTestPath(config *internal.TestConfig, args []string) {
+	if len(args) > 0 {
+		config.SetTestPath(strings.Join(args, " "))
+	}
+}
+
 func overrideConfig(config *internal.TestConfig, cmd *cobra.Command) {
 	if cmd.Flags().Lookup("cmd").Changed {
 		config.SetCommandBase(strings.Fields(commandBase))
@@ -149,10 +670,147 @@ func overrideConfig(config *internal.TestConfig, cmd *cobra.Command) {
 	if cmd.Flags().Lookup("count").Changed {
 		config.SetCount(count)
 	}
+	if cmd.Flags().Lookup("timeout").Changed {
+		config.SetTimeout(timeout)
+	}
+	if cmd.Flags().Lookup("fuzztime").Changed {
+		config.SetFuzzTime(fuzzTime)
+	}
 	if cmd.Flags().Lookup("cls").Changed {
 		config.SetClearScreen(clearScreen)
 	}
 	if cmd.Flags().Lookup("color").Changed {
-		config.SetColor(color)
+		switch color {
+		case colorAlways:
+			config.SetColor(true)
+		case colorNever:
+			config.SetColor(false)
+		}
+	}
+	if cmd.Flags().Lookup("color-backend").Changed {
+		config.SetColorBackend(string(backend))
+	}
+	if cmd.Flags().Lookup("quiet").Changed {
+		config.SetQuiet(quiet)
+	}
+	if cmd.Flags().Lookup("race").Changed {
+		config.SetRace(race)
+	}
+	if cmd.Flags().Lookup("failfast").Changed {
+		config.SetFailFast(failFast)
+	}
+	if cmd.Flags().Lookup("cover").Changed {
+		config.SetCover(cover)
+	}
+	if cmd.Flags().Lookup("short").Changed {
+		config.SetShort(short)
+	}
+	if cmd.Flags().Lookup("auto-skip-long-tests").Changed {
+		config.SetAutoShort(autoShort)
+	}
+	if cmd.Flags().Lookup("run-on-paste-guard").Changed {
+		config.SetPasteGuard(pasteGuard)
+	}
+	if cmd.Flags().Lookup("single-key").Changed {
+		config.SetSingleKey(singleKey)
+	}
+	if cmd.Flags().Lookup("no-input").Changed {
+		config.SetNoInput(noInput)
+	}
+	if cmd.Flags().Lookup("test-path-relative-to-git-root").Changed {
+		config.SetGitRootRelative(gitRoot)
+	}
+	if cmd.Flags().Lookup("summary-json").Changed {
+		config.SetSummaryJSON(summaryJSON)
+	}
+	if cmd.Flags().Lookup("watch-min-file-size").Changed {
+		config.SetWatchMinFileSize(minFileSize)
+	}
+	if cmd.Flags().Lookup("profile-summary").Changed {
+		config.SetProfileSummary(slowest)
+	}
+	if cmd.Flags().Lookup("clear-screen-respect-scroll-region").Changed {
+		config.SetAltScreen(altScreen)
+	}
+	if cmd.Flags().Lookup("first-run-skip-cache").Changed {
+		config.SetFirstRunSkipCache(skipCache)
+	}
+	if cmd.Flags().Lookup("deadlock-detector").Changed {
+		config.SetDeadlockTimeout(deadlock)
+	}
+	if cmd.Flags().Lookup("prompt-shows-pending-changes").Changed {
+		config.SetPromptPending(showPending)
+	}
+	if cmd.Flags().Lookup("runner").Changed {
+		config.SetRunner(runnerName)
+	}
+	if cmd.Flags().Lookup("exit-on-first-pass").Changed {
+		config.SetExitOnFirstPass(exitOnPass)
+	}
+	if cmd.Flags().Lookup("ci").Changed {
+		config.SetCI(ci)
+	}
+	if cmd.Flags().Lookup("watch-quiet-period-before-run").Changed {
+		config.SetWatchQuietPeriod(quietPeriod)
+	}
+	if cmd.Flags().Lookup("report-changed-files-in-summary").Changed {
+		config.SetReportChangedFiles(reportFiles)
+	}
+	if cmd.Flags().Lookup("output-file").Changed {
+		config.SetOutputFile(outputFile)
+	}
+	if cmd.Flags().Lookup("run-logs").Changed {
+		config.SetRunLogs(runLogs)
+	}
+	if cmd.Flags().Lookup("structured-summary").Changed {
+		config.SetStructuredSummary(structured)
+	}
+	if cmd.Flags().Lookup("summary-line").Changed {
+		config.SetSummaryLine(summary)
+	}
+	if cmd.Flags().Lookup("webhook-url").Changed {
+		config.SetWebhookURL(webhookURL)
+	}
+	if cmd.Flags().Lookup("junit").Changed {
+		config.SetJUnitPath(junitPath)
+	}
+	if cmd.Flags().Lookup("control-socket").Changed {
+		config.SetControlSocket(controlPath)
+	}
+	if cmd.Flags().Lookup("http").Changed {
+		config.SetHTTPAddr(httpAddr)
+	}
+	if cmd.Flags().Lookup("coverage-file").Changed {
+		config.SetCoverageFile(coverFile)
+	}
+	if cmd.Flags().Lookup("failure-locations").Changed {
+		config.SetFailureLocations(failuresTo)
+	}
+	if cmd.Flags().Lookup("run-budget").Changed {
+		config.SetRunBudget(runBudget.String())
+	}
+	if cmd.Flags().Lookup("vet").Changed {
+		config.SetVet(vet)
+	}
+	if cmd.Flags().Lookup("vet-failure-skips-tests").Changed {
+		config.SetVetSkipsTests(vetStrict)
+	}
+	if cmd.Flags().Lookup("exclude").Changed {
+		config.SetExclude(exclude)
+	}
+	if cmd.Flags().Lookup("poll").Changed {
+		config.SetPoll(poll)
+	}
+	if cmd.Flags().Lookup("smart").Changed {
+		config.SetSmartMode(smartMode)
+	}
+	if cmd.Flags().Lookup("smart-include-dependents").Changed {
+		config.SetSmartDependents(smartDeps)
+	}
+	if cmd.Flags().Lookup("since").Changed {
+		config.SetSince(since)
+	}
+	if cmd.Flags().Lookup("stdout-line-prefix").Changed {
+		config.SetLinePrefix(linePrefix)
 	}
 }
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/mikowitz/gotest-watch/internal"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // createTestCommand creates a fresh command with all flags for isolated testing
@@ -24,7 +27,7 @@ func TestOverrideConfig(t *testing.T) {
 		config.SetRunPattern("TestFoo")
 		config.SetSkipPattern("TestBar")
 		config.SetCount(5)
-		config.ClearScreen = true
+		config.ClearScreen = internal.ClearAlways
 		config.Color = true
 		config.SetCommandBase([]string{"richgo", "test"})
 		config.SetTestPath("./pkg/...")
@@ -39,7 +42,7 @@ func TestOverrideConfig(t *testing.T) {
 		assert.Equal(t, "TestFoo", config.GetRunPattern())
 		assert.Equal(t, "TestBar", config.GetSkipPattern())
 		assert.Equal(t, 5, config.GetCount())
-		assert.True(t, config.GetClearScreen())
+		assert.Equal(t, internal.ClearAlways, config.GetClearScreen())
 		assert.True(t, config.GetColor())
 		assert.Equal(t, []string{"richgo", "test"}, config.GetCommandBase())
 		assert.Equal(t, "./pkg/...", config.GetTestPath())
@@ -51,7 +54,7 @@ func TestOverrideConfig(t *testing.T) {
 		config.SetRunPattern("TestFoo")
 		config.SetSkipPattern("TestBar")
 		config.SetCount(5)
-		config.ClearScreen = true
+		config.ClearScreen = internal.ClearAlways
 		config.Color = true
 		config.SetCommandBase([]string{"richgo", "test"})
 		config.SetTestPath("./pkg/...")
@@ -68,7 +71,7 @@ func TestOverrideConfig(t *testing.T) {
 		// All other config values should be preserved
 		assert.True(t, config.GetVerbose())
 		assert.Equal(t, "TestBar", config.GetSkipPattern())
-		assert.True(t, config.GetClearScreen())
+		assert.Equal(t, internal.ClearAlways, config.GetClearScreen())
 		assert.True(t, config.GetColor())
 		assert.Equal(t, []string{"richgo", "test"}, config.GetCommandBase())
 		assert.Equal(t, "./pkg/...", config.GetTestPath())
@@ -77,7 +80,7 @@ func TestOverrideConfig(t *testing.T) {
 	t.Run("can set boolean flags to false explicitly", func(t *testing.T) {
 		config := internal.NewTestConfig()
 		config.SetVerbose(true)
-		config.ClearScreen = true
+		config.ClearScreen = internal.ClearAlways
 		config.Color = true
 
 		cmd := createTestCommand()
@@ -88,7 +91,7 @@ func TestOverrideConfig(t *testing.T) {
 		// Explicitly set to false should override
 		assert.False(t, config.GetVerbose())
 		// Non-set booleans should preserve config values
-		assert.True(t, config.GetClearScreen())
+		assert.Equal(t, internal.ClearAlways, config.GetClearScreen())
 		assert.True(t, config.GetColor())
 	})
 
@@ -114,7 +117,7 @@ func TestOverrideConfig(t *testing.T) {
 		config.SetRunPattern("TestFoo")
 		config.SetSkipPattern("TestBar")
 		config.SetCount(5)
-		config.ClearScreen = true
+		config.ClearScreen = internal.ClearAlways
 		config.Color = true
 		config.SetCommandBase([]string{"richgo", "test"})
 		config.SetTestPath("./pkg/...")
@@ -138,7 +141,7 @@ func TestOverrideConfig(t *testing.T) {
 		assert.Equal(t, "TestCLI", config.GetRunPattern())
 		assert.Equal(t, "TestSkipCLI", config.GetSkipPattern())
 		assert.Equal(t, 1, config.GetCount())
-		assert.False(t, config.GetClearScreen())
+		assert.Equal(t, internal.ClearNever, config.GetClearScreen())
 		assert.False(t, config.GetColor())
 		assert.Equal(t, []string{"go", "test", "-tags", "integration"}, config.GetCommandBase())
 		assert.Equal(t, "./cli/...", config.GetTestPath())
@@ -312,38 +315,49 @@ func TestCountFlag(t *testing.T) {
 func TestClearScreenFlag(t *testing.T) {
 	t.Run("no flag preserves config value", func(t *testing.T) {
 		config := internal.NewTestConfig()
-		config.ClearScreen = true
+		config.ClearScreen = internal.ClearAlways
 
 		cmd := createTestCommand()
 		_ = cmd.ParseFlags([]string{})
 
 		overrideConfig(config, cmd)
 
-		assert.True(t, config.GetClearScreen())
+		assert.Equal(t, internal.ClearAlways, config.GetClearScreen())
 	})
 
 	t.Run("flag overrides config value", func(t *testing.T) {
 		config := internal.NewTestConfig()
-		config.ClearScreen = false
+		config.ClearScreen = internal.ClearNever
 
 		cmd := createTestCommand()
 		_ = cmd.ParseFlags([]string{"--cls"})
 
 		overrideConfig(config, cmd)
 
-		assert.True(t, config.GetClearScreen())
+		assert.Equal(t, internal.ClearAlways, config.GetClearScreen())
 	})
 
 	t.Run("explicit false overrides true config", func(t *testing.T) {
 		config := internal.NewTestConfig()
-		config.ClearScreen = true
+		config.ClearScreen = internal.ClearAlways
 
 		cmd := createTestCommand()
 		_ = cmd.ParseFlags([]string{"--cls=false"})
 
 		overrideConfig(config, cmd)
 
-		assert.False(t, config.GetClearScreen())
+		assert.Equal(t, internal.ClearNever, config.GetClearScreen())
+	})
+
+	t.Run("accepts auto mode", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		require.NoError(t, cmd.ParseFlags([]string{"--cls=auto"}))
+
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, internal.ClearAuto, config.GetClearScreen())
 	})
 }
 
@@ -371,6 +385,25 @@ func TestColorFlag(t *testing.T) {
 
 		assert.True(t, config.GetColor())
 	})
+
+	t.Run("accepts auto, always and never", func(t *testing.T) {
+		for flag, want := range map[string]bool{"--color=always": true, "--color=never": false, "--color=auto": true} {
+			config := internal.NewTestConfig()
+			config.Color = true
+
+			cmd := createTestCommand()
+			require.NoError(t, cmd.ParseFlags([]string{flag}))
+
+			overrideConfig(config, cmd)
+
+			assert.Equal(t, want, config.GetColor(), flag)
+		}
+	})
+
+	t.Run("rejects other modes", func(t *testing.T) {
+		cmd := createTestCommand()
+		assert.Error(t, cmd.ParseFlags([]string{"--color=sometimes"}))
+	})
 }
 
 func TestCommandBaseFlag(t *testing.T) {
@@ -436,3 +469,833 @@ func TestTestPathFlag(t *testing.T) {
 		assert.Equal(t, "./cli/...", config.GetTestPath())
 	})
 }
+
+func TestStdoutLinePrefixFlag(t *testing.T) {
+	t.Run("no flag preserves config value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.SetLinePrefix("[api] ")
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{})
+
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, "[api] ", config.GetLinePrefix())
+	})
+
+	t.Run("flag overrides config value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.SetLinePrefix("[api] ")
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--stdout-line-prefix=[web] "})
+
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, "[web] ", config.GetLinePrefix())
+	})
+}
+
+func TestAutoSkipLongTestsFlag(t *testing.T) {
+	t.Run("no flag preserves config value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.SetAutoShort(true)
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetAutoShort())
+	})
+
+	t.Run("flag enables auto short", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--auto-skip-long-tests"})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetAutoShort())
+	})
+}
+
+func TestRunOnPasteGuardFlag(t *testing.T) {
+	t.Run("no flag preserves config value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.SetPasteGuard(true)
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetPasteGuard())
+	})
+
+	t.Run("flag enables paste guard", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--run-on-paste-guard"})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetPasteGuard())
+	})
+}
+
+func TestSingleKeyFlag(t *testing.T) {
+	t.Run("no flag preserves config value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.SetSingleKey(true)
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetSingleKey())
+	})
+
+	t.Run("flag enables single-key mode", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--single-key"})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetSingleKey())
+	})
+}
+
+func TestTestPathRelativeToGitRootFlag(t *testing.T) {
+	t.Run("no flag preserves config value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.SetGitRootRelative(true)
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetGitRootRelative())
+	})
+
+	t.Run("flag enables git root resolution", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--test-path-relative-to-git-root"})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetGitRootRelative())
+	})
+}
+
+func TestSummaryJSONFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--summary-json"})
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetSummaryJSON())
+}
+
+func TestWatchMinFileSizeFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--watch-min-file-size=16"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, 16, config.GetWatchMinFileSize())
+}
+
+func TestProfileSummaryFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--profile-summary=5"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, 5, config.GetProfileSummary())
+}
+
+func TestClearScreenRespectScrollRegionFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--clear-screen-respect-scroll-region"})
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetAltScreen())
+}
+
+func TestFirstRunSkipCacheFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--first-run-skip-cache"})
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetFirstRunSkipCache())
+}
+
+func TestDeadlockDetectorFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--deadlock-detector=300"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, 300, config.GetDeadlockTimeout())
+}
+
+func TestRunnerFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--runner=custom"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, "custom", config.GetRunner())
+}
+
+func TestExitOnFirstPassFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--exit-on-first-pass"})
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetExitOnFirstPass())
+}
+
+func TestOutputFileFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--output-file=test-output.log"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, "test-output.log", config.GetOutputFile())
+}
+
+func TestStructuredSummaryFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--structured-summary"})
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetStructuredSummary())
+}
+
+func TestSummaryLineFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--summary-line"})
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetSummaryLine())
+}
+
+func TestWebhookURLFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--webhook-url", "http://localhost:8080/runs"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, "http://localhost:8080/runs", config.GetWebhookURL())
+}
+
+func TestJUnitFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--junit", "reports/"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, "reports/", config.GetJUnitPath())
+	assert.Equal(t, "go test ./... -json", config.BuildCommand())
+}
+
+func TestRunLogsFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--run-logs=20"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, 20, config.GetRunLogs())
+}
+
+func TestRunBudgetFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--run-budget", "90s"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, "1m30s", config.GetRunBudget())
+}
+
+func TestQuietFlag(t *testing.T) {
+	t.Run("no flag preserves config value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.SetQuiet(true)
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetQuiet())
+	})
+
+	t.Run("flag overrides config value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"-q"})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetQuiet())
+	})
+}
+
+func TestOnceCommand(t *testing.T) {
+	once, _, err := gotestWatchCmd.Find([]string{"once"})
+	require.NoError(t, err)
+	assert.Equal(t, onceCmd, once)
+
+	t.Run("accepts the same flags as watching", func(t *testing.T) {
+		assert.NotNil(t, once.Flags().Lookup("verbose"))
+		assert.NotNil(t, once.Flags().Lookup("junit"))
+	})
+
+	t.Run("accepts packages", func(t *testing.T) {
+		assert.NoError(t, once.Args(once, []string{"./internal/...", "./cmd/..."}))
+	})
+}
+
+func TestPrecommitCommand(t *testing.T) {
+	precommit, _, err := gotestWatchCmd.Find([]string{"precommit"})
+	require.NoError(t, err)
+	assert.Equal(t, precommitCmd, precommit)
+
+	t.Run("accepts the same flags as watching", func(t *testing.T) {
+		assert.NotNil(t, precommit.Flags().Lookup("verbose"))
+		assert.NotNil(t, precommit.Flags().Lookup("junit"))
+	})
+
+	t.Run("rejects packages", func(t *testing.T) {
+		assert.Error(t, precommit.Args(precommit, []string{"./internal/..."}))
+	})
+
+	t.Run("fails outside a git repository", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.SetWorkingDir(t.TempDir())
+
+		staged, err := preparePrecommit(config)
+
+		require.Error(t, err)
+		assert.False(t, staged)
+		assert.Equal(t, "./...", config.GetTestPath())
+	})
+}
+
+func TestPackageArgs(t *testing.T) {
+	t.Run("replace the test path", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.SetTestPath("./pkg/...")
+
+		overrideTestPath(config, []string{"./internal/...", "./cmd/..."})
+
+		assert.Equal(t, "./internal/... ./cmd/...", config.GetTestPath())
+	})
+
+	t.Run("leave the test path alone when there are none", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.SetTestPath("./pkg/...")
+
+		overrideTestPath(config, nil)
+
+		assert.Equal(t, "./pkg/...", config.GetTestPath())
+	})
+
+	t.Run("are accepted without --path", func(t *testing.T) {
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"-v"})
+
+		assert.NoError(t, packageArgs(cmd, []string{"./internal/..."}))
+	})
+
+	t.Run("can't be combined with --path", func(t *testing.T) {
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--path=./cmd/..."})
+
+		assert.Error(t, packageArgs(cmd, []string{"./internal/..."}))
+		assert.NoError(t, packageArgs(cmd, nil))
+	})
+}
+
+func TestNoInputFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--no-input"})
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetNoInput())
+}
+
+func TestControlSocketFlag(t *testing.T) {
+	t.Run("defaults to the project's socket when given without a value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--control-socket"})
+
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, internal.DefaultControlSocket, config.GetControlSocket())
+	})
+
+	t.Run("accepts a path", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--control-socket=/tmp/gw.sock"})
+
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, "/tmp/gw.sock", config.GetControlSocket())
+	})
+}
+
+func TestHTTPFlag(t *testing.T) {
+	t.Run("defaults to localhost when given without a value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--http"})
+
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, internal.DefaultHTTPAddr, config.GetHTTPAddr())
+	})
+
+	t.Run("accepts an address", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--http=:8080"})
+
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, ":8080", config.GetHTTPAddr())
+	})
+}
+
+func TestControlSocketPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("/project", ".gotest-watch", "control.sock"),
+		controlSocketPath(internal.DefaultControlSocket, "/project"))
+	assert.Equal(t, "/tmp/gw.sock", controlSocketPath("/tmp/gw.sock", "/project"))
+}
+
+func TestSendCommand(t *testing.T) {
+	command, _, err := gotestWatchCmd.Find([]string{"send"})
+	require.NoError(t, err)
+	assert.Equal(t, sendCmd, command)
+
+	t.Run("has a socket flag", func(t *testing.T) {
+		assert.NotNil(t, command.Flags().Lookup("socket"))
+	})
+
+	t.Run("requires a command", func(t *testing.T) {
+		assert.Error(t, command.Args(command, nil))
+		assert.NoError(t, command.Args(command, []string{"r", "TestFoo"}))
+	})
+}
+
+func TestColorBackendFlag(t *testing.T) {
+	t.Run("sets the color backend", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		require.NoError(t, cmd.ParseFlags([]string{"--color-backend=auto"}))
+
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, internal.ColorBackendAuto, config.GetColorBackend())
+	})
+
+	t.Run("rejects unknown backends", func(t *testing.T) {
+		cmd := createTestCommand()
+		assert.Error(t, cmd.ParseFlags([]string{"--color-backend=rainbow"}))
+	})
+}
+
+func TestSinceFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--since=main"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, "main", config.GetSince())
+}
+
+func TestFailureLocationsFlag(t *testing.T) {
+	t.Run("defaults to stderr when given without a value", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--failure-locations"})
+
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, "stderr", config.GetFailureLocations())
+	})
+
+	t.Run("accepts a file", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--failure-locations=.gotest-watch/failures"})
+
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, ".gotest-watch/failures", config.GetFailureLocations())
+	})
+}
+
+func TestJSONEventsFlag(t *testing.T) {
+	t.Run("defaults to stdout when given without a value", func(t *testing.T) {
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--json-events"})
+
+		assert.Equal(t, "stdout", cmd.Flags().Lookup("json-events").Value.String())
+	})
+
+	t.Run("rejects targets that aren't stdout or a file descriptor", func(t *testing.T) {
+		err := openJSONEvents("events.json")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file descriptor number")
+	})
+
+	t.Run("rejects file descriptors that aren't open", func(t *testing.T) {
+		assert.Error(t, openJSONEvents("987654"))
+	})
+}
+
+func TestInitCommand(t *testing.T) {
+	command, _, err := gotestWatchCmd.Find([]string{"init"})
+	require.NoError(t, err)
+	assert.Equal(t, initCmd, command)
+
+	t.Run("has a force flag", func(t *testing.T) {
+		force := command.Flags().Lookup("force")
+		require.NotNil(t, force)
+		assert.Equal(t, "false", force.DefValue)
+	})
+
+	t.Run("rejects arguments", func(t *testing.T) {
+		assert.Error(t, command.Args(command, []string{"config.yml"}))
+	})
+}
+
+func TestCoverageFileFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--coverage-file=reports/cover.out"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, "reports/cover.out", config.GetCoverageFile())
+}
+
+func TestCIFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	require.NoError(t, cmd.ParseFlags([]string{"--ci"}))
+	t.Cleanup(func() { ci = false })
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetCI())
+}
+
+func TestLoadConfig_CI(t *testing.T) {
+	t.Run("from the environment", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		t.Setenv("GOTEST_WATCH_CI", "true")
+		t.Cleanup(func() { ci = false })
+
+		cmd := createTestCommand()
+		require.NoError(t, cmd.ParseFlags([]string{}))
+		config, _, _ := loadConfig(cmd, nil)
+
+		assert.True(t, config.GetCI(), "the run should go through the once path")
+		prepareCI(config)
+		assert.Equal(t, internal.ClearNever, config.GetClearScreen())
+		assert.True(t, config.GetSummaryLine())
+	})
+
+	t.Run("from the config file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".gotest-watch.yml"), []byte("ci: true\n"), 0o600))
+		t.Chdir(dir)
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		cmd := createTestCommand()
+		require.NoError(t, cmd.ParseFlags([]string{}))
+		config, _, _ := loadConfig(cmd, nil)
+
+		assert.True(t, config.GetCI(), "the run should go through the once path")
+	})
+
+	t.Run("the flag overrides the config file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".gotest-watch.yml"), []byte("ci: true\n"), 0o600))
+		t.Chdir(dir)
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		cmd := createTestCommand()
+		require.NoError(t, cmd.ParseFlags([]string{"--ci=false"}))
+		config, _, _ := loadConfig(cmd, nil)
+
+		assert.False(t, config.GetCI())
+	})
+}
+
+func TestSmartFlags(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--smart", "--smart-include-dependents"})
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetSmartMode())
+	assert.True(t, config.GetSmartDependents())
+}
+
+func TestTimeoutFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--timeout=90s"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, "90s", config.GetTimeout())
+}
+
+func TestVetFlags(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--vet", "--vet-failure-skips-tests"})
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetVet())
+	assert.True(t, config.GetVetSkipsTests())
+}
+
+func TestExcludeFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--exclude=vendor,testdata", "--exclude", "internal/gen"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, []string{"vendor", "testdata", "internal/gen"}, config.GetExclude())
+}
+
+func TestPollFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--poll=500ms"})
+
+	overrideConfig(config, cmd)
+
+	assert.Equal(t, "500ms", config.GetPoll())
+}
+
+func TestRaceFailFastCoverFlags(t *testing.T) {
+	t.Run("flags enable the modes", func(t *testing.T) {
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--race", "--failfast", "--cover"})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetRace())
+		assert.True(t, config.GetFailFast())
+		assert.True(t, config.GetCover())
+		assert.Equal(t, "go test ./... -race -failfast -cover", config.BuildCommand())
+	})
+
+	t.Run("false flags override true config", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.ToggleRace()
+		config.ToggleFailFast()
+		config.ToggleCover()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--race=false", "--failfast=false", "--cover=false"})
+
+		overrideConfig(config, cmd)
+
+		assert.False(t, config.GetRace())
+		assert.False(t, config.GetFailFast())
+		assert.False(t, config.GetCover())
+	})
+
+	t.Run("unset flags preserve config values", func(t *testing.T) {
+		config := internal.NewTestConfig()
+		config.ToggleRace()
+		config.ToggleCover()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{})
+
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetRace())
+		assert.False(t, config.GetFailFast())
+		assert.True(t, config.GetCover())
+	})
+}
+
+func TestShortFlag(t *testing.T) {
+	config := internal.NewTestConfig()
+
+	cmd := createTestCommand()
+	_ = cmd.ParseFlags([]string{"--short"})
+
+	overrideConfig(config, cmd)
+
+	assert.True(t, config.GetShort())
+}
+
+func TestSetFlagsFromEnv(t *testing.T) {
+	t.Run("environment sets flags that were not given", func(t *testing.T) {
+		t.Setenv("GOTEST_WATCH_VERBOSE", "true")
+		t.Setenv("GOTEST_WATCH_PATH", "./internal/...")
+		t.Setenv("GOTEST_WATCH_CMD", "richgo test")
+		t.Setenv("GOTEST_WATCH_STDOUT_LINE_PREFIX", "| ")
+		t.Setenv("GOTEST_WATCH_EXCLUDE", "vendor,testdata")
+		config := internal.NewTestConfig()
+		config.SetCount(2)
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{})
+		setFlagsFromEnv(cmd)
+		overrideConfig(config, cmd)
+
+		assert.True(t, config.GetVerbose())
+		assert.Equal(t, "./internal/...", config.GetTestPath())
+		assert.Equal(t, []string{"richgo", "test"}, config.GetCommandBase())
+		assert.Equal(t, "| ", config.GetLinePrefix())
+		assert.Equal(t, []string{"vendor", "testdata"}, config.GetExclude())
+		assert.Equal(t, 2, config.GetCount(), "settings without a variable should be kept")
+	})
+
+	t.Run("command line flags override the environment", func(t *testing.T) {
+		t.Setenv("GOTEST_WATCH_RUN", "TestFromEnv")
+		t.Setenv("GOTEST_WATCH_COUNT", "3")
+		config := internal.NewTestConfig()
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{"--run=TestFromFlag"})
+		setFlagsFromEnv(cmd)
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, "TestFromFlag", config.GetRunPattern())
+		assert.Equal(t, 3, config.GetCount())
+	})
+
+	t.Run("invalid values are ignored", func(t *testing.T) {
+		t.Setenv("GOTEST_WATCH_COUNT", "many")
+		config := internal.NewTestConfig()
+		config.SetCount(4)
+
+		cmd := createTestCommand()
+		_ = cmd.ParseFlags([]string{})
+		setFlagsFromEnv(cmd)
+		overrideConfig(config, cmd)
+
+		assert.Equal(t, 4, config.GetCount())
+	})
+}
+
+func TestEnvVarName(t *testing.T) {
+	assert.Equal(t, "GOTEST_WATCH_VERBOSE", envVarName("verbose"))
+	assert.Equal(t, "GOTEST_WATCH_TEST_PATH_RELATIVE_TO_GIT_ROOT", envVarName("test-path-relative-to-git-root"))
+}
+
+func TestUserStateHome(t *testing.T) {
+	t.Run("prefers XDG_STATE_HOME", func(t *testing.T) {
+		stateHome := t.TempDir()
+		t.Setenv("XDG_STATE_HOME", stateHome)
+
+		for _, goos := range []string{"linux", "darwin"} {
+			dir, err := userStateHome(goos)
+			require.NoError(t, err)
+			assert.Equal(t, stateHome, dir, goos)
+		}
+	})
+
+	t.Run("uses ~/.local/state otherwise", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("XDG_STATE_HOME", "")
+		t.Setenv("HOME", home)
+
+		dir, err := userStateHome("linux")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(home, ".local", "state"), dir)
+	})
+
+	t.Run("fails without a home directory", func(t *testing.T) {
+		t.Setenv("XDG_STATE_HOME", "")
+		t.Setenv("HOME", "")
+
+		_, err := userStateHome("linux")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Conditions under which a Notification posts the result of a run.
+const (
+	NotifyOnFailure  = "failure"
+	NotifyOnRecovery = "recovery"
+	NotifyOnPass     = "pass"
+)
+
+// defaultNotifyOn is when a Notification posts if it doesn't say.
+var defaultNotifyOn = []string{NotifyOnFailure, NotifyOnRecovery}
+
+// maxFailedTestsPosted limits how many failed tests are named in a chat
+// message, so that a broken build doesn't flood the channel.
+const maxFailedTestsPosted = 10
+
+// Notification posts the results of runs to a Slack or Discord channel
+// through an incoming webhook.
+type Notification struct {
+	Slack   string   `yaml:"slack"`   // Slack incoming webhook URL
+	Discord string   `yaml:"discord"` // Discord webhook URL
+	On      []string `yaml:"on"`      // Which runs to post: failure, recovery and pass (default: failure and recovery)
+}
+
+// String names the service n posts to, leaving out the URL as it is a
+// secret.
+func (n Notification) String() string {
+	return n.service() + " on " + strings.Join(n.conditions(), ", ")
+}
+
+// service returns the name of the service n posts to.
+func (n Notification) service() string {
+	if n.Discord != "" {
+		return "discord"
+	}
+	return "slack"
+}
+
+// conditions returns when n posts.
+func (n Notification) conditions() []string {
+	if len(n.On) == 0 {
+		return defaultNotifyOn
+	}
+	return n.On
+}
+
+// wants reports whether n posts about a run that passed or not, and that
+// recovered from a failure of the run before it.
+func (n Notification) wants(passed, recovered bool) bool {
+	on := n.conditions()
+	switch {
+	case !passed:
+		return slices.Contains(on, NotifyOnFailure)
+	case recovered && slices.Contains(on, NotifyOnRecovery):
+		return true
+	}
+	return slices.Contains(on, NotifyOnPass)
+}
+
+// payload returns the JSON body that posts text to n's service.
+func (n Notification) payload(text string) (string, any) {
+	if n.Discord != "" {
+		return n.Discord, map[string]string{"content": text}
+	}
+	return n.Slack, map[string]string{"text": text}
+}
+
+// check returns what is wrong with n, or "" if nothing is.
+func (n Notification) check() string {
+	if (n.Slack == "") == (n.Discord == "") {
+		return "each notification needs either a slack or a discord webhook URL"
+	}
+	for _, condition := range n.On {
+		switch condition {
+		case NotifyOnFailure, NotifyOnRecovery, NotifyOnPass:
+		default:
+			return fmt.Sprintf("unknown notification condition %q (must be failure, recovery or pass)", condition)
+		}
+	}
+	return ""
+}
+
+// chatText returns the message posted to a chat channel about a run, which
+// has the title and text of its desktop notification with room for more of
+// the tests that failed.
+func chatText(message runMessage) string {
+	return message.title + "\n" + message.text(maxFailedTestsPosted)
+}
+
+// postNotifications posts message to each of notifications that wants to
+// hear about the run, without waiting for the posts to be delivered.
+func postNotifications(notifications []Notification, message runMessage) {
+	text := chatText(message)
+	for _, n := range notifications {
+		if !n.wants(message.passed, message.recovered) {
+			continue
+		}
+		url, payload := n.payload(text)
+		deliveries.Add(1)
+		go func() {
+			defer deliveries.Done()
+			if err := postWebhook(context.Background(), url, payload); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not post to %s: %v\n", n.service(), err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNotification_Wants tests which runs each condition posts
+func TestNotification_Wants(t *testing.T) {
+	tests := []struct {
+		name      string
+		on        []string
+		passed    bool
+		recovered bool
+		expected  bool
+	}{
+		{name: "failures by default", passed: false, expected: true},
+		{name: "recoveries by default", passed: true, recovered: true, expected: true},
+		{name: "not other passes by default", passed: true, expected: false},
+		{name: "every pass", on: []string{NotifyOnPass}, passed: true, expected: true},
+		{name: "only recoveries", on: []string{NotifyOnRecovery}, passed: false, expected: false},
+		{name: "only failures", on: []string{NotifyOnFailure}, passed: true, recovered: true, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := Notification{Slack: "https://hooks.slack.com/x", On: tt.on}
+			assert.Equal(t, tt.expected, n.wants(tt.passed, tt.recovered))
+		})
+	}
+}
+
+// TestNotification_Check tests that each notification needs exactly one URL and known conditions
+func TestNotification_Check(t *testing.T) {
+	assert.Empty(t, Notification{Slack: "https://hooks.slack.com/x"}.check())
+	assert.NotEmpty(t, Notification{}.check())
+	assert.NotEmpty(t, Notification{Slack: "https://a", Discord: "https://b"}.check())
+	assert.NotEmpty(t, Notification{Discord: "https://b", On: []string{"always"}}.check())
+}
+
+// TestNotification_String tests that the webhook URL is not shown
+func TestNotification_String(t *testing.T) {
+	n := Notification{Discord: "https://discord.com/api/webhooks/1/secret"}
+
+	assert.Equal(t, "discord on failure, recovery", n.String())
+}
+
+// TestChatText tests that the message names the failed tests, up to a limit
+func TestChatText(t *testing.T) {
+	failed := make([]string, 12)
+	for i := range failed {
+		failed[i] = "TestCase" + string(rune('A'+i))
+	}
+	message := newRunMessage(RunSummary{Pass: 3, Fail: 12, Elapsed: 1.5}, failed, false)
+
+	text := chatText(message)
+
+	assert.Contains(t, text, "gotest-watch: FAIL\n")
+	assert.Contains(t, text, "- TestCaseA\n")
+	assert.Contains(t, text, "- TestCaseJ\nand 2 more")
+	assert.NotContains(t, text, "TestCaseK")
+}
+
+// TestRunMessageText tests that desktop notifications name fewer failed tests than chat messages from the same text
+func TestRunMessageText(t *testing.T) {
+	message := newRunMessage(RunSummary{Fail: 4, Elapsed: 1.5}, []string{"TestA", "TestB", "TestC", "TestD"}, false)
+
+	assert.Equal(t, "✓ 0 passed ✗ 4 failed ⊘ 0 skipped in 1.5s\n- TestA\n- TestB\n- TestC\nand 1 more",
+		message.text(maxFailedTestsNotified))
+	assert.Equal(t, message.title+"\n"+message.text(maxFailedTestsPosted), chatText(message))
+}
+
+// TestNewRunMessage_Recovered tests that a pass after a failure is announced as a recovery
+func TestNewRunMessage_Recovered(t *testing.T) {
+	message := newRunMessage(RunSummary{Pass: 3, OK: true}, nil, true)
+
+	assert.Equal(t, "gotest-watch: PASS (recovered)", message.title)
+	assert.True(t, message.recovered)
+	assert.False(t, newRunMessage(RunSummary{Fail: 1}, []string{"TestA"}, true).recovered)
+}
+
+// TestPostNotifications tests that each service gets the message in its own format
+func TestPostNotifications(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string]map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		bodies[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	message := newRunMessage(RunSummary{Fail: 1}, []string{"TestParse"}, false)
+	postNotifications([]Notification{
+		{Slack: server.URL + "/slack"},
+		{Discord: server.URL + "/discord"},
+		{Slack: server.URL + "/passes", On: []string{NotifyOnPass}},
+	}, message)
+	WaitForDeliveries()
+
+	text := chatText(message)
+	assert.Equal(t, map[string]map[string]string{
+		"/slack":   {"text": text},
+		"/discord": {"content": text},
+	}, bodies)
+}
+
+// TestRunTests_PostsRecovery tests that a passing run after a failing one is posted as a recovery, with its passes counted
+func TestRunTests_PostsRecovery(t *testing.T) {
+	var mu sync.Mutex
+	var posted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		posted = append(posted, body["text"])
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	tempDir := setupTestModule(t, "package testmodule\n\nimport \"testing\"\n\nfunc TestPasses(t *testing.T) {}\n")
+
+	config := NewTestConfig()
+	config.WorkingDir = tempDir
+	history.record(runRecord{passed: false})
+	config.SetNotifications([]Notification{{Slack: server.URL}})
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		select {
+		case result := <-testCompleteChan:
+			require.True(t, result.Passed, stdoutBuf.String())
+		case <-time.After(30 * time.Second):
+			t.Fatal("TestCompleteMessage was not sent within timeout")
+		}
+	})
+	WaitForDeliveries()
+
+	require.Len(t, posted, 1)
+	assert.Contains(t, posted[0], "gotest-watch: PASS (recovered)")
+	assert.Contains(t, posted[0], "✓ 1 passed", "passes should be counted without -v")
+}
+
+// TestRunTests_CancelledRunsAreNotPosted tests that cancelled runs are neither posted nor taken as the failure a later pass recovers from
+func TestRunTests_CancelledRunsAreNotPosted(t *testing.T) {
+	var mu sync.Mutex
+	var posted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		posted = append(posted, body["text"])
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	saved := history
+	history = &runHistory{}
+	t.Cleanup(func() { history = saved })
+	history.record(runRecord{passed: true})
+
+	initRegistry()
+	RegisterRunner("sleeping", commandRunner{"sleep", "10"})
+	config := NewTestConfig()
+	config.SetRunner("sleeping")
+	config.SetNotifications([]Notification{{Slack: server.URL}})
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+		waitForTestCompletion(t, testCompleteChan)
+	})
+	WaitForDeliveries()
+
+	tempDir := setupTestModule(t, "package testmodule\n\nimport \"testing\"\n\nfunc TestPasses(t *testing.T) {}\n")
+	config.SetRunner("")
+	config.WorkingDir = tempDir
+	captureStdout(t, func() {
+		go RunTests(WithConfig(context.Background(), config), testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+	WaitForDeliveries()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Empty(t, posted, "neither the cancelled run nor the pass after it should be posted")
+}
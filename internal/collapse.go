@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// packageTrailer matches the line `go test` prints when it finishes a
+// package, such as "ok  	pkg	0.01s", "FAIL	pkg [build failed]" or
+// "?   	pkg	[no test files]".
+var packageTrailer = regexp.MustCompile(`^(ok|FAIL|\?)\s+(\S+)`)
+
+// packageOutput holds back the output of each package until its trailer
+// shows whether it passed, so that passing packages can be collapsed to
+// their trailer.
+type packageOutput struct {
+	sync.Mutex
+	held []string
+	// collapsed maps each package that passed in the last run to the
+	// output that was hidden
+	collapsed map[string][]string
+}
+
+// collapsedOutput is shared by the test runner and the show command.
+var collapsedOutput = &packageOutput{}
+
+// reset forgets the output of the previous run.
+func (p *packageOutput) reset() {
+	p.Lock()
+	defer p.Unlock()
+	p.held = nil
+	p.collapsed = nil
+}
+
+// collapse is a decoder for test output, which may be several lines, that
+// shows the output of a package once it finishes: in full if it failed, or
+// as just its trailer if it passed.
+func (p *packageOutput) collapse(output string) (string, bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	var shown []string
+	for _, line := range strings.Split(output, "\n") {
+		m := packageTrailer.FindStringSubmatch(line)
+		if m == nil {
+			p.held = append(p.held, line)
+			continue
+		}
+		if m[1] == "ok" {
+			if len(p.held) > 0 {
+				if p.collapsed == nil {
+					p.collapsed = map[string][]string{}
+				}
+				p.collapsed[m[2]] = p.held
+			}
+		} else {
+			shown = append(shown, p.held...)
+		}
+		shown = append(shown, line)
+		p.held = nil
+	}
+	if len(shown) == 0 {
+		return "", false
+	}
+	return strings.Join(shown, "\n"), true
+}
+
+// flush returns the output held back when a run ended without a trailer to
+// show it, such as when the run was interrupted.
+func (p *packageOutput) flush() []string {
+	p.Lock()
+	defer p.Unlock()
+	held := p.held
+	p.held = nil
+	return held
+}
+
+// packages returns the sorted packages whose output was collapsed.
+func (p *packageOutput) packages() []string {
+	p.Lock()
+	defer p.Unlock()
+	packages := make([]string, 0, len(p.collapsed))
+	for pkg := range p.collapsed {
+		packages = append(packages, pkg)
+	}
+	slices.Sort(packages)
+	return packages
+}
+
+// get returns the collapsed output of pkg, which is either the package's
+// full import path or a unique suffix of it, such as internal or
+// ./internal.
+func (p *packageOutput) get(pkg string) ([]string, error) {
+	p.Lock()
+	defer p.Unlock()
+	if lines, ok := p.collapsed[pkg]; ok {
+		return lines, nil
+	}
+
+	suffix := "/" + strings.TrimPrefix(strings.TrimPrefix(pkg, "."), "/")
+	var matches []string
+	for name := range p.collapsed {
+		if strings.HasSuffix(name, suffix) {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no collapsed output for %s", pkg)
+	case 1:
+		return p.collapsed[matches[0]], nil
+	}
+	slices.Sort(matches)
+	return nil, fmt.Errorf("%s matches several packages: %s", pkg, strings.Join(matches, ", "))
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPackageOutput_CollapsesPassingPackages tests that passing packages are shown as their trailer and failing ones in full
+func TestPackageOutput_CollapsesPassingPackages(t *testing.T) {
+	p := &packageOutput{}
+	lines := []string{
+		"=== RUN   TestPasses",
+		"--- PASS: TestPasses (0.00s)",
+		"PASS",
+		"ok  \texample.com/app/internal\t0.01s",
+		"?   \texample.com/app/cmd\t[no test files]",
+		"=== RUN   TestFails",
+		"    a_test.go:9: boom",
+		"--- FAIL: TestFails (0.00s)",
+		"FAIL",
+		"FAIL\texample.com/app/other\t0.01s",
+		"ok  \texample.com/app/quiet\t(cached)",
+	}
+
+	var shown []string
+	for _, line := range lines {
+		if output, ok := p.collapse(line); ok {
+			shown = append(shown, output)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"ok  \texample.com/app/internal\t0.01s",
+		"?   \texample.com/app/cmd\t[no test files]",
+		"=== RUN   TestFails\n    a_test.go:9: boom\n--- FAIL: TestFails (0.00s)\nFAIL\nFAIL\texample.com/app/other\t0.01s",
+		"ok  \texample.com/app/quiet\t(cached)",
+	}, shown)
+	assert.Equal(t, []string{"example.com/app/internal"}, p.packages())
+
+	collapsed, err := p.get("example.com/app/internal")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"=== RUN   TestPasses", "--- PASS: TestPasses (0.00s)", "PASS"}, collapsed)
+}
+
+// TestPackageOutput_CollapsesMultilineOutput tests that output decoded into several lines is split at package trailers
+func TestPackageOutput_CollapsesMultilineOutput(t *testing.T) {
+	p := &packageOutput{}
+
+	output, ok := p.collapse("PASS\nok  \texample.com/app\t0.01s\nstill running")
+	require.True(t, ok)
+	assert.Equal(t, "ok  \texample.com/app\t0.01s", output)
+	assert.Equal(t, []string{"still running"}, p.flush())
+	assert.Empty(t, p.flush())
+}
+
+// TestPackageOutput_GetMatchesSuffix tests that a collapsed package can be named by the end of its import path
+func TestPackageOutput_GetMatchesSuffix(t *testing.T) {
+	p := &packageOutput{collapsed: map[string][]string{
+		"example.com/app/internal":     {"internal output"},
+		"example.com/app/api/handlers": {"api handlers"},
+		"example.com/app/web/handlers": {"web handlers"},
+	}}
+
+	for _, name := range []string{"internal", "./internal", "app/internal"} {
+		lines, err := p.get(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, []string{"internal output"}, lines, name)
+	}
+
+	lines, err := p.get("./api/handlers")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api handlers"}, lines)
+
+	_, err = p.get("handlers")
+	assert.EqualError(t, err,
+		"handlers matches several packages: example.com/app/api/handlers, example.com/app/web/handlers")
+
+	_, err = p.get("nal")
+	assert.EqualError(t, err, "no collapsed output for nal")
+}
+
+// TestPackageOutput_Reset tests that reset forgets the previous run
+func TestPackageOutput_Reset(t *testing.T) {
+	p := &packageOutput{}
+	p.collapse("PASS")
+	p.collapse("ok  \texample.com/app\t0.01s")
+	p.collapse("=== RUN   TestNext")
+
+	p.reset()
+
+	assert.Empty(t, p.packages())
+	assert.Empty(t, p.flush())
+}
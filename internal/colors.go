@@ -0,0 +1,257 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// colorTheme holds the SGR parameters used for each kind of output line.
+// Empty fields use the default colors.
+type colorTheme struct {
+	pass     string
+	fail     string
+	skip     string
+	location string
+	text     string
+}
+
+// colorThemes are the built-in themes that colorTheme can name.
+var colorThemes = map[string]colorTheme{
+	"default": {},
+	// colorblind avoids telling results apart by red and green alone
+	"colorblind": {
+		pass:     "1;38;5;33",
+		fail:     "1;38;5;208",
+		skip:     "1;38;5;220",
+		location: "1;38;5;141",
+	},
+	// monochrome uses text attributes only, for terminals without color
+	"monochrome": {
+		pass:     "1",
+		fail:     "1;7",
+		skip:     "2",
+		location: "4",
+		text:     "0",
+	},
+}
+
+// colorNames are the colors that can be named in the colors setting, with
+// their SGR parameters.
+var colorNames = map[string]string{
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+}
+
+// newColorTheme returns the built-in theme called name, with the colors in
+// overrides, keyed by pass, fail, skip, location or text, replacing its
+// own. Unknown themes, keys and colors are ignored, as they are reported
+// when the config is loaded.
+func newColorTheme(name string, overrides map[string]string) colorTheme {
+	theme := colorThemes[name]
+	for key, value := range overrides {
+		code, err := parseColor(value)
+		if err != nil {
+			continue
+		}
+		if field := theme.field(key); field != nil {
+			*field = code
+		}
+	}
+	return theme
+}
+
+// colorTheme returns the theme tc's output is colored with.
+func (tc *TestConfig) colorTheme() colorTheme {
+	return newColorTheme(tc.GetColorTheme(), tc.GetColors())
+}
+
+// field returns the field of t for the kind of line named key, or nil if
+// there is none.
+func (t *colorTheme) field(key string) *string {
+	switch key {
+	case "pass":
+		return &t.pass
+	case "fail":
+		return &t.fail
+	case "skip":
+		return &t.skip
+	case "location":
+		return &t.location
+	case "text":
+		return &t.text
+	}
+	return nil
+}
+
+// parseColor returns the SGR parameters for value, which is a color name
+// such as red, a 256-color palette index such as 196, or a truecolor hex
+// code such as #00ff00. Colors are bold, like the defaults.
+func parseColor(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if code, ok := colorNames[value]; ok {
+		return "1;" + code, nil
+	}
+	if hex, ok := strings.CutPrefix(value, "#"); ok {
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return "", fmt.Errorf("invalid color %q (hex colors look like #00ff00)", value)
+		}
+		return fmt.Sprintf("1;38;2;%d;%d;%d", rgb>>16, rgb>>8&0xff, rgb&0xff), nil
+	}
+	if index, err := strconv.Atoi(value); err == nil {
+		if index < 0 || index > 255 {
+			return "", fmt.Errorf("invalid color %q (palette colors are 0 to 255)", value)
+		}
+		return fmt.Sprintf("1;38;5;%d", index), nil
+	}
+	return "", fmt.Errorf("invalid color %q (must be a name, a number from 0 to 255, or #rrggbb)", value)
+}
+
+// code returns the SGR parameters that t uses in place of colorizer, one of
+// the default colors.
+func (t colorTheme) code(colorizer string) string {
+	var code string
+	switch colorizer {
+	case Green:
+		code = t.pass
+	case Red:
+		code = t.fail
+	case Yellow:
+		code = t.skip
+	case Magenta:
+		code = t.location
+	case White:
+		code = t.text
+	}
+	if code == "" {
+		return colorizer
+	}
+	return code
+}
+
+// paint colors output with t's replacement for colorizer.
+func (t colorTheme) paint(output, colorizer string) string {
+	return colorizeWith(output, t.code(colorizer))
+}
+
+// colorize colors output by what it contains.
+func (t colorTheme) colorize(output string) string {
+	return t.paint(output, selectColorizer(output))
+}
+
+// Color backends, which colorBackend chooses between.
+const (
+	// ColorBackendInternal colors test output with the color theme.
+	ColorBackendInternal = "internal"
+	// ColorBackendRichgo pipes test output through richgo, which colors and
+	// decorates it.
+	ColorBackendRichgo = "richgo"
+	// ColorBackendGotest runs rakyll's gotest in place of go test, which
+	// colors its output as it runs.
+	ColorBackendGotest = "gotest"
+	// ColorBackendAuto uses richgo when it is installed, and the color
+	// theme otherwise.
+	ColorBackendAuto = "auto"
+)
+
+// colorWrapper returns the color backend other than the color theme that
+// tc's run should color its output with, or "" for the color theme.
+// Coloring is only handed over for runs of plain go test, and to gotest only
+// when it runs locally and its output isn't read as -json, which it leaves
+// uncolored. Callers must hold tc's lock.
+func (tc *TestConfig) colorWrapper() string {
+	if !tc.Color || len(tc.CommandBase) < 2 || tc.CommandBase[0] != "go" || tc.CommandBase[1] != "test" {
+		return ""
+	}
+	switch tc.ColorBackend {
+	case ColorBackendRichgo:
+		return ColorBackendRichgo
+	case ColorBackendGotest:
+		if tc.wantsJSON() || (tc.Runner != "" && tc.Runner != defaultRunnerName) {
+			return ""
+		}
+		return ColorBackendGotest
+	case ColorBackendAuto:
+		if _, err := exec.LookPath(ColorBackendRichgo); err == nil {
+			return ColorBackendRichgo
+		}
+	}
+	return ""
+}
+
+// delegateColor makes tc's run color its output with its color wrapper, if
+// it has one. tc must be a snapshot for a single run.
+func delegateColor(tc *TestConfig) {
+	switch tc.colorWrapper() {
+	case ColorBackendRichgo:
+		// go test is still run as it is, so that its output can be read,
+		// and only what is shown is piped through richgo
+		tc.colorFilter = []string{ColorBackendRichgo, "testfilter"}
+	case ColorBackendGotest:
+		tc.CommandBase = append([]string{ColorBackendGotest}, tc.CommandBase[2:]...)
+		if tc.Env == nil {
+			tc.Env = map[string]string{}
+		}
+		// gotest leaves output to pipes uncolored unless it appears to be
+		// running on one of the CI services it knows of
+		if _, ok := tc.Env["CI"]; !ok {
+			tc.Env["CI"] = "circleci"
+		}
+		tc.colorDelegated = true
+	}
+}
+
+// colorFilter is a program, such as richgo testfilter, that the output of a
+// run is written through to be colored on its way to the terminal.
+type colorFilter struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+}
+
+// startColorFilter starts argv with its output going to w.
+func startColorFilter(argv []string, w io.Writer) (*colorFilter, error) {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	// richgo leaves output to pipes uncolored unless told otherwise
+	cmd.Env = append(os.Environ(), "RICHGO_FORCE_COLOR=1")
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &colorFilter{cmd: cmd, in: in}, nil
+}
+
+func (f *colorFilter) Write(p []byte) (int, error) {
+	return f.in.Write(p)
+}
+
+// Close waits for the filter to write out everything written to it.
+func (f *colorFilter) Close() error {
+	if err := f.in.Close(); err != nil {
+		return err
+	}
+	return f.cmd.Wait()
+}
+
+// ansiEscape matches the SGR sequences that color output.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// stripColor removes any color from line.
+func stripColor(line string) string {
+	return ansiEscape.ReplaceAllString(line, "")
+}
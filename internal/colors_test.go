@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"red", "1;31"},
+		{" Cyan ", "1;36"},
+		{"196", "1;38;5;196"},
+		{"0", "1;38;5;0"},
+		{"#00ff00", "1;38;2;0;255;0"},
+		{"#FF8800", "1;38;2;255;136;0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseColor(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, value := range []string{"256", "-1", "#00ff0", "#gggggg", "purple", ""} {
+		t.Run("rejects "+value, func(t *testing.T) {
+			_, err := parseColor(value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestColorTheme(t *testing.T) {
+	t.Run("the default theme keeps the default colors", func(t *testing.T) {
+		theme := newColorTheme("default", nil)
+		for _, color := range []string{Red, Green, Yellow, Magenta, White} {
+			assert.Equal(t, color, theme.code(color))
+		}
+		assert.Equal(t, colorizeOutput("FAIL\tpkg"), theme.colorize("FAIL\tpkg"))
+	})
+
+	t.Run("overrides replace the theme's colors", func(t *testing.T) {
+		theme := newColorTheme("colorblind", map[string]string{"fail": "196", "bogus": "red", "skip": "nope"})
+		assert.Equal(t, "1;38;5;196", theme.code(Red))
+		assert.Equal(t, "1;38;5;33", theme.code(Green))
+		assert.Equal(t, "1;38;5;220", theme.code(Yellow), "invalid colors should be ignored")
+		assert.Equal(t, White, theme.code(White))
+	})
+
+	t.Run("colors lines by what they contain", func(t *testing.T) {
+		theme := newColorTheme("", map[string]string{"pass": "#00ff00"})
+		assert.Equal(t, "\033[1;38;2;0;255;0mok  \tpkg\033[0m", theme.colorize("ok  \tpkg"))
+	})
+
+	t.Run("is read from the config", func(t *testing.T) {
+		config := NewTestConfig()
+		config.ColorTheme = "monochrome"
+		config.Colors = map[string]string{"location": "blue"}
+		theme := config.colorTheme()
+		assert.Equal(t, "1;7", theme.code(Red))
+		assert.Equal(t, "1;34", theme.code(Magenta))
+	})
+}
+
+func TestColorWrapper(t *testing.T) {
+	t.Run("richgo colors plain go test runs", func(t *testing.T) {
+		config := NewTestConfig()
+		config.SetColorBackend(ColorBackendRichgo)
+
+		assert.Empty(t, config.colorWrapper(), "nothing to color without color")
+
+		config.SetColor(true)
+		assert.Equal(t, "richgo", config.colorWrapper())
+
+		config.SetQuietPass(true)
+		config.SetRunner("ssh devbox:src/app")
+		assert.Equal(t, "richgo", config.colorWrapper(), "richgo only colors what is shown")
+	})
+
+	t.Run("auto uses richgo only when it is installed", func(t *testing.T) {
+		config := NewTestConfig()
+		config.SetColor(true)
+		config.SetColorBackend(ColorBackendAuto)
+
+		t.Setenv("PATH", t.TempDir())
+		assert.Empty(t, config.colorWrapper())
+
+		installFakeCommands(t, map[string]string{"richgo": "exit 0"})
+		assert.Equal(t, "richgo", config.colorWrapper())
+	})
+
+	t.Run("gotest colors local go test runs", func(t *testing.T) {
+		config := NewTestConfig()
+		config.SetColor(true)
+		config.SetColorBackend(ColorBackendGotest)
+		assert.Equal(t, "gotest", config.colorWrapper())
+
+		config.SetRunner(defaultRunnerName)
+		assert.Equal(t, "gotest", config.colorWrapper())
+	})
+
+	t.Run("the color theme is used otherwise", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			backend   string
+			configure func(*TestConfig)
+		}{
+			{"internal backend", ColorBackendInternal, func(*TestConfig) {}},
+			{"other commands", ColorBackendRichgo, func(tc *TestConfig) { tc.SetCommandBase([]string{"gotestsum"}) }},
+			{"gotest with -json output", ColorBackendGotest, func(tc *TestConfig) { tc.SetQuietPass(true) }},
+			{"gotest with docker", ColorBackendGotest, func(tc *TestConfig) { tc.SetRunner(dockerRunnerName) }},
+			{"gotest over ssh", ColorBackendGotest, func(tc *TestConfig) { tc.SetRunner("ssh devbox:src/app") }},
+		}
+		for _, tt := range tests {
+			config := NewTestConfig()
+			config.SetColor(true)
+			config.SetColorBackend(tt.backend)
+			tt.configure(config)
+
+			assert.Empty(t, config.colorWrapper(), tt.name)
+		}
+	})
+}
+
+// TestRunContext_DelegatesColor tests that runs colored by richgo pipe their
+// output through it, and runs colored by gotest run it in place of go test
+func TestRunContext_DelegatesColor(t *testing.T) {
+	t.Run("richgo", func(t *testing.T) {
+		config := NewTestConfig()
+		config.SetColor(true)
+		config.SetColorBackend(ColorBackendRichgo)
+		config.SetVerbose(true)
+
+		snapshot := getConfig(runContext(context.Background(), config, triggerForceRun))
+
+		assert.Equal(t, "go test ./... -v", snapshot.BuildCommand())
+		assert.Equal(t, []string{"richgo", "testfilter"}, snapshot.colorFilter)
+		assert.False(t, snapshot.colorDelegated)
+		assert.Empty(t, snapshot.GetEnv())
+	})
+
+	t.Run("gotest", func(t *testing.T) {
+		config := NewTestConfig()
+		config.SetColor(true)
+		config.SetColorBackend(ColorBackendGotest)
+		config.SetVerbose(true)
+
+		snapshot := getConfig(runContext(context.Background(), config, triggerForceRun))
+
+		assert.Equal(t, "gotest ./... -v", snapshot.BuildCommand())
+		assert.Equal(t, "circleci", snapshot.GetEnv()["CI"])
+		assert.True(t, snapshot.colorDelegated)
+		assert.Equal(t, "go test ./... -v", config.BuildCommand(), "shared config should not be changed")
+		assert.Empty(t, config.GetEnv())
+	})
+}
+
+// TestRunTests_ColorFilter tests that output piped through a color filter is
+// still read as go test wrote it
+func TestRunTests_ColorFilter(t *testing.T) {
+	testContent := `package filtered
+
+import "testing"
+
+func TestPasses(t *testing.T) {}
+
+func TestFails(t *testing.T) {
+	t.Fail()
+}
+`
+	tempDir := setupTestModule(t, testContent)
+	installFakeCommands(t, map[string]string{"richgo": `sed 's/^/richgo: /'`})
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetVerbose(true)
+	config.SetColor(true)
+	config.SetColorBackend(ColorBackendRichgo)
+	config.SetSummaryLine(true)
+	config.WorkingDir = tempDir
+	delegateColor(config)
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	output := stdoutBuf.String()
+	assert.Contains(t, output, "richgo: --- FAIL: TestFails")
+	assert.Contains(t, output, "1 passed")
+	assert.Contains(t, output, "1 failed")
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// commandBuilder puts together the command a run executes from the command
+// base and the go test arguments for its settings, in the way the program
+// the command base runs expects them.
+type commandBuilder interface {
+	// build returns the words of the command for tc. Callers must hold tc's
+	// lock.
+	build(tc *TestConfig) []string
+}
+
+// builderFor returns the commandBuilder for the program commandBase runs.
+func builderFor(commandBase []string) commandBuilder {
+	if isGotestsum(commandBase) {
+		return gotestsumBuilder{}
+	}
+	return goTestBuilder{}
+}
+
+// isGotestsum reports whether commandBase runs gotestsum, installed or as
+// a tool of the module.
+func isGotestsum(commandBase []string) bool {
+	if len(commandBase) == 0 {
+		return false
+	}
+	if strings.TrimSuffix(filepath.Base(commandBase[0]), ".exe") == "gotestsum" {
+		return true
+	}
+	return len(commandBase) >= 3 && commandBase[0] == "go" && commandBase[1] == "tool" &&
+		filepath.Base(commandBase[2]) == "gotestsum"
+}
+
+// goTestBuilder adds the go test arguments to the end of the command base,
+// for go test and wrappers of it such as richgo.
+type goTestBuilder struct{}
+
+func (goTestBuilder) build(tc *TestConfig) []string {
+	return append(slices.Clone(tc.CommandBase), tc.goTestArgs()...)
+}
+
+// gotestsumJSONFile is where runs of gotestsum write the go test -json output
+// that settings such as quietPass and junit read, relative to the directory
+// tests run in.
+var gotestsumJSONFile = filepath.Join(".gotest-watch", "gotestsum.json")
+
+// keepGotestsumJSON has tc's run write its go test -json output to
+// gotestsumJSONFile if it runs gotestsum and its settings read that output,
+// since gotestsum prints its own output in place of it. tc must be a
+// snapshot for a single run.
+func keepGotestsumJSON(tc *TestConfig) {
+	if !isGotestsum(tc.CommandBase) || !tc.wantsJSON() {
+		return
+	}
+	if strings.HasPrefix(tc.Runner, sshRunnerPrefix) {
+		fmt.Fprintln(os.Stderr, "Warning: the results of gotestsum can't be read over ssh")
+		return
+	}
+	dir := filepath.Join(tc.WorkingDir, filepath.Dir(gotestsumJSONFile))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not keep the results of gotestsum: %v\n", err)
+		return
+	}
+	tc.jsonFile = gotestsumJSONFile
+}
+
+// readJSONFile calls observe with each line of the -json output gotestsum
+// wrote to path.
+func readJSONFile(path string, observe func(line string)) error {
+	f, err := os.Open(path) //nolint:gosec // the file is one the run was told to write
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	r := bufio.NewScanner(f)
+	for r.Scan() {
+		observe(r.Text())
+	}
+	return r.Err()
+}
+
+// gotestsumBuilder keeps gotestsum's own options before -- and passes the go
+// test arguments after it. Verbose output is asked for with gotestsum's
+// standard-verbose format unless the command base picks a format, and -json
+// is left out, as gotestsum adds it itself and prints its own output. Runs
+// that read the -json output have gotestsum write it to a file with
+// --jsonfile instead.
+type gotestsumBuilder struct{}
+
+func (gotestsumBuilder) build(tc *TestConfig) []string {
+	options, passthrough := tc.CommandBase, []string(nil)
+	if i := slices.Index(options, "--"); i >= 0 {
+		options, passthrough = options[:i], options[i+1:]
+	}
+	options = slices.Clone(options)
+	if tc.Verbose && !hasFormatOption(options) {
+		options = append(options, "--format", "standard-verbose")
+	}
+	if tc.jsonFile != "" {
+		options = append(options, "--jsonfile", tc.jsonFile)
+	}
+
+	args := tc.goTestArgs()
+	// Arguments after -args are the test binary's, and are passed on as
+	// they are
+	end := slices.Index(args, "-args")
+	if end < 0 {
+		end = len(args)
+	}
+	flags := slices.DeleteFunc(slices.Clone(args[:end]), func(arg string) bool {
+		return arg == "-v" || arg == "-json"
+	})
+
+	command := append(options, "--")
+	command = append(command, passthrough...)
+	command = append(command, flags...)
+	return append(command, args[end:]...)
+}
+
+// hasFormatOption reports whether gotestsum options choose an output format.
+func hasFormatOption(options []string) bool {
+	return slices.ContainsFunc(options, func(option string) bool {
+		return option == "--format" || option == "-f" ||
+			strings.HasPrefix(option, "--format=") || strings.HasPrefix(option, "-f=")
+	})
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildCommand_Gotestsum(t *testing.T) {
+	tests := []struct {
+		name        string
+		commandBase []string
+		configure   func(*TestConfig)
+		expected    string
+	}{
+		{
+			name:        "go test flags go after --",
+			commandBase: []string{"gotestsum"},
+			configure: func(tc *TestConfig) {
+				tc.SetRunPattern("TestA")
+				tc.SetSkipPattern("TestB")
+				tc.SetCount(2)
+				tc.SetRace(true)
+			},
+			expected: "gotestsum -- ./... -race -count=2 -run=TestA -skip=TestB",
+		},
+		{
+			name:        "verbose output uses the standard-verbose format",
+			commandBase: []string{"gotestsum"},
+			configure:   func(tc *TestConfig) { tc.SetVerbose(true) },
+			expected:    "gotestsum --format standard-verbose -- ./...",
+		},
+		{
+			name:        "a chosen format is kept",
+			commandBase: []string{"gotestsum", "--format", "dots"},
+			configure:   func(tc *TestConfig) { tc.SetVerbose(true) },
+			expected:    "gotestsum --format dots -- ./...",
+		},
+		{
+			name:        "go test arguments in the command base stay after --",
+			commandBase: []string{"gotestsum", "--", "-tags=integration"},
+			configure: func(tc *TestConfig) {
+				tc.SetVerbose(true)
+				tc.SetRunPattern("TestA")
+			},
+			expected: "gotestsum --format standard-verbose -- -tags=integration ./... -run=TestA",
+		},
+		{
+			name:        "-json is left to gotestsum",
+			commandBase: []string{"gotestsum"},
+			configure:   func(tc *TestConfig) { tc.SetQuietPass(true) },
+			expected:    "gotestsum -- ./...",
+		},
+		{
+			name:        "-json output that is read is written to a file",
+			commandBase: []string{"gotestsum"},
+			configure: func(tc *TestConfig) {
+				tc.SetQuietPass(true)
+				keepGotestsumJSON(tc)
+			},
+			expected: "gotestsum --jsonfile .gotest-watch/gotestsum.json -- ./...",
+		},
+		{
+			name:        "test binary arguments are passed on as they are",
+			commandBase: []string{"gotestsum"},
+			configure:   func(tc *TestConfig) { tc.SetTestArgs([]string{"-v", "-update"}) },
+			expected:    "gotestsum -- ./... -args -v -update",
+		},
+		{
+			name:        "gotestsum run as a module tool",
+			commandBase: []string{"go", "tool", "gotestsum"},
+			configure:   func(tc *TestConfig) { tc.SetRunPattern("TestA") },
+			expected:    "go tool gotestsum -- ./... -run=TestA",
+		},
+		{
+			name:        "other programs get go test flags directly",
+			commandBase: []string{"richgo", "test"},
+			configure: func(tc *TestConfig) {
+				tc.SetVerbose(true)
+				tc.SetQuietPass(true)
+			},
+			expected: "richgo test ./... -v -json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewTestConfig()
+			config.SetCommandBase(tt.commandBase)
+			tt.configure(config)
+
+			assert.Equal(t, tt.expected, config.BuildCommand())
+		})
+	}
+}
+
+// TestBuildCommand_GotestsumKeepsCommandBase tests that building the command doesn't change the command base
+func TestBuildCommand_GotestsumKeepsCommandBase(t *testing.T) {
+	config := NewTestConfig()
+	config.SetCommandBase([]string{"gotestsum", "--", "-tags=integration", "-p=1"})
+	config.SetVerbose(true)
+
+	config.BuildCommand()
+
+	assert.Equal(t, []string{"gotestsum", "--", "-tags=integration", "-p=1"}, config.GetCommandBase())
+}
+
+// TestRunTests_ReadsGotestsumJSON tests that runs of gotestsum are counted
+// from the -json output it writes rather than from what it prints
+func TestRunTests_ReadsGotestsumJSON(t *testing.T) {
+	tempDir := t.TempDir()
+	installFakeCommands(t, map[string]string{"gotestsum": `while [ "$1" != --jsonfile ]; do shift; done
+printf '%s\n' '{"Action":"pass","Package":"p","Test":"TestA"}' \
+	'{"Action":"fail","Package":"p","Test":"TestB"}' '{"Action":"fail","Package":"p"}' > "$2"
+echo "--- PASS: TestPrinted"
+exit 1`})
+
+	config := NewTestConfig()
+	config.SetCommandBase([]string{"gotestsum"})
+	config.SetSummaryLine(true)
+	config.WorkingDir = tempDir
+	keepGotestsumJSON(config)
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	output := stdoutBuf.String()
+	assert.Contains(t, output, "--- PASS: TestPrinted")
+	assert.Contains(t, output, "✓ 1 passed ✗ 1 failed")
+}
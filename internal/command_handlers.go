@@ -1,10 +1,14 @@
 package internal
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func handleVerbose(config *TestConfig, _ []string) error {
@@ -47,6 +51,92 @@ func handleCover(config *TestConfig, _ []string) error {
 	return nil
 }
 
+func handleCoverPkg(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		config.SetCoverPkg("")
+		fmt.Println("Coverpkg: cleared")
+		return nil
+	}
+
+	// Accept both "./a/...,./b" and "./a/... ./b"
+	patterns := strings.FieldsFunc(strings.Join(args, ","), func(r rune) bool { return r == ',' })
+	if len(patterns) == 0 {
+		fmt.Printf("Error: invalid coverpkg patterns %q (must be like ./internal/...)\n", strings.Join(args, " "))
+		return nil // Don't return error to avoid breaking the flow
+	}
+	list := strings.Join(patterns, ",")
+	config.SetCoverPkg(list)
+	fmt.Printf("Coverpkg: %s\n", list)
+	return nil
+}
+
+func handleVet(config *TestConfig, args []string) error {
+	if len(args) > 0 {
+		if args[0] != "strict" {
+			fmt.Printf("Error: vet accepts no arguments or \"strict\", got %q\n", args[0])
+			return nil // Don't return error to avoid breaking the flow
+		}
+		config.ToggleVetSkipsTests()
+		if config.GetVetSkipsTests() {
+			fmt.Println("Vet strict: enabled")
+		} else {
+			fmt.Println("Vet strict: disabled")
+		}
+		return nil
+	}
+
+	config.ToggleVet()
+	if config.GetVet() {
+		fmt.Println("Vet: enabled")
+	} else {
+		fmt.Println("Vet: disabled")
+	}
+	return nil
+}
+
+func handleShort(config *TestConfig, _ []string) error {
+	config.ToggleShort()
+	if config.GetShort() {
+		fmt.Println("Short: enabled")
+	} else {
+		fmt.Println("Short: disabled")
+	}
+	return nil
+}
+
+func handleShuffle(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		if config.GetShuffle() == "" {
+			config.SetShuffle("on")
+			fmt.Println("Shuffle: enabled")
+		} else {
+			config.SetShuffle("")
+			fmt.Println("Shuffle: disabled")
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "on":
+		config.SetShuffle("on")
+		fmt.Println("Shuffle: enabled")
+		return nil
+	case "off":
+		config.SetShuffle("")
+		fmt.Println("Shuffle: disabled")
+		return nil
+	}
+
+	if _, err := strconv.ParseInt(args[0], 10, 64); err != nil {
+		fmt.Printf("Error: invalid shuffle seed %q (must be an integer)\n", args[0])
+		return nil // Don't return error to avoid breaking the flow
+	}
+
+	config.SetShuffle(args[0])
+	fmt.Printf("Shuffle: seed %s\n", args[0])
+	return nil
+}
+
 func handleColor(config *TestConfig, _ []string) error {
 	config.ToggleColor()
 	if config.GetColor() {
@@ -85,6 +175,126 @@ func handleCount(config *TestConfig, args []string) error {
 	return nil
 }
 
+func handleTimeout(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		config.SetTimeout("")
+		fmt.Println("Timeout: cleared")
+		return nil
+	}
+
+	timeout, err := time.ParseDuration(args[0])
+	if err != nil || timeout < 0 {
+		fmt.Printf("Error: invalid timeout %q (must be a duration like 30s or 5m)\n", args[0])
+		return nil // Don't return error to avoid breaking the flow
+	}
+
+	config.SetTimeout(args[0])
+	fmt.Printf("Timeout: %s\n", args[0])
+	return nil
+}
+
+func handleParallel(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		config.SetParallel(0)
+		fmt.Println("Parallel: cleared")
+		return nil
+	}
+
+	parallel, err := strconv.Atoi(args[0])
+	if err != nil || parallel < 1 {
+		fmt.Printf("Error: invalid parallel value %q (must be a positive integer)\n", args[0])
+		return nil // Don't return error to avoid breaking the flow
+	}
+
+	config.SetParallel(parallel)
+	fmt.Printf("Parallel: %d\n", parallel)
+	return nil
+}
+
+func handleCPU(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		config.SetCPU("")
+		fmt.Println("CPU: cleared")
+		return nil
+	}
+
+	// Accept both "1,2,4" and "1 2 4"
+	cpus := strings.FieldsFunc(strings.Join(args, ","), func(r rune) bool { return r == ',' })
+	valid := len(cpus) > 0
+	for _, cpu := range cpus {
+		if n, err := strconv.Atoi(cpu); err != nil || n < 1 {
+			valid = false
+		}
+	}
+	if !valid {
+		fmt.Printf("Error: invalid cpu list %q (must be positive integers like 1,2,4)\n", strings.Join(args, " "))
+		return nil // Don't return error to avoid breaking the flow
+	}
+
+	list := strings.Join(cpus, ",")
+	config.SetCPU(list)
+	fmt.Printf("CPU: %s\n", list)
+	return nil
+}
+
+func handleTestArgs(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		config.SetTestArgs(nil)
+		fmt.Println("Args: cleared")
+		return nil
+	}
+
+	config.SetTestArgs(append([]string(nil), args...))
+	fmt.Printf("Args: %s\n", strings.Join(args, " "))
+	return nil
+}
+
+func handleEnv(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		env := config.GetEnv()
+		if len(env) == 0 {
+			fmt.Println("Env: none set")
+			return nil
+		}
+		fmt.Println("Env:")
+		for _, pair := range formatEnv(env) {
+			fmt.Printf("  %s\n", pair)
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "clear":
+		config.ClearEnv()
+		fmt.Println("Env: cleared")
+		return nil
+	case "unset":
+		if len(args) == 1 {
+			return fmt.Errorf("env unset requires a variable name")
+		}
+		for _, key := range args[1:] {
+			if config.UnsetEnvVar(key) {
+				fmt.Printf("Env: unset %s\n", key)
+			} else {
+				fmt.Printf("Env: %s was not set\n", key)
+			}
+		}
+		return nil
+	}
+
+	for _, arg := range args {
+		if key, _, ok := strings.Cut(arg, "="); !ok || key == "" {
+			return fmt.Errorf("invalid env assignment %q (expected KEY=VALUE)", arg)
+		}
+	}
+	for _, arg := range args {
+		key, value, _ := strings.Cut(arg, "=")
+		config.SetEnvVar(key, value)
+		fmt.Printf("Env: %s=%s\n", key, value)
+	}
+	return nil
+}
+
 func handleClear(config *TestConfig, _ []string) error {
 	config.Clear()
 	fmt.Println("All parameters cleared")
@@ -98,11 +308,266 @@ func handleRunPattern(config *TestConfig, args []string) error {
 		return nil
 	}
 	pattern := args[0]
+	if config.GetLiteralPatterns() {
+		pattern = regexp.QuoteMeta(pattern)
+	}
 	config.SetRunPattern(pattern)
 	fmt.Println("Run pattern:", pattern)
 	return nil
 }
 
+func handlePushPattern(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("push requires a run pattern")
+	}
+	pattern := args[0]
+	if config.GetLiteralPatterns() {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	config.PushRunPattern(pattern)
+	fmt.Println("Run pattern:", pattern)
+	return nil
+}
+
+func handlePopPattern(config *TestConfig, _ []string) error {
+	pattern, ok := config.PopRunPattern()
+	if !ok {
+		fmt.Println("Run pattern stack is empty")
+		return nil
+	}
+	if pattern == "" {
+		fmt.Println("Run pattern: cleared")
+	} else {
+		fmt.Println("Run pattern:", pattern)
+	}
+	return nil
+}
+
+func handleLiteral(config *TestConfig, _ []string) error {
+	config.ToggleLiteralPatterns()
+	if config.GetLiteralPatterns() {
+		fmt.Println("Literal run patterns: enabled")
+	} else {
+		fmt.Println("Literal run patterns: disabled")
+	}
+	return nil
+}
+
+func handleQuietPass(config *TestConfig, _ []string) error {
+	config.ToggleQuietPass()
+	if config.GetQuietPass() {
+		fmt.Println("Quiet pass: enabled")
+	} else {
+		fmt.Println("Quiet pass: disabled")
+	}
+	return nil
+}
+
+func handleCollapse(config *TestConfig, _ []string) error {
+	config.ToggleCollapsePassing()
+	if config.GetCollapsePassing() {
+		fmt.Println("Collapse passing packages: enabled")
+	} else {
+		fmt.Println("Collapse passing packages: disabled")
+	}
+	return nil
+}
+
+// handleShow prints the output of a package that was collapsed in the last
+// run, or lists the collapsed packages if none is given.
+func handleShow(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		packages := collapsedOutput.packages()
+		if len(packages) == 0 {
+			fmt.Println("No collapsed packages")
+			return nil
+		}
+		fmt.Println("Collapsed packages:")
+		for _, pkg := range packages {
+			fmt.Printf("  %s\n", pkg)
+		}
+		return nil
+	}
+
+	lines, err := collapsedOutput.get(args[0])
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if config.GetColor() {
+			line = config.colorTheme().colorize(line)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func handleNotify(config *TestConfig, _ []string) error {
+	config.ToggleNotify()
+	if config.GetNotify() {
+		fmt.Println("Notifications: enabled")
+	} else {
+		fmt.Println("Notifications: disabled")
+	}
+	return nil
+}
+
+func handleGroup(config *TestConfig, _ []string) error {
+	config.ToggleGroupByPackage()
+	if config.GetGroupByPackage() {
+		fmt.Println("Group verbose output by package: enabled")
+	} else {
+		fmt.Println("Group verbose output by package: disabled")
+	}
+	return nil
+}
+
+func handleNoCache(config *TestConfig, _ []string) error {
+	config.ToggleNoCache()
+	if config.GetNoCache() {
+		fmt.Println("Bypass test cache: enabled")
+	} else {
+		fmt.Println("Bypass test cache: disabled")
+	}
+	return nil
+}
+
+func handleSmart(config *TestConfig, args []string) error {
+	if len(args) > 0 {
+		if args[0] != "deps" {
+			fmt.Printf("Error: smart accepts no arguments or \"deps\", got %q\n", args[0])
+			return nil // Don't return error to avoid breaking the flow
+		}
+		config.ToggleSmartDependents()
+		if config.GetSmartDependents() {
+			fmt.Println("Smart mode dependents: enabled")
+		} else {
+			fmt.Println("Smart mode dependents: disabled")
+		}
+		return nil
+	}
+
+	config.ToggleSmartMode()
+	if config.GetSmartMode() {
+		fmt.Println("Smart mode: enabled")
+	} else {
+		fmt.Println("Smart mode: disabled")
+	}
+	return nil
+}
+
+// handleChanged restricts runs to the packages changed since a git ref, or
+// with "off" goes back to testing the whole test path. The dispatcher starts
+// a run either way.
+func handleChanged(config *TestConfig, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: changed [ref|off]")
+	}
+	if len(args) == 1 && args[0] == "off" {
+		config.SetSince("")
+		fmt.Println("Testing all packages")
+		return nil
+	}
+
+	ref := DefaultSinceRef
+	if len(args) == 1 {
+		ref = args[0]
+	}
+	if err := verifyGitRef(config.GetWorkingDir(), ref); err != nil {
+		return err
+	}
+	config.SetSince(ref)
+	fmt.Printf("Testing packages changed since %s\n", ref)
+	return nil
+}
+
+func handleWatch(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		dirs := config.GetWatchDirs()
+		if len(dirs) == 0 {
+			fmt.Println("Extra watch dirs: none")
+			return nil
+		}
+		fmt.Println("Extra watch dirs:")
+		for _, dir := range dirs {
+			fmt.Printf("  %s\n", dir)
+		}
+		return nil
+	}
+
+	dir, err := resolveWatchDir(config, args[0])
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("path does not exist: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("path %q is not a directory", args[0])
+	}
+
+	if config.AddWatchDir(dir) {
+		fmt.Println("Watching:", dir)
+	} else {
+		fmt.Println("Already watching:", dir)
+	}
+	return nil
+}
+
+func handleUnwatch(config *TestConfig, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("unwatch requires a directory")
+	}
+
+	dir, err := resolveWatchDir(config, args[0])
+	if err != nil {
+		return err
+	}
+	if !config.RemoveWatchDir(dir) {
+		return fmt.Errorf("not watching %s (only directories added with watch can be unwatched)", dir)
+	}
+	fmt.Println("Unwatched:", dir)
+	return nil
+}
+
+func handleRescan(_ *TestConfig, _ []string) error {
+	// The dispatcher asks the file watcher to rebuild its watch list
+	fmt.Println("Rescanning watched directories")
+	return nil
+}
+
+func handleStatus(config *TestConfig, _ []string) error {
+	fmt.Println("Status:")
+	for _, line := range configSettings(config) {
+		fmt.Printf("  %s\n", line)
+	}
+
+	command, err := statusCommand(config)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Command:", command)
+	return nil
+}
+
+// resolveWatchDir returns the absolute path of dir, which is relative to the
+// working directory unless absolute.
+func resolveWatchDir(config *TestConfig, dir string) (string, error) {
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir), nil
+	}
+	base := config.GetWorkingDir()
+	if base == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		base = cwd
+	}
+	return filepath.Join(base, dir), nil
+}
+
 func handleSkipPattern(config *TestConfig, args []string) error {
 	if len(args) == 0 {
 		config.SetSkipPattern("")
@@ -119,9 +584,19 @@ func handleTestPath(config *TestConfig, args []string) error {
 	var path string
 	if len(args) == 0 {
 		path = "./..."
+	} else if isGlob(args[0]) {
+		paths, err := expandPathGlob(args[0], config.GetWorkingDir())
+		if err != nil {
+			return err
+		}
+		path = strings.Join(paths, " ")
 	} else {
 		path = args[0]
-		info, err := os.Stat(path)
+		statPath := strings.TrimSuffix(path, "/...")
+		if workingDir := config.GetWorkingDir(); workingDir != "" && !filepath.IsAbs(statPath) {
+			statPath = filepath.Join(workingDir, statPath)
+		}
+		info, err := os.Stat(statPath)
 		if err != nil {
 			return fmt.Errorf("path does not exist: %w", err)
 		}
@@ -134,12 +609,66 @@ func handleTestPath(config *TestConfig, args []string) error {
 	return nil
 }
 
-func handleCls(config *TestConfig, _ []string) error {
-	config.ToggleClearScreen()
-	if config.GetClearScreen() {
-		fmt.Println("Clear screen before each run: enabled")
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// expandPathGlob expands pattern, relative to workingDir if set, into the
+// directories it matches. Matches that are not directories are skipped.
+func expandPathGlob(pattern, workingDir string) ([]string, error) {
+	globPattern := pattern
+	if workingDir != "" && !filepath.IsAbs(pattern) {
+		globPattern = filepath.Join(workingDir, pattern)
+	}
+
+	matches, err := filepath.Glob(globPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+
+	var paths []string
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		if workingDir != "" && !filepath.IsAbs(pattern) {
+			if match, err = filepath.Rel(workingDir, match); err != nil {
+				continue
+			}
+		}
+		if !filepath.IsAbs(match) {
+			// go test treats paths without a leading ./ as import paths
+			match = "./" + filepath.ToSlash(match)
+		}
+		paths = append(paths, match)
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("glob %q did not match any directories", pattern)
+	}
+	return paths, nil
+}
+
+// handleCls sets when the screen is cleared before a run to the mode given,
+// or cycles to the next mode if none is.
+func handleCls(config *TestConfig, args []string) error {
+	if len(args) > 0 {
+		var mode ClearMode
+		if err := mode.Set(args[0]); err != nil {
+			return err
+		}
+		config.SetClearScreen(mode)
 	} else {
-		fmt.Println("Clear screen before each run: disabled")
+		config.CycleClearScreen()
+	}
+	switch config.GetClearScreen() {
+	case ClearAlways:
+		fmt.Println("Clear screen before each run: always")
+	case ClearAuto:
+		fmt.Println("Clear screen before each run: auto (only runs started by file changes)")
+	default:
+		fmt.Println("Clear screen before each run: never")
 	}
 	return nil
 }
@@ -148,6 +677,65 @@ func handleForceRun(_ *TestConfig, _ []string) error {
 	return nil
 }
 
+// handleInterrupt runs when no tests are running; interrupting a run in
+// progress is handled by the dispatcher.
+func handleInterrupt(_ *TestConfig, _ []string) error {
+	fmt.Println("No test run to interrupt")
+	return nil
+}
+
+// handleCoverHTML has nothing to change; the dispatcher starts a run that
+// writes a coverage profile and opens it in the browser.
+func handleCoverHTML(_ *TestConfig, _ []string) error {
+	return nil
+}
+
+// handleFuncs lists the least-covered functions in the coverage profile
+// kept from the last run with cover on, in the package given if any.
+func handleFuncs(config *TestConfig, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: funcs [pkg]")
+	}
+	profile := lastCoverProfile.get()
+	if profile == "" {
+		return fmt.Errorf("no coverage profile yet; turn on cover and run the tests first")
+	}
+	dir := absWorkingDir(config)
+	funcs, err := coverFuncs(dir, profile)
+	if err != nil {
+		return err
+	}
+
+	scope := "the last run"
+	if len(args) == 1 {
+		scope = args[0]
+		packages, err := goList(dir, args)
+		if err != nil {
+			return err
+		}
+		funcs = inPackages(funcs, packages)
+		if len(funcs) == 0 {
+			return fmt.Errorf("no functions in %s in the coverage profile", args[0])
+		}
+	}
+
+	uncovered := leastCovered(funcs)
+	if len(uncovered) == 0 {
+		fmt.Printf("All %d functions in %s are fully covered\n", len(funcs), scope)
+		return nil
+	}
+	fmt.Printf("Least-covered functions in %s (%d of %d not fully covered):\n", scope, len(uncovered), len(funcs))
+	for _, line := range formatFuncCoverage(uncovered[:min(len(uncovered), maxFuncsShown)]) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// handleFuzz checks the fuzz target; the dispatcher starts the fuzzing run.
+func handleFuzz(_ *TestConfig, args []string) error {
+	return validateFuzzArgs(args)
+}
+
 func handleCommandBase(config *TestConfig, args []string) error {
 	var cmdBase []string
 	if len(args) == 0 {
@@ -155,30 +743,208 @@ func handleCommandBase(config *TestConfig, args []string) error {
 	} else {
 		cmdBase = args
 	}
+	if err := checkExecutable(cmdBase[0]); err != nil {
+		return fmt.Errorf("could not set the test command: %w", err)
+	}
 	config.SetCommandBase(cmdBase)
 	fmt.Println("Test command:", strings.Join(cmdBase, " "))
 	return nil
 }
 
+func handleMetrics(_ *TestConfig, _ []string) error {
+	fmt.Println(metrics.String())
+	return nil
+}
+
+func handleReplayRun(_ *TestConfig, args []string) error {
+	return replayRun(history, args)
+}
+
+// replayRun announces that the nth run in h is replayed, once it has
+// checked that there is one. The dispatcher starts the run itself with
+// replayContext.
+func replayRun(h *runHistory, args []string) error {
+	n, run, err := findRun(h, args)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Replaying run %d: %s\n", n, run.command)
+	return nil
+}
+
+// replayContext returns a context for a single run with the config used for
+// the nth run in h. The session's config is left alone, so that settings
+// only that run had, such as a test path narrowed to the changed packages,
+// don't carry over to the runs that follow.
+func replayContext(ctx context.Context, h *runHistory, args []string) (context.Context, error) {
+	_, run, err := findRun(h, args)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := run.config.runSnapshot()
+	retainCoverProfile(snapshot)
+	return WithConfig(ctx, snapshot), nil
+}
+
+// findRun returns the run in h whose number is the first of args.
+func findRun(h *runHistory, args []string) (int, runRecord, error) {
+	if len(args) == 0 {
+		return 0, runRecord{}, fmt.Errorf("replay-run requires a run number")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, runRecord{}, fmt.Errorf("invalid run number %q", args[0])
+	}
+	run, ok := h.get(n)
+	if !ok {
+		return 0, runRecord{}, h.missing(n)
+	}
+	return n, run, nil
+}
+
+func handleHistory(_ *TestConfig, args []string) error {
+	return showHistory(history, args)
+}
+
+// showHistory lists the runs in h, or shows the summary of the nth run.
+func showHistory(h *runHistory, args []string) error {
+	if len(args) == 0 {
+		first, runs := h.all()
+		if len(runs) == 0 {
+			fmt.Println("No runs yet")
+			return nil
+		}
+		if first > 1 {
+			fmt.Printf("(runs 1-%d are no longer kept)\n", first-1)
+		}
+		for i, run := range runs {
+			fmt.Println(formatRunEntry(first+i, run))
+		}
+		return nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid run number %q", args[0])
+	}
+	run, ok := h.get(n)
+	if !ok {
+		return h.missing(n)
+	}
+	for _, line := range formatRunDetails(n, run) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// defaultSlowCount is how many tests the slow command lists by default.
+const defaultSlowCount = 10
+
+func handleSlow(_ *TestConfig, args []string) error {
+	return showSlowest(history, args)
+}
+
+// showSlowest lists the slowest tests of the last run in h, and those that
+// took the longest in total over all its runs.
+func showSlowest(h *runHistory, args []string) error {
+	n := defaultSlowCount
+	if len(args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n <= 0 {
+			return fmt.Errorf("invalid number of tests %q", args[0])
+		}
+	}
+
+	_, runs := h.all()
+	if len(runs) == 0 || len(sessionDurations(runs)) == 0 {
+		fmt.Println("No test durations recorded yet (they are read from -v or -json output)")
+		return nil
+	}
+
+	if last := runs[len(runs)-1].durations; len(last) > 0 {
+		fmt.Println("Slowest tests in the last run:")
+		for _, line := range formatDurations(slowestTests(last, n)) {
+			fmt.Println(line)
+		}
+	} else {
+		fmt.Println("No test durations recorded in the last run")
+	}
+	fmt.Printf("Slowest tests over the session (%d runs):\n", len(runs))
+	for _, line := range formatDurations(slowestTests(sessionDurations(runs), n)) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
 func handleHelp(_ *TestConfig, _ []string) error {
 	fmt.Println("Available commands:")
 	fmt.Println("  v            Toggle verbose mode (-v flag)")
 	fmt.Println("  race         Toggle race mode (-race flag)")
 	fmt.Println("  ff           Toggle failfast mode (-failfast flag)")
 	fmt.Println("  cover        Toggle cover mode (-cover flag)")
+	fmt.Println("  short        Toggle short mode (-short flag)")
+	fmt.Println("  shuffle      Toggle shuffled test order (-shuffle=on)")
+	fmt.Println("  shuffle <n>  Shuffle using seed n to reproduce a previous order")
+	fmt.Println("  coverpkg <patterns>  Measure coverage of matching packages (-coverpkg=<patterns>)")
+	fmt.Println("  coverpkg     Clear coverpkg patterns")
+	fmt.Println("  coverhtml    Run tests and open an HTML coverage report")
+	fmt.Println("  funcs [pkg]  List the least-covered functions of the last run with cover")
+	fmt.Println("  vet          Toggle running go vet before tests")
+	fmt.Println("  vet strict   Toggle skipping tests when go vet fails")
 	fmt.Println("  color        Toggle color mode (internal config)")
+	fmt.Println("  quietpass    Toggle showing only failures and a count of the results")
+	fmt.Println("  collapse     Toggle showing passing packages as a single line")
+	fmt.Println("  group        Toggle grouping verbose output by package")
+	fmt.Println("  notify       Toggle a desktop notification when each run finishes")
+	fmt.Println("  show <pkg>   Show the output of a collapsed package from the last run")
+	fmt.Println("  show         List the collapsed packages from the last run")
 	fmt.Println("  count <n>    Set test count (-count=<n>, n > 0)")
 	fmt.Println("  count        Clear count")
+	fmt.Println("  nocache      Toggle bypassing the test cache (-count=1)")
+	fmt.Println("  timeout <d>  Set test timeout (-timeout=<d>, e.g. 30s)")
+	fmt.Println("  timeout      Clear timeout")
+	fmt.Println("  par <n>      Run at most n tests in parallel (-parallel=<n>)")
+	fmt.Println("  par          Clear parallel limit")
+	fmt.Println("  cpu <list>   Run tests with each GOMAXPROCS value (-cpu=1,2,4)")
+	fmt.Println("  cpu          Clear cpu list")
+	fmt.Println("  args <a...>  Pass arguments to the test binary (-args <a...>)")
+	fmt.Println("  args         Clear test binary arguments")
 	fmt.Println("  r <pattern>  Set test run pattern (-run=<pattern>)")
 	fmt.Println("  r            Clear run pattern")
+	fmt.Println("  push <pattern>  Save the run pattern and set a new one")
+	fmt.Println("  pop          Restore the last saved run pattern")
+	fmt.Println("  literal      Toggle treating run patterns as literal text")
+	fmt.Println("  smart        Toggle testing only the packages containing changed files")
+	fmt.Println("  smart deps   Toggle also testing packages that import changed packages")
+	fmt.Println("  changed [ref]  Test only packages changed since a git ref (default: HEAD) and their dependents")
+	fmt.Println("  changed off  Test the whole test path again")
 	fmt.Println("  s <pattern>  Set test skip pattern (-skip=<pattern>)")
 	fmt.Println("  s            Clear skip pattern")
 	fmt.Println("  p <path>     Set test path (default: ./...")
+	fmt.Println("  p <glob>     Set test path to the directories matching a glob")
 	fmt.Println("  p            Set test path to default (./...)")
 	fmt.Println("  cmd          Set the base command to run (default: go test)")
+	fmt.Println("  env          List environment variables set for test runs")
+	fmt.Println("  env K=V ...  Set environment variables for test runs")
+	fmt.Println("  env unset K  Remove an environment variable")
+	fmt.Println("  env clear    Remove all environment variables")
+	fmt.Println("  watch <dir>  Also watch another directory for changes")
+	fmt.Println("  watch        List extra watched directories")
+	fmt.Println("  unwatch <dir>  Stop watching a directory added with watch")
+	fmt.Println("  rescan       Rebuild the watch list, e.g. after a large checkout")
 	fmt.Println("  clear        Clear all parameters")
-	fmt.Println("  cls          Clear screen")
+	fmt.Println("  cls          Clear screen before runs: cycle always, auto (file changes only), never")
+	fmt.Println("  cls <mode>   Set when the screen is cleared (always, auto or never)")
 	fmt.Println("  f            Force test run")
+	fmt.Println("  fuzz <name> [pkg]  Fuzz a fuzz test until a file changes or x is entered")
+	fmt.Println("  x            Interrupt the running tests")
+	fmt.Println("  replay-run <n>  Re-run the nth run of the session with its exact config")
+	fmt.Println("  history      List the runs of the session")
+	fmt.Println("  history <n>  Show the summary of the nth run")
+	fmt.Println("  slow [n]     Show the n slowest tests of the last run and the session")
+	fmt.Println("  metrics      Show watcher and test run counters")
+	fmt.Println("  status       Show the current settings and the command a run would execute")
 	fmt.Println("  h            Show this help")
+	fmt.Println("  a; b; ...    Run several commands in order (e.g. v; race; f)")
 	return nil
 }
@@ -3,6 +3,7 @@ package internal
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -88,6 +89,9 @@ func TestHandleClear_ResetsAllFields(t *testing.T) {
 		Verbose:     true,
 		RunPattern:  "TestFoo",
 		SkipPattern: "FooBar",
+		Timeout:     "30s",
+		NoCache:     true,
+		Since:       "main",
 	}
 
 	output := captureStdout(t, func() {
@@ -99,6 +103,9 @@ func TestHandleClear_ResetsAllFields(t *testing.T) {
 	assert.False(t, config.GetVerbose(), "Verbose should be reset to false")
 	assert.Equal(t, "", config.GetRunPattern(), "RunPattern should be reset to empty")
 	assert.Equal(t, "", config.GetSkipPattern(), "SkipPattern should be reset to empty")
+	assert.Equal(t, "", config.GetTimeout(), "Timeout should be reset to empty")
+	assert.False(t, config.GetNoCache(), "NoCache should be reset to false")
+	assert.Equal(t, "", config.GetSince(), "Since should be reset to empty")
 	assert.Equal(t, "All parameters cleared\n", output, "Should print cleared message")
 }
 
@@ -476,6 +483,21 @@ func TestHandleTestPath_WithNilArgs(t *testing.T) {
 	assert.Equal(t, "Test path: ./...\n", output, "Should print path message")
 }
 
+// TestHandleTestPath_ResolvesRelativeToWorkingDir tests that relative paths are checked against the working dir
+func TestHandleTestPath_ResolvesRelativeToWorkingDir(t *testing.T) {
+	workingDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(workingDir, "pkg"), 0o750))
+
+	config := NewTestConfig()
+	config.SetWorkingDir(workingDir)
+
+	captureStdout(t, func() {
+		require.NoError(t, handleTestPath(config, []string{"./pkg/..."}))
+	})
+
+	assert.Equal(t, "./pkg/...", config.GetTestPath())
+}
+
 // TestHandleTestPath_WithInvalidPath tests error handling for non-existent path
 func TestHandleTestPath_WithInvalidPath(t *testing.T) {
 	config := &TestConfig{
@@ -533,22 +555,33 @@ func TestHandleTestPath_IgnoresExtraArgs(t *testing.T) {
 
 func TestHandleCls_UpdatesConfig(t *testing.T) {
 	config := NewTestConfig()
+	assert.Equal(t, ClearNever, config.GetClearScreen(), "initial config should not clear screen before test runs")
 
-	clearA := config.GetClearScreen()
-
-	err := handleCls(config, []string{})
-	require.NoError(t, err)
+	var modes []ClearMode
+	output := captureStdout(t, func() {
+		for range 3 {
+			require.NoError(t, handleCls(config, []string{}))
+			modes = append(modes, config.GetClearScreen())
+		}
+	})
 
-	clearB := config.GetClearScreen()
+	assert.Equal(t, []ClearMode{ClearAlways, ClearAuto, ClearNever}, modes, "handling the command should cycle through the modes")
+	assert.Equal(t, "Clear screen before each run: always\n"+
+		"Clear screen before each run: auto (only runs started by file changes)\n"+
+		"Clear screen before each run: never\n", output)
+}
 
-	err = handleCls(config, []string{})
-	require.NoError(t, err)
+func TestHandleCls_SetsMode(t *testing.T) {
+	config := NewTestConfig()
 
-	clearC := config.GetClearScreen()
+	captureStdout(t, func() {
+		require.NoError(t, handleCls(config, []string{"auto"}))
+	})
+	assert.Equal(t, ClearAuto, config.GetClearScreen())
 
-	assert.False(t, clearA, "initial config should not clear screen before test runs")
-	assert.True(t, clearB, "handling the command should toggle clearing the screen")
-	assert.False(t, clearC, "handling the command should toggle clearing the screen")
+	assert.EqualError(t, handleCls(config, []string{"sometimes"}),
+		`invalid clear screen mode "sometimes" (must be auto, always or never)`)
+	assert.Equal(t, ClearAuto, config.GetClearScreen())
 }
 
 // TestHandleRunPattern_WorksViaRegistry tests run pattern through the registry
@@ -605,7 +638,7 @@ func TestHandleCls_WorksViaRegistry(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	assert.Equal(t, "Clear screen before each run: enabled\n", output)
+	assert.Equal(t, "Clear screen before each run: always\n", output)
 }
 
 // TestHandleRun_WorksViaRegistry tests run through the registry
@@ -749,12 +782,24 @@ func TestHandleCommandBase_WithCommand(t *testing.T) {
 	config := NewTestConfig()
 
 	output := captureStdout(t, func() {
-		err := handleCommand(Command("cmd"), config, []string{"grc", "go", "test"})
+		err := handleCommand(Command("cmd"), config, []string{"nice", "go", "test"})
 		require.NoError(t, err)
 	})
 
-	assert.Equal(t, []string{"grc", "go", "test"}, config.GetCommandBase())
-	assert.Equal(t, "Test command: grc go test\n", output)
+	assert.Equal(t, []string{"nice", "go", "test"}, config.GetCommandBase())
+	assert.Equal(t, "Test command: nice go test\n", output)
+}
+
+func TestHandleCommandBase_RejectsMissingProgram(t *testing.T) {
+	initRegistry()
+
+	config := NewTestConfig()
+
+	err := handleCommand(Command("cmd"), config, []string{"no-such-binary-for-gotest-watch", "test"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found in PATH")
+	assert.Equal(t, []string{"go", "test"}, config.GetCommandBase(), "command base should be unchanged")
 }
 
 func TestHandleCommandBase_WithEmptyArgs(t *testing.T) {
@@ -922,6 +967,190 @@ func TestHandleFailFast_IgnoresArguments(t *testing.T) {
 	assert.True(t, config.GetFailFast(), "Should toggle regardless of arguments")
 }
 
+func TestHandleTimeout_WithValidDuration(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		err := handleTimeout(config, []string{"30s"})
+		require.NoError(t, err)
+	})
+
+	assert.Equal(t, "30s", config.GetTimeout(), "Should set timeout to 30s")
+	assert.Equal(t, "Timeout: 30s\n", output, "Should print timeout message")
+	assert.Equal(t, "go test ./... -timeout=30s", config.BuildCommand())
+}
+
+func TestHandleTimeout_WithoutArgs(t *testing.T) {
+	config := NewTestConfig()
+	config.SetTimeout("5m")
+
+	output := captureStdout(t, func() {
+		err := handleTimeout(config, nil)
+		require.NoError(t, err)
+	})
+
+	assert.Empty(t, config.GetTimeout(), "Should clear timeout")
+	assert.Equal(t, "Timeout: cleared\n", output, "Should print cleared message")
+}
+
+func TestHandleTimeout_WithInvalidDuration(t *testing.T) {
+	for _, arg := range []string{"soon", "30", "-5s"} {
+		config := NewTestConfig()
+		config.SetTimeout("5m")
+
+		output := captureStdout(t, func() {
+			err := handleTimeout(config, []string{arg})
+			require.NoError(t, err)
+		})
+
+		assert.Equal(t, "5m", config.GetTimeout(), "Should keep previous timeout for %q", arg)
+		assert.Contains(t, output, "Error: invalid timeout", "Should print error for %q", arg)
+	}
+}
+
+func TestHandleParallel_WithValidNumber(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleParallel(config, []string{"2"}))
+	})
+
+	assert.Equal(t, 2, config.GetParallel())
+	assert.Equal(t, "Parallel: 2\n", output)
+	assert.Equal(t, "go test ./... -parallel=2", config.BuildCommand())
+}
+
+func TestHandleParallel_WithoutArgs(t *testing.T) {
+	config := NewTestConfig()
+	config.SetParallel(4)
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleParallel(config, nil))
+	})
+
+	assert.Equal(t, 0, config.GetParallel(), "Should clear parallel")
+	assert.Equal(t, "Parallel: cleared\n", output)
+	assert.Equal(t, "go test ./...", config.BuildCommand())
+}
+
+func TestHandleParallel_WithInvalidValue(t *testing.T) {
+	for _, arg := range []string{"many", "0", "-1"} {
+		config := NewTestConfig()
+		config.SetParallel(4)
+
+		output := captureStdout(t, func() {
+			require.NoError(t, handleParallel(config, []string{arg}))
+		})
+
+		assert.Equal(t, 4, config.GetParallel(), "Should keep previous parallel for %q", arg)
+		assert.Contains(t, output, "Error: invalid parallel value", "Should print error for %q", arg)
+	}
+}
+
+func TestHandleCoverPkg_WithPatterns(t *testing.T) {
+	for _, args := range [][]string{{"./internal/...,./pkg"}, {"./internal/...", "./pkg"}} {
+		config := NewTestConfig()
+
+		output := captureStdout(t, func() {
+			require.NoError(t, handleCoverPkg(config, args))
+		})
+
+		assert.Equal(t, "./internal/...,./pkg", config.GetCoverPkg(), "Should set coverpkg for %q", args)
+		assert.Equal(t, "Coverpkg: ./internal/...,./pkg\n", output)
+		assert.Equal(t, "go test ./... -coverpkg=./internal/...,./pkg", config.BuildCommand())
+	}
+}
+
+func TestHandleCoverPkg_WithoutArgs(t *testing.T) {
+	config := NewTestConfig()
+	config.SetCoverPkg("./internal/...")
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleCoverPkg(config, nil))
+	})
+
+	assert.Empty(t, config.GetCoverPkg(), "Should clear coverpkg")
+	assert.Equal(t, "Coverpkg: cleared\n", output)
+}
+
+func TestHandleCoverPkg_WithInvalidPatterns(t *testing.T) {
+	config := NewTestConfig()
+	config.SetCoverPkg("./internal/...")
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleCoverPkg(config, []string{","}))
+	})
+
+	assert.Equal(t, "./internal/...", config.GetCoverPkg(), "Should keep previous coverpkg")
+	assert.Contains(t, output, "Error: invalid coverpkg patterns")
+}
+
+func TestHandleCPU_WithList(t *testing.T) {
+	for _, args := range [][]string{{"1,2,4"}, {"1", "2", "4"}, {"1,", "2,4"}} {
+		config := NewTestConfig()
+
+		output := captureStdout(t, func() {
+			require.NoError(t, handleCPU(config, args))
+		})
+
+		assert.Equal(t, "1,2,4", config.GetCPU(), "Should set cpu list for %q", args)
+		assert.Equal(t, "CPU: 1,2,4\n", output)
+		assert.Equal(t, "go test ./... -cpu=1,2,4", config.BuildCommand())
+	}
+}
+
+func TestHandleCPU_WithoutArgs(t *testing.T) {
+	config := NewTestConfig()
+	config.SetCPU("1,2")
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleCPU(config, nil))
+	})
+
+	assert.Empty(t, config.GetCPU(), "Should clear cpu list")
+	assert.Equal(t, "CPU: cleared\n", output)
+}
+
+func TestHandleCPU_WithInvalidList(t *testing.T) {
+	for _, arg := range []string{"one", "1,0", "2,-1", ","} {
+		config := NewTestConfig()
+		config.SetCPU("1,2")
+
+		output := captureStdout(t, func() {
+			require.NoError(t, handleCPU(config, []string{arg}))
+		})
+
+		assert.Equal(t, "1,2", config.GetCPU(), "Should keep previous cpu list for %q", arg)
+		assert.Contains(t, output, "Error: invalid cpu list", "Should print error for %q", arg)
+	}
+}
+
+func TestHandleTestArgs_SetsArgs(t *testing.T) {
+	config := NewTestConfig()
+	config.SetVerbose(true)
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleTestArgs(config, []string{"-update", "-golden=testdata"}))
+	})
+
+	assert.Equal(t, []string{"-update", "-golden=testdata"}, config.GetTestArgs())
+	assert.Equal(t, "Args: -update -golden=testdata\n", output)
+	assert.Equal(t, "go test ./... -v -args -update -golden=testdata", config.BuildCommand())
+}
+
+func TestHandleTestArgs_WithoutArgs(t *testing.T) {
+	config := NewTestConfig()
+	config.SetTestArgs([]string{"-update"})
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleTestArgs(config, nil))
+	})
+
+	assert.Empty(t, config.GetTestArgs(), "Should clear test args")
+	assert.Equal(t, "Args: cleared\n", output)
+	assert.Equal(t, "go test ./...", config.BuildCommand())
+}
+
 func TestHandleCount_WithValidPositiveNumber(t *testing.T) {
 	config := &TestConfig{
 		TestPath: "./...",
@@ -1102,6 +1331,72 @@ func TestHandleCount_WorksViaRegistry(t *testing.T) {
 	assert.Equal(t, "Count: 7\n", output)
 }
 
+// ============================================================================
+// Short Toggle Tests
+// ============================================================================
+
+func TestHandleShort_Toggles(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleShort(config, nil))
+	})
+	assert.True(t, config.GetShort(), "Short should be toggled to true")
+	assert.Equal(t, "Short: enabled\n", output)
+	assert.Equal(t, "go test ./... -short", config.BuildCommand())
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleShort(config, nil))
+	})
+	assert.False(t, config.GetShort(), "Short should be toggled to false")
+	assert.Equal(t, "Short: disabled\n", output)
+}
+
+func TestHandleShuffle_Toggles(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleShuffle(config, nil))
+	})
+	assert.Equal(t, "on", config.GetShuffle())
+	assert.Equal(t, "Shuffle: enabled\n", output)
+	assert.Equal(t, "go test ./... -shuffle=on", config.BuildCommand())
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleShuffle(config, nil))
+	})
+	assert.Empty(t, config.GetShuffle())
+	assert.Equal(t, "Shuffle: disabled\n", output)
+	assert.Equal(t, "go test ./...", config.BuildCommand())
+}
+
+func TestHandleShuffle_WithSeed(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleShuffle(config, []string{"1700000000"}))
+	})
+	assert.Equal(t, "1700000000", config.GetShuffle())
+	assert.Equal(t, "Shuffle: seed 1700000000\n", output)
+	assert.Equal(t, "go test ./... -shuffle=1700000000", config.BuildCommand())
+
+	captureStdout(t, func() {
+		require.NoError(t, handleShuffle(config, nil))
+	})
+	assert.Empty(t, config.GetShuffle(), "toggling a seeded shuffle should disable it")
+}
+
+func TestHandleShuffle_WithInvalidSeed(t *testing.T) {
+	config := NewTestConfig()
+	config.SetShuffle("42")
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleShuffle(config, []string{"random"}))
+	})
+	assert.Equal(t, "42", config.GetShuffle(), "Should keep previous shuffle setting")
+	assert.Contains(t, output, "Error: invalid shuffle seed")
+}
+
 // ============================================================================
 // Cover Toggle Tests
 // ============================================================================
@@ -1243,3 +1538,420 @@ func TestHandleColor_IgnoresArguments(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, config.GetColor(), "Should toggle regardless of arguments")
 }
+
+// TestHandleRunPattern_LiteralModeEscapesPattern tests that literal mode stores a QuoteMeta-escaped pattern
+func TestHandleRunPattern_LiteralModeEscapesPattern(t *testing.T) {
+	config := NewTestConfig()
+	config.SetLiteralPatterns(true)
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleRunPattern(config, []string{"TestA.B"}))
+	})
+
+	assert.Equal(t, `TestA\.B`, config.GetRunPattern())
+	assert.Equal(t, "Run pattern: TestA\\.B\n", output)
+}
+
+// TestHandleRunPattern_RegexpModeKeepsRawPattern tests that patterns are passed through by default
+func TestHandleRunPattern_RegexpModeKeepsRawPattern(t *testing.T) {
+	config := NewTestConfig()
+
+	captureStdout(t, func() {
+		require.NoError(t, handleRunPattern(config, []string{"TestA.B"}))
+	})
+
+	assert.Equal(t, "TestA.B", config.GetRunPattern())
+}
+
+// TestHandleLiteral_TogglesMode tests that the literal command toggles literal mode
+func TestHandleLiteral_TogglesMode(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleLiteral(config, nil))
+	})
+	assert.True(t, config.GetLiteralPatterns())
+	assert.Equal(t, "Literal run patterns: enabled\n", output)
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleLiteral(config, nil))
+	})
+	assert.False(t, config.GetLiteralPatterns())
+	assert.Equal(t, "Literal run patterns: disabled\n", output)
+}
+
+func TestHandleQuietPass_TogglesMode(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleQuietPass(config, nil))
+	})
+	assert.True(t, config.GetQuietPass())
+	assert.Equal(t, "Quiet pass: enabled\n", output)
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleQuietPass(config, nil))
+	})
+	assert.False(t, config.GetQuietPass())
+	assert.Equal(t, "Quiet pass: disabled\n", output)
+}
+
+func TestHandleCollapse_TogglesMode(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleCollapse(config, nil))
+	})
+	assert.True(t, config.GetCollapsePassing())
+	assert.Equal(t, "Collapse passing packages: enabled\n", output)
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleCollapse(config, nil))
+	})
+	assert.False(t, config.GetCollapsePassing())
+	assert.Equal(t, "Collapse passing packages: disabled\n", output)
+}
+
+func TestHandleGroup_TogglesMode(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleGroup(config, nil))
+	})
+	assert.True(t, config.GetGroupByPackage())
+	assert.Equal(t, "Group verbose output by package: enabled\n", output)
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleGroup(config, nil))
+	})
+	assert.False(t, config.GetGroupByPackage())
+	assert.Equal(t, "Group verbose output by package: disabled\n", output)
+}
+
+func TestHandleNoCache_TogglesMode(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleNoCache(config, nil))
+	})
+	assert.True(t, config.GetNoCache())
+	assert.Equal(t, "Bypass test cache: enabled\n", output)
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleNoCache(config, nil))
+	})
+	assert.False(t, config.GetNoCache())
+	assert.Equal(t, "Bypass test cache: disabled\n", output)
+}
+
+// TestHandleShow tests listing and expanding the packages collapsed in the last run
+func TestHandleShow(t *testing.T) {
+	collapsedOutput.reset()
+	t.Cleanup(collapsedOutput.reset)
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleShow(config, nil))
+	})
+	assert.Equal(t, "No collapsed packages\n", output)
+
+	collapsedOutput.collapse("--- PASS: TestPasses (0.00s)\nPASS\nok  \texample.com/app\t0.01s")
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleShow(config, nil))
+	})
+	assert.Equal(t, "Collapsed packages:\n  example.com/app\n", output)
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleShow(config, []string{"example.com/app"}))
+	})
+	assert.Equal(t, "--- PASS: TestPasses (0.00s)\nPASS\n", output)
+
+	assert.EqualError(t, handleShow(config, []string{"other"}), "no collapsed output for other")
+}
+
+func TestHandleNotify_TogglesMode(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleNotify(config, nil))
+	})
+	assert.True(t, config.GetNotify())
+	assert.Equal(t, "Notifications: enabled\n", output)
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleNotify(config, nil))
+	})
+	assert.False(t, config.GetNotify())
+	assert.Equal(t, "Notifications: disabled\n", output)
+}
+
+// TestHandleTestPath_ExpandsGlobToDirectories tests that a glob stores every matching directory
+func TestHandleTestPath_ExpandsGlobToDirectories(t *testing.T) {
+	workingDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(workingDir, "internal", "alpha"), 0o750))
+	require.NoError(t, os.MkdirAll(filepath.Join(workingDir, "internal", "beta"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(workingDir, "internal", "doc.go"), []byte("package internal"), 0o600))
+
+	config := NewTestConfig()
+	config.SetWorkingDir(workingDir)
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleTestPath(config, []string{"./internal/*"}))
+	})
+
+	assert.Equal(t, "./internal/alpha ./internal/beta", config.GetTestPath())
+	assert.Equal(t, "Test path: ./internal/alpha ./internal/beta\n", output)
+}
+
+// TestHandleTestPath_NonMatchingGlobErrors tests that a glob matching no directories is rejected
+func TestHandleTestPath_NonMatchingGlobErrors(t *testing.T) {
+	config := NewTestConfig()
+	config.SetWorkingDir(t.TempDir())
+
+	err := handleTestPath(config, []string{"./missing/*"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not match any directories")
+	assert.Equal(t, "./...", config.GetTestPath(), "TestPath should not change on error")
+}
+
+// TestHandlePushPop_RestoresPatternsInLIFOOrder tests that pushed patterns are restored last-in first-out
+func TestHandlePushPop_RestoresPatternsInLIFOOrder(t *testing.T) {
+	config := NewTestConfig()
+	config.SetRunPattern("TestOriginal")
+
+	captureStdout(t, func() {
+		require.NoError(t, handlePushPattern(config, []string{"TestFoo"}))
+		require.NoError(t, handlePushPattern(config, []string{"TestBar"}))
+	})
+	assert.Equal(t, "TestBar", config.GetRunPattern())
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handlePopPattern(config, nil))
+	})
+	assert.Equal(t, "TestFoo", config.GetRunPattern())
+	assert.Equal(t, "Run pattern: TestFoo\n", output)
+
+	captureStdout(t, func() {
+		require.NoError(t, handlePopPattern(config, nil))
+	})
+	assert.Equal(t, "TestOriginal", config.GetRunPattern())
+}
+
+// TestHandlePopPattern_EmptyStackIsNoOp tests that popping an empty stack leaves the pattern alone
+func TestHandlePopPattern_EmptyStackIsNoOp(t *testing.T) {
+	config := NewTestConfig()
+	config.SetRunPattern("TestFoo")
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handlePopPattern(config, nil))
+	})
+
+	assert.Equal(t, "TestFoo", config.GetRunPattern())
+	assert.Equal(t, "Run pattern stack is empty\n", output)
+}
+
+// TestHandlePushPattern_RequiresPattern tests that push without an argument is rejected
+func TestHandlePushPattern_RequiresPattern(t *testing.T) {
+	config := NewTestConfig()
+
+	require.Error(t, handlePushPattern(config, nil))
+}
+
+// TestHandleInterrupt_WhenIdle tests that x reports there is nothing to interrupt outside a run
+func TestHandleInterrupt_WhenIdle(t *testing.T) {
+	initRegistry()
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		err := handleCommand(InterruptCmd, config, nil)
+		require.NoError(t, err)
+	})
+
+	assert.Equal(t, "No test run to interrupt\n", output)
+}
+
+// TestHandleSmart_TogglesModes tests that smart toggles smart mode and smart deps toggles dependents
+func TestHandleSmart_TogglesModes(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleSmart(config, nil))
+		require.NoError(t, handleSmart(config, []string{"deps"}))
+	})
+
+	assert.True(t, config.GetSmartMode())
+	assert.True(t, config.GetSmartDependents())
+	assert.Equal(t, "Smart mode: enabled\nSmart mode dependents: enabled\n", output)
+
+	captureStdout(t, func() {
+		require.NoError(t, handleSmart(config, nil))
+	})
+	assert.False(t, config.GetSmartMode())
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleSmart(config, []string{"everything"}))
+	})
+	assert.Equal(t, "Error: smart accepts no arguments or \"deps\", got \"everything\"\n", output)
+	assert.True(t, config.GetSmartDependents(), "an invalid argument should change nothing")
+}
+
+// TestHandleEnv_SetsListsUnsetsAndClears tests the env subcommands
+func TestHandleEnv_SetsListsUnsetsAndClears(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleEnv(config, nil))
+		require.NoError(t, handleEnv(config, []string{"DB_URL=postgres://localhost/test?a=b", "DEBUG="}))
+		require.NoError(t, handleEnv(config, nil))
+	})
+	assert.Equal(t, map[string]string{"DB_URL": "postgres://localhost/test?a=b", "DEBUG": ""}, config.GetEnv())
+	assert.Equal(t, "Env: none set\n"+
+		"Env: DB_URL=postgres://localhost/test?a=b\n"+
+		"Env: DEBUG=\n"+
+		"Env:\n"+
+		"  DB_URL=postgres://localhost/test?a=b\n"+
+		"  DEBUG=\n", output)
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleEnv(config, []string{"unset", "DEBUG", "MISSING"}))
+	})
+	assert.Equal(t, map[string]string{"DB_URL": "postgres://localhost/test?a=b"}, config.GetEnv())
+	assert.Equal(t, "Env: unset DEBUG\nEnv: MISSING was not set\n", output)
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleEnv(config, []string{"clear"}))
+	})
+	assert.Empty(t, config.GetEnv())
+	assert.Equal(t, "Env: cleared\n", output)
+}
+
+// TestHandleEnv_RejectsInvalidAssignments tests that nothing is set if any assignment is malformed
+func TestHandleEnv_RejectsInvalidAssignments(t *testing.T) {
+	config := NewTestConfig()
+
+	assert.Error(t, handleEnv(config, []string{"GOOD=1", "BAD"}))
+	assert.Error(t, handleEnv(config, []string{"=value"}))
+	assert.Error(t, handleEnv(config, []string{"unset"}))
+	assert.Empty(t, config.GetEnv(), "no variables should be set from a rejected command")
+}
+
+// ============================================================================
+// Watch Dir Tests
+// ============================================================================
+
+func TestHandleWatch_AddsDirectory(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "shared"), 0o750))
+	config := NewTestConfig()
+	config.SetWorkingDir(filepath.Join(base, "project"))
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleWatch(config, []string{"../shared"}))
+	})
+	assert.Equal(t, []string{filepath.Join(base, "shared")}, config.GetWatchDirs())
+	assert.Equal(t, "Watching: "+filepath.Join(base, "shared")+"\n", output)
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleWatch(config, []string{filepath.Join(base, "shared")}))
+	})
+	assert.Len(t, config.GetWatchDirs(), 1, "should not add a directory twice")
+	assert.Contains(t, output, "Already watching:")
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleWatch(config, nil))
+	})
+	assert.Equal(t, "Extra watch dirs:\n  "+filepath.Join(base, "shared")+"\n", output)
+}
+
+func TestHandleWatch_RejectsMissingDirectory(t *testing.T) {
+	config := NewTestConfig()
+
+	err := handleWatch(config, []string{filepath.Join(t.TempDir(), "missing")})
+
+	require.Error(t, err)
+	assert.Empty(t, config.GetWatchDirs())
+}
+
+func TestHandleUnwatch(t *testing.T) {
+	dir := t.TempDir()
+	config := NewTestConfig()
+	config.AddWatchDir(dir)
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleUnwatch(config, []string{dir}))
+	})
+	assert.Empty(t, config.GetWatchDirs())
+	assert.Equal(t, "Unwatched: "+dir+"\n", output)
+
+	require.Error(t, handleUnwatch(config, []string{dir}), "should not unwatch a directory that is not watched")
+	require.Error(t, handleUnwatch(config, nil), "should require a directory")
+}
+
+func TestHandleRescan(t *testing.T) {
+	output := captureStdout(t, func() {
+		require.NoError(t, handleRescan(NewTestConfig(), nil))
+	})
+
+	assert.Equal(t, "Rescanning watched directories\n", output)
+}
+
+// ============================================================================
+// Status Tests
+// ============================================================================
+
+func TestHandleStatus_ShowsSettingsAndCommand(t *testing.T) {
+	config := NewTestConfig()
+	config.SetVerbose(true)
+	config.SetRunPattern("TestFoo")
+	config.SetCount(3)
+	config.SetTestArgs([]string{"-update", "-golden"})
+	config.SetEnvVar("DB_URL", "postgres://localhost/test")
+	config.SetEnvVar("API_KEY", "secret")
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleStatus(config, nil))
+	})
+
+	assert.True(t, strings.HasPrefix(output, "Status:\n"))
+	assert.Contains(t, output, "  testPath: ./...\n")
+	assert.Contains(t, output, "  verbose: true\n")
+	assert.Contains(t, output, "  runPattern: TestFoo\n")
+	assert.Contains(t, output, "  skipPattern: \"\"\n")
+	assert.Contains(t, output, "  commandBase: [go, test]\n")
+	assert.Contains(t, output, "  count: 3\n")
+	assert.Contains(t, output, "  race: false\n")
+	assert.Contains(t, output, "  testArgs: [-update, -golden]\n")
+	assert.Contains(t, output, "  env: {API_KEY=secret, DB_URL=postgres://localhost/test}\n")
+	assert.True(t, strings.HasSuffix(output, "Command: go test ./... -v -count=3 -run=TestFoo -args -update -golden\n"))
+}
+
+func TestHandleStatus_ListsEverySetting(t *testing.T) {
+	output := captureStdout(t, func() {
+		require.NoError(t, handleStatus(NewTestConfig(), nil))
+	})
+
+	configType := reflect.TypeOf(TestConfig{})
+	for i := range configType.NumField() {
+		field := configType.Field(i)
+		key := field.Tag.Get("yaml")
+		if !field.IsExported() || key == "" {
+			continue
+		}
+		assert.Contains(t, output, "  "+key+": ", "status should show %s", field.Name)
+	}
+}
+
+func TestHandleStatus_UnknownRunner(t *testing.T) {
+	config := NewTestConfig()
+	config.SetRunner("missing")
+
+	var err error
+	captureStdout(t, func() {
+		err = handleStatus(config, nil)
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown runner "missing"`)
+}
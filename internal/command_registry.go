@@ -24,8 +24,40 @@ func initRegistry() {
 	commandRegistry[RaceCmd] = handleRace
 	commandRegistry[FailFastCmd] = handleFailFast
 	commandRegistry[CountCmd] = handleCount
+	commandRegistry[TimeoutCmd] = handleTimeout
+	commandRegistry[ParallelCmd] = handleParallel
+	commandRegistry[CPUCmd] = handleCPU
+	commandRegistry[TestArgsCmd] = handleTestArgs
 	commandRegistry[CoverCmd] = handleCover
+	commandRegistry[ShortCmd] = handleShort
+	commandRegistry[ShuffleCmd] = handleShuffle
 	commandRegistry[ColorCmd] = handleColor
+	commandRegistry[MetricsCmd] = handleMetrics
+	commandRegistry[LiteralCmd] = handleLiteral
+	commandRegistry[ReplayRunCmd] = handleReplayRun
+	commandRegistry[PushPatternCmd] = handlePushPattern
+	commandRegistry[PopPatternCmd] = handlePopPattern
+	commandRegistry[InterruptCmd] = handleInterrupt
+	commandRegistry[SmartCmd] = handleSmart
+	commandRegistry[CoverHTMLCmd] = handleCoverHTML
+	commandRegistry[EnvCmd] = handleEnv
+	commandRegistry[FuzzCmd] = handleFuzz
+	commandRegistry[VetCmd] = handleVet
+	commandRegistry[WatchCmd] = handleWatch
+	commandRegistry[UnwatchCmd] = handleUnwatch
+	commandRegistry[RescanCmd] = handleRescan
+	commandRegistry[StatusCmd] = handleStatus
+	commandRegistry[QuietPassCmd] = handleQuietPass
+	commandRegistry[CollapseCmd] = handleCollapse
+	commandRegistry[ShowCmd] = handleShow
+	commandRegistry[HistoryCmd] = handleHistory
+	commandRegistry[NotifyCmd] = handleNotify
+	commandRegistry[SlowCmd] = handleSlow
+	commandRegistry[GroupCmd] = handleGroup
+	commandRegistry[NoCacheCmd] = handleNoCache
+	commandRegistry[ChangedCmd] = handleChanged
+	commandRegistry[FuncsCmd] = handleFuncs
+	commandRegistry[CoverPkgCmd] = handleCoverPkg
 }
 
 func handleCommand(command Command, config *TestConfig, args []string) error {
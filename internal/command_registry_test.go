@@ -36,6 +36,20 @@ func TestInitRegistry_RegistersSimpleHandlers(t *testing.T) {
 	assert.True(t, hasHelp, "Should register 'help' command")
 }
 
+// TestInitRegistry_RegistersCoverToggle tests that cover is dispatched like race and ff
+func TestInitRegistry_RegistersCoverToggle(t *testing.T) {
+	initRegistry()
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleCommand(CoverCmd, config, nil))
+	})
+
+	assert.True(t, config.GetCover(), "cover command should enable -cover")
+	assert.Equal(t, "Cover: enabled\n", output)
+	assert.Equal(t, "go test ./... -cover", config.BuildCommand())
+}
+
 // TestInitRegistry_RegistersParameterHandlers tests that initRegistry registers parameter handlers
 func TestInitRegistry_RegistersParameterHandlers(t *testing.T) {
 	initRegistry()
@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// testNamePrefixes are the prefixes of the functions `go test` runs.
+var testNamePrefixes = []string{"Test", "Benchmark", "Fuzz", "Example"}
+
+// completeInput completes the word at the end of line, which is the part of
+// a command typed before the cursor. Command names are completed from the
+// registry and the configured macros, directories for the commands that take one, and the names of the
+// tests under the working directory for the commands that take a test name
+// pattern. It returns the byte offset in line of the start of the word and
+// its possible completions, in order.
+func completeInput(config *TestConfig, line string) (int, []string) {
+	// Only the last of several commands on the line is completed
+	offset := strings.LastIndex(line, commandSeparator) + 1
+	offset += len(line[offset:]) - len(strings.TrimLeftFunc(line[offset:], unicode.IsSpace))
+	line = line[offset:]
+
+	cmdEnd := strings.IndexFunc(line, unicode.IsSpace)
+	if cmdEnd < 0 {
+		var names []string
+		for cmd := range commandRegistry {
+			if strings.HasPrefix(string(cmd), line) {
+				names = append(names, string(cmd)+" ")
+			}
+		}
+		if config != nil {
+			for macro := range config.GetMacros() {
+				if strings.HasPrefix(macro, line) && commandRegistry[Command(macro)] == nil {
+					names = append(names, macro+" ")
+				}
+			}
+		}
+		slices.Sort(names)
+		return offset, names
+	}
+
+	start := strings.LastIndexFunc(line, unicode.IsSpace) + 1
+	word := line[start:]
+	switch Command(line[:cmdEnd]) {
+	case SetPathCmd, WatchCmd, UnwatchCmd:
+		return offset + start, completeDir(testDir(config), word)
+	case SetPatternCmd, SetSkipCmd, PushPatternCmd:
+		// Complete the last alternative of a pattern such as
+		// ^TestFoo$|^TestBar
+		start += strings.LastIndexAny(word, "|^(") + 1
+		return offset + start, completeTestName(testDir(config), line[start:])
+	}
+	return offset + start, nil
+}
+
+// testDir returns the directory tests run in, which completions and other
+// relative paths are resolved from.
+func testDir(config *TestConfig) string {
+	if config != nil && config.GetWorkingDir() != "" {
+		return config.GetWorkingDir()
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		return cwd
+	}
+	return "."
+}
+
+// completeDir returns the directories that word, a path relative to base
+// unless it is absolute, could be completed to, each with a trailing slash.
+// Hidden directories are only offered once a `.` has been typed.
+func completeDir(base, word string) []string {
+	dir, prefix := path.Split(filepath.ToSlash(word))
+	if dir == "" && word == "" {
+		dir = "./"
+	}
+	searchDir := filepath.FromSlash(dir)
+	if !filepath.IsAbs(searchDir) {
+		searchDir = filepath.Join(base, searchDir)
+	}
+
+	entries, err := os.ReadDir(searchDir)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(searchDir, name)); err != nil || !info.IsDir() {
+			continue
+		}
+		dirs = append(dirs, dir+name+"/")
+	}
+	return dirs
+}
+
+// completeTestName returns the names of the tests, benchmarks, fuzz tests
+// and examples declared under base that start with prefix.
+func completeTestName(base, prefix string) []string {
+	var names []string
+	for _, name := range findTestNames(base) {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// findTestNames returns the sorted names of the test functions declared in
+// the _test.go files under root, skipping hidden, vendor and testdata
+// directories.
+func findTestNames(root string) []string {
+	seen := map[string]bool{}
+	fset := token.NewFileSet()
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if p != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, p, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if ok && fn.Recv == nil && isTestName(fn.Name.Name) {
+				seen[fn.Name.Name] = true
+			}
+		}
+		return nil
+	})
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// isTestName reports whether name is the name of a function that `go test`
+// runs, which is one of testNamePrefixes not followed by a lower-case letter.
+func isTestName(name string) bool {
+	if name == "TestMain" {
+		return false
+	}
+	for _, prefix := range testNamePrefixes {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			r, _ := utf8.DecodeRuneInString(rest)
+			return rest == "" || !unicode.IsLower(r)
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCompletionTree creates a directory with packages and tests to
+// complete, and returns a config that runs tests in it.
+func setupCompletionTree(t *testing.T) *TestConfig {
+	t.Helper()
+	root := t.TempDir()
+
+	for _, dir := range []string{"internal/store", "integration", "cmd", ".git", "testdata", "vendor/dep"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o600))
+
+	files := map[string]string{
+		"internal/store/store_test.go": `package store
+
+import "testing"
+
+func TestMain(m *testing.M) {}
+func TestStoreGet(t *testing.T) {}
+func TestStorePut(t *testing.T) {}
+func Testify(t *testing.T) {}
+func BenchmarkStore(b *testing.B) {}
+func FuzzStore(f *testing.F) {}
+func ExampleStore() {}
+func helper(t *testing.T) {}
+
+type suite struct{}
+
+func (suite) TestMethod(t *testing.T) {}
+`,
+		"cmd/cmd_test.go": `package cmd
+
+import "testing"
+
+func TestCommand(t *testing.T) {}
+func TestStoreGet(t *testing.T) {}
+`,
+		"cmd/broken_test.go":              "package cmd\n\nfunc TestBroken(",
+		"testdata/data_test.go":           "package data\n\nfunc TestData(t *testing.T) {}\n",
+		"vendor/dep/dep_test.go":          "package dep\n\nfunc TestVendored(t *testing.T) {}\n",
+		"internal/store/store.go":         "package store\n\nfunc TestNotInATestFile() {}\n",
+		"integration/integration_test.go": "package integration\n\nfunc Test(t *testing.T) {}\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o600))
+	}
+
+	config := NewTestConfig()
+	config.SetWorkingDir(root)
+	config.SetMacros(map[string]string{"setup": "v; race", "f": "v"})
+	return config
+}
+
+func TestFindTestNames(t *testing.T) {
+	config := setupCompletionTree(t)
+
+	assert.Equal(t, []string{
+		"BenchmarkStore",
+		"ExampleStore",
+		"FuzzStore",
+		"Test",
+		"TestCommand",
+		"TestStoreGet",
+		"TestStorePut",
+	}, findTestNames(config.GetWorkingDir()))
+}
+
+func TestCompleteInput(t *testing.T) {
+	initRegistry()
+	config := setupCompletionTree(t)
+
+	tests := []struct {
+		name          string
+		line          string
+		expectedStart int
+		expected      []string
+	}{
+		{"command names", "ra", 0, []string{"race "}},
+		{"several command names", "co", 0, []string{"collapse ", "color ", "count ", "cover ", "coverhtml ", "coverpkg "}},
+		{"unknown command name", "zz", 0, nil},
+		{"test names for r", "r TestSt", 2, []string{"TestStoreGet", "TestStorePut"}},
+		{"test names for s", "s Bench", 2, []string{"BenchmarkStore"}},
+		{"test names for push", "push Fuzz", 5, []string{"FuzzStore"}},
+		{"last alternative of a pattern", "r ^TestCommand$|^TestStoreP", 17, []string{"TestStorePut"}},
+		{"directories for p", "p ./in", 2, []string{"./integration/", "./internal/"}},
+		{"nested directories for p", "p ./internal/s", 2, []string{"./internal/store/"}},
+		{"directories without ./", "p int", 2, []string{"integration/", "internal/"}},
+		{"all directories", "p ", 2, []string{"./cmd/", "./integration/", "./internal/", "./testdata/", "./vendor/"}},
+		{"hidden directories once typed", "p ./.g", 2, []string{"./.git/"}},
+		{"files are not offered", "p ./ma", 2, nil},
+		{"directories for watch", "watch ./cm", 6, []string{"./cmd/"}},
+		{"no completion for other arguments", "count 1", 6, nil},
+		{"macro names", "set", 0, []string{"setup "}},
+		{"last of several commands", "v; ra", 3, []string{"race "}},
+		{"arguments of the last of several commands", "v;r TestSt", 4, []string{"TestStoreGet", "TestStorePut"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, candidates := completeInput(config, tt.line)
+			assert.Equal(t, tt.expectedStart, start)
+			assert.Equal(t, tt.expected, candidates)
+		})
+	}
+}
+
+func TestLineEditor_Completion(t *testing.T) {
+	complete := func(before string) (int, []string) {
+		start := strings.LastIndex(before, " ") + 1
+		var candidates []string
+		for _, name := range []string{"race ", "rescan ", "replay-run ", "TestStoreGet", "TestStorePut"} {
+			if strings.HasPrefix(name, before[start:]) {
+				candidates = append(candidates, name)
+			}
+		}
+		return start, candidates
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		listed   string
+	}{
+		{"single completion", "ra\t\n", "race ", ""},
+		{"completes as far as completions agree", "r TestS\t\n", "r TestStore", ""},
+		{"completion in the middle of a line", "ra TestS\x01\x06\x06\t\n", "race  TestS", ""},
+		{"lists completions that cannot be extended", "re\t\n", "re", "rescan  replay-run"},
+		{"no completions", "x\t\n", "x", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var echoed bytes.Buffer
+			e := newLineEditor(bufio.NewReader(strings.NewReader(tt.input)), &echoed)
+			e.complete = complete
+			e.prompt = func() string { return "> " }
+
+			require.True(t, e.Scan())
+			assert.Equal(t, tt.expected, e.Text())
+			if tt.listed != "" {
+				assert.Contains(t, echoed.String(), "\n"+tt.listed+"\n> "+tt.expected)
+			} else {
+				assert.NotContains(t, echoed.String(), "\n> ")
+			}
+		})
+	}
+}
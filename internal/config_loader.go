@@ -1,18 +1,53 @@
 package internal
 
-import "log"
+import (
+	"errors"
+	"fmt"
+	"log"
+)
 
+// LoadOrDefaultConfig returns the default config, overridden by the user's
+// global config file if there is one, and then by the project's config file
+// in dirpath. A file that cannot be parsed is skipped with a warning.
 func LoadOrDefaultConfig(dirpath string) *TestConfig {
+	config, problems, err := loadConfig(dirpath)
+	for _, problem := range problems {
+		log.Printf("Warning: %s", problem)
+	}
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return config
+}
+
+// loadConfig returns the config LoadOrDefaultConfig would, along with the
+// problems with the settings in the config files and an error for each file
+// that could not be parsed.
+func loadConfig(dirpath string) (*TestConfig, []configProblem, error) {
+	config := NewTestConfig()
+	var problems []configProblem
+	var errs []error
+
+	if path, err := FindGlobalConfigFile(); err == nil {
+		globalProblems, err := mergeConfigFile(config, path, dirpath)
+		problems = append(problems, globalProblems...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse config file %s: %w", path, err))
+			config = NewTestConfig()
+		}
+	}
+
 	filepath, err := FindConfigFile(dirpath)
 	if err != nil {
-		return NewTestConfig()
+		return config, problems, errors.Join(errs...)
 	}
 
-	config, err := LoadConfigFromYAML(filepath)
+	project := config.Snapshot()
+	projectProblems, err := mergeConfigFile(project, filepath, dirpath)
 	if err != nil {
-		log.Printf("Warning: failed to parse config file %s: %v", filepath, err)
-		return NewTestConfig()
+		errs = append(errs, fmt.Errorf("failed to parse config file %s: %w", filepath, err))
+		return config, problems, errors.Join(errs...)
 	}
 
-	return config
+	return project, append(problems, projectProblems...), errors.Join(errs...)
 }
@@ -13,6 +13,9 @@ import (
 )
 
 func TestLoadOrDefaultConfig(t *testing.T) {
+	// Keep the user's own global config out of these tests
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
 	t.Run("returns default config when no config file exists", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -143,7 +146,7 @@ workingDir: /tmp/work
 		assert.True(t, config.Cover)
 		assert.True(t, config.FailFast)
 		assert.Equal(t, 5, config.Count)
-		assert.True(t, config.ClearScreen)
+		assert.Equal(t, ClearAlways, config.ClearScreen)
 		assert.True(t, config.Color)
 		assert.Equal(t, "/tmp/work", config.WorkingDir)
 	})
@@ -202,3 +205,115 @@ this is: invalid: yaml: content
 		assert.Empty(t, logOutput, "Expected no log output when config file doesn't exist, got: %s", logOutput)
 	})
 }
+
+// writeGlobalConfig writes content as the global config in a new config
+// home, and returns the file's path.
+func writeGlobalConfig(t *testing.T, content string) string {
+	t.Helper()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	dir := filepath.Join(configHome, "gotest-watch")
+	require.NoError(t, os.MkdirAll(dir, 0o750))
+	path := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadOrDefaultConfig_GlobalConfig(t *testing.T) {
+	globalContent := `---
+color: true
+clearScreen: true
+commandBase:
+- richgo
+- test
+env:
+  GOFLAGS: -mod=mod
+  DB_URL: postgres://localhost/global
+`
+
+	t.Run("applies the global config without a project config", func(t *testing.T) {
+		writeGlobalConfig(t, globalContent)
+
+		config := LoadOrDefaultConfig(t.TempDir())
+
+		assert.True(t, config.Color)
+		assert.Equal(t, ClearAlways, config.ClearScreen)
+		assert.Equal(t, []string{"richgo", "test"}, config.CommandBase)
+		assert.Equal(t, "./...", config.TestPath, "settings missing from both files keep their defaults")
+	})
+
+	t.Run("project config overrides the global config", func(t *testing.T) {
+		writeGlobalConfig(t, globalContent)
+		projectDir := t.TempDir()
+		projectContent := `---
+clearScreen: false
+commandBase:
+- go
+- test
+testPath: ./pkg/...
+env:
+  DB_URL: postgres://localhost/project
+`
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".gotest-watch.yml"), []byte(projectContent), 0o600))
+
+		config := LoadOrDefaultConfig(projectDir)
+
+		assert.True(t, config.Color, "settings only in the global config apply")
+		assert.Equal(t, ClearNever, config.ClearScreen, "settings in the project config win")
+		assert.Equal(t, []string{"go", "test"}, config.CommandBase)
+		assert.Equal(t, "./pkg/...", config.TestPath)
+		assert.Equal(t, map[string]string{
+			"GOFLAGS": "-mod=mod",
+			"DB_URL":  "postgres://localhost/project",
+		}, config.Env, "env entries are merged")
+	})
+
+	t.Run("invalid project config falls back to the global config", func(t *testing.T) {
+		writeGlobalConfig(t, globalContent)
+		projectDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".gotest-watch.yml"), []byte("verbose: [unclosed"), 0o600))
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(os.Stderr)
+
+		config := LoadOrDefaultConfig(projectDir)
+
+		assert.True(t, config.Color)
+		assert.False(t, config.Verbose)
+		assert.Contains(t, buf.String(), "Warning")
+	})
+
+	t.Run("invalid global config is skipped with a warning", func(t *testing.T) {
+		path := writeGlobalConfig(t, "color: [unclosed")
+		projectDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".gotest-watch.yml"), []byte("verbose: true\n"), 0o600))
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer log.SetOutput(os.Stderr)
+
+		config := LoadOrDefaultConfig(projectDir)
+
+		assert.True(t, config.Verbose)
+		assert.False(t, config.Color)
+		assert.Contains(t, buf.String(), path)
+	})
+}
+
+func TestLoadOrDefaultConfig_LogsConfigProblems(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".gotest-watch.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("verbose: true\ncount: many\n"), 0o600))
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	config := LoadOrDefaultConfig(tmpDir)
+
+	assert.True(t, config.Verbose, "the rest of the file should still be used")
+	assert.Contains(t, buf.String(), "Warning: "+configPath+":2: cannot unmarshal !!str `many` into int")
+}
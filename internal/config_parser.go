@@ -1,36 +1,132 @@
 package internal
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
+// globalConfigDir is the directory, under the user's config directory,
+// holding the config that applies to every project.
+const globalConfigDir = "gotest-watch"
+
+// configFileNames are the names of the project's config file, in order of
+// preference.
+var configFileNames = []string{
+	".gotest-watch.yml",
+	".gotest-watch.yaml",
+	".gotest-watch.toml",
+	".gotest-watch.json",
+}
+
+// globalConfigFileNames are the names of the user's config file, in order of
+// preference.
+var globalConfigFileNames = []string{"config.yml", "config.yaml", "config.toml", "config.json"}
+
 func LoadConfigFromYAML(file string) (*TestConfig, error) {
+	tc := NewTestConfig()
+	if err := mergeConfigFromYAML(tc, file); err != nil {
+		return nil, err
+	}
+
+	return tc, nil
+}
+
+// mergeConfigFromYAML sets the settings in file on tc, leaving those the
+// file does not mention alone. Entries in maps such as env are added to
+// those already set.
+func mergeConfigFromYAML(tc *TestConfig, file string) error {
 	file = filepath.Clean(file)
 	config, err := os.ReadFile(file)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	tc := NewTestConfig()
-	err = yaml.Unmarshal(config, tc)
+	return yaml.Unmarshal(config, tc)
+}
+
+// mergeConfigFile is mergeConfigFromYAML for a config file in any supported
+// format, chosen by its extension: .toml, .json, or YAML otherwise. The keys
+// are the same in every format. Rather than failing, it returns the
+// problems with settings that are unknown or have invalid values, resolving
+// relative paths from dir.
+func mergeConfigFile(tc *TestConfig, file, dir string) ([]configProblem, error) {
+	config, err := os.ReadFile(filepath.Clean(file))
 	if err != nil {
 		return nil, err
 	}
 
-	return tc, nil
+	var unmarshal func([]byte, any) error
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".toml":
+		unmarshal = toml.Unmarshal
+	case ".json":
+		unmarshal = json.Unmarshal
+	default:
+		return decodeSettings(tc, file, config, dir, true)
+	}
+
+	// Convert the settings to YAML, so that they are decoded and merged
+	// just as YAML settings are
+	var settings map[string]any
+	if err := unmarshal(config, &settings); err != nil {
+		return nil, err
+	}
+	config, err = yaml.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSettings(tc, file, config, dir, false)
 }
 
+// FindConfigFile returns the path of the project's config file in dirpath,
+// trying each of configFileNames in turn.
 func FindConfigFile(dirpath string) (string, error) {
-	ymlPath := filepath.Join(dirpath, ".gotest-watch.yml")
-	if _, err := os.Stat(ymlPath); err == nil {
-		return ymlPath, nil
-	}
-	yamlPath := filepath.Join(dirpath, ".gotest-watch.yaml")
-	if _, err := os.Stat(yamlPath); err == nil {
-		return yamlPath, nil
+	for _, name := range configFileNames {
+		path := filepath.Join(dirpath, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
 	}
 	return "", fmt.Errorf("gotest-watch config file not found")
 }
+
+// FindGlobalConfigFile returns the path of the user's config file, one of
+// globalConfigFileNames in $XDG_CONFIG_HOME/gotest-watch, or, if
+// XDG_CONFIG_HOME is not set, ~/.config/gotest-watch, or
+// %AppData%\gotest-watch on Windows.
+func FindGlobalConfigFile() (string, error) {
+	configHome, err := userConfigHome(runtime.GOOS)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(configHome, globalConfigDir)
+	for _, name := range globalConfigFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("gotest-watch global config file not found")
+}
+
+// userConfigHome returns the directory user config files go in on goos.
+func userConfigHome(goos string) (string, error) {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return configHome, nil
+	}
+	if goos == "windows" {
+		return os.UserConfigDir()
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config"), nil
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -38,11 +39,41 @@ workingDir: /tmp/test
 		assert.True(t, config.Race)
 		assert.True(t, config.Cover)
 		assert.True(t, config.FailFast)
-		assert.True(t, config.ClearScreen)
+		assert.Equal(t, ClearAlways, config.ClearScreen)
 		assert.True(t, config.Color)
 		assert.Equal(t, "/tmp/test", config.WorkingDir)
 	})
 
+	t.Run("loads env map", func(t *testing.T) {
+		yamlContent := `---
+env:
+  DB_URL: postgres://localhost/test
+  DEBUG: "1"
+`
+		tmpFile := createTempYAMLFile(t, yamlContent)
+		defer os.Remove(tmpFile)
+
+		config, err := LoadConfigFromYAML(tmpFile)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{"DB_URL": "postgres://localhost/test", "DEBUG": "1"}, config.GetEnv())
+	})
+
+	t.Run("loads ignore list", func(t *testing.T) {
+		yamlContent := `---
+ignore:
+- "**/mocks/**"
+- "*_gen.go"
+`
+		tmpFile := createTempYAMLFile(t, yamlContent)
+		defer os.Remove(tmpFile)
+
+		config, err := LoadConfigFromYAML(tmpFile)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"**/mocks/**", "*_gen.go"}, config.GetIgnore())
+	})
+
 	t.Run("handles empty strings correctly", func(t *testing.T) {
 		yamlContent := `---
 commandBase:
@@ -73,7 +104,7 @@ workingDir: ""
 		assert.False(t, config.Race)
 		assert.False(t, config.Cover)
 		assert.False(t, config.FailFast)
-		assert.False(t, config.ClearScreen)
+		assert.Equal(t, ClearNever, config.ClearScreen)
 		assert.False(t, config.Color)
 		assert.Equal(t, "", config.WorkingDir)
 	})
@@ -205,6 +236,29 @@ func TestFindConfigFile(t *testing.T) {
 		assert.Equal(t, ymlPath, found)
 	})
 
+	t.Run("finds .gotest-watch.toml and .gotest-watch.json", func(t *testing.T) {
+		for _, name := range []string{".gotest-watch.toml", ".gotest-watch.json"} {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, name)
+			require.NoError(t, os.WriteFile(configPath, []byte(""), 0o600))
+
+			found, err := FindConfigFile(tmpDir)
+			require.NoError(t, err)
+			assert.Equal(t, configPath, found)
+		}
+	})
+
+	t.Run("prefers YAML over TOML and JSON", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		ymlPath := filepath.Join(tmpDir, ".gotest-watch.yml")
+		require.NoError(t, os.WriteFile(ymlPath, []byte("verbose: true"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".gotest-watch.toml"), []byte("verbose = true"), 0o600))
+
+		found, err := FindConfigFile(tmpDir)
+		require.NoError(t, err)
+		assert.Equal(t, ymlPath, found)
+	})
+
 	t.Run("returns error when no config file exists", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -229,6 +283,86 @@ func TestFindConfigFile(t *testing.T) {
 	})
 }
 
+func TestMergeConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "config.toml",
+			content: `commandBase = ["richgo", "test"]
+verbose = true
+count = 3
+testArgs = ["-update"]
+
+[env]
+DB_URL = "postgres://localhost/test"
+`,
+		},
+		{
+			name: "config.json",
+			content: `{
+  "commandBase": ["richgo", "test"],
+  "verbose": true,
+  "count": 3,
+  "testArgs": ["-update"],
+  "env": {"DB_URL": "postgres://localhost/test"}
+}`,
+		},
+		{
+			name: "config.yml",
+			content: `commandBase: [richgo, test]
+verbose: true
+count: 3
+testArgs: [-update]
+env:
+  DB_URL: postgres://localhost/test
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.name)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			config := NewTestConfig()
+			config.SetEnvVar("DEBUG", "1")
+			_, err := mergeConfigFile(config, path, t.TempDir())
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"richgo", "test"}, config.CommandBase)
+			assert.True(t, config.Verbose)
+			assert.Equal(t, 3, config.Count)
+			assert.Equal(t, []string{"-update"}, config.TestArgs)
+			assert.Equal(t, "./...", config.TestPath, "unset fields should keep their values")
+			assert.Equal(t, map[string]string{"DEBUG": "1", "DB_URL": "postgres://localhost/test"}, config.Env)
+		})
+	}
+
+	t.Run("returns error for invalid TOML and JSON", func(t *testing.T) {
+		for name, content := range map[string]string{"config.toml": "verbose = ", "config.json": "{\"verbose\": }"} {
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			_, err := mergeConfigFile(NewTestConfig(), path, t.TempDir())
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("reports wrongly typed values without their line", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"count": "many", "verbose": true}`), 0o600))
+
+		config := NewTestConfig()
+		problems, err := mergeConfigFile(config, path, t.TempDir())
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.Equal(t, path+": cannot unmarshal !!str `many` into int", problems[0].String())
+		assert.True(t, config.Verbose)
+	})
+}
+
 // createTempYAMLFile creates a temporary YAML file with the given content
 func createTempYAMLFile(t *testing.T, content string) string {
 	t.Helper()
@@ -247,3 +381,71 @@ func createTempYAMLFile(t *testing.T, content string) string {
 
 	return tmpFile.Name()
 }
+
+func TestFindGlobalConfigFile(t *testing.T) {
+	t.Run("finds config.yml under XDG_CONFIG_HOME", func(t *testing.T) {
+		path := writeGlobalConfig(t, "color: true")
+
+		found, err := FindGlobalConfigFile()
+		require.NoError(t, err)
+		assert.Equal(t, path, found)
+	})
+
+	t.Run("finds config.yaml", func(t *testing.T) {
+		configHome := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", configHome)
+		path := filepath.Join(configHome, "gotest-watch", "config.yaml")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte("color: true"), 0o600))
+
+		found, err := FindGlobalConfigFile()
+		require.NoError(t, err)
+		assert.Equal(t, path, found)
+	})
+
+	t.Run("defaults to ~/.config", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the config directory defaults to %AppData% on Windows")
+		}
+		home := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("HOME", home)
+		path := filepath.Join(home, ".config", "gotest-watch", "config.yml")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte("color: true"), 0o600))
+
+		found, err := FindGlobalConfigFile()
+		require.NoError(t, err)
+		assert.Equal(t, path, found)
+	})
+
+	t.Run("returns error when there is no global config", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		_, err := FindGlobalConfigFile()
+		assert.Error(t, err)
+	})
+}
+
+func TestUserConfigHome(t *testing.T) {
+	t.Run("prefers XDG_CONFIG_HOME on every platform", func(t *testing.T) {
+		configHome := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", configHome)
+
+		for _, goos := range []string{"linux", "darwin", "windows"} {
+			dir, err := userConfigHome(goos)
+			require.NoError(t, err)
+			assert.Equal(t, configHome, dir, goos)
+		}
+	})
+
+	t.Run("uses ~/.config on macOS rather than Library", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("HOME", home)
+
+		dir, err := userConfigHome("darwin")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(home, ".config"), dir)
+	})
+}
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// restartSettings are the settings, named as in .gotest-watch.yml, that are
+// only read when gotest-watch starts, so reloading the config file can't
+// change them.
+var restartSettings = []string{
+	"altScreen",
+	"controlSocket",
+	"deadlockTimeout",
+	"httpAddr",
+	"noInput",
+	"pasteGuard",
+	"promptShowsPendingChanges",
+	"singleKey",
+	"testPathRelativeToGitRoot",
+	"watchMinFileSize",
+	"watchQuietPeriod",
+	"workingDir",
+}
+
+// watchSettings are the settings that decide which files are watched, so
+// the watcher must rebuild its watch list when they change.
+var watchSettings = []string{"exclude", "followSymlinks", "ignore", "poll", "watchDirs"}
+
+// configReloader reloads the project's config file when it changes.
+type configReloader struct {
+	dir      string
+	requests chan struct{}
+	// loaded is the config as last read from the config files, before
+	// any flags or commands changed it
+	loaded *TestConfig
+}
+
+// isConfigFile reports whether path is the project's config file.
+func (r *configReloader) isConfigFile(path string) bool {
+	if r == nil || filepath.Dir(path) != filepath.Clean(r.dir) {
+		return false
+	}
+	return slices.Contains(configFileNames, filepath.Base(path))
+}
+
+// configFiles returns the paths the project's config file may have.
+func (r *configReloader) configFiles() []string {
+	if r == nil {
+		return nil
+	}
+	paths := make([]string, len(configFileNames))
+	for i, name := range configFileNames {
+		paths[i] = filepath.Join(r.dir, name)
+	}
+	return paths
+}
+
+// request asks the dispatcher to reload the config. It never blocks, since
+// one pending request is enough.
+func (r *configReloader) request() {
+	select {
+	case r.requests <- struct{}{}:
+	default:
+	}
+}
+
+// reloadRequests returns the channel on which reloads are requested, or nil
+// if config files are not reloaded.
+func (r *configReloader) reloadRequests() <-chan struct{} {
+	if r == nil {
+		return nil
+	}
+	return r.requests
+}
+
+// reload rereads the config files and applies to config the settings that
+// changed in them since they were last read, so that settings changed since
+// by flags or commands are kept unless the file changes them too. Each
+// change is printed to w. It returns the names of the settings that
+// changed, including those that need a restart to take effect, and the
+// problems with the settings in the files. It leaves config alone if a file
+// could not be parsed.
+func (r *configReloader) reload(w io.Writer, config *TestConfig) ([]string, []configProblem, error) {
+	loaded, problems, err := loadConfig(r.dir)
+	if err != nil {
+		return nil, problems, err
+	}
+
+	previous := reflect.ValueOf(r.loaded).Elem()
+	next := reflect.ValueOf(loaded).Elem()
+	// Apply copies, so that later changes to config don't reach loaded
+	applied := reflect.ValueOf(loaded.Snapshot()).Elem()
+
+	var changed, lines []string
+	config.Lock()
+	current := reflect.ValueOf(config).Elem()
+	t := current.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || key == "" || sameSetting(previous.Field(i), next.Field(i)) {
+			continue
+		}
+
+		changed = append(changed, key)
+		line := fmt.Sprintf("  %s: %s -> %s", key, formatSetting(current.Field(i)), formatSetting(next.Field(i)))
+		if slices.Contains(restartSettings, key) {
+			line += " (restart gotest-watch to apply)"
+		} else {
+			current.Field(i).Set(applied.Field(i))
+		}
+		lines = append(lines, line)
+	}
+	config.Unlock()
+	r.loaded = loaded
+
+	if len(lines) > 0 {
+		fmt.Fprintln(w, "\nConfig file changed:")
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	}
+	return changed, problems, nil
+}
+
+// sameSetting reports whether a and b are the same value of a setting, with
+// empty and missing lists or maps counting as the same.
+func sameSetting(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Slice, reflect.Map:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// changedSettings returns the names of the settings, as in
+// .gotest-watch.yml, that differ between before and after.
+func changedSettings(before, after *TestConfig) []string {
+	a := reflect.ValueOf(before).Elem()
+	b := reflect.ValueOf(after).Elem()
+	t := a.Type()
+	var changed []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if field.IsExported() && key != "" && !sameSetting(a.Field(i), b.Field(i)) {
+			changed = append(changed, key)
+		}
+	}
+	return changed
+}
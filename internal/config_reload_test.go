@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestReloader writes content to a config file in a temp dir and returns
+// a reloader for it along with the config loaded from it
+func newTestReloader(t *testing.T, content string) (*configReloader, *TestConfig) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gotest-watch.yml"), []byte(content), 0o600))
+
+	config := LoadOrDefaultConfig(dir)
+	return &configReloader{dir: dir, requests: make(chan struct{}, 1), loaded: config.Snapshot()}, config
+}
+
+func writeReloadedConfig(t *testing.T, r *configReloader, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(r.dir, ".gotest-watch.yml"), []byte(content), 0o600))
+}
+
+func TestConfigReloader_AppliesChangedSettings(t *testing.T) {
+	r, config := newTestReloader(t, "verbose: false\ncount: 2\nignore: []\n")
+	// Changed by a command since the file was loaded
+	config.SetCount(5)
+	config.ToggleRace()
+
+	writeReloadedConfig(t, r, "verbose: true\ncount: 2\ntestArgs: [-update]\n")
+	var out bytes.Buffer
+	changed, _, err := r.reload(&out, config)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"verbose", "testArgs"}, changed)
+	assert.True(t, config.GetVerbose())
+	assert.Equal(t, []string{"-update"}, config.GetTestArgs())
+	assert.Equal(t, 5, config.GetCount(), "settings the file didn't change should be kept")
+	assert.True(t, config.GetRace(), "settings the file didn't change should be kept")
+	assert.Equal(t, "\nConfig file changed:\n  verbose: false -> true\n  testArgs: [] -> [-update]\n", out.String())
+}
+
+func TestConfigReloader_ComparesWithLastReload(t *testing.T) {
+	r, config := newTestReloader(t, "verbose: false\n")
+
+	writeReloadedConfig(t, r, "verbose: true\n")
+	_, _, err := r.reload(&bytes.Buffer{}, config)
+	require.NoError(t, err)
+	config.SetVerbose(false)
+
+	var out bytes.Buffer
+	changed, _, err := r.reload(&out, config)
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+	assert.False(t, config.GetVerbose())
+	assert.Empty(t, out.String())
+}
+
+func TestConfigReloader_ReportsRestartSettings(t *testing.T) {
+	r, config := newTestReloader(t, "singleKey: false\n")
+
+	writeReloadedConfig(t, r, "singleKey: true\n")
+	var out bytes.Buffer
+	changed, _, err := r.reload(&out, config)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"singleKey"}, changed)
+	assert.False(t, config.GetSingleKey())
+	assert.Contains(t, out.String(), "singleKey: false -> true (restart gotest-watch to apply)")
+}
+
+func TestConfigReloader_KeepsSettingsWhenFileIsInvalid(t *testing.T) {
+	r, config := newTestReloader(t, "verbose: true\n")
+
+	writeReloadedConfig(t, r, "verbose: [\n")
+	var out bytes.Buffer
+	changed, _, err := r.reload(&out, config)
+	require.Error(t, err)
+	assert.Empty(t, changed)
+	assert.True(t, config.GetVerbose())
+	assert.Empty(t, out.String())
+}
+
+func TestConfigReloader_IsConfigFile(t *testing.T) {
+	r := &configReloader{dir: "/project/"}
+
+	assert.True(t, r.isConfigFile("/project/.gotest-watch.yml"))
+	assert.True(t, r.isConfigFile("/project/.gotest-watch.yaml"))
+	assert.True(t, r.isConfigFile("/project/.gotest-watch.toml"))
+	assert.False(t, r.isConfigFile("/project/sub/.gotest-watch.yml"))
+	assert.False(t, r.isConfigFile("/project/main.go"))
+
+	var none *configReloader
+	assert.False(t, none.isConfigFile("/project/.gotest-watch.yml"))
+	assert.Nil(t, none.reloadRequests())
+}
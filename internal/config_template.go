@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// configTemplate is the config file written by `gotest-watch init`. It sets
+// every setting to its default, with a comment saying what it does.
+const configTemplate = `# gotest-watch settings. Every setting is shown with its default; delete the
+# ones you don't change. Flags and interactive commands override them.
+
+# The test command
+testPath: ./...              # Packages to test, separated by spaces
+commandBase: [go, test]      # Command the flags below are added to, e.g. [richgo, test]
+runner: go                   # Where the command runs: go (locally), docker or ssh host:path
+dockerService: ""            # With runner docker, the docker compose service to run in
+dockerImage: ""              # Otherwise, the image to run in a new container of (default: golang)
+dockerWorkdir: ""            # The project's directory in the container (default: /src)
+workingDir: ""               # Directory tests run in, if not the project root
+testPathRelativeToGitRoot: false
+verbose: false               # -v
+runPattern: ""               # -run
+skipPattern: ""              # -skip
+literalPatterns: false       # Match run patterns as literal text rather than regexps
+race: false                  # -race
+failfast: false              # -failfast
+cover: false                 # -cover
+coverPkg: ""                 # -coverpkg, packages coverage is measured for, e.g. ./internal/...
+short: false                 # -short
+autoSkipLongTests: false     # Add -short to runs started by file changes
+count: 0                     # -count, if above 0
+noCache: false               # Add -count=1 to bypass the test cache, unless count is set
+firstRunSkipCache: false     # Add -count=1 to the first run only
+timeout: ""                  # -timeout, e.g. 30s
+shuffle: ""                  # -shuffle: "on", or a seed to reproduce an ordering
+parallel: 0                  # -parallel, if above 0
+cpu: ""                      # -cpu, e.g. 1,2,4
+tags: ""                     # -tags
+testArgs: []                 # Arguments passed to the test binary after -args
+fuzzTime: ""                 # How long fuzz runs for; empty fuzzes until interrupted
+env: {}                      # Environment variables set for each run
+macros: {}                   # Named commands that run several commands separated by ;
+packages: {}                 # Settings for runs that only test matching packages
+vet: false                   # Run go vet before each run
+vetFailureSkipsTests: false  # Skip the run when go vet reports problems
+smartMode: false             # Only test the packages containing changed files
+smartIncludeDependents: false # In smart mode, also test the packages importing them
+since: ""                    # Only test packages changed since this git ref, and those importing them
+
+# Output
+color: false
+colorTheme: default          # default, colorblind or monochrome
+colorBackend: internal       # What colors test output: internal, richgo, gotest, or auto for richgo if installed
+colors: {}                   # pass, fail, skip, location and text colors, e.g. {fail: "196"}
+clearScreen: never           # always, never, or auto (only runs started by file changes)
+keepScrollback: false        # Scroll earlier runs into the scrollback when clearing
+altScreen: false             # Use the terminal's alternate screen
+linePrefix: ""               # Prefix for each line of test output
+quiet: false                 # Leave out the command echo, prompts and status messages
+quietPass: false             # Only show failures and a count of the results
+collapsePassing: false       # Show each passing package as its ok line
+groupByPackage: false        # With -v, show each package's output once it finishes
+structuredSummary: false     # Print passed, failed and skipped counts for each package
+summaryLine: false           # Print a count of passed, failed and skipped tests
+summaryJSON: false           # Print a JSON summary of each run to stderr
+profileSummary: 0            # Number of slowest tests to list after each run
+reportChangedFilesInSummary: false # List the files that started each run
+runBudget: ""                # Warn when a run takes longer than this, e.g. 30s
+deadlockTimeout: 0           # Seconds without progress before goroutines are dumped
+
+# Reports
+outputFile: ""               # File test output is also appended to
+runLogs: 0                   # Number of runs to keep the output of in .gotest-watch/runs
+junit: ""                    # File or directory JUnit XML reports are written to
+coverageFile: ""             # File coverage profiles are written to
+failureLocations: ""         # stderr, or a file, that failures are written to as file:line: message
+notify: false                # Send a desktop notification after each run
+webhookURL: ""               # URL each run's result is posted to as JSON
+notifications: []            # Slack or Discord webhooks, e.g. [{slack: URL, on: [failure, recovery]}]
+
+# Watching
+watchDirs: []                # More directories to watch, relative to the project root
+exclude: []                  # Directories never watched, by name or path
+ignore: []                   # Globs of paths that never start a run, e.g. "*_gen.go"
+followSymlinks: false        # Watch directories reached through symlinks
+poll: ""                     # Scan for changes at this interval instead, e.g. 500ms
+watchMinFileSize: 0          # Ignore changes to files smaller than this many bytes
+watchQuietPeriod: 0          # Milliseconds the tree must be quiet before a run
+exitOnFirstPass: false       # Exit once a run passes
+ci: false                    # Run the tests once and exit with their exit code, as --ci
+
+# Input
+singleKey: false             # Act on single keypresses without waiting for Enter
+noInput: false               # Never read commands or show the prompt, as when stdin isn't a terminal
+controlSocket: ""            # Unix socket gotest-watch send delivers commands to, e.g. .gotest-watch/control.sock
+httpAddr: ""                 # Address a JSON status endpoint is served on, e.g. localhost:7357
+pasteGuard: false            # Ignore multi-line pastes unless they end with a blank line
+promptShowsPendingChanges: false # Mark the prompt with * while changes are pending
+`
+
+// WriteConfigTemplate writes configTemplate to the project config file in
+// dir and returns its path. An existing file is only overwritten if force
+// is set.
+func WriteConfigTemplate(dir string, force bool) (string, error) {
+	path := filepath.Join(dir, configFileNames[0])
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(filepath.Clean(path), flags, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return "", fmt.Errorf("%s already exists; use --force to overwrite it", path)
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(configTemplate); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigTemplate(t *testing.T) {
+	t.Run("contains every setting", func(t *testing.T) {
+		var settings map[string]any
+		require.NoError(t, yaml.Unmarshal([]byte(configTemplate), &settings))
+
+		for key := range settingKeys() {
+			assert.Contains(t, settings, key)
+		}
+	})
+
+	t.Run("decodes to the defaults without problems", func(t *testing.T) {
+		dir := t.TempDir()
+		config := NewTestConfig()
+
+		problems, err := decodeSettings(config, ".gotest-watch.yml", []byte(configTemplate), dir, true)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+
+		defaults := NewTestConfig()
+		assert.Equal(t, defaults.BuildCommand(), config.BuildCommand())
+		assert.Equal(t, defaults.GetClearScreen(), config.GetClearScreen())
+		assert.Equal(t, defaults.colorTheme(), config.colorTheme())
+	})
+}
+
+func TestWriteConfigTemplate(t *testing.T) {
+	t.Run("writes the template", func(t *testing.T) {
+		dir := t.TempDir()
+
+		path, err := WriteConfigTemplate(dir, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, filepath.Join(dir, ".gotest-watch.yml"), path)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, configTemplate, string(data))
+	})
+
+	t.Run("refuses to overwrite an existing file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, ".gotest-watch.yml")
+		require.NoError(t, os.WriteFile(path, []byte("verbose: true\n"), 0o600))
+
+		_, err := WriteConfigTemplate(dir, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--force")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "verbose: true\n", string(data))
+	})
+
+	t.Run("overwrites an existing file with force", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, ".gotest-watch.yml")
+		require.NoError(t, os.WriteFile(path, []byte("verbose: true\n"), 0o600))
+
+		_, err := WriteConfigTemplate(dir, true)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, configTemplate, string(data))
+	})
+}
@@ -0,0 +1,233 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configProblem is a problem with a setting in a config file. The rest of
+// the file is still used.
+type configProblem struct {
+	file string
+	// line is the line of the file the problem is on, or 0 if it is not
+	// known
+	line    int
+	message string
+}
+
+func (p configProblem) String() string {
+	if p.line > 0 {
+		return fmt.Sprintf("%s:%d: %s", p.file, p.line, p.message)
+	}
+	return fmt.Sprintf("%s: %s", p.file, p.message)
+}
+
+// typeErrorLine matches the line number yaml puts at the start of each
+// error in a yaml.TypeError.
+var typeErrorLine = regexp.MustCompile(`^line (\d+): `)
+
+// decodeSettings decodes the YAML document in data onto tc, returning the
+// problems with its settings. Settings with values of the wrong type are
+// skipped rather than failing the whole document. dir is the directory
+// relative paths in the settings are resolved from. If hasLines is false,
+// data was converted from another format, so its line numbers are not
+// reported.
+func decodeSettings(tc *TestConfig, file string, data []byte, dir string, hasLines bool) ([]configProblem, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	problemAt := func(line int, format string, args ...any) configProblem {
+		if !hasLines {
+			line = 0
+		}
+		return configProblem{file: file, line: line, message: fmt.Sprintf(format, args...)}
+	}
+
+	var problems []configProblem
+	if err := doc.Decode(tc); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, err
+		}
+		for _, message := range typeErr.Errors {
+			line := 0
+			if m := typeErrorLine.FindStringSubmatch(message); m != nil {
+				line, _ = strconv.Atoi(m[1])
+				message = message[len(m[0]):]
+			}
+			problems = append(problems, problemAt(line, "%s", message))
+		}
+	}
+
+	settings := doc.Content[0]
+	if settings.Kind != yaml.MappingNode {
+		return problems, nil
+	}
+	keys := settingKeys()
+	for i := 0; i+1 < len(settings.Content); i += 2 {
+		key, value := settings.Content[i], settings.Content[i+1]
+		if !keys[key.Value] {
+			problems = append(problems, problemAt(key.Line, "unknown setting %q", key.Value))
+			continue
+		}
+		if message := checkSetting(key.Value, value, tc, dir); message != "" {
+			problems = append(problems, problemAt(value.Line, "%s", message))
+		}
+	}
+	slices.SortStableFunc(problems, func(a, b configProblem) int { return a.line - b.line })
+	return problems, nil
+}
+
+// settingKeys returns the names of the settings in a config file.
+func settingKeys() map[string]bool {
+	keys := map[string]bool{}
+	t := reflect.TypeFor[TestConfig]()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if field.IsExported() && key != "" {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// checkSetting returns what is wrong with the value of the setting named
+// key, or "" if nothing is. Values that could not be decoded are reported
+// by decodeSettings instead.
+func checkSetting(key string, value *yaml.Node, tc *TestConfig, dir string) string {
+	switch key {
+	case "runPattern", "skipPattern":
+		if value.Kind != yaml.ScalarNode {
+			return ""
+		}
+		if _, err := regexp.Compile(value.Value); err != nil {
+			return fmt.Sprintf("invalid %s: %v", key, err)
+		}
+	case "runBudget":
+		if value.Kind != yaml.ScalarNode || value.Value == "" {
+			return ""
+		}
+		if _, err := time.ParseDuration(value.Value); err != nil {
+			return fmt.Sprintf("invalid runBudget %q (must be a duration like 30s or 5m)", value.Value)
+		}
+	case "colorTheme":
+		if value.Kind != yaml.ScalarNode || value.Value == "" {
+			return ""
+		}
+		if _, ok := colorThemes[value.Value]; !ok {
+			return fmt.Sprintf("unknown colorTheme %q (must be default, colorblind or monochrome)", value.Value)
+		}
+	case "colorBackend":
+		if value.Kind != yaml.ScalarNode || value.Value == "" {
+			return ""
+		}
+		switch value.Value {
+		case ColorBackendInternal, ColorBackendRichgo, ColorBackendGotest, ColorBackendAuto:
+		default:
+			return fmt.Sprintf("unknown colorBackend %q (must be internal, richgo, gotest or auto)", value.Value)
+		}
+	case "notifications":
+		for _, n := range tc.Notifications {
+			if problem := n.check(); problem != "" {
+				return problem
+			}
+		}
+	case "colors":
+		if value.Kind != yaml.MappingNode {
+			return ""
+		}
+		var theme colorTheme
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			name, color := value.Content[i].Value, value.Content[i+1].Value
+			if theme.field(name) == nil {
+				return fmt.Sprintf("unknown color %q (must be pass, fail, skip, location or text)", name)
+			}
+			if _, err := parseColor(color); err != nil {
+				return fmt.Sprintf("%s: %v", name, err)
+			}
+		}
+	case "testPath":
+		if value.Kind != yaml.ScalarNode {
+			return ""
+		}
+		base := dir
+		if wd := tc.WorkingDir; wd != "" {
+			if filepath.IsAbs(wd) {
+				base = wd
+			} else {
+				base = filepath.Join(dir, wd)
+			}
+		}
+		for _, path := range strings.Fields(value.Value) {
+			if !testPathExists(base, path) {
+				return fmt.Sprintf("testPath %s does not exist", path)
+			}
+		}
+	case "packages":
+		if value.Kind != yaml.MappingNode {
+			return ""
+		}
+		keys := settingKeys()
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			path, settings := value.Content[i], value.Content[i+1]
+			if settings.Kind != yaml.MappingNode {
+				continue
+			}
+			for j := 0; j < len(settings.Content); j += 2 {
+				if key := settings.Content[j].Value; !keys[key] || key == "packages" {
+					return fmt.Sprintf("unknown setting %q for %s", key, path.Value)
+				}
+			}
+		}
+	case "commandBase":
+		if value.Kind != yaml.SequenceNode {
+			return ""
+		}
+		if len(value.Content) == 0 {
+			return "commandBase is empty"
+		}
+		if _, err := exec.LookPath(value.Content[0].Value); err != nil {
+			return fmt.Sprintf("commandBase command %s not found", value.Content[0].Value)
+		}
+	}
+	return ""
+}
+
+// testPathExists reports whether path, a package path given to go test,
+// exists relative to base. Only paths in the file system, which start with
+// . or /, are checked, since others are import paths.
+func testPathExists(base, path string) bool {
+	if !strings.HasPrefix(path, ".") && !filepath.IsAbs(path) {
+		return true
+	}
+	dir := strings.TrimSuffix(strings.TrimSuffix(path, "..."), "/")
+	if dir == "" {
+		dir = "."
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(base, dir)
+	}
+	if strings.ContainsAny(dir, "*?[") {
+		matches, err := filepath.Glob(dir)
+		return err == nil && len(matches) > 0
+	}
+	_, err := os.Stat(dir)
+	return err == nil
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeSettings(t *testing.T) {
+	t.Run("reports each problem with its line", func(t *testing.T) {
+		dir := t.TempDir()
+		content := `---
+verbos: true
+count: many
+runPattern: "TestFoo("
+testPath: ./missing/...
+commandBase: [no-such-binary-for-gotest-watch, test]
+race: true
+runBudget: soon
+colorTheme: neon
+colors:
+  fail: "300"
+clearScreen: sometimes
+colorBackend: rainbow
+notifications: [{slack: "https://hooks.slack.com/x", on: [sometimes]}]
+`
+		config := NewTestConfig()
+		problems, err := decodeSettings(config, ".gotest-watch.yml", []byte(content), dir, true)
+		require.NoError(t, err)
+
+		var messages []string
+		for _, problem := range problems {
+			messages = append(messages, problem.String())
+		}
+		assert.Equal(t, []string{
+			`.gotest-watch.yml:2: unknown setting "verbos"`,
+			".gotest-watch.yml:3: cannot unmarshal !!str `many` into int",
+			".gotest-watch.yml:4: invalid runPattern: error parsing regexp: missing closing ): `TestFoo(`",
+			".gotest-watch.yml:5: testPath ./missing/... does not exist",
+			".gotest-watch.yml:6: commandBase command no-such-binary-for-gotest-watch not found",
+			`.gotest-watch.yml:8: invalid runBudget "soon" (must be a duration like 30s or 5m)`,
+			`.gotest-watch.yml:9: unknown colorTheme "neon" (must be default, colorblind or monochrome)`,
+			`.gotest-watch.yml:11: fail: invalid color "300" (palette colors are 0 to 255)`,
+			`.gotest-watch.yml:12: invalid clear screen mode "sometimes" (must be auto, always or never)`,
+			`.gotest-watch.yml:13: unknown colorBackend "rainbow" (must be internal, richgo, gotest or auto)`,
+			`.gotest-watch.yml:14: unknown notification condition "sometimes" (must be failure, recovery or pass)`,
+		}, messages)
+		assert.True(t, config.Race, "valid settings should still be applied")
+	})
+
+	t.Run("accepts a valid config", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0o750))
+		content := `testPath: ./pkg/... github.com/example/lib/...
+runPattern: ^TestFoo$|^TestBar/sub
+commandBase: [go, test]
+runBudget: 2m
+colorTheme: colorblind
+colors: {fail: "196", pass: "#00ff00", skip: yellow}
+clearScreen: auto
+colorBackend: auto
+notifications: [{discord: "https://discord.com/api/webhooks/1/x", on: [failure, pass]}]
+`
+		problems, err := decodeSettings(NewTestConfig(), ".gotest-watch.yml", []byte(content), dir, true)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("resolves testPath from workingDir", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "service", "api"), 0o750))
+		content := "workingDir: service\ntestPath: ./api\n"
+
+		problems, err := decodeSettings(NewTestConfig(), ".gotest-watch.yml", []byte(content), dir, true)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("returns an error for invalid YAML", func(t *testing.T) {
+		_, err := decodeSettings(NewTestConfig(), ".gotest-watch.yml", []byte("this is: invalid: yaml"), t.TempDir(), true)
+		assert.Error(t, err)
+	})
+
+	t.Run("accepts an empty file", func(t *testing.T) {
+		problems, err := decodeSettings(NewTestConfig(), ".gotest-watch.yml", nil, t.TempDir(), true)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+}
+
+func TestTestPathExists(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "internal", "server"), 0o750))
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"./...", true},
+		{".", true},
+		{"./internal/...", true},
+		{"./internal/server", true},
+		{"./internal/*", true},
+		{"./cmd/...", false},
+		{"./cmd/*", false},
+		{"github.com/example/lib/...", true},
+		{filepath.Join(dir, "internal"), true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, testPathExists(dir, tt.path), tt.path)
+	}
+}
+
+func TestDecodeSettings_ChecksPackageSettings(t *testing.T) {
+	content := `packages:
+  ./integration/...:
+    tags: integration
+    timout: 5m
+`
+	config := NewTestConfig()
+	problems, err := decodeSettings(config, ".gotest-watch.yml", []byte(content), t.TempDir(), true)
+	require.NoError(t, err)
+
+	require.Len(t, problems, 1)
+	assert.Equal(t, `.gotest-watch.yml:2: unknown setting "timout" for ./integration/...`, problems[0].String())
+	assert.Equal(t, "integration", config.Packages["./integration/..."]["tags"])
+}
@@ -2,10 +2,23 @@ package internal
 
 import (
 	"context"
+	"log/slog"
 )
 
 type configKey struct{}
 
+type loggerKey struct{}
+
+type changedFilesKey struct{}
+
+type postRunKey struct{}
+
+type watchRequestsKey struct{}
+
+type configReloadKey struct{}
+
+type runTriggerKey struct{}
+
 func WithConfig(ctx context.Context, config *TestConfig) context.Context {
 	return context.WithValue(ctx, configKey{}, config)
 }
@@ -21,3 +34,105 @@ func getConfig(ctx context.Context) *TestConfig {
 	}
 	return nil
 }
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// getLogger returns the logger stored in ctx, or the default logger if none
+// was stored.
+func getLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// withChangedFiles returns a context recording the files whose changes
+// triggered a test run.
+func withChangedFiles(ctx context.Context, paths []string) context.Context {
+	return context.WithValue(ctx, changedFilesKey{}, paths)
+}
+
+// getChangedFiles returns the files recorded by withChangedFiles, or nil if
+// the run was not triggered by file changes.
+func getChangedFiles(ctx context.Context) []string {
+	paths, _ := ctx.Value(changedFilesKey{}).([]string)
+	return paths
+}
+
+// withRunTrigger returns a context recording what caused a test run.
+func withRunTrigger(ctx context.Context, trigger runTrigger) context.Context {
+	return context.WithValue(ctx, runTriggerKey{}, trigger)
+}
+
+// getRunTrigger returns the trigger recorded by withRunTrigger, and whether
+// there is one.
+func getRunTrigger(ctx context.Context) (runTrigger, bool) {
+	trigger, ok := ctx.Value(runTriggerKey{}).(runTrigger)
+	return trigger, ok
+}
+
+// withPostRun returns a context carrying an action to perform once the test
+// run using it has finished.
+func withPostRun(ctx context.Context, action func(config *TestConfig, passed bool)) context.Context {
+	return context.WithValue(ctx, postRunKey{}, action)
+}
+
+// getPostRun returns the action recorded by withPostRun, or nil if there is
+// none.
+func getPostRun(ctx context.Context) func(config *TestConfig, passed bool) {
+	action, _ := ctx.Value(postRunKey{}).(func(config *TestConfig, passed bool))
+	return action
+}
+
+// watchRequest is a request from the dispatcher to the file watcher.
+type watchRequest int
+
+const (
+	// watchUpdate asks the watcher to watch the directories in the config
+	watchUpdate watchRequest = iota
+	// watchRescan asks the watcher to rebuild its watch list from scratch
+	watchRescan
+)
+
+// WithWatchRequests returns a context carrying a channel through which the
+// dispatcher makes requests of the file watcher.
+func WithWatchRequests(ctx context.Context) context.Context {
+	return context.WithValue(ctx, watchRequestsKey{}, make(chan watchRequest, 10))
+}
+
+// getWatchRequests returns the channel recorded by WithWatchRequests, or nil
+// if there is none.
+func getWatchRequests(ctx context.Context) chan watchRequest {
+	requests, _ := ctx.Value(watchRequestsKey{}).(chan watchRequest)
+	return requests
+}
+
+// requestWatch sends request to the file watcher. It never blocks: requests
+// are dropped if there is no watcher or too many are already pending.
+func requestWatch(ctx context.Context, request watchRequest) {
+	select {
+	case getWatchRequests(ctx) <- request:
+	default:
+	}
+}
+
+// WithConfigReload returns a context through which the file watcher asks the
+// dispatcher to reload the config when the config file in dir changes.
+// loaded is the config as read from the config files, before any flags were
+// applied to it.
+func WithConfigReload(ctx context.Context, dir string, loaded *TestConfig) context.Context {
+	return context.WithValue(ctx, configReloadKey{}, &configReloader{
+		dir:      dir,
+		requests: make(chan struct{}, 1),
+		loaded:   loaded.Snapshot(),
+	})
+}
+
+// getConfigReloader returns the reloader recorded by WithConfigReload, or nil
+// if there is none.
+func getConfigReloader(ctx context.Context) *configReloader {
+	reloader, _ := ctx.Value(configReloadKey{}).(*configReloader)
+	return reloader
+}
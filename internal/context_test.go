@@ -2,6 +2,8 @@ package internal
 
 import (
 	"context"
+	"io"
+	"log/slog"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -102,3 +104,31 @@ func TestWithConfig_CanBeChained(t *testing.T) {
 	assert.False(t, retrieved.GetVerbose(), "should have config2's verbose setting")
 	assert.Equal(t, "TestNew", retrieved.GetRunPattern(), "should have config2's run pattern")
 }
+
+// TestGetLogger_DefaultsWhenMissing tests that getLogger falls back to the default logger
+func TestGetLogger_DefaultsWhenMissing(t *testing.T) {
+	assert.Equal(t, slog.Default(), getLogger(context.Background()))
+}
+
+// TestWithLogger_StoresLoggerInContext tests that WithLogger stores a retrievable logger
+func TestWithLogger_StoresLoggerInContext(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	assert.Same(t, logger, getLogger(WithLogger(context.Background(), logger)))
+}
+
+// TestRequestWatch_NeverBlocks tests that watch requests are queued in order and never block
+func TestRequestWatch_NeverBlocks(t *testing.T) {
+	assert.NotPanics(t, func() { requestWatch(context.Background(), watchUpdate) }, "should do nothing without a channel")
+
+	ctx := WithWatchRequests(context.Background())
+	requestWatch(ctx, watchUpdate)
+	requestWatch(ctx, watchRescan)
+	assert.Equal(t, watchUpdate, <-getWatchRequests(ctx))
+	assert.Equal(t, watchRescan, <-getWatchRequests(ctx))
+
+	for range cap(getWatchRequests(ctx)) + 1 {
+		requestWatch(ctx, watchUpdate)
+	}
+	assert.Len(t, getWatchRequests(ctx), cap(getWatchRequests(ctx)), "requests beyond capacity should be dropped")
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultControlSocket is where the control socket is made, relative to the
+// project root, when no other path is given.
+var DefaultControlSocket = filepath.Join(".gotest-watch", "control.sock")
+
+// controlDialTimeout limits how long connecting to the control socket may
+// take.
+const controlDialTimeout = 2 * time.Second
+
+// ListenControl listens on the unix socket at path and reads commands from
+// each connection to it as ReadStdin does from stdin, so that editors and
+// scripts can send commands to a running gotest-watch. A stale socket left
+// by a gotest-watch that didn't exit cleanly is replaced, but one that
+// another gotest-watch is listening on is not. The socket is removed once
+// ctx is cancelled.
+func ListenControl(
+	ctx context.Context,
+	path string,
+	cmdChan chan CommandMessage,
+	helpChan chan HelpMessage,
+) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		conn, err := net.DialTimeout("unix", path, controlDialTimeout)
+		if err == nil {
+			_ = conn.Close()
+			return fmt.Errorf("another gotest-watch is listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "unix", path)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		if err := listener.Close(); err != nil {
+			log.Println(err)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Println(err)
+				}
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				ReadStdin(ctx, conn, cmdChan, helpChan)
+			}()
+		}
+	}()
+	return nil
+}
+
+// SendControl sends line, which may hold several commands separated by ;,
+// to the gotest-watch listening on the unix socket at path.
+func SendControl(path, line string) error {
+	conn, err := net.DialTimeout("unix", path, controlDialTimeout)
+	if err != nil {
+		return fmt.Errorf("could not reach gotest-watch at %s (is it running with --control-socket?): %w", path, err)
+	}
+	if _, err := fmt.Fprintln(conn, line); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	return conn.Close()
+}
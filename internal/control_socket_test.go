@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// controlSocketPath returns a path for a control socket in a new temporary
+// directory, short enough for the limit on unix socket paths.
+func controlSocketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "gw")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return filepath.Join(dir, ".gotest-watch", "control.sock")
+}
+
+// receiveCommand returns the next command sent on cmdChan.
+func receiveCommand(t *testing.T, cmdChan chan CommandMessage) CommandMessage {
+	t.Helper()
+	select {
+	case cmd := <-cmdChan:
+		return cmd
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a command")
+		return CommandMessage{}
+	}
+}
+
+func TestControlSocket(t *testing.T) {
+	InitRegistry()
+
+	t.Run("delivers sent commands", func(t *testing.T) {
+		path := controlSocketPath(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cmdChan := make(chan CommandMessage, 10)
+		helpChan := make(chan HelpMessage, 10)
+
+		require.NoError(t, ListenControl(ctx, path, cmdChan, helpChan))
+		require.NoError(t, SendControl(path, "r TestFoo"))
+		cmd := receiveCommand(t, cmdChan)
+		assert.Equal(t, SetPatternCmd, cmd.Command)
+		assert.Equal(t, []string{"TestFoo"}, cmd.Args)
+
+		require.NoError(t, SendControl(path, "f"))
+		assert.Equal(t, ForceRunCmd, receiveCommand(t, cmdChan).Command)
+	})
+
+	t.Run("delivers help requests", func(t *testing.T) {
+		path := controlSocketPath(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cmdChan := make(chan CommandMessage, 10)
+		helpChan := make(chan HelpMessage, 10)
+
+		require.NoError(t, ListenControl(ctx, path, cmdChan, helpChan))
+		require.NoError(t, SendControl(path, "h"))
+
+		select {
+		case <-helpChan:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for help")
+		}
+	})
+
+	t.Run("refuses a socket another gotest-watch is listening on", func(t *testing.T) {
+		path := controlSocketPath(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cmdChan := make(chan CommandMessage, 10)
+		helpChan := make(chan HelpMessage, 10)
+
+		require.NoError(t, ListenControl(ctx, path, cmdChan, helpChan))
+		err := ListenControl(ctx, path, cmdChan, helpChan)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "another gotest-watch")
+	})
+
+	t.Run("replaces a stale socket", func(t *testing.T) {
+		path := controlSocketPath(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cmdChan := make(chan CommandMessage, 10)
+		helpChan := make(chan HelpMessage, 10)
+
+		require.NoError(t, ListenControl(ctx, path, cmdChan, helpChan))
+		require.NoError(t, SendControl(path, "f"))
+
+		assert.Equal(t, ForceRunCmd, receiveCommand(t, cmdChan).Command)
+	})
+
+	t.Run("removes the socket once cancelled", func(t *testing.T) {
+		path := controlSocketPath(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cmdChan := make(chan CommandMessage, 10)
+		helpChan := make(chan HelpMessage, 10)
+
+		require.NoError(t, ListenControl(ctx, path, cmdChan, helpChan))
+		cancel()
+
+		assert.Eventually(t, func() bool {
+			_, err := os.Stat(path)
+			return os.IsNotExist(err)
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Error(t, SendControl(path, "f"))
+	})
+
+	t.Run("sending fails when nothing is listening", func(t *testing.T) {
+		err := SendControl(controlSocketPath(t), "f")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "--control-socket")
+	})
+}
@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// retainedCoverProfile is where runs with cover on write their coverage
+// profile, relative to the directory tests run in, when no coverageFile is
+// set.
+var retainedCoverProfile = filepath.Join(".gotest-watch", "cover.out")
+
+// maxFuncsShown limits how many functions the funcs command lists.
+const maxFuncsShown = 20
+
+// lastCoverProfile is the coverage profile written by the last run that
+// kept one, for the funcs command to break down.
+var lastCoverProfile = &coverProfile{}
+
+type coverProfile struct {
+	sync.Mutex
+	// path is absolute, or empty if no run has kept a profile
+	path string
+}
+
+func (p *coverProfile) get() string {
+	p.Lock()
+	defer p.Unlock()
+	return p.path
+}
+
+func (p *coverProfile) set(path string) {
+	p.Lock()
+	defer p.Unlock()
+	p.path = path
+}
+
+// retainCoverProfile has a run that measures coverage keep its coverage
+// profile, so that the funcs command can break it down, unless the run
+// writes one to coverageFile already. Runs on another machine are left
+// alone, as their profile would be written there.
+func retainCoverProfile(tc *TestConfig) {
+	if !tc.Cover && tc.CoverPkg == "" {
+		return
+	}
+	if tc.coverProfile != "" || tc.CoverageFile != "" || strings.HasPrefix(tc.Runner, sshRunnerPrefix) {
+		return
+	}
+	dir := filepath.Join(tc.WorkingDir, filepath.Dir(retainedCoverProfile))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not keep the coverage profile: %v\n", err)
+		return
+	}
+	tc.coverProfile = retainedCoverProfile
+}
+
+// keptCoverProfile returns the absolute path of the coverage profile a run
+// with tc writes and keeps, or "" if it keeps none. Profiles written for a
+// single use, such as the coverhtml report, are not kept.
+func keptCoverProfile(tc *TestConfig) string {
+	profile := tc.CoverageFile
+	if tc.coverProfile != "" {
+		if tc.coverProfile != retainedCoverProfile {
+			return ""
+		}
+		profile = tc.coverProfile
+	}
+	if profile == "" || strings.HasPrefix(tc.Runner, sshRunnerPrefix) {
+		return ""
+	}
+	if filepath.IsAbs(profile) {
+		return profile
+	}
+	return filepath.Join(absWorkingDir(tc), profile)
+}
+
+// funcCoverage is the statement coverage of a single function, as reported
+// by `go tool cover -func`.
+type funcCoverage struct {
+	// location is the function's file, as an import path, and line
+	location string
+	name     string
+	percent  float64
+}
+
+// coverFuncs breaks the coverage profile down by function with
+// `go tool cover -func`, run in dir so that the profile's packages can be
+// found.
+func coverFuncs(dir, profile string) ([]funcCoverage, error) {
+	if _, err := os.Stat(profile); err != nil {
+		return nil, fmt.Errorf("no coverage profile: %w", err)
+	}
+	//nolint:gosec // the profile path is one written by a previous run
+	cmd := exec.CommandContext(context.Background(), "go", "tool", "cover", "-func="+profile)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("go tool cover failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return parseCoverFuncs(string(output)), nil
+}
+
+// parseCoverFuncs parses the output of `go tool cover -func`, leaving out
+// its total.
+func parseCoverFuncs(output string) []funcCoverage {
+	var funcs []funcCoverage
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] == "total:" {
+			continue
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(fields[2], "%"), 64)
+		if err != nil {
+			continue
+		}
+		funcs = append(funcs, funcCoverage{
+			location: strings.TrimSuffix(fields[0], ":"),
+			name:     fields[1],
+			percent:  percent,
+		})
+	}
+	return funcs
+}
+
+// inPackages returns the functions of funcs in the packages with the
+// import paths given.
+func inPackages(funcs []funcCoverage, packages []string) []funcCoverage {
+	return slices.DeleteFunc(slices.Clone(funcs), func(f funcCoverage) bool {
+		file, _, _ := strings.Cut(f.location, ":")
+		return !slices.Contains(packages, path.Dir(file))
+	})
+}
+
+// leastCovered returns the functions that aren't fully covered, least
+// covered first.
+func leastCovered(funcs []funcCoverage) []funcCoverage {
+	uncovered := slices.DeleteFunc(slices.Clone(funcs), func(f funcCoverage) bool {
+		return f.percent >= 100
+	})
+	slices.SortStableFunc(uncovered, func(a, b funcCoverage) int {
+		return cmp.Compare(a.percent, b.percent)
+	})
+	return uncovered
+}
+
+// formatFuncCoverage formats the functions' coverage as aligned lines.
+func formatFuncCoverage(funcs []funcCoverage) []string {
+	width := 0
+	for _, f := range funcs {
+		width = max(width, len(f.location))
+	}
+	lines := make([]string, len(funcs))
+	for i, f := range funcs {
+		lines[i] = fmt.Sprintf("  %5.1f%%  %-*s  %s", f.percent, width, f.location, f.name)
+	}
+	return lines
+}
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseCoverFuncs tests that each function's coverage is read, leaving out the total
+func TestParseCoverFuncs(t *testing.T) {
+	output := "example.com/app/a.go:3:\tParse\t\t66.7%\n" +
+		"example.com/app/a.go:12:\tformat\t\t100.0%\n" +
+		"total:\t\t\t(statements)\t75.0%\n"
+
+	assert.Equal(t, []funcCoverage{
+		{location: "example.com/app/a.go:3", name: "Parse", percent: 66.7},
+		{location: "example.com/app/a.go:12", name: "format", percent: 100},
+	}, parseCoverFuncs(output))
+}
+
+// TestInPackages tests that only the functions of the packages given are kept
+func TestInPackages(t *testing.T) {
+	funcs := []funcCoverage{
+		{location: "example.com/app/internal/parse/parse.go:3", name: "Parse"},
+		{location: "example.com/app/internal/internal.go:3", name: "Run"},
+		{location: "example.com/app/main.go:5", name: "main"},
+	}
+
+	kept := inPackages(funcs, []string{"example.com/app/internal/parse", "example.com/app"})
+
+	assert.Equal(t, []funcCoverage{funcs[0], funcs[2]}, kept)
+}
+
+// TestLeastCovered tests that fully covered functions are left out and the rest sorted by coverage
+func TestLeastCovered(t *testing.T) {
+	funcs := []funcCoverage{
+		{name: "A", percent: 50},
+		{name: "B", percent: 100},
+		{name: "C", percent: 0},
+		{name: "D", percent: 50},
+	}
+
+	var names []string
+	for _, f := range leastCovered(funcs) {
+		names = append(names, f.name)
+	}
+	assert.Equal(t, []string{"C", "A", "D"}, names)
+}
+
+// TestKeptCoverProfile tests which runs keep the profile they write
+func TestKeptCoverProfile(t *testing.T) {
+	config := NewTestConfig()
+	config.WorkingDir = "/project"
+	assert.Empty(t, keptCoverProfile(config))
+
+	config.CoverageFile = "reports/cover.out"
+	assert.Equal(t, filepath.Join("/project", "reports", "cover.out"), keptCoverProfile(config))
+
+	config.coverProfile = "/tmp/gotest-watch-1.coverprofile"
+	assert.Empty(t, keptCoverProfile(config), "the coverhtml profile is removed once it is shown")
+
+	config.CoverageFile = ""
+	config.coverProfile = retainedCoverProfile
+	assert.Equal(t, filepath.Join("/project", retainedCoverProfile), keptCoverProfile(config))
+}
+
+// TestRunContext_RetainsCoverProfile tests that runs with cover on keep their profile unless it goes elsewhere
+func TestRunContext_RetainsCoverProfile(t *testing.T) {
+	config := NewTestConfig()
+	config.WorkingDir = t.TempDir()
+	assert.Empty(t, getConfig(runContext(context.Background(), config, triggerForceRun)).coverProfile)
+
+	config.ToggleCover()
+	snapshot := getConfig(runContext(context.Background(), config, triggerForceRun))
+	assert.Equal(t, "go test ./... -cover -coverprofile="+retainedCoverProfile, snapshot.BuildCommand())
+	assert.DirExists(t, filepath.Join(config.WorkingDir, ".gotest-watch"))
+
+	config.ToggleCover()
+	config.SetCoverPkg("./...")
+	snapshot = getConfig(runContext(context.Background(), config, triggerForceRun))
+	assert.Equal(t, retainedCoverProfile, snapshot.coverProfile, "-coverpkg measures coverage without -cover")
+
+	config.SetCoverageFile("cover.out")
+	assert.Empty(t, getConfig(runContext(context.Background(), config, triggerForceRun)).coverProfile)
+
+	config.SetCoverageFile("")
+	config.SetRunner("ssh devbox:/srv/app")
+	assert.Empty(t, getConfig(runContext(context.Background(), config, triggerForceRun)).coverProfile)
+}
+
+// TestHandleFuncs_ListsLeastCoveredFunctions tests that funcs breaks down the profile kept by the last run
+func TestHandleFuncs_ListsLeastCoveredFunctions(t *testing.T) {
+	tempDir := setupTestModule(t, `package testmodule
+
+import "testing"
+
+func TestHalf(t *testing.T) {
+	Half(true)
+	Whole()
+}
+`)
+	source := `package testmodule
+
+func Half(covered bool) int {
+	if covered {
+		return 1
+	}
+	return 2
+}
+
+func Whole() int {
+	return 1
+}
+
+func None() int {
+	return 0
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "half.go"), []byte(source), 0o600))
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.ToggleCover()
+	config.WorkingDir = tempDir
+	runCtx := runContext(context.Background(), config, triggerForceRun)
+	t.Cleanup(func() { lastCoverProfile.set("") })
+
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(runCtx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+	require.Equal(t, filepath.Join(tempDir, retainedCoverProfile), lastCoverProfile.get())
+
+	output := captureStdout(t, func() {
+		require.NoError(t, handleFuncs(config, nil))
+	})
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	require.Len(t, lines, 3, output)
+	assert.Equal(t, "Least-covered functions in the last run (2 of 3 not fully covered):", lines[0])
+	assert.Regexp(t, `^\s+0\.0%\s+testmodule/half\.go:14\s+None$`, lines[1])
+	assert.Regexp(t, `^\s+66\.7%\s+testmodule/half\.go:3\s+Half$`, lines[2])
+
+	output = captureStdout(t, func() {
+		require.NoError(t, handleFuncs(config, []string{"."}))
+	})
+	assert.Contains(t, output, "Least-covered functions in .")
+
+	assert.Error(t, handleFuncs(config, []string{"other"}))
+}
+
+// TestHandleFuncs_RequiresProfile tests that funcs explains that a run with cover is needed first
+func TestHandleFuncs_RequiresProfile(t *testing.T) {
+	lastCoverProfile.set("")
+
+	err := handleFuncs(NewTestConfig(), nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "turn on cover")
+}
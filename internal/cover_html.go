@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// coverHTMLContext returns a context for a run that writes a coverage
+// profile to a new file under the project's .gotest-watch directory and,
+// once the run finishes, opens it as an HTML report in the browser with
+// `go tool cover`. The profile is named relative to the directory tests run
+// in, so that runs in a container, where the project is mounted, write it
+// where it can be read. Runs on another machine write it there, so they
+// can't open a report.
+func coverHTMLContext(ctx context.Context) (context.Context, error) {
+	config := getConfig(ctx)
+	if config == nil {
+		return nil, fmt.Errorf("config not found in context")
+	}
+	if runner := config.GetRunner(); strings.HasPrefix(runner, sshRunnerPrefix) {
+		return nil, fmt.Errorf("coverhtml can't open a report of runs on another machine (runner: %s)", runner)
+	}
+
+	workingDir := absWorkingDir(config)
+	dir := filepath.Join(workingDir, filepath.Dir(retainedCoverProfile))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("could not create coverage profile: %w", err)
+	}
+	profile, err := os.CreateTemp(dir, "coverhtml-*.coverprofile")
+	if err != nil {
+		return nil, fmt.Errorf("could not create coverage profile: %w", err)
+	}
+	if err := profile.Close(); err != nil {
+		return nil, fmt.Errorf("could not create coverage profile: %w", err)
+	}
+	name, err := filepath.Rel(workingDir, profile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("could not create coverage profile: %w", err)
+	}
+
+	config.Lock()
+	config.coverProfile = filepath.ToSlash(name)
+	config.Unlock()
+	return withPostRun(ctx, openCoverReport), nil
+}
+
+// openCoverReport opens the coverage profile written by a run in the
+// browser, then removes it.
+func openCoverReport(config *TestConfig, _ bool) {
+	profile := config.coverProfile
+	if !filepath.IsAbs(profile) {
+		profile = filepath.Join(absWorkingDir(config), profile)
+	}
+	defer func() {
+		if err := os.Remove(profile); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not remove coverage profile: %v\n", err)
+		}
+	}()
+
+	if info, err := os.Stat(profile); err != nil || info.Size() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no coverage profile was written")
+		return
+	}
+
+	//nolint:gosec // the profile path is a file created by coverHTMLContext
+	cmd := exec.CommandContext(context.Background(), "go", "tool", "cover", "-html="+profile)
+	cmd.Dir = config.WorkingDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not open coverage report: %v\n", err)
+	}
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCoverHTMLContext_SetsProfileForRunOnly tests that coverhtml adds -coverprofile to the run's config only
+func TestCoverHTMLContext_SetsProfileForRunOnly(t *testing.T) {
+	config := NewTestConfig()
+	config.WorkingDir = t.TempDir()
+	runCtx := runContext(context.Background(), config, triggerForceRun)
+
+	runCtx, err := coverHTMLContext(runCtx)
+	require.NoError(t, err)
+
+	snapshot := getConfig(runCtx)
+	assert.False(t, filepath.IsAbs(snapshot.coverProfile), "profile should be found from the directory tests run in")
+	assert.FileExists(t, filepath.Join(config.WorkingDir, snapshot.coverProfile))
+	assert.Equal(t, "go test ./... -coverprofile="+snapshot.coverProfile, snapshot.BuildCommand())
+	assert.Equal(t, "go test ./...", config.BuildCommand(), "shared config should not write a profile")
+	assert.Empty(t, snapshot.Snapshot().coverProfile, "profile should not outlive the run")
+	assert.NotNil(t, getPostRun(runCtx), "run should open the report when it finishes")
+}
+
+// TestCoverHTMLContext_RejectsRemoteRuns tests that coverhtml is refused for runs whose profile would be written on another machine
+func TestCoverHTMLContext_RejectsRemoteRuns(t *testing.T) {
+	config := NewTestConfig()
+	config.WorkingDir = t.TempDir()
+	config.SetRunner("ssh build-box")
+
+	_, err := coverHTMLContext(runContext(context.Background(), config, triggerForceRun))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "another machine")
+	assert.NoDirExists(t, filepath.Join(config.WorkingDir, ".gotest-watch"))
+}
+
+// TestOpenCoverReport_RemovesEmptyProfile tests that a run which wrote no profile reports it and cleans up
+func TestOpenCoverReport_RemovesEmptyProfile(t *testing.T) {
+	profile, err := os.CreateTemp(t.TempDir(), "empty-*.coverprofile")
+	require.NoError(t, err)
+	require.NoError(t, profile.Close())
+
+	config := NewTestConfig()
+	config.coverProfile = profile.Name()
+
+	stderr := captureStderr(t, func() {
+		openCoverReport(config, false)
+	})
+
+	assert.Contains(t, stderr, "no coverage profile was written")
+	assert.NoFileExists(t, profile.Name())
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"sync"
+)
+
+// coverageCache remembers the coverage each package last reported, so that
+// later runs can show how it changed.
+type coverageCache struct {
+	sync.Mutex
+	percents map[string]float64
+}
+
+// lastCoverage is shared by every run of the session. Packages keep their
+// coverage across runs that don't test them, such as in smart mode.
+var lastCoverage = &coverageCache{}
+
+// snapshot returns the coverage last reported by each package.
+func (c *coverageCache) snapshot() map[string]float64 {
+	c.Lock()
+	defer c.Unlock()
+	return maps.Clone(c.percents)
+}
+
+// update records the coverage reported by the packages of a run.
+func (c *coverageCache) update(coverage map[string]float64) {
+	c.Lock()
+	defer c.Unlock()
+	if c.percents == nil {
+		c.percents = map[string]float64{}
+	}
+	maps.Copy(c.percents, coverage)
+}
+
+// annotateCoverage adds how a package's coverage changed since previous to
+// the line reporting it, as in "coverage: 80.0% of statements (-2.5%)".
+// Other lines, and coverage that didn't change, are returned as they are.
+func annotateCoverage(line string, previous map[string]float64) string {
+	pkg, percent, ok := parseCoverage(line)
+	if !ok {
+		return line
+	}
+	before, ok := previous[pkg]
+	if !ok {
+		return line
+	}
+	// Coverage is reported to a tenth of a percent
+	delta := math.Round((percent-before)*10) / 10
+	if delta == 0 {
+		return line
+	}
+	return fmt.Sprintf("%s (%+.1f%%)", line, delta)
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnnotateCoverage tests that coverage lines show how they changed since the previous run
+func TestAnnotateCoverage(t *testing.T) {
+	previous := map[string]float64{"example.com/app": 82.5, "example.com/lib": 50}
+
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{
+			name:     "decreased",
+			line:     "ok  \texample.com/app\t0.01s\tcoverage: 80.0% of statements",
+			expected: "ok  \texample.com/app\t0.01s\tcoverage: 80.0% of statements (-2.5%)",
+		},
+		{
+			name:     "increased from the cache",
+			line:     "ok  \texample.com/lib\t(cached)\tcoverage: 75.3% of statements",
+			expected: "ok  \texample.com/lib\t(cached)\tcoverage: 75.3% of statements (+25.3%)",
+		},
+		{
+			name:     "unchanged",
+			line:     "ok  \texample.com/lib\t0.01s\tcoverage: 50.0% of statements",
+			expected: "ok  \texample.com/lib\t0.01s\tcoverage: 50.0% of statements",
+		},
+		{
+			name:     "not tested before",
+			line:     "ok  \texample.com/new\t0.01s\tcoverage: 10.0% of statements",
+			expected: "ok  \texample.com/new\t0.01s\tcoverage: 10.0% of statements",
+		},
+		{
+			name:     "not a coverage line",
+			line:     "--- PASS: TestApp (0.00s)",
+			expected: "--- PASS: TestApp (0.00s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, annotateCoverage(tt.line, previous))
+		})
+	}
+}
+
+// TestCoverageCache_KeepsPackagesNotRun tests that a run only replaces the coverage of the packages it tested
+func TestCoverageCache_KeepsPackagesNotRun(t *testing.T) {
+	cache := &coverageCache{}
+	cache.update(map[string]float64{"a": 10, "b": 20})
+	cache.update(map[string]float64{"b": 30})
+
+	assert.Equal(t, map[string]float64{"a": 10, "b": 30}, cache.snapshot())
+}
+
+// TestRunTests_ShowsCoverageDelta tests that a run shows how coverage changed since the package was last tested
+func TestRunTests_ShowsCoverageDelta(t *testing.T) {
+	tempDir := setupTestModule(t, `package testmodule
+
+import "testing"
+
+func TestHalf(t *testing.T) {
+	if Half(true) != 1 {
+		t.Fatal("wrong")
+	}
+}
+`)
+	source := `package testmodule
+
+func Half(covered bool) int {
+	if covered {
+		return 1
+	}
+	return 2
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "half.go"), []byte(source), 0o600))
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.ToggleCover()
+	config.WorkingDir = tempDir
+	ctx := WithConfig(context.Background(), config)
+
+	run := func() string {
+		testCompleteChan := make(chan TestCompleteMessage, 1)
+		var stdoutBuf, stderrBuf bytes.Buffer
+		captureStdout(t, func() {
+			go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+			waitForTestCompletion(t, testCompleteChan)
+		})
+		return stdoutBuf.String()
+	}
+
+	lastCoverage.update(map[string]float64{"testmodule": 70})
+	assert.Contains(t, run(), "coverage: 66.7% of statements (-3.3%)")
+	assert.Contains(t, run(), "coverage: 66.7% of statements\n", "unchanged coverage should show no delta")
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// totalCoverage returns the percentage of statements covered across every
+// package in the coverage profile at path, as `go tool cover -func` totals
+// it. It reports false if the profile wasn't written since since, such as
+// when the run failed to build, or covers no statements.
+func totalCoverage(path string, since time.Time) (float64, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.ModTime().Before(since) {
+		return 0, false, nil
+	}
+	f, err := os.Open(path) //nolint:gosec // the profile is one the run was told to write
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	// A block is listed once for each test binary that covers it when
+	// packages are covered by other packages' tests, so it counts as
+	// covered if any of them ran it
+	statements := map[string]int{}
+	covered := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// Each block is "file:start.col,end.col statements count"
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return 0, false, fmt.Errorf("invalid coverage profile line %q", line)
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid coverage profile line %q", line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid coverage profile line %q", line)
+		}
+		statements[fields[0]] = n
+		if count > 0 {
+			covered[fields[0]] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, false, err
+	}
+
+	total, hit := 0, 0
+	for block, n := range statements {
+		total += n
+		if covered[block] {
+			hit += n
+		}
+	}
+	if total == 0 {
+		return 0, false, nil
+	}
+	// Coverage is reported to a tenth of a percent
+	return math.Round(float64(hit)/float64(total)*1000) / 10, true, nil
+}
+
+// formatTotalCoverage returns the note added to the footer of a run with the
+// total coverage of its summary, or "" if it has none.
+func formatTotalCoverage(summary RunSummary) string {
+	if summary.Coverage == nil {
+		return ""
+	}
+	return fmt.Sprintf(", %.1f%% of statements covered", *summary.Coverage)
+}
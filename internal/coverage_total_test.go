@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTotalCoverage tests that statements are totalled across packages, counting each block once
+func TestTotalCoverage(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	content := `mode: set
+example.com/app/a.go:3.20,5.2 2 1
+example.com/app/a.go:6.2,6.10 1 0
+example.com/app/lib/b.go:3.15,4.2 3 0
+example.com/app/lib/b.go:3.15,4.2 3 1
+example.com/app/lib/b.go:5.15,6.2 4 0
+`
+	require.NoError(t, os.WriteFile(profile, []byte(content), 0o600))
+
+	total, ok, err := totalCoverage(profile, time.Time{})
+
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.InDelta(t, 50.0, total, 0.001)
+}
+
+// TestTotalCoverage_IgnoresStaleProfile tests that a profile the run didn't write is not totalled
+func TestTotalCoverage_IgnoresStaleProfile(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	require.NoError(t, os.WriteFile(profile, []byte("mode: set\na.go:1.1,2.2 1 1\n"), 0o600))
+
+	_, ok, err := totalCoverage(profile, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = totalCoverage(filepath.Join(t.TempDir(), "missing.out"), time.Time{})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// TestTotalCoverage_RejectsInvalidProfile tests that a malformed profile is reported
+func TestTotalCoverage_RejectsInvalidProfile(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	require.NoError(t, os.WriteFile(profile, []byte("mode: set\nnot a profile line\n"), 0o600))
+
+	_, _, err := totalCoverage(profile, time.Time{})
+
+	assert.Error(t, err)
+}
+
+// TestRunTests_ReportsTotalCoverage tests that the footer and summary JSON give the coverage across packages
+func TestRunTests_ReportsTotalCoverage(t *testing.T) {
+	tempDir := setupTestModule(t, `package testmodule
+
+import "testing"
+
+func TestHalf(t *testing.T) {
+	Half(true)
+}
+`)
+	source := `package testmodule
+
+func Half(covered bool) int {
+	if covered {
+		return 1
+	}
+	return 2
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "half.go"), []byte(source), 0o600))
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.ToggleCover()
+	config.SetSummaryJSON(true)
+	config.WorkingDir = tempDir
+	runCtx := runContext(context.Background(), config, triggerForceRun)
+	t.Cleanup(func() { lastCoverProfile.set("") })
+
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(runCtx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.Regexp(t, `Finished in \d+\.\ds, 66\.7% of statements covered`, stdoutBuf.String())
+
+	var summary RunSummary
+	lines := strings.Split(strings.TrimSpace(stderrBuf.String()), "\n")
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &summary))
+	require.NotNil(t, summary.Coverage)
+	assert.InDelta(t, 66.7, *summary.Coverage, 0.001)
+}
@@ -4,10 +4,110 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"time"
 )
 
+// shutdownTimeout is how long shutting down waits for a running test run to
+// finish once it has been cancelled, which includes stopping its processes.
+const shutdownTimeout = 5 * time.Second
+
+// runTrigger identifies what caused a test run.
+type runTrigger int
+
+const (
+	triggerFileChange runTrigger = iota
+	triggerForceRun
+	triggerStartup
+)
+
+func (t runTrigger) String() string {
+	switch t {
+	case triggerFileChange:
+		return "file change"
+	case triggerForceRun:
+		return "manual"
+	case triggerStartup:
+		return "startup"
+	}
+	return "unknown"
+}
+
+// runContext returns a context carrying a snapshot of config for a single
+// test run, adjusted for what triggered the run and with the settings for
+// the packages it tests applied.
+func runContext(ctx context.Context, config *TestConfig, trigger runTrigger) context.Context {
+	snapshot := config.Snapshot()
+	applyPackageSettings(snapshot, strings.Fields(snapshot.TestPath))
+	delegateColor(snapshot)
+	retainCoverProfile(snapshot)
+	keepGotestsumJSON(snapshot)
+	if trigger == triggerFileChange && snapshot.AutoShort {
+		snapshot.Short = true
+	}
+	if trigger == triggerStartup && snapshot.FirstRunSkipCache && snapshot.Count == 0 {
+		snapshot.Count = 1
+	}
+	if snapshot.ClearScreen == ClearAuto {
+		// Keep the scrollback of runs asked for, clearing only for those
+		// that happen by themselves
+		snapshot.ClearScreen = ClearNever
+		if trigger == triggerFileChange {
+			snapshot.ClearScreen = ClearAlways
+		}
+	}
+	return withRunTrigger(WithConfig(ctx, snapshot), trigger)
+}
+
+// changeRunContext returns the context for a run triggered by changes to
+// paths. In smart mode the run is narrowed to the packages containing them.
+func changeRunContext(ctx context.Context, config *TestConfig, paths []string) context.Context {
+	runCtx := withChangedFiles(runContext(ctx, config, triggerFileChange), paths)
+	snapshot := getConfig(runCtx)
+	if !snapshot.SmartMode || len(paths) == 0 {
+		return runCtx
+	}
+
+	packages, err := changedPackages(snapshot.WorkingDir, paths, snapshot.TestPath, snapshot.SmartDependents)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: smart mode could not find changed packages, testing %s: %v\n",
+			snapshot.TestPath, err)
+		return runCtx
+	}
+	if len(packages) > 0 {
+		snapshot.TestPath = strings.Join(packages, " ")
+		// The changed files already narrow the run further than the
+		// packages changed since a git ref would
+		snapshot.Since = ""
+	}
+
+	if len(packages) == 0 || len(snapshot.Packages) == 0 {
+		return runCtx
+	}
+
+	// The packages are import paths, so match the settings against their
+	// directories instead. They include any dependents, so the settings
+	// are those of every package tested rather than only the changed ones.
+	dirs, err := packageDirs(snapshot.WorkingDir, packages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: smart mode could not apply package settings: %v\n", err)
+		return runCtx
+	}
+	applyPackageSettings(snapshot, dirs)
+	return runCtx
+}
+
+// StartupContext returns the context to use for the initial test run that
+// happens before files are watched.
+func StartupContext(ctx context.Context) context.Context {
+	config := getConfig(ctx)
+	if config == nil {
+		return ctx
+	}
+	return changedSinceContext(runContext(ctx, config, triggerStartup))
+}
+
 //nolint:funlen
 func Dispatcher(
 	ctx context.Context,
@@ -17,6 +117,22 @@ func Dispatcher(
 	testCompleteChan chan TestCompleteMessage,
 ) {
 	testRunning := false
+	pendingChanges := false
+	// queuedChanges is set when files change during a test run, so that
+	// another run starts as soon as the current one completes
+	queuedChanges := false
+	var queuedPaths []string
+	// cancelRun cancels the test run in progress, and interrupted is set
+	// once the user has asked for it to be cancelled
+	cancelRun := context.CancelFunc(func() {})
+	interrupted := false
+	// fuzzing is set while a fuzz run, which never finishes on its own, is
+	// in progress
+	fuzzing := false
+	startRun := func(runCtx context.Context) {
+		runCtx, cancelRun = context.WithCancel(changedSinceContext(runCtx))
+		go RunTests(runCtx, testCompleteChan, nil, nil)
+	}
 
 	config := getConfig(ctx)
 	if config == nil {
@@ -24,28 +140,170 @@ func Dispatcher(
 		return
 	}
 
+	watchdog := startWatchdog(ctx, config)
+
+	reloader := getConfigReloader(ctx)
+	// reloadQueued is set when the config file changes during a test run,
+	// so that it is reloaded once the run completes
+	reloadQueued := false
+	// reloadConfig applies changes to the config file, and reports whether
+	// anything was printed
+	reloadConfig := func() bool {
+		changed, problems, err := reloader.reload(os.Stdout, config)
+		if len(problems) > 0 {
+			fmt.Fprintln(os.Stderr)
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", problem)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\nWarning: could not reload config, keeping the current settings: %v\n", err)
+			return true
+		}
+		if len(changed) > 0 {
+			jsonEvents.emit(configChangedEvent(config, changed, "config file"))
+		}
+		if slices.ContainsFunc(changed, func(key string) bool { return slices.Contains(watchSettings, key) }) {
+			requestWatch(ctx, watchRescan)
+		}
+		return len(changed) > 0 || len(problems) > 0
+	}
+
+	// queuedCommands holds the commands left to run from a line of several,
+	// which wait for any test run started by an earlier one to complete
+	var queuedCommands []CommandMessage
+	// executeCommand runs a command's handler and reports whether it started
+	// a test run
+	executeCommand := func(cmd CommandMessage) bool {
+		before := config.Snapshot()
+		err := handleCommand(cmd.Command, config, cmd.Args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		if changed := changedSettings(before, config.Snapshot()); len(changed) > 0 {
+			jsonEvents.emit(configChangedEvent(config, changed, "command"))
+		}
+
+		// The run context is only built for the commands that start a run,
+		// as preparing a run can warn and create files
+		var runCtx context.Context
+		if err == nil {
+			switch cmd.Command {
+			case CoverHTMLCmd:
+				runCtx, err = coverHTMLContext(runContext(ctx, config, triggerForceRun))
+			case FuzzCmd:
+				runCtx, err = fuzzContext(runContext(ctx, config, triggerForceRun), cmd.Args)
+			case ReplayRunCmd:
+				runCtx, err = replayContext(withRunTrigger(ctx, triggerForceRun), history, cmd.Args)
+			case WatchCmd, UnwatchCmd:
+				requestWatch(ctx, watchUpdate)
+			case RescanCmd:
+				requestWatch(ctx, watchRescan)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		}
+
+		// Spawn test runner if command requires it
+		switch {
+		case cmd.Command == FuzzCmd && err == nil:
+			// Fuzzing runs until it is stopped, so it is not
+			// watched for deadlocks
+			pendingChanges = false
+			testRunning = true
+			fuzzing = true
+			startRun(runCtx)
+		case cmd.Command == ForceRunCmd ||
+			(cmd.Command == ReplayRunCmd || cmd.Command == CoverHTMLCmd || cmd.Command == ChangedCmd) && err == nil:
+			if runCtx == nil {
+				runCtx = runContext(ctx, config, triggerForceRun)
+			}
+			pendingChanges = false
+			testRunning = true
+			watchdog.arm()
+			startRun(runCtx)
+		default:
+			return false
+		}
+		return true
+	}
+	// runQueuedCommands runs the queued commands in order until one starts a
+	// test run, and shows the prompt if none does
+	runQueuedCommands := func() {
+		for len(queuedCommands) > 0 {
+			cmd := queuedCommands[0]
+			queuedCommands = queuedCommands[1:]
+			if executeCommand(cmd) {
+				return
+			}
+		}
+		// Show prompt after non-test commands
+		displayPrompt(config, pendingChanges)
+	}
+
 	// Show initial prompt
-	displayPrompt()
+	displayPrompt(config, pendingChanges)
 
 	for {
+		watchdog.kick()
 		if testRunning {
 			// While test is running, only listen for test completion and context cancellation
-			// Ignore file changes and user commands (but show feedback for commands)
+			// Queue file changes and ignore user commands (but show feedback for commands)
 			select {
-			case <-fileChangeChan:
-				// Ignore file changes while test is running
+			case msg := <-fileChangeChan:
+				// Remember file changes so they trigger a run once this one completes
+				if msg.Pending {
+					pendingChanges = true
+					continue
+				}
+				queuedChanges = true
+				for _, path := range msg.Paths {
+					if !slices.Contains(queuedPaths, path) {
+						queuedPaths = append(queuedPaths, path)
+					}
+				}
+				if fuzzing {
+					fuzzing = false
+					announce(config, "\nFile change detected, stopping fuzzing...")
+					cancelRun()
+				}
 			case cmd := <-commandChan:
-				// Show the full line that was typed, so user knows what was ignored
-				fullCmd := string(cmd.Command)
-				if len(cmd.Args) > 0 {
-					fullCmd = fullCmd + " " + strings.Join(cmd.Args, " ")
+				if cmd.Command == InterruptCmd {
+					if !interrupted {
+						interrupted = true
+						fmt.Println("\nInterrupting test run...")
+						cancelRun()
+					}
+					continue
 				}
-				fmt.Printf("\n(Tests running - ignored input: '%s')\n", fullCmd)
+				// Show the full line that was typed, so user knows what was ignored
+				fmt.Printf("\n(Tests running - ignored input: '%s')\n", cmd.String())
 			case <-helpChan:
 				// Show that help was requested but ignored
 				fmt.Println("\n(Tests running - ignored input: 'h')")
-			case <-testCompleteChan:
+			case <-reloader.reloadRequests():
+				reloadQueued = true
+			case result := <-testCompleteChan:
 				testRunning = false
+				fuzzing = false
+				watchdog.disarm()
+				cancelRun()
+
+				if interrupted {
+					// Return to the prompt rather than rerunning for changes
+					// seen during the interrupted run
+					interrupted = false
+					queuedChanges = false
+					queuedPaths = nil
+					queuedCommands = nil
+					fmt.Println("Test run interrupted")
+				}
+
+				if result.Passed && config.GetExitOnFirstPass() {
+					fmt.Println("Tests passed, exiting...")
+					return
+				}
 
 				// Drain any commands that accumulated during test run
 				drainedCommands := 0
@@ -55,11 +313,7 @@ func Dispatcher(
 					select {
 					case cmd := <-commandChan:
 						drainedCommands++
-						fullCmd := string(cmd.Command)
-						if len(cmd.Args) > 0 {
-							fullCmd = fullCmd + " " + strings.Join(cmd.Args, " ")
-						}
-						fmt.Printf("(Ignored during test: '%s')\n", fullCmd)
+						fmt.Printf("(Ignored during test: '%s')\n", cmd.String())
 					case <-helpChan:
 						drainedHelp++
 						fmt.Println("(Ignored during test: 'h')")
@@ -72,15 +326,33 @@ func Dispatcher(
 					fmt.Println()
 				}
 
-				// Show prompt
-				displayPrompt()
+				if reloadQueued {
+					reloadQueued = false
+					reloadConfig()
+				}
+
+				if queuedChanges {
+					runCtx := changeRunContext(ctx, config, queuedPaths)
+					queuedChanges = false
+					queuedPaths = nil
+					pendingChanges = false
+					testRunning = true
+					watchdog.arm()
+					announce(config, "Files changed during test run, running tests again...")
+					startRun(runCtx)
+					continue
+				}
+
+				// Carry on with any commands left from the line that
+				// started the run, and show the prompt
+				runQueuedCommands()
 			case <-ctx.Done():
 				// Wait for test to finish before shutting down
 				select {
 				case <-testCompleteChan:
-					fmt.Println("Shutting down...")
+					announce(config, "Shutting down...")
 					return
-				case <-time.After(5 * time.Second):
+				case <-time.After(shutdownTimeout):
 					fmt.Fprintln(os.Stderr, "Timeout waiting for test to complete, forcing shutdown...")
 					return
 				}
@@ -88,24 +360,29 @@ func Dispatcher(
 		} else {
 			// When idle, process all events
 			select {
-			case <-fileChangeChan:
+			case msg := <-fileChangeChan:
+				if msg.Pending {
+					// Changes are still being debounced; show them in the prompt
+					if !pendingChanges {
+						pendingChanges = true
+						fmt.Print("\r")
+						displayPrompt(config, pendingChanges)
+					}
+					continue
+				}
+				pendingChanges = false
 				testRunning = true
-				fmt.Println("\nFile change detected, running tests...")
-				go RunTests(ctx, testCompleteChan, nil, nil)
+				watchdog.arm()
+				announce(config, "\nFile change detected, running tests...")
+				startRun(changeRunContext(ctx, config, msg.Paths))
 
 			case cmd := <-commandChan:
-				// Execute command handler
-				if err := handleCommand(cmd.Command, config, cmd.Args); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				}
+				queuedCommands = cmd.Sequence()
+				runQueuedCommands()
 
-				// Spawn test runner if command requires it
-				if cmd.Command == ForceRunCmd {
-					testRunning = true
-					go RunTests(ctx, testCompleteChan, nil, nil)
-				} else {
-					// Show prompt after non-test commands
-					displayPrompt()
+			case <-reloader.reloadRequests():
+				if reloadConfig() {
+					displayPrompt(config, pendingChanges)
 				}
 
 			case <-helpChan:
@@ -114,10 +391,10 @@ func Dispatcher(
 					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				}
 				// Show prompt after help
-				displayPrompt()
+				displayPrompt(config, pendingChanges)
 
 			case <-ctx.Done():
-				fmt.Println("Shutting down...")
+				announce(config, "Shutting down...")
 				return
 			}
 		}
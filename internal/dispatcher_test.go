@@ -2,10 +2,16 @@ package internal
 
 import (
 	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestDispatcher_FileChangeSpawnsTestRunner tests that FileChangeMessage spawns test runner
@@ -41,8 +47,8 @@ func TestDispatcher_FileChangeSpawnsTestRunner(t *testing.T) {
 	cancel()
 }
 
-// TestDispatcher_FileChangeIgnoredWhenTestRunning tests that FileChangeMessage ignored when testRunning=true
-func TestDispatcher_FileChangeIgnoredWhenTestRunning(t *testing.T) {
+// TestDispatcher_FileChangeQueuedWhenTestRunning tests that FileChangeMessage is queued when testRunning=true
+func TestDispatcher_FileChangeQueuedWhenTestRunning(t *testing.T) {
 	config := NewTestConfig()
 
 	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
@@ -64,7 +70,7 @@ func TestDispatcher_FileChangeIgnoredWhenTestRunning(t *testing.T) {
 	// Wait for test to start
 	time.Sleep(50 * time.Millisecond)
 
-	// Send another file change while test is running - it will be drained and ignored
+	// Send another file change while test is running - it will be drained and queued
 	fileChangeChan <- FileChangeMessage{}
 
 	// Wait a bit for the dispatcher to drain it
@@ -76,8 +82,8 @@ func TestDispatcher_FileChangeIgnoredWhenTestRunning(t *testing.T) {
 	// Wait for completion to be processed
 	time.Sleep(50 * time.Millisecond)
 
-	// The second file change should have been drained and ignored (not in channel anymore)
-	assert.Equal(t, 0, len(fileChangeChan), "second file change should have been drained and ignored")
+	// The second file change should have been drained and queued (not in channel anymore)
+	assert.Equal(t, 0, len(fileChangeChan), "second file change should have been drained and queued")
 
 	cancel()
 }
@@ -111,6 +117,80 @@ func TestDispatcher_CommandMessageCallsHandler(t *testing.T) {
 	assert.True(t, config.GetVerbose(), "verbose command should have been executed")
 }
 
+// TestDispatcher_CommandWithoutRunLeavesFilesAlone tests that commands that don't start a run don't prepare one
+func TestDispatcher_CommandWithoutRunLeavesFilesAlone(t *testing.T) {
+	initRegistry()
+
+	config := NewTestConfig()
+	config.WorkingDir = t.TempDir()
+	config.ToggleCover()
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	defer cancel()
+	fileChangeChan := make(chan FileChangeMessage, 1)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	go func() {
+		captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	commandChan <- CommandMessage{Command: VerboseCmd, Args: nil}
+	time.Sleep(50 * time.Millisecond)
+
+	assert.True(t, config.GetVerbose())
+	assert.NoDirExists(t, filepath.Join(config.WorkingDir, ".gotest-watch"), "only runs should keep their cover profile")
+}
+
+// TestDispatcher_CommandWithoutRunKeepsNoGotestsumJSON tests that commands that don't start a run neither keep
+// gotestsum's -json output nor warn that it can't be read
+func TestDispatcher_CommandWithoutRunKeepsNoGotestsumJSON(t *testing.T) {
+	initRegistry()
+
+	config := NewTestConfig()
+	config.WorkingDir = t.TempDir()
+	config.SetCommandBase([]string{"gotestsum"})
+	config.SetSummaryLine(true)
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	defer cancel()
+	fileChangeChan := make(chan FileChangeMessage, 1)
+	commandChan := make(chan CommandMessage, 2)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	oldStderr := os.Stderr
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stderr = w
+	defer func() { os.Stderr = oldStderr }()
+
+	go func() {
+		captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	commandChan <- CommandMessage{Command: VerboseCmd, Args: nil}
+	time.Sleep(50 * time.Millisecond)
+	assert.NoDirExists(t, filepath.Join(config.WorkingDir, ".gotest-watch"))
+
+	config.SetRunner("ssh devbox:/srv/app")
+	commandChan <- CommandMessage{Command: VerboseCmd, Args: nil}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	_ = w.Close()
+	os.Stderr = oldStderr
+	stderr, _ := io.ReadAll(r)
+	_ = r.Close()
+	assert.NotContains(t, string(stderr), "gotestsum can't be read")
+}
+
 // TestDispatcher_CommandMessageSpawnsTestRunner tests that CommandMessage spawns test runner
 func TestDispatcher_CommandMessageSpawnsTestRunner(t *testing.T) {
 	config := NewTestConfig()
@@ -243,11 +323,11 @@ func TestDispatcher_TestCompleteMessageUpdatesState(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 
 	// Second test should have started (testRunning should be true again)
-	// We can verify by checking that a third file change is ignored
+	// We can verify by checking that a third file change is queued rather than started
 	fileChangeChan <- FileChangeMessage{}
 	time.Sleep(50 * time.Millisecond)
-	// Third change should have been drained and ignored
-	assert.Equal(t, 0, len(fileChangeChan), "third file change should be drained and ignored while second test runs")
+	// Third change should have been drained and queued
+	assert.Equal(t, 0, len(fileChangeChan), "third file change should be drained and queued while second test runs")
 
 	cancel()
 }
@@ -309,10 +389,10 @@ func TestDispatcher_StateTransitions(t *testing.T) {
 	// Wait for test to start
 	time.Sleep(50 * time.Millisecond)
 
-	// While running, file changes should be ignored (drained from channel)
+	// While running, file changes should be queued (drained from channel)
 	fileChangeChan <- FileChangeMessage{}
 	time.Sleep(50 * time.Millisecond)
-	assert.Equal(t, 0, len(fileChangeChan), "file change should be drained and ignored while running")
+	assert.Equal(t, 0, len(fileChangeChan), "file change should be drained and queued while running")
 
 	// Complete test
 	testCompleteChan <- TestCompleteMessage{}
@@ -324,10 +404,425 @@ func TestDispatcher_StateTransitions(t *testing.T) {
 	fileChangeChan <- FileChangeMessage{}
 	time.Sleep(50 * time.Millisecond)
 
-	// New test should have started, so another file change should be ignored
+	// New test should have started, so another file change should be queued
 	fileChangeChan <- FileChangeMessage{}
 	time.Sleep(50 * time.Millisecond)
-	assert.Equal(t, 0, len(fileChangeChan), "file change should be drained and ignored while second test runs")
+	assert.Equal(t, 0, len(fileChangeChan), "file change should be drained and queued while second test runs")
+
+	cancel()
+}
+
+// TestRunContext_FileChangeAddsShortWhenAutoShort tests that file-change runs include -short in auto-short mode
+func TestRunContext_FileChangeAddsShortWhenAutoShort(t *testing.T) {
+	config := NewTestConfig()
+	config.SetAutoShort(true)
+
+	runCtx := runContext(context.Background(), config, triggerFileChange)
+
+	assert.Contains(t, getConfig(runCtx).BuildCommand(), "-short", "file-change run should include -short")
+	assert.False(t, config.GetShort(), "shared config should not be modified")
+}
+
+// TestRunContext_ForceRunOmitsShortWhenAutoShort tests that force runs run the full suite in auto-short mode
+func TestRunContext_ForceRunOmitsShortWhenAutoShort(t *testing.T) {
+	config := NewTestConfig()
+	config.SetAutoShort(true)
+
+	runCtx := runContext(context.Background(), config, triggerForceRun)
+
+	assert.NotContains(t, getConfig(runCtx).BuildCommand(), "-short", "force run should omit -short")
+}
+
+// TestRunContext_FileChangeOmitsShortByDefault tests that -short is not added unless auto-short is enabled
+func TestRunContext_FileChangeOmitsShortByDefault(t *testing.T) {
+	config := NewTestConfig()
+
+	runCtx := runContext(context.Background(), config, triggerFileChange)
+
+	assert.NotContains(t, getConfig(runCtx).BuildCommand(), "-short")
+}
+
+// TestRunContext_AutoClearsOnlyForFileChanges tests that auto clear mode keeps the scrollback of manual runs
+func TestRunContext_AutoClearsOnlyForFileChanges(t *testing.T) {
+	config := NewTestConfig()
+	config.SetClearScreen(ClearAuto)
+
+	assert.Equal(t, ClearAlways, getConfig(runContext(context.Background(), config, triggerFileChange)).GetClearScreen())
+	assert.Equal(t, ClearNever, getConfig(runContext(context.Background(), config, triggerForceRun)).GetClearScreen())
+	assert.Equal(t, ClearNever, getConfig(runContext(context.Background(), config, triggerStartup)).GetClearScreen())
+	assert.Equal(t, ClearAuto, config.GetClearScreen(), "shared config should not be modified")
+}
+
+// TestDispatcher_PromptShowsPendingChanges tests that the prompt gains * while changes are pending
+// and loses it once the run launches
+func TestDispatcher_PromptShowsPendingChanges(t *testing.T) {
+	tempDir := setupTestModule(t, `package pending
+
+import "testing"
+
+func TestPending(t *testing.T) {}
+`)
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetWorkingDir(tempDir)
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	defer cancel()
+	fileChangeChan := make(chan FileChangeMessage, 1)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	outputChan := make(chan string, 1)
+	go func() {
+		outputChan <- captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	fileChangeChan <- FileChangeMessage{Pending: true}
+	time.Sleep(50 * time.Millisecond)
+	fileChangeChan <- FileChangeMessage{}
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	var output string
+	select {
+	case output = <-outputChan:
+	case <-time.After(10 * time.Second):
+		t.Fatal("dispatcher did not shut down")
+	}
+
+	pendingAt := strings.Index(output, "\r*> ")
+	require.NotEqual(t, -1, pendingAt, "prompt should show pending changes")
+	launchAt := strings.Index(output, "File change detected")
+	require.Greater(t, launchAt, pendingAt, "run should launch after the pending prompt")
+	assert.NotContains(t, output[launchAt:], "*> ", "pending indicator should be gone once the run launches")
+}
+
+// TestDispatcher_PendingChangeDoesNotStartRun tests that a pending notification never launches a run
+func TestDispatcher_PendingChangeDoesNotStartRun(t *testing.T) {
+	config := NewTestConfig()
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	defer cancel()
+	fileChangeChan := make(chan FileChangeMessage, 1)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	outputChan := make(chan string, 1)
+	go func() {
+		outputChan <- captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	fileChangeChan <- FileChangeMessage{Pending: true}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	output := <-outputChan
+	assert.NotContains(t, output, "running tests", "pending changes should not start a run")
+}
+
+// sequenceRunner fails or passes runs in a fixed order
+type sequenceRunner struct {
+	mu       sync.Mutex
+	outcomes []bool
+}
+
+func (r *sequenceRunner) Command(_ *TestConfig) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pass := r.outcomes[0]
+	r.outcomes = r.outcomes[1:]
+	if pass {
+		return []string{"go", "version"}
+	}
+	return []string{"go", "no-such-subcommand"}
+}
+
+func (r *sequenceRunner) ProcessLine(line string) string {
+	return line
+}
+
+// TestDispatcher_ExitOnFirstPass tests that the dispatcher exits after the first passing run and not before
+func TestDispatcher_ExitOnFirstPass(t *testing.T) {
+	initRegistry()
+	RegisterRunner("fail-fail-pass", &sequenceRunner{outcomes: []bool{false, false, true}})
+
+	config := NewTestConfig()
+	config.SetRunner("fail-fail-pass")
+	config.SetExitOnFirstPass(true)
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	defer cancel()
+	fileChangeChan := make(chan FileChangeMessage, 1)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
 
+	done := make(chan struct{})
+	go func() {
+		captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+		close(done)
+	}()
+
+	for i := 0; i < 2; i++ {
+		commandChan <- CommandMessage{Command: ForceRunCmd}
+		select {
+		case <-done:
+			t.Fatalf("dispatcher exited after failing run %d", i+1)
+		case <-time.After(time.Second):
+		}
+	}
+
+	commandChan <- CommandMessage{Command: ForceRunCmd}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatcher should exit after the first passing run")
+	}
+}
+
+// TestDispatcher_ReportsChangedFilesForChangeRunsOnly tests that only change-triggered runs list their files
+func TestDispatcher_ReportsChangedFilesForChangeRunsOnly(t *testing.T) {
+	initRegistry()
+	RegisterRunner("pass-pass", &sequenceRunner{outcomes: []bool{true, true}})
+
+	config := NewTestConfig()
+	config.SetRunner("pass-pass")
+	config.SetReportChangedFiles(true)
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	fileChangeChan := make(chan FileChangeMessage, 1)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	outputChan := make(chan string, 1)
+	go func() {
+		outputChan <- captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	fileChangeChan <- FileChangeMessage{Paths: []string{"internal/foo.go", "internal/bar.go"}}
+	time.Sleep(time.Second)
+	commandChan <- CommandMessage{Command: ForceRunCmd}
+	time.Sleep(time.Second)
+	cancel()
+
+	output := <-outputChan
+	assert.Equal(t, 1, strings.Count(output, "Triggered by:"), "force run should not list changed files")
+	assert.Contains(t, output, "Triggered by: internal/foo.go, internal/bar.go")
+}
+
+// countingRunner runs a command that takes a moment to finish and counts
+// how many runs it was asked for
+type countingRunner struct {
+	mu   sync.Mutex
+	runs int
+}
+
+func (r *countingRunner) Command(_ *TestConfig) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs++
+	return []string{"sleep", "0.5"}
+}
+
+func (r *countingRunner) ProcessLine(line string) string {
+	return line
+}
+
+func (r *countingRunner) Runs() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.runs
+}
+
+// TestDispatcher_RerunsAfterChangesDuringRun tests that changes seen mid-run start one more run when it completes
+func TestDispatcher_RerunsAfterChangesDuringRun(t *testing.T) {
+	initRegistry()
+	runner := &countingRunner{}
+	RegisterRunner("counting", runner)
+
+	config := NewTestConfig()
+	config.SetRunner("counting")
+	config.SetReportChangedFiles(true)
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	outputChan := make(chan string, 1)
+	go func() {
+		outputChan <- captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	fileChangeChan <- FileChangeMessage{Paths: []string{"first.go"}}
+	time.Sleep(100 * time.Millisecond)
+	fileChangeChan <- FileChangeMessage{Paths: []string{"second.go"}}
+	fileChangeChan <- FileChangeMessage{Paths: []string{"third.go", "second.go"}}
+	time.Sleep(2 * time.Second)
 	cancel()
+
+	output := <-outputChan
+	assert.Equal(t, 2, runner.Runs(), "changes during a run should start exactly one more run")
+	assert.Contains(t, output, "Files changed during test run")
+	assert.Contains(t, output, "Triggered by: second.go, third.go", "queued run should report the queued files")
+}
+
+// TestDispatcher_InterruptCancelsRun tests that x stops the running tests and returns to the prompt without rerunning
+func TestDispatcher_InterruptCancelsRun(t *testing.T) {
+	initRegistry()
+	RegisterRunner("hanging", commandRunner{"sleep", "30"})
+
+	config := NewTestConfig()
+	config.SetRunner("hanging")
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	outputChan := make(chan string, 1)
+	go func() {
+		outputChan <- captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	fileChangeChan <- FileChangeMessage{}
+	time.Sleep(100 * time.Millisecond)
+	fileChangeChan <- FileChangeMessage{}
+	commandChan <- CommandMessage{Command: InterruptCmd}
+	time.Sleep(time.Second)
+	cancel()
+
+	output := <-outputChan
+	assert.Contains(t, output, "Test run interrupted")
+	assert.NotContains(t, output, "running tests again", "changes queued before the interrupt should not rerun")
+	assert.True(t, strings.HasSuffix(output, "> Shutting down...\n"), "dispatcher should be idle at the prompt")
+}
+
+// TestDispatcher_RunsChainedCommandsInOrder tests that the commands on one line run in order, each waiting for any run started before it
+func TestDispatcher_RunsChainedCommandsInOrder(t *testing.T) {
+	initRegistry()
+	runner := &countingRunner{}
+	RegisterRunner("counting", runner)
+
+	config := NewTestConfig()
+	config.SetRunner("counting")
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	outputChan := make(chan string, 1)
+	go func() {
+		outputChan <- captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	commandChan <- CommandMessage{Command: VerboseCmd, Then: []CommandMessage{
+		{Command: ForceRunCmd},
+		{Command: RaceCmd},
+		{Command: ForceRunCmd},
+	}}
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, config.GetVerbose())
+	assert.False(t, config.GetRace(), "race should wait for the first run to complete")
+	assert.Equal(t, 1, runner.Runs())
+
+	time.Sleep(1500 * time.Millisecond)
+	cancel()
+
+	output := <-outputChan
+	assert.True(t, config.GetRace())
+	assert.Equal(t, 2, runner.Runs())
+	assert.Equal(t, 1, strings.Count(output, "Verbose:"), "verbose should only be toggled once")
+	assert.True(t, strings.HasSuffix(output, "> Shutting down...\n"), "dispatcher should be idle at the prompt")
+}
+
+// TestDispatcher_InterruptDropsChainedCommands tests that interrupting a run started by a chain skips the rest of it
+func TestDispatcher_InterruptDropsChainedCommands(t *testing.T) {
+	initRegistry()
+	RegisterRunner("hanging", commandRunner{"sleep", "30"})
+
+	config := NewTestConfig()
+	config.SetRunner("hanging")
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	outputChan := make(chan string, 1)
+	go func() {
+		outputChan <- captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	commandChan <- CommandMessage{Command: ForceRunCmd, Then: []CommandMessage{{Command: VerboseCmd}}}
+	time.Sleep(100 * time.Millisecond)
+	commandChan <- CommandMessage{Command: InterruptCmd}
+	time.Sleep(time.Second)
+	cancel()
+
+	output := <-outputChan
+	assert.Contains(t, output, "Test run interrupted")
+	assert.False(t, config.GetVerbose(), "commands after an interrupted run should not run")
+}
+
+// TestDispatcher_ReloadsConfigAfterRun tests that a config file change during a run is applied once it completes
+func TestDispatcher_ReloadsConfigAfterRun(t *testing.T) {
+	initRegistry()
+	runner := &countingRunner{}
+	RegisterRunner("counting", runner)
+
+	r, config := newTestReloader(t, "runner: counting\n")
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	ctx = context.WithValue(ctx, configReloadKey{}, r)
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	outputChan := make(chan string, 1)
+	go func() {
+		outputChan <- captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	commandChan <- CommandMessage{Command: ForceRunCmd}
+	time.Sleep(100 * time.Millisecond)
+	writeReloadedConfig(t, r, "runner: counting\nverbose: true\n")
+	r.request()
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, config.GetVerbose(), "the reload should wait for the run to complete")
+
+	time.Sleep(time.Second)
+	cancel()
+
+	output := <-outputChan
+	assert.True(t, config.GetVerbose())
+	assert.Contains(t, output, "Config file changed:\n  verbose: false -> true\n")
+	assert.Equal(t, 1, runner.Runs(), "reloading should not start a run")
 }
@@ -2,13 +2,214 @@ package internal
 
 import (
 	"fmt"
+	"io"
+	"log"
+	"strconv"
 	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClearMode is when the screen is cleared before a run.
+type ClearMode string
+
+const (
+	// ClearAuto clears the screen before runs started by file changes,
+	// keeping the scrollback of runs started with commands such as f
+	ClearAuto   ClearMode = "auto"
+	ClearAlways ClearMode = "always"
+	ClearNever  ClearMode = "never"
+)
+
+func (m *ClearMode) String() string {
+	return string(*m)
+}
+
+// Set sets m to value, one of the modes, or true or false, from when
+// clearing the screen was a boolean, for always and never.
+func (m *ClearMode) Set(value string) error {
+	switch value {
+	case string(ClearAuto), string(ClearAlways), string(ClearNever):
+		*m = ClearMode(value)
+	case "true":
+		*m = ClearAlways
+	case "false":
+		*m = ClearNever
+	default:
+		return fmt.Errorf("invalid clear screen mode %q (must be auto, always or never)", value)
+	}
+	return nil
+}
+
+func (m *ClearMode) Type() string {
+	return "mode"
+}
+
+// UnmarshalYAML decodes a mode, or a boolean as Set does, reporting invalid
+// modes like the other settings that can't be decoded.
+func (m *ClearMode) UnmarshalYAML(value *yaml.Node) error {
+	if err := m.Set(value.Value); err != nil {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %v", value.Line, err)}}
+	}
+	return nil
+}
+
+const (
+	enterAltScreen = "\x1b[?1049h"
+	leaveAltScreen = "\x1b[?1049l"
+	// eraseScreen moves the cursor home and erases the whole screen, which
+	// many terminals do without keeping what was on it
+	eraseScreen = "\x1b[H\x1b[2J"
+	// eraseBelow moves the cursor home and erases from there down
+	eraseBelow = "\x1b[H\x1b[J"
+	// cursorToBottom moves the cursor to the first column of the last row
+	cursorToBottom = "\x1b[999;1H"
 )
 
-func displayPrompt() {
-	fmt.Print("> ")
+// defaultTerminalRows is assumed when the terminal's size can't be read.
+const defaultTerminalRows = 24
+
+// clearSequence returns what to print to clear a terminal with rows rows
+// before a run. If keepScrollback is set, the screen is scrolled up by a
+// full screen of newlines from its last row, pushing earlier runs into the
+// scrollback, before the now blank screen is erased below the cursor.
+func clearSequence(keepScrollback bool, rows int) string {
+	if !keepScrollback {
+		return eraseScreen
+	}
+	return cursorToBottom + strings.Repeat("\n", rows) + eraseBelow
+}
+
+// lastPrompt is the prompt most recently displayed, so that the line editor
+// can redraw it.
+var lastPrompt atomic.Value
+
+func displayPrompt(config *TestConfig, pending bool) {
+	if config != nil && (config.GetQuiet() || config.GetNoInput()) {
+		lastPrompt.Store("")
+		return
+	}
+	prompt := promptString(config, pending)
+	lastPrompt.Store(prompt)
+	fmt.Print(prompt)
+}
+
+// shownPrompt returns the prompt most recently displayed.
+func shownPrompt() string {
+	prompt, _ := lastPrompt.Load().(string)
+	return prompt
+}
+
+// promptString builds the prompt, marking it with a * when file changes are
+// waiting to trigger a run.
+func promptString(config *TestConfig, pending bool) string {
+	prompt := promptModes(config)
+	if flags := promptFlags(config); flags != "" {
+		prompt += flags + " "
+	}
+	if pending {
+		prompt += "*"
+	}
+	return prompt + "> "
+}
+
+// promptModes returns the markers for any input modes that change how
+// commands are interpreted, so they are visible at the prompt.
+func promptModes(config *TestConfig) string {
+	if config == nil {
+		return ""
+	}
+	if config.GetLiteralPatterns() {
+		return "(literal) "
+	}
+	return ""
+}
+
+// promptFlags returns the settings that change the test command, each named
+// after the command that sets it, such as "[v][race][run=TestFoo]", so that
+// the current mode is always visible at the prompt.
+func promptFlags(config *TestConfig) string {
+	if config == nil {
+		return ""
+	}
+	tc := config.Snapshot()
+
+	var flags []string
+	for _, flag := range []struct {
+		name string
+		on   bool
+	}{
+		{"v", tc.Verbose},
+		{"race", tc.Race},
+		{"ff", tc.FailFast},
+		{"cover", tc.Cover},
+		{"short", tc.Short},
+		{"nocache", tc.NoCache && tc.Count == 0},
+		{"smart", tc.SmartMode},
+		{"vet", tc.Vet},
+	} {
+		if flag.on {
+			flags = append(flags, flag.name)
+		}
+	}
+	for _, setting := range []struct {
+		name  string
+		value string
+	}{
+		{"count", countString(tc.Count)},
+		{"timeout", tc.Timeout},
+		{"shuffle", tc.Shuffle},
+		{"run", tc.RunPattern},
+		{"skip", tc.SkipPattern},
+		{"since", tc.Since},
+	} {
+		if setting.value != "" {
+			flags = append(flags, setting.name+"="+setting.value)
+		}
+	}
+
+	var b strings.Builder
+	for _, flag := range flags {
+		b.WriteString("[" + flag + "]")
+	}
+	return b.String()
+}
+
+// countString returns count as a string, or "" if it is not set.
+func countString(count int) string {
+	if count <= 0 {
+		return ""
+	}
+	return strconv.Itoa(count)
+}
+
+// announce prints a status message, such as why a run is starting, unless
+// the config asks for quiet output.
+func announce(config *TestConfig, message string) {
+	if !config.GetQuiet() {
+		fmt.Println(message)
+	}
 }
 
 func displayCommand(command []string) {
 	fmt.Println(strings.Join(command, " "))
 }
+
+// EnterAltScreen switches w to the terminal's alternate screen buffer when
+// the config asks for it, and returns a function that switches back so the
+// terminal's prior content is restored on exit.
+func EnterAltScreen(w io.Writer, config *TestConfig) func() {
+	if !config.GetAltScreen() {
+		return func() {}
+	}
+
+	if _, err := fmt.Fprint(w, enterAltScreen); err != nil {
+		log.Println(err)
+	}
+	return func() {
+		if _, err := fmt.Fprint(w, leaveAltScreen); err != nil {
+			log.Println(err)
+		}
+	}
+}
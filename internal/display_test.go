@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,19 +11,45 @@ import (
 func TestDisplayPrompt_OutputFormat(t *testing.T) {
 	// Call the function
 	actual := captureStdout(t, func() {
-		displayPrompt()
+		displayPrompt(NewTestConfig(), false)
 	})
 
 	// Verify exact format: "> "
 	assert.Equal(t, "> ", actual)
 }
 
+// TestDisplayPrompt_Quiet tests that no prompt is shown in quiet mode
+func TestDisplayPrompt_Quiet(t *testing.T) {
+	config := NewTestConfig()
+	config.SetQuiet(true)
+
+	actual := captureStdout(t, func() {
+		displayPrompt(config, true)
+	})
+
+	assert.Empty(t, actual)
+	assert.Empty(t, shownPrompt())
+}
+
+// TestDisplayPrompt_NoInput tests that no prompt is shown when no commands are read
+func TestDisplayPrompt_NoInput(t *testing.T) {
+	config := NewTestConfig()
+	config.SetNoInput(true)
+
+	actual := captureStdout(t, func() {
+		displayPrompt(config, true)
+	})
+
+	assert.Empty(t, actual)
+	assert.Empty(t, shownPrompt())
+}
+
 // TestDisplayPrompt_DoesNotPanic tests that displayPrompt doesn't panic
 func TestDisplayPrompt_DoesNotPanic(t *testing.T) {
 	// Should not panic
 	assert.NotPanics(t, func() {
 		captureStdout(t, func() {
-			displayPrompt()
+			displayPrompt(NewTestConfig(), false)
 		})
 	})
 }
@@ -129,7 +156,7 @@ func TestDisplayCommand_PrintsToStdout(t *testing.T) {
 // TestDisplayPrompt_PrintsToStdout tests that displayPrompt writes to stdout, not stderr
 func TestDisplayPrompt_PrintsToStdout(t *testing.T) {
 	actual := captureStdout(t, func() {
-		displayPrompt()
+		displayPrompt(NewTestConfig(), false)
 	})
 
 	// Should write to stdout, not stderr
@@ -176,3 +203,75 @@ func TestDisplayCommand_WithRealCommandFormat(t *testing.T) {
 		})
 	}
 }
+
+// TestEnterAltScreen_EmitsEnterAndLeaveWhenEnabled tests the alternate screen lifecycle sequences
+func TestEnterAltScreen_EmitsEnterAndLeaveWhenEnabled(t *testing.T) {
+	config := NewTestConfig()
+	config.SetAltScreen(true)
+
+	var out bytes.Buffer
+	restore := EnterAltScreen(&out, config)
+
+	assert.Equal(t, "\x1b[?1049h", out.String(), "should enter the alternate screen at start")
+
+	restore()
+
+	assert.Equal(t, "\x1b[?1049h\x1b[?1049l", out.String(), "should leave the alternate screen on shutdown")
+}
+
+// TestEnterAltScreen_NoOpWhenDisabled tests that nothing is written when the option is off
+func TestEnterAltScreen_NoOpWhenDisabled(t *testing.T) {
+	config := NewTestConfig()
+
+	var out bytes.Buffer
+	restore := EnterAltScreen(&out, config)
+	restore()
+
+	assert.Empty(t, out.String())
+}
+
+// TestDisplayPrompt_ShowsLiteralMode tests that literal mode is shown in the prompt
+func TestDisplayPrompt_ShowsLiteralMode(t *testing.T) {
+	config := NewTestConfig()
+	config.SetLiteralPatterns(true)
+
+	actual := captureStdout(t, func() {
+		displayPrompt(config, false)
+	})
+
+	assert.Equal(t, "(literal) > ", actual)
+}
+
+// TestPromptString_ShowsPendingIndicator tests that pending changes mark the prompt
+func TestPromptString_ShowsPendingIndicator(t *testing.T) {
+	config := NewTestConfig()
+
+	assert.Equal(t, "> ", promptString(config, false))
+	assert.Equal(t, "*> ", promptString(config, true))
+
+	config.SetLiteralPatterns(true)
+	assert.Equal(t, "(literal) *> ", promptString(config, true))
+}
+
+// TestPromptString_ShowsActiveFlags tests that the settings changing the test command are shown in the prompt
+func TestPromptString_ShowsActiveFlags(t *testing.T) {
+	config := NewTestConfig()
+	config.SetVerbose(true)
+	config.ToggleRace()
+	config.SetRunPattern("TestFoo")
+
+	assert.Equal(t, "[v][race][run=TestFoo] > ", promptString(config, false))
+
+	config.SetCount(3)
+	config.SetNoCache(true)
+	config.SetSkipPattern("TestSlow")
+	config.SetLiteralPatterns(true)
+	assert.Equal(t, "(literal) [v][race][count=3][run=TestFoo][skip=TestSlow] *> ", promptString(config, true),
+		"nocache should not be shown when an explicit count replaces it")
+}
+
+// TestClearSequence tests erasing the screen with and without keeping the scrollback
+func TestClearSequence(t *testing.T) {
+	assert.Equal(t, "\x1b[H\x1b[2J", clearSequence(false, 3))
+	assert.Equal(t, "\x1b[999;1H\n\n\n\x1b[H\x1b[J", clearSequence(true, 3))
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// dockerRunnerName selects the runner that runs tests in a container.
+const dockerRunnerName = "docker"
+
+const (
+	// defaultDockerImage is the image tests run in when neither a compose
+	// service nor an image is configured.
+	defaultDockerImage = "golang"
+	// defaultDockerWorkdir is where the project is mounted in containers
+	// started with docker run.
+	defaultDockerWorkdir = "/src"
+)
+
+// dockerPidFile is where, in a compose service, the command of each run
+// records its pid, so that an interrupted run can be stopped there. It is
+// named after gotest-watch's own pid so that sessions sharing a service
+// don't stop each other's runs.
+var dockerPidFile = fmt.Sprintf("/tmp/gotest-watch-%d.pid", os.Getpid())
+
+// dockerStopTimeout limits how long stopping an interrupted run in a
+// compose service may take.
+const dockerStopTimeout = 10 * time.Second
+
+// dockerCacheVolumes keep the module and build caches of the golang images
+// between runs, so that each docker run doesn't start from nothing.
+var dockerCacheVolumes = []string{
+	"-v", "gotest-watch-gomodcache:/go/pkg/mod",
+	"-v", "gotest-watch-gocache:/root/.cache/go-build",
+}
+
+// dockerRunner runs the configured command in a container: with
+// `docker compose exec` in the dockerService service if one is set, or
+// otherwise with `docker run` in a new container of dockerImage with the
+// project mounted at dockerWorkdir. No TTY is allocated, so that the output
+// can be parsed and colored as for a local run; the env setting, and TERM
+// when color is on, are passed into the container.
+//
+// Interrupting `docker run` stops its container, which runs the command
+// under an init process that passes the interrupt on. `docker compose exec`
+// leaves the command running when it is interrupted, so the command records
+// its pid in dockerPidFile for Stop to interrupt it.
+type dockerRunner struct{}
+
+func (dockerRunner) Command(config *TestConfig) []string {
+	command := strings.Fields(config.BuildCommand())
+	service := config.GetDockerService()
+	workdir := config.GetDockerWorkdir()
+
+	var argv []string
+	if service != "" {
+		argv = []string{"docker", "compose", "exec", "-T"}
+		if workdir != "" {
+			argv = append(argv, "-w", workdir)
+		}
+	} else {
+		workdir = cmp.Or(workdir, defaultDockerWorkdir)
+		argv = []string{"docker", "run", "--rm", "--init", "-v", absWorkingDir(config) + ":" + workdir, "-w", workdir}
+		argv = append(argv, dockerCacheVolumes...)
+	}
+	for _, pair := range formatEnv(config.GetEnv()) {
+		argv = append(argv, "-e", pair)
+	}
+	if config.GetColor() && os.Getenv("TERM") != "" {
+		argv = append(argv, "-e", "TERM")
+	}
+
+	if service != "" {
+		argv = append(argv, service, "sh", "-c", `echo $$ > `+dockerPidFile+` && exec "$@"`, "sh")
+	} else {
+		argv = append(argv, cmp.Or(config.GetDockerImage(), defaultDockerImage))
+	}
+	return append(argv, command...)
+}
+
+// Stop interrupts the command of a run in a compose service, which
+// interrupting `docker compose exec` doesn't. Runs started with `docker run`
+// are stopped with their container.
+func (dockerRunner) Stop(config *TestConfig) error {
+	service := config.GetDockerService()
+	if service == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerStopTimeout)
+	defer cancel()
+	//nolint:gosec // the service is the configured dockerService
+	cmd := exec.CommandContext(ctx, "docker", "compose", "exec", "-T", service,
+		"sh", "-c", `kill -INT "$(cat `+dockerPidFile+`)" 2>/dev/null; rm -f `+dockerPidFile)
+	cmd.Dir = config.GetWorkingDir()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (dockerRunner) ProcessLine(line string) string {
+	return line
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDockerRunner_IsRegistered tests that the docker runner can be selected by name
+func TestDockerRunner_IsRegistered(t *testing.T) {
+	runner, err := lookupRunner("docker")
+
+	require.NoError(t, err)
+	assert.Equal(t, dockerRunner{}, runner)
+}
+
+// TestDockerRunner_ComposeService tests that a configured service runs the command with docker compose exec, recording its pid for Stop
+func TestDockerRunner_ComposeService(t *testing.T) {
+	config := NewTestConfig()
+	config.SetDockerService("app")
+	config.SetDockerWorkdir("/workspace")
+	config.SetEnvVar("CGO_ENABLED", "0")
+	config.SetVerbose(true)
+
+	assert.Equal(t, []string{
+		"docker", "compose", "exec", "-T", "-w", "/workspace", "-e", "CGO_ENABLED=0",
+		"app", "sh", "-c", `echo $$ > ` + dockerPidFile + ` && exec "$@"`, "sh", "go", "test", "./...", "-v",
+	}, dockerRunner{}.Command(config))
+}
+
+// TestDockerRunner_Run tests that without a service the project is mounted in a new container, whose init passes on interrupts
+func TestDockerRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	config := NewTestConfig()
+	config.SetWorkingDir(dir)
+
+	t.Run("uses the golang image and /src by default", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"docker", "run", "--rm", "--init", "-v", dir + ":/src", "-w", "/src",
+			"-v", "gotest-watch-gomodcache:/go/pkg/mod", "-v", "gotest-watch-gocache:/root/.cache/go-build",
+			"golang", "go", "test", "./...",
+		}, dockerRunner{}.Command(config))
+	})
+
+	t.Run("uses the configured image and workdir", func(t *testing.T) {
+		config.SetDockerImage("golang:1.24-alpine")
+		config.SetDockerWorkdir("/app")
+
+		argv := dockerRunner{}.Command(config)
+
+		assert.Contains(t, argv, dir+":/app")
+		assert.Equal(t, []string{"golang:1.24-alpine", "go", "test", "./..."}, argv[len(argv)-4:])
+	})
+}
+
+// TestDockerRunner_PassesTermWithColor tests that TERM reaches the container only when color is on
+func TestDockerRunner_PassesTermWithColor(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	config := NewTestConfig()
+	config.SetDockerService("app")
+
+	assert.NotContains(t, dockerRunner{}.Command(config), "TERM")
+
+	config.SetColor(true)
+	assert.Contains(t, dockerRunner{}.Command(config), "TERM")
+}
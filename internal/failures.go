@@ -0,0 +1,272 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// failureLocation is where a test failed, as reported in its output. File
+// is as the test printed it, relative to the test's package directory.
+// Failed tests that reported no location have no File.
+type failureLocation struct {
+	Package string
+	Test    string
+	File    string
+	Line    int
+	Message string
+}
+
+// locationLine matches a line a test logged, such as
+// "    foo_test.go:12: expected 1, got 2", capturing its indentation, file,
+// line number and message.
+var locationLine = regexp.MustCompile(`^(\s+)(\S+\.go):(\d+): ?(.*)$`)
+
+// testStartLine matches the lines `go test -v` prints when a test starts or
+// resumes, capturing its name.
+var testStartLine = regexp.MustCompile(`^\s*=== (?:RUN|CONT)\s+(\S+)`)
+
+// failureCollector finds where tests failed in the output of a test run,
+// in both the plain text output of `go test` and the event stream of
+// `go test -json`, and reports each failure once its package finishes. It
+// is safe to feed from both the stdout and stderr streamers at once.
+type failureCollector struct {
+	mu       sync.Mutex
+	report   func(failureLocation)
+	packages map[string]*packageFailures
+}
+
+// packageFailures tracks the failures in a single package's output. Plain
+// text output doesn't name the package until it finishes, so it is
+// tracked under "".
+type packageFailures struct {
+	// current is the test that the next logged line belongs to
+	current string
+	// failed lists the tests that failed, in the order they finished
+	failed []string
+	// locations maps each test to the locations it logged
+	locations map[string][]failureLocation
+	// last is the location later lines continue, indented by indent
+	last   *failureLocation
+	indent int
+}
+
+func newFailureCollector(report func(failureLocation)) *failureCollector {
+	return &failureCollector{report: report, packages: map[string]*packageFailures{}}
+}
+
+func (c *failureCollector) parseLine(line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	event, ok := decodeTestEvent(line)
+	if !ok {
+		c.parseOutput("", line)
+		return
+	}
+	if event.Test == "" {
+		switch event.Action {
+		case "pass", "fail":
+			c.finish(event.Package)
+		}
+		return
+	}
+	pkg := c.packageNamed(event.Package)
+	pkg.current = event.Test
+	switch event.Action {
+	case "output":
+		c.parseOutput(event.Package, strings.TrimSuffix(event.Output, "\n"))
+	case "fail":
+		pkg.fail(event.Test)
+	}
+}
+
+// parseOutput parses a line of output from the package named pkg. Callers
+// must hold c.mu.
+func (c *failureCollector) parseOutput(pkg, line string) {
+	failures := c.packageNamed(pkg)
+	if m := packageTrailer.FindStringSubmatch(line); m != nil && pkg == "" {
+		c.finishAs("", m[2])
+		return
+	}
+	if m := testStartLine.FindStringSubmatch(line); m != nil {
+		failures.current = m[1]
+		failures.last = nil
+		return
+	}
+	if m := testResultLine.FindStringSubmatch(line); m != nil {
+		name, _, _ := strings.Cut(strings.TrimSpace(line[len(m[0]):]), " ")
+		failures.current = name
+		failures.last = nil
+		switch m[1] {
+		case "FAIL":
+			failures.fail(name)
+		default:
+			// Logs of tests that didn't fail are not failures
+			delete(failures.locations, name)
+		}
+		return
+	}
+	if m := locationLine.FindStringSubmatch(line); m != nil {
+		number, _ := strconv.Atoi(m[3])
+		locations := append(failures.locations[failures.current], failureLocation{
+			Test:    failures.current,
+			File:    m[2],
+			Line:    number,
+			Message: m[4],
+		})
+		failures.locations[failures.current] = locations
+		failures.last = &locations[len(locations)-1]
+		failures.indent = len(m[1])
+		return
+	}
+	// Lines indented further than a location continue its message, as
+	// multi-line messages such as testify's do
+	trimmed := strings.TrimLeft(line, " \t")
+	if failures.last != nil && trimmed != "" && len(line)-len(trimmed) > failures.indent {
+		failures.last.Message = strings.TrimPrefix(failures.last.Message+"\n"+strings.TrimSpace(trimmed), "\n")
+		return
+	}
+	failures.last = nil
+}
+
+// finish reports the failures in the package named pkg. Callers must hold
+// c.mu.
+func (c *failureCollector) finish(pkg string) {
+	c.finishAs(pkg, pkg)
+}
+
+// finishAs reports the failures tracked under key as those of the package
+// named pkg, and stops tracking them. Callers must hold c.mu.
+func (c *failureCollector) finishAs(key, pkg string) {
+	failures, ok := c.packages[key]
+	if !ok {
+		return
+	}
+	delete(c.packages, key)
+	for _, test := range failures.failed {
+		locations := failures.locations[test]
+		if len(locations) == 0 {
+			// A test whose subtests failed only fails because they did
+			if slices.ContainsFunc(failures.failed, func(other string) bool {
+				return strings.HasPrefix(other, test+"/")
+			}) {
+				continue
+			}
+			locations = []failureLocation{{Test: test}}
+		}
+		for _, location := range locations {
+			location.Package = pkg
+			c.report(location)
+		}
+	}
+}
+
+// packageNamed returns the failures tracked for the package named name,
+// adding it if it has not been seen yet. Callers must hold c.mu.
+func (c *failureCollector) packageNamed(name string) *packageFailures {
+	failures, ok := c.packages[name]
+	if !ok {
+		failures = &packageFailures{locations: map[string][]failureLocation{}}
+		c.packages[name] = failures
+	}
+	return failures
+}
+
+// fail records that the test named name failed.
+func (p *packageFailures) fail(name string) {
+	if !slices.Contains(p.failed, name) {
+		p.failed = append(p.failed, name)
+	}
+}
+
+// formatFailureLocations renders each of failures that has a location as a
+// compiler-style "file:line: test: message" line, for editors to jump to.
+// Files are resolved from their packages' directories with `go list` run
+// in workingDir, and shown relative to it where possible.
+func formatFailureLocations(workingDir string, failures []failureLocation) []string {
+	var packages []string
+	for _, failure := range failures {
+		if failure.File != "" && !slices.Contains(packages, failure.Package) {
+			packages = append(packages, failure.Package)
+		}
+	}
+	if len(packages) == 0 {
+		return nil
+	}
+
+	dirs := map[string]string{}
+	lines, err := goList(workingDir, append([]string{"-f", "{{.ImportPath}}\t{{.Dir}}"}, packages...))
+	if err != nil {
+		log.Println(err)
+	}
+	for _, line := range lines {
+		if pkg, dir, ok := strings.Cut(line, "\t"); ok {
+			dirs[pkg] = dir
+		}
+	}
+
+	base := workingDir
+	if base == "" {
+		base, _ = os.Getwd()
+	}
+	var formatted []string
+	for _, failure := range failures {
+		if failure.File == "" {
+			continue
+		}
+		path := failure.File
+		if dir, ok := dirs[failure.Package]; ok && !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+			if rel, err := filepath.Rel(base, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+		formatted = append(formatted, fmt.Sprintf("%s:%d: %s: %s",
+			filepath.ToSlash(path), failure.Line, failure.Test, oneLine(failure.Message)))
+	}
+	return formatted
+}
+
+// oneLine joins the lines of message with "; ", collapsing the whitespace
+// within them.
+func oneLine(message string) string {
+	var parts []string
+	for _, line := range strings.Split(message, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// writeFailureLocations writes lines to stderr if target is "stderr", or
+// replaces the file named target with them, so that it always lists the
+// failures of the last run.
+func writeFailureLocations(target string, stderr io.Writer, lines []string) error {
+	var out strings.Builder
+	for _, line := range lines {
+		out.WriteString(line + "\n")
+	}
+	if target == failureLocationsStderr {
+		_, err := io.WriteString(stderr, out.String())
+		return err
+	}
+	if dir := filepath.Dir(target); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filepath.Clean(target), []byte(out.String()), 0o600)
+}
+
+// failureLocationsStderr is the failureLocations setting that writes
+// failures to stderr rather than to a file.
+const failureLocationsStderr = "stderr"
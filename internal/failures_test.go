@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectFailures feeds lines to a failureCollector and returns the
+// failures it reported.
+func collectFailures(lines ...string) []failureLocation {
+	var reported []failureLocation
+	c := newFailureCollector(func(location failureLocation) {
+		reported = append(reported, location)
+	})
+	for _, line := range lines {
+		c.parseLine(line)
+	}
+	return reported
+}
+
+// testEventLine renders a `go test -json` event as a line of output.
+func testEventLine(t *testing.T, event testEvent) string {
+	t.Helper()
+	line, err := json.Marshal(event)
+	require.NoError(t, err)
+	return string(line)
+}
+
+func TestFailureCollector(t *testing.T) {
+	t.Run("plain output", func(t *testing.T) {
+		reported := collectFailures(
+			"--- FAIL: TestB (0.00s)",
+			"    b_test.go:4: bad 1",
+			"--- FAIL: TestC (0.00s)",
+			"    --- FAIL: TestC/sub (0.00s)",
+			"        b_test.go:6: boom",
+			"            second line",
+			"FAIL",
+			"FAIL\tm/b\t0.003s",
+		)
+
+		assert.Equal(t, []failureLocation{
+			{Package: "m/b", Test: "TestB", File: "b_test.go", Line: 4, Message: "bad 1"},
+			{Package: "m/b", Test: "TestC/sub", File: "b_test.go", Line: 6, Message: "boom\nsecond line"},
+		}, reported)
+	})
+
+	t.Run("verbose output", func(t *testing.T) {
+		reported := collectFailures(
+			"=== RUN   TestA",
+			"    a_test.go:3: just logging",
+			"--- PASS: TestA (0.00s)",
+			"=== RUN   TestB",
+			"    b_test.go:4: bad 1",
+			"--- FAIL: TestB (0.00s)",
+			"FAIL",
+			"FAIL\tm/b\t0.003s",
+		)
+
+		assert.Equal(t, []failureLocation{
+			{Package: "m/b", Test: "TestB", File: "b_test.go", Line: 4, Message: "bad 1"},
+		}, reported)
+	})
+
+	t.Run("testify messages start on the next line", func(t *testing.T) {
+		reported := collectFailures(
+			"--- FAIL: TestB (0.00s)",
+			"    b_test.go:4: ",
+			"        \tError Trace:\tb_test.go:4",
+			"        \tError:      \tShould be true",
+			"FAIL\tm/b\t0.003s",
+		)
+
+		require.Len(t, reported, 1)
+		assert.Equal(t, "Error Trace:\tb_test.go:4\nError:      \tShould be true", reported[0].Message)
+	})
+
+	t.Run("failed tests without a location", func(t *testing.T) {
+		reported := collectFailures(
+			"--- FAIL: TestPanics (0.00s)",
+			"panic: oops [recovered]",
+			"FAIL\tm/b\t0.003s",
+		)
+
+		assert.Equal(t, []failureLocation{{Package: "m/b", Test: "TestPanics"}}, reported)
+	})
+
+	t.Run("nothing is reported for passing packages", func(t *testing.T) {
+		reported := collectFailures(
+			"ok  \tm/a\t0.002s",
+			"?   \tm/c\t[no test files]",
+		)
+
+		assert.Empty(t, reported)
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		reported := collectFailures(
+			testEventLine(t, testEvent{Action: "run", Package: "m/b", Test: "TestB"}),
+			testEventLine(t, testEvent{Action: "output", Package: "m/b", Test: "TestB", Output: "=== RUN   TestB\n"}),
+			testEventLine(t, testEvent{Action: "output", Package: "m/b", Test: "TestB", Output: "    b_test.go:4: bad 1\n"}),
+			testEventLine(t, testEvent{Action: "output", Package: "m/b", Test: "TestB", Output: "--- FAIL: TestB (0.00s)\n"}),
+			testEventLine(t, testEvent{Action: "fail", Package: "m/b", Test: "TestB"}),
+			testEventLine(t, testEvent{Action: "output", Package: "m/b", Output: "FAIL\tm/b\t0.003s\n"}),
+			testEventLine(t, testEvent{Action: "fail", Package: "m/b"}),
+		)
+
+		assert.Equal(t, []failureLocation{
+			{Package: "m/b", Test: "TestB", File: "b_test.go", Line: 4, Message: "bad 1"},
+		}, reported)
+	})
+
+	t.Run("json output keeps packages apart", func(t *testing.T) {
+		reported := collectFailures(
+			testEventLine(t, testEvent{Action: "output", Package: "m/b", Test: "TestB", Output: "    b_test.go:4: bad\n"}),
+			testEventLine(t, testEvent{Action: "output", Package: "m/c", Test: "TestC", Output: "    c_test.go:9: worse\n"}),
+			testEventLine(t, testEvent{Action: "fail", Package: "m/c", Test: "TestC"}),
+			testEventLine(t, testEvent{Action: "fail", Package: "m/c"}),
+			testEventLine(t, testEvent{Action: "pass", Package: "m/b", Test: "TestB"}),
+			testEventLine(t, testEvent{Action: "pass", Package: "m/b"}),
+		)
+
+		require.Len(t, reported, 1)
+		assert.Equal(t, "m/c", reported[0].Package)
+		assert.Equal(t, "c_test.go", reported[0].File)
+		assert.False(t, strings.Contains(reported[0].Message, "bad"))
+	})
+}
+
+func TestFormatFailureLocations(t *testing.T) {
+	t.Run("resolves files from their packages", func(t *testing.T) {
+		dir := setupTestModule(t, "package testmodule\n")
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "sub_test.go"), []byte("package sub\n"), 0o600))
+
+		lines := formatFailureLocations(dir, []failureLocation{
+			{Package: "testmodule/sub", Test: "TestB", File: "sub_test.go", Line: 4, Message: "bad 1"},
+			{Package: "testmodule", Test: "TestC/sub", File: "example_test.go", Line: 9, Message: "\nError:  \tNot equal\nexpected: 1"},
+			{Package: "testmodule", Test: "TestPanics"},
+		})
+
+		assert.Equal(t, []string{
+			"sub/sub_test.go:4: TestB: bad 1",
+			"example_test.go:9: TestC/sub: Error: Not equal; expected: 1",
+		}, lines)
+	})
+
+	t.Run("keeps files as printed when their packages can't be found", func(t *testing.T) {
+		lines := formatFailureLocations(t.TempDir(), []failureLocation{
+			{Package: "example.com/missing", Test: "TestB", File: "b_test.go", Line: 4, Message: "bad"},
+		})
+
+		assert.Equal(t, []string{"b_test.go:4: TestB: bad"}, lines)
+	})
+
+	t.Run("nothing to format", func(t *testing.T) {
+		assert.Empty(t, formatFailureLocations(t.TempDir(), nil))
+	})
+}
+
+func TestWriteFailureLocations(t *testing.T) {
+	t.Run("writes to stderr", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		require.NoError(t, writeFailureLocations("stderr", &stderr, []string{"a_test.go:1: TestA: bad"}))
+
+		assert.Equal(t, "a_test.go:1: TestA: bad\n", stderr.String())
+	})
+
+	t.Run("replaces the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".gotest-watch", "failures")
+
+		require.NoError(t, writeFailureLocations(path, nil, []string{"a_test.go:1: TestA: bad"}))
+		require.NoError(t, writeFailureLocations(path, nil, nil))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Empty(t, data)
+	})
+}
+
+// TestRunTests_WritesFailureLocations tests that failures are written
+// compiler-style after a run
+func TestRunTests_WritesFailureLocations(t *testing.T) {
+	testContent := `package locations
+
+import "testing"
+
+func TestFails(t *testing.T) {
+	t.Error("intentional failure")
+}
+`
+	tempDir := setupTestModule(t, testContent)
+	target := filepath.Join(tempDir, "failures.txt")
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetFailureLocations(target)
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "example_test.go:6: TestFails: intentional failure\n", string(data))
+}
@@ -2,29 +2,193 @@ package internal
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
+// debounceInterval is how long the watched tree must be quiet after a change
+// before a run is triggered, unless a longer quiet period is configured.
+const debounceInterval = 200 * time.Millisecond
+
 func isGoFile(filename string) bool {
 	return filepath.Ext(filename) == ".go"
 }
 
-func addWatchRecursive(watcher *fsnotify.Watcher, rootpath string) error {
+// fileWatcher is a source of file system events for the watched trees.
+type fileWatcher interface {
+	Events() <-chan fsnotify.Event
+	Errors() <-chan error
+	Close() error
+	// addDir starts watching dir and the directories below it
+	addDir(dir string) error
+	// removeDir stops watching dir and the directories below it
+	removeDir(dir string) error
+}
+
+// newFileWatcher returns a fileWatcher for the trees under roots, skipping
+// hidden directories and those ignored by ignore, and following symlinks to
+// directories if followSymlinks is set. If pollInterval is set the trees are
+// scanned that often, also checking configFiles, otherwise fsnotify is used,
+// which reports every file in the directories it watches.
+func newFileWatcher(
+	roots []string,
+	ignore *ignoreMatcher,
+	pollInterval time.Duration,
+	followSymlinks bool,
+	configFiles []string,
+) (fileWatcher, error) {
+	if pollInterval > 0 {
+		return newPollWatcher(roots, ignore, pollInterval, followSymlinks, configFiles)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := fsnotifyWatcher{watcher: watcher, ignore: ignore, followSymlinks: followSymlinks}
+	for _, root := range roots {
+		if err := w.addDir(root); err != nil {
+			// Keep watching whatever was added before the error
+			log.Print(err)
+		}
+	}
+	return w, nil
+}
+
+// fsnotifyWatcher is a fileWatcher backed by fsnotify.
+type fsnotifyWatcher struct {
+	watcher        *fsnotify.Watcher
+	ignore         *ignoreMatcher
+	followSymlinks bool
+}
+
+func (w fsnotifyWatcher) Events() <-chan fsnotify.Event {
+	return w.watcher.Events
+}
+
+func (w fsnotifyWatcher) Errors() <-chan error {
+	return w.watcher.Errors
+}
+
+func (w fsnotifyWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+func (w fsnotifyWatcher) addDir(dir string) error {
+	var visited map[string]bool
+	if w.followSymlinks {
+		visited = map[string]bool{}
+	}
+	return addWatchRecursive(w.watcher, dir, w.ignore, visited)
+}
+
+func (w fsnotifyWatcher) removeDir(dir string) error {
+	for _, path := range w.watcher.WatchList() {
+		if isWithin(path, dir) {
+			if err := w.watcher.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isWithin reports whether path is dir or is below it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// watchRoots returns the directories to watch: root, and the extra
+// directories in config, which are relative to root unless absolute.
+func watchRoots(root string, config *TestConfig) []string {
+	roots := []string{root}
+	if config == nil {
+		return roots
+	}
+	for _, dir := range config.GetWatchDirs() {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		if !slices.Contains(roots, dir) {
+			roots = append(roots, dir)
+		}
+	}
+	return roots
+}
+
+// updateWatchRoots makes watcher watch roots rather than current.
+func updateWatchRoots(watcher fileWatcher, current, roots []string) {
+	removed := false
+	for _, dir := range current {
+		if !slices.Contains(roots, dir) {
+			removed = true
+			if err := watcher.removeDir(dir); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+	for _, dir := range roots {
+		// Removing a directory may also have removed watches below it that
+		// belong to another root, so restore them
+		if removed || !slices.Contains(current, dir) {
+			if err := watcher.addDir(dir); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
+
+// addWatchRecursive watches rootpath and every directory below it, skipping
+// hidden directories and those ignored by ignore. The .gitignore files of
+// watched directories are loaded into ignore as they are found.
+//
+// If visited is not nil, symlinks to directories are followed as well, and
+// visited records the real path of every directory watched so that symlink
+// cycles are only walked once.
+func addWatchRecursive(
+	watcher *fsnotify.Watcher,
+	rootpath string,
+	ignore *ignoreMatcher,
+	visited map[string]bool,
+) error {
 	return filepath.WalkDir(rootpath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
+		if isSymlinkToDir(path, d, visited) {
+			if strings.HasPrefix(filepath.Base(path), ".") || ignore.ignored(path, true) {
+				return nil
+			}
+			// WalkDir does not follow symlinks, except for a root with a
+			// trailing separator
+			if err := addWatchRecursive(watcher, path+string(filepath.Separator), ignore, visited); err != nil {
+				log.Print(err)
+			}
+			return nil
+		}
+
 		if d.IsDir() {
-			if strings.HasPrefix(filepath.Base(path), ".") {
+			if strings.HasPrefix(filepath.Base(path), ".") || ignore.ignored(path, true) {
 				return filepath.SkipDir
 			}
+			if !markVisited(path, visited) {
+				return filepath.SkipDir
+			}
+			if err := ignore.loadGitignore(path); err != nil {
+				log.Print(err)
+			}
 			err = watcher.Add(path)
 			if err != nil {
 				return err
@@ -34,6 +198,56 @@ func addWatchRecursive(watcher *fsnotify.Watcher, rootpath string) error {
 	})
 }
 
+// filesBelow returns the files in dir and the directories below it, skipping
+// hidden directories and those ignored by ignore, as addWatchRecursive does.
+func filesBelow(dir string, ignore *ignoreMatcher) []string {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(filepath.Base(path), ".") || ignore.ignored(path, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		log.Print(err)
+	}
+	return files
+}
+
+// isSymlinkToDir reports whether d, found at path, is a symlink to a
+// directory that should be followed: that is, if visited is not nil.
+func isSymlinkToDir(path string, d fs.DirEntry, visited map[string]bool) bool {
+	if visited == nil || d.Type()&fs.ModeSymlink == 0 {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// markVisited records the directory at path in visited, reporting false if
+// it was already there. It always reports true if visited is nil.
+func markVisited(path string, visited map[string]bool) bool {
+	if visited == nil {
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		resolved = filepath.Clean(path)
+	}
+	if visited[resolved] {
+		return false
+	}
+	visited[resolved] = true
+	return true
+}
+
 func WatchFiles(
 	ctx context.Context,
 	dir string,
@@ -46,7 +260,29 @@ func WatchFiles(
 	case <-ctx.Done():
 		return
 	}
-	watcher, err := fsnotify.NewWatcher()
+	minFileSize := 0
+	interval := debounceInterval
+	var onPending func()
+	config := getConfig(ctx)
+	if config != nil {
+		minFileSize = config.GetWatchMinFileSize()
+		interval = quietInterval(config)
+		if config.GetPromptPending() {
+			onPending = func() {
+				fileChangeChan <- FileChangeMessage{Pending: true}
+			}
+		}
+	}
+
+	reloader := getConfigReloader(ctx)
+	roots := watchRoots(dir, config)
+	ignorePatterns, pollInterval, followSymlinks := watchOptions(config)
+	ignore := newIgnoreMatcher(dir, ignorePatterns)
+	watcher, err := newFileWatcher(roots, ignore, pollInterval, followSymlinks, reloader.configFiles())
+	if err != nil {
+		log.Print(err)
+		return
+	}
 	defer func() {
 		err := watcher.Close()
 		if err != nil {
@@ -54,59 +290,230 @@ func WatchFiles(
 		}
 	}()
 
-	if err != nil {
-		log.Print(err)
-	}
-	err = addWatchRecursive(watcher, dir)
-	if err != nil {
-		log.Print(err)
+	// rebuild replaces the watcher with a new one for the current roots and
+	// settings, rereading .gitignore files
+	rebuild := func() error {
+		newRoots := watchRoots(dir, config)
+		ignorePatterns, pollInterval, followSymlinks := watchOptions(config)
+		newIgnore := newIgnoreMatcher(dir, ignorePatterns)
+		newWatcher, err := newFileWatcher(newRoots, newIgnore, pollInterval, followSymlinks, reloader.configFiles())
+		if err != nil {
+			return err
+		}
+		if err := watcher.Close(); err != nil {
+			log.Print(err)
+		}
+		watcher, ignore, roots = newWatcher, newIgnore, newRoots
+		return nil
 	}
 
 	debounceChan := make(chan fsnotify.Event, 10)
-	go debounceLoop(200*time.Millisecond, debounceChan, func(_ fsnotify.Event) {
-		fileChangeChan <- FileChangeMessage{}
+	go debounceLoop(interval, debounceChan, onPending, func(paths []string) {
+		fileChangeChan <- FileChangeMessage{Paths: paths}
 	})
 
+	// Editors often write a file in several steps, so the config is only
+	// reloaded once its file has been quiet for a moment
+	var reloadTimer *time.Timer
+	defer func() {
+		if reloadTimer != nil {
+			reloadTimer.Stop()
+		}
+	}()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case event, ok := <-watcher.Events:
+		case event, ok := <-watcher.Events():
 			if !ok {
-				return
+				if !recoverWatcher(os.Stderr, "stopped unexpectedly", rebuild) {
+					return
+				}
+				continue
+			}
+
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watcher.addDir(event.Name); err != nil {
+						log.Print(err)
+					}
+					// Files moved in with the directory, or written to it
+					// before it was watched, have no events of their own
+					for _, path := range filesBelow(event.Name, ignore) {
+						created := fsnotify.Event{Name: path, Op: fsnotify.Create}
+						if shouldTriggerRun(created, minFileSize, ignore) {
+							metrics.recordEvent()
+							debounceChan <- created
+						}
+					}
+					continue
+				}
+			}
+
+			if reloader.isConfigFile(event.Name) {
+				if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+					if reloadTimer == nil {
+						reloadTimer = time.AfterFunc(debounceInterval, reloader.request)
+					} else {
+						reloadTimer.Reset(debounceInterval)
+					}
+				}
+				continue
 			}
 
-			if isTrackedChangeEvent(event) && filepath.Ext(event.Name) == ".go" {
+			if shouldTriggerRun(event, minFileSize, ignore) {
 				// fmt.Println(event.String())
+				metrics.recordEvent()
 				debounceChan <- event
 			}
-		case err, ok := <-watcher.Errors:
+		case request := <-getWatchRequests(ctx):
+			if request == watchRescan {
+				// The tree may have changed entirely, so start from scratch
+				if err := rebuild(); err != nil {
+					log.Printf("Warning: rescan failed, still using the previous watch list: %v", err)
+				}
+				continue
+			}
+			newRoots := watchRoots(dir, config)
+			updateWatchRoots(watcher, roots, newRoots)
+			roots = newRoots
+		case err, ok := <-watcher.Errors():
 			if !ok {
-				return
+				if !recoverWatcher(os.Stderr, "stopped unexpectedly", rebuild) {
+					return
+				}
+				continue
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				if !recoverWatcher(os.Stderr, "overflowed", rebuild) {
+					return
+				}
+				continue
 			}
 			log.Println(err)
 		}
 	}
 }
 
-func debounceLoop(interval time.Duration, input chan fsnotify.Event, callback func(event fsnotify.Event)) {
-	var event fsnotify.Event
+// watchOptions returns the ignore patterns, poll interval and whether to
+// follow symlinks set in config for the watcher. A zero poll interval means
+// fsnotify events are used instead.
+func watchOptions(config *TestConfig) ([]string, time.Duration, bool) {
+	if config == nil {
+		return nil, 0, false
+	}
+	ignorePatterns := append(excludePatterns(config.GetExclude()), config.GetIgnore()...)
+	var pollInterval time.Duration
+	if poll := config.GetPoll(); poll != "" {
+		d, err := time.ParseDuration(poll)
+		if err != nil || d <= 0 {
+			log.Printf("Warning: invalid poll interval %q, using fsnotify", poll)
+		} else {
+			pollInterval = d
+		}
+	}
+	return ignorePatterns, pollInterval, config.GetFollowSymlinks()
+}
+
+// recoverWatcher replaces a watcher that has overflowed or stopped using
+// rebuild, warning on w that file changes may have been missed. It reports
+// false if the watcher could not be replaced.
+func recoverWatcher(w io.Writer, reason string, rebuild func() error) bool {
+	fmt.Fprintf(w, "\nWarning: file watcher %s, so file changes may have been missed; restarting it\n", reason)
+	if err := rebuild(); err != nil {
+		fmt.Fprintf(w, "Warning: could not restart file watcher, file changes will no longer trigger runs: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// excludePatterns converts excluded directories into ignore patterns. A bare
+// name such as `vendor` excludes directories with that name anywhere in the
+// tree, while a path such as `internal/gen` or `./build` is relative to the
+// watched root.
+func excludePatterns(dirs []string) []string {
+	patterns := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		anchored := strings.HasPrefix(filepath.ToSlash(dir), "./")
+		dir = strings.Trim(filepath.ToSlash(filepath.Clean(dir)), "/")
+		if dir == "" || dir == "." {
+			continue
+		}
+		if anchored || strings.Contains(dir, "/") {
+			dir = "/" + dir
+		}
+		patterns = append(patterns, dir+"/")
+	}
+	return patterns
+}
+
+// quietInterval returns how long the watched tree must be quiet before a run
+// starts: the configured quiet period, or the debounce interval if that is
+// longer.
+func quietInterval(config *TestConfig) time.Duration {
+	quiet := time.Duration(config.GetWatchQuietPeriod()) * time.Millisecond
+	if quiet > debounceInterval {
+		return quiet
+	}
+	return debounceInterval
+}
+
+// debounceLoop calls callback with the paths of a burst of events once input
+// has been quiet for interval. If onPending is set, it is called when the
+// first event of a burst arrives.
+func debounceLoop(
+	interval time.Duration,
+	input chan fsnotify.Event,
+	onPending func(),
+	callback func(paths []string),
+) {
+	var paths []string
+	pending := false
 	timer := time.NewTimer(interval)
 	<-timer.C
 
 	for {
 		select {
-		case event = <-input:
+		case event := <-input:
 			// fmt.Println("======= resetting debounce timer")
+			if !slices.Contains(paths, event.Name) {
+				paths = append(paths, event.Name)
+			}
+			if pending {
+				metrics.recordDebounced()
+			} else if onPending != nil {
+				onPending()
+			}
+			pending = true
 			timer.Reset(interval)
 		case <-timer.C:
 			// fmt.Println("===== timeout reached:")
-			// fmt.Println("    ", event.String())
-			callback(event)
+			pending = false
+			callback(paths)
+			paths = nil
 		}
 	}
 }
 
+// shouldTriggerRun reports whether event should lead to a test run. Files
+// ignored by ignore never trigger a run. Creates and writes of files smaller
+// than minFileSize bytes are ignored, which filters out editors that create
+// an empty file before writing it.
+func shouldTriggerRun(event fsnotify.Event, minFileSize int, ignore *ignoreMatcher) bool {
+	if !isTrackedChangeEvent(event) || !isGoFile(event.Name) || ignore.ignored(event.Name, false) {
+		return false
+	}
+	if minFileSize <= 0 || !(event.Has(fsnotify.Create) || event.Has(fsnotify.Write)) {
+		return true
+	}
+	info, err := os.Stat(event.Name)
+	if err != nil {
+		return false
+	}
+	return info.Size() >= int64(minFileSize)
+}
+
 func isTrackedChangeEvent(event fsnotify.Event) bool {
 	return event.Has(fsnotify.Create) ||
 		event.Has(fsnotify.Remove) ||
@@ -1,9 +1,12 @@
 package internal
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
@@ -142,7 +145,7 @@ func TestAddWatchRecursive_WithSimpleDirectory(t *testing.T) {
 	defer watcher.Close()
 
 	// Add directory recursively
-	err = addWatchRecursive(watcher, tempDir)
+	err = addWatchRecursive(watcher, tempDir, nil, nil)
 	require.NoError(t, err, "should successfully add directory to watcher")
 
 	// Verify the directory is being watched
@@ -178,7 +181,7 @@ func TestAddWatchRecursive_WithNestedDirectories(t *testing.T) {
 	defer watcher.Close()
 
 	// Add directory recursively
-	err = addWatchRecursive(watcher, tempDir)
+	err = addWatchRecursive(watcher, tempDir, nil, nil)
 	require.NoError(t, err, "should successfully add nested directories")
 
 	// Verify all directories are being watched
@@ -218,7 +221,7 @@ func TestAddWatchRecursive_ExcludesHiddenDirectories(t *testing.T) {
 	defer watcher.Close()
 
 	// Add directory recursively
-	err = addWatchRecursive(watcher, tempDir)
+	err = addWatchRecursive(watcher, tempDir, nil, nil)
 	require.NoError(t, err)
 
 	// Verify hidden directories are NOT being watched
@@ -229,6 +232,53 @@ func TestAddWatchRecursive_ExcludesHiddenDirectories(t *testing.T) {
 	assert.NotContains(t, watchList, nestedHiddenDir, "should NOT watch nested hidden directory")
 }
 
+// TestAddWatchRecursive_ExcludesIgnoredDirectories tests that directories ignored by .gitignore or the ignore list are excluded
+func TestAddWatchRecursive_ExcludesIgnoredDirectories(t *testing.T) {
+	tempDir := t.TempDir()
+
+	pkgDir := filepath.Join(tempDir, "pkg")
+	vendorDir := filepath.Join(tempDir, "vendor")
+	mocksDir := filepath.Join(tempDir, "pkg", "mocks")
+	for _, dir := range []string{pkgDir, vendorDir, mocksDir} {
+		require.NoError(t, os.MkdirAll(dir, 0o750))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("vendor/\n"), 0o600))
+
+	watcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	ignore := newIgnoreMatcher(tempDir, []string{"**/mocks/**"})
+	require.NoError(t, addWatchRecursive(watcher, tempDir, ignore, nil))
+
+	watchList := watcher.WatchList()
+	assert.Contains(t, watchList, pkgDir, "should watch pkg directory")
+	assert.NotContains(t, watchList, vendorDir, "should NOT watch directory ignored by .gitignore")
+	assert.NotContains(t, watchList, mocksDir, "should NOT watch directory ignored by config")
+}
+
+// TestAddWatchRecursive_FollowsSymlinks tests that symlinked directories are only watched when following symlinks
+func TestAddWatchRecursive_FollowsSymlinks(t *testing.T) {
+	tempDir := t.TempDir()
+	shared := filepath.Join(t.TempDir(), "shared")
+	require.NoError(t, os.MkdirAll(filepath.Join(shared, "util"), 0o750))
+	link := filepath.Join(tempDir, "shared")
+	require.NoError(t, os.Symlink(shared, link))
+	// A link back to the project root forms a cycle
+	require.NoError(t, os.Symlink(tempDir, filepath.Join(shared, "project")))
+
+	watcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	require.NoError(t, addWatchRecursive(watcher, tempDir, nil, nil))
+	assert.ElementsMatch(t, []string{tempDir}, watcher.WatchList(), "should not follow symlinks by default")
+
+	require.NoError(t, addWatchRecursive(watcher, tempDir, nil, map[string]bool{}))
+	assert.ElementsMatch(t, []string{tempDir, link, filepath.Join(link, "util")}, watcher.WatchList(),
+		"should watch symlinked directories once, without following the cycle")
+}
+
 // TestAddWatchRecursive_WithInvalidPath tests error handling for invalid path
 func TestAddWatchRecursive_WithInvalidPath(t *testing.T) {
 	watcher, err := fsnotify.NewWatcher()
@@ -236,7 +286,7 @@ func TestAddWatchRecursive_WithInvalidPath(t *testing.T) {
 	defer watcher.Close()
 
 	// Try to watch non-existent directory
-	err = addWatchRecursive(watcher, "/nonexistent/path/that/does/not/exist")
+	err = addWatchRecursive(watcher, "/nonexistent/path/that/does/not/exist", nil, nil)
 	assert.Error(t, err, "should return error for non-existent path")
 }
 
@@ -254,7 +304,7 @@ func TestAddWatchRecursive_WithFile(t *testing.T) {
 	defer watcher.Close()
 
 	// Try to watch a file directly - should handle gracefully or error
-	err = addWatchRecursive(watcher, filePath)
+	err = addWatchRecursive(watcher, filePath, nil, nil)
 	// Implementation should either skip files or return error
 	// For this test, we expect it to handle files appropriately
 	if err == nil {
@@ -524,6 +574,97 @@ func TestWatchFiles_IgnoresHiddenDirectories(t *testing.T) {
 	}
 }
 
+// TestWatchFiles_WatchesNewDirectories tests that directories created after startup are watched
+func TestWatchFiles_WatchesNewDirectories(t *testing.T) {
+	tempDir := t.TempDir()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	newDir := filepath.Join(tempDir, "internal", "newpkg")
+	require.NoError(t, os.MkdirAll(newDir, 0o750))
+	// Give the watcher time to add the new directories
+	time.Sleep(50 * time.Millisecond)
+
+	path := filepath.Join(newDir, "foo.go")
+	require.NoError(t, os.WriteFile(path, []byte("package newpkg"), 0o600))
+
+	select {
+	case msg := <-fileChangeChan:
+		assert.Equal(t, []string{path}, msg.Paths)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for FileChangeMessage from new directory")
+	}
+}
+
+// TestWatchFiles_DetectsFilesInNewDirectories tests that Go files already in a directory when it appears, such as one moved into the tree, trigger a run
+func TestWatchFiles_DetectsFilesInNewDirectories(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := t.TempDir()
+	staged := filepath.Join(outside, "newpkg")
+	require.NoError(t, os.MkdirAll(filepath.Join(staged, "sub"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(staged, "foo.go"), []byte("package newpkg"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(staged, "sub", "bar.go"), []byte("package sub"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(staged, "README.md"), []byte("docs"), 0o600))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	newDir := filepath.Join(tempDir, "newpkg")
+	require.NoError(t, os.Rename(staged, newDir))
+
+	select {
+	case msg := <-fileChangeChan:
+		assert.ElementsMatch(t, []string{
+			filepath.Join(newDir, "foo.go"),
+			filepath.Join(newDir, "sub", "bar.go"),
+		}, msg.Paths)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for FileChangeMessage from moved directory")
+	}
+}
+
+// TestWatchFiles_IgnoresNewHiddenDirectories tests that hidden directories created after startup are not watched
+func TestWatchFiles_IgnoresNewHiddenDirectories(t *testing.T) {
+	tempDir := t.TempDir()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	hiddenDir := filepath.Join(tempDir, ".cache")
+	require.NoError(t, os.MkdirAll(hiddenDir, 0o750))
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(hiddenDir, "gen.go"), []byte("package cache"), 0o600))
+
+	select {
+	case <-fileChangeChan:
+		t.Fatal("should not receive FileChangeMessage for files in new hidden directories")
+	case <-time.After(400 * time.Millisecond):
+	}
+}
+
 // TestWatchFiles_ContextCancellation tests that watcher stops when context is cancelled
 func TestWatchFiles_ContextCancellation(t *testing.T) {
 	tempDir := t.TempDir()
@@ -658,3 +799,411 @@ func TestWatchFiles_FileRemoval(t *testing.T) {
 		t.Fatal("timeout waiting for FileChangeMessage after file removal")
 	}
 }
+
+// ============================================================================
+// shouldTriggerRun Tests
+// ============================================================================
+
+// TestShouldTriggerRun_IgnoresZeroByteCreate tests that an empty .go file below the threshold is ignored
+func TestShouldTriggerRun_IgnoresZeroByteCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.go")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	event := fsnotify.Event{Name: path, Op: fsnotify.Create}
+
+	assert.False(t, shouldTriggerRun(event, 1, nil), "zero-byte create should be ignored")
+	assert.True(t, shouldTriggerRun(event, 0, nil), "no threshold should honor every .go change")
+}
+
+// TestShouldTriggerRun_HonorsWrittenFile tests that a .go file at or above the threshold triggers
+func TestShouldTriggerRun_HonorsWrittenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main"), 0o600))
+
+	event := fsnotify.Event{Name: path, Op: fsnotify.Write}
+
+	assert.True(t, shouldTriggerRun(event, 1, nil))
+	assert.True(t, shouldTriggerRun(event, len("package main"), nil), "file exactly at threshold should trigger")
+	assert.False(t, shouldTriggerRun(event, 100, nil), "file below threshold should be ignored")
+}
+
+// TestShouldTriggerRun_RemoveBypassesSizeCheck tests that removals trigger even though the file is gone
+func TestShouldTriggerRun_RemoveBypassesSizeCheck(t *testing.T) {
+	event := fsnotify.Event{Name: filepath.Join(t.TempDir(), "gone.go"), Op: fsnotify.Remove}
+
+	assert.True(t, shouldTriggerRun(event, 1, nil))
+}
+
+// TestShouldTriggerRun_IgnoresIgnoredFiles tests that files matching ignore patterns never trigger
+func TestShouldTriggerRun_IgnoresIgnoredFiles(t *testing.T) {
+	root := t.TempDir()
+	ignore := newIgnoreMatcher(root, []string{"*_gen.go"})
+
+	assert.False(t, shouldTriggerRun(fsnotify.Event{Name: filepath.Join(root, "model_gen.go"), Op: fsnotify.Write}, 0, ignore))
+	assert.True(t, shouldTriggerRun(fsnotify.Event{Name: filepath.Join(root, "model.go"), Op: fsnotify.Write}, 0, ignore))
+}
+
+// TestShouldTriggerRun_IgnoresNonGoFiles tests that non-.go files never trigger
+func TestShouldTriggerRun_IgnoresNonGoFiles(t *testing.T) {
+	event := fsnotify.Event{Name: "swap.txt", Op: fsnotify.Write}
+
+	assert.False(t, shouldTriggerRun(event, 0, nil))
+}
+
+// TestWatchFiles_MinFileSizeIgnoresEmptyCreate tests the watcher ignores an empty create but not the following write
+func TestWatchFiles_MinFileSizeIgnoresEmptyCreate(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := NewTestConfig()
+	config.SetWatchMinFileSize(1)
+	ctx, cancel := context.WithTimeout(WithConfig(context.Background(), config), 2*time.Second)
+	defer cancel()
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	testFile := filepath.Join(tempDir, "new.go")
+	require.NoError(t, os.WriteFile(testFile, nil, 0o600))
+
+	select {
+	case <-fileChangeChan:
+		t.Fatal("zero-byte .go create should not trigger a run")
+	case <-time.After(400 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(testFile, []byte("package main"), 0o600))
+
+	select {
+	case <-fileChangeChan:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("timeout waiting for FileChangeMessage after writing the file")
+	}
+}
+
+// TestWatchFiles_SendsPendingBeforeDebouncedChange tests that a pending notice precedes the debounced change
+func TestWatchFiles_SendsPendingBeforeDebouncedChange(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := NewTestConfig()
+	config.SetPromptPending(true)
+	ctx, cancel := context.WithTimeout(WithConfig(context.Background(), config), 2*time.Second)
+	defer cancel()
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main"), 0o600))
+
+	for _, wantPending := range []bool{true, false} {
+		select {
+		case msg := <-fileChangeChan:
+			assert.Equal(t, wantPending, msg.Pending)
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for FileChangeMessage")
+		}
+	}
+}
+
+// TestExcludePatterns tests that excluded directories become directory-only ignore patterns
+func TestExcludePatterns(t *testing.T) {
+	assert.Equal(t,
+		[]string{"vendor/", "testdata/", "/internal/gen/", "/build/"},
+		excludePatterns([]string{"vendor", "testdata/", "internal/gen", "./build", "", "."}))
+}
+
+// TestWatchFiles_ExcludedDirectoriesAreNotWatched tests that changes in excluded directories never trigger runs
+func TestWatchFiles_ExcludedDirectoriesAreNotWatched(t *testing.T) {
+	tempDir := t.TempDir()
+	vendorDir := filepath.Join(tempDir, "pkg", "vendor")
+	require.NoError(t, os.MkdirAll(vendorDir, 0o750))
+
+	config := NewTestConfig()
+	config.SetExclude([]string{"vendor"})
+	ctx, cancel := context.WithTimeout(WithConfig(context.Background(), config), 2*time.Second)
+	defer cancel()
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(vendorDir, "lib.go"), []byte("package vendor"), 0o600))
+
+	select {
+	case <-fileChangeChan:
+		t.Fatal("should not receive FileChangeMessage for files in excluded directories")
+	case <-time.After(400 * time.Millisecond):
+	}
+}
+
+// TestWatchRoots tests that extra watch dirs are resolved against the root
+func TestWatchRoots(t *testing.T) {
+	config := NewTestConfig()
+	config.SetWatchDirs([]string{"../shared", "/abs/lib", "/project"})
+
+	assert.Equal(t, []string{"/project"}, watchRoots("/project", nil))
+	assert.Equal(t, []string{"/project", "/shared", "/abs/lib"}, watchRoots("/project", config))
+}
+
+// TestUpdateWatchRoots tests that the fsnotify watch list follows added and removed roots
+func TestUpdateWatchRoots(t *testing.T) {
+	root := t.TempDir()
+	extra := t.TempDir()
+	extraSub := filepath.Join(extra, "sub")
+	require.NoError(t, os.MkdirAll(extraSub, 0o750))
+
+	watcher, err := newFileWatcher([]string{root}, nil, 0, false, nil)
+	require.NoError(t, err)
+	defer watcher.Close()
+	watchList := func() []string { return watcher.(fsnotifyWatcher).watcher.WatchList() }
+
+	updateWatchRoots(watcher, []string{root}, []string{root, extra})
+	assert.ElementsMatch(t, []string{root, extra, extraSub}, watchList())
+
+	updateWatchRoots(watcher, []string{root, extra}, []string{root})
+	assert.ElementsMatch(t, []string{root}, watchList())
+}
+
+// TestWatchFiles_WatchRequestAddsDirectory tests that a watch request makes the watcher pick up new watch dirs
+func TestWatchFiles_WatchRequestAddsDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	extra := t.TempDir()
+
+	config := NewTestConfig()
+	ctx, cancel := context.WithTimeout(WithConfig(context.Background(), config), 2*time.Second)
+	defer cancel()
+	ctx = WithWatchRequests(ctx)
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	config.AddWatchDir(extra)
+	requestWatch(ctx, watchUpdate)
+	time.Sleep(50 * time.Millisecond)
+
+	path := filepath.Join(extra, "lib.go")
+	require.NoError(t, os.WriteFile(path, []byte("package lib"), 0o600))
+
+	select {
+	case msg := <-fileChangeChan:
+		assert.Equal(t, []string{path}, msg.Paths)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for FileChangeMessage from added watch dir")
+	}
+}
+
+// TestWatchFiles_RescanRereadsGitignore tests that a rescan rebuilds the watch list from the current tree
+func TestWatchFiles_RescanRereadsGitignore(t *testing.T) {
+	tempDir := t.TempDir()
+	genDir := filepath.Join(tempDir, "gen")
+	require.NoError(t, os.MkdirAll(genDir, 0o750))
+	gitignore := filepath.Join(tempDir, ".gitignore")
+	require.NoError(t, os.WriteFile(gitignore, []byte("gen/\n"), 0o600))
+
+	config := NewTestConfig()
+	ctx, cancel := context.WithTimeout(WithConfig(context.Background(), config), 3*time.Second)
+	defer cancel()
+	ctx = WithWatchRequests(ctx)
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	// A checkout stops ignoring gen/, which the watcher cannot notice itself
+	require.NoError(t, os.WriteFile(gitignore, nil, 0o600))
+	path := filepath.Join(genDir, "gen.go")
+	require.NoError(t, os.WriteFile(path, []byte("package gen"), 0o600))
+
+	select {
+	case <-fileChangeChan:
+		t.Fatal("should not receive FileChangeMessage for a directory ignored when the watcher started")
+	case <-time.After(400 * time.Millisecond):
+	}
+
+	requestWatch(ctx, watchRescan)
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(path, []byte("package gen\n"), 0o600))
+
+	select {
+	case msg := <-fileChangeChan:
+		assert.Equal(t, []string{path}, msg.Paths)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for FileChangeMessage after rescan")
+	}
+}
+
+// TestWatchFiles_FollowSymlinksDetectsChanges tests that changes in symlinked directories trigger runs when enabled
+func TestWatchFiles_FollowSymlinksDetectsChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	shared := t.TempDir()
+	require.NoError(t, os.Symlink(shared, filepath.Join(tempDir, "shared")))
+
+	config := NewTestConfig()
+	config.SetFollowSymlinks(true)
+	ctx, cancel := context.WithTimeout(WithConfig(context.Background(), config), 2*time.Second)
+	defer cancel()
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(shared, "lib.go"), []byte("package shared"), 0o600))
+
+	select {
+	case msg := <-fileChangeChan:
+		assert.Equal(t, []string{filepath.Join(tempDir, "shared", "lib.go")}, msg.Paths)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for FileChangeMessage from symlinked directory")
+	}
+}
+
+// TestRecoverWatcher_RebuildsAndWarns tests that recovering a watcher rebuilds it and warns that changes may have been lost
+func TestRecoverWatcher_RebuildsAndWarns(t *testing.T) {
+	var out bytes.Buffer
+	rebuilt := 0
+
+	ok := recoverWatcher(&out, "overflowed", func() error {
+		rebuilt++
+		return nil
+	})
+
+	assert.True(t, ok)
+	assert.Equal(t, 1, rebuilt)
+	assert.Equal(t, "\nWarning: file watcher overflowed, so file changes may have been missed; restarting it\n", out.String())
+}
+
+// TestRecoverWatcher_ReportsFailedRebuild tests that a failed rebuild is reported
+func TestRecoverWatcher_ReportsFailedRebuild(t *testing.T) {
+	var out bytes.Buffer
+
+	ok := recoverWatcher(&out, "stopped unexpectedly", func() error {
+		return errors.New("too many open files")
+	})
+
+	assert.False(t, ok)
+	assert.Contains(t, out.String(), "could not restart file watcher")
+	assert.Contains(t, out.String(), "too many open files")
+}
+
+// TestQuietInterval tests that the quiet period only lengthens the debounce interval
+func TestQuietInterval(t *testing.T) {
+	config := NewTestConfig()
+	assert.Equal(t, debounceInterval, quietInterval(config))
+
+	config.SetWatchQuietPeriod(50)
+	assert.Equal(t, debounceInterval, quietInterval(config), "shorter quiet periods keep the debounce interval")
+
+	config.SetWatchQuietPeriod(500)
+	assert.Equal(t, 500*time.Millisecond, quietInterval(config))
+}
+
+// TestWatchFiles_QuietPeriodDefersRunUntilStreamStops tests that steady changes defer the run
+func TestWatchFiles_QuietPeriodDefersRunUntilStreamStops(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := NewTestConfig()
+	config.SetWatchQuietPeriod(600)
+	ctx, cancel := context.WithTimeout(WithConfig(context.Background(), config), 5*time.Second)
+	defer cancel()
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	// Changes spaced wider than the debounce interval but under the quiet period
+	testFile := filepath.Join(tempDir, "test.go")
+	for i := range 4 {
+		require.NoError(t, os.WriteFile(testFile, []byte("package main // "+strconv.Itoa(i)), 0o600))
+		time.Sleep(300 * time.Millisecond)
+		assert.Empty(t, fileChangeChan, "run should not start while changes keep arriving")
+	}
+
+	select {
+	case <-fileChangeChan:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for FileChangeMessage after changes stopped")
+	}
+}
+
+// TestDebounceLoop_CollectsPathsOfBurst tests that each changed file is reported once per burst
+func TestDebounceLoop_CollectsPathsOfBurst(t *testing.T) {
+	input := make(chan fsnotify.Event, 10)
+	fired := make(chan []string, 10)
+	go debounceLoop(50*time.Millisecond, input, nil, func(paths []string) {
+		fired <- paths
+	})
+
+	input <- fsnotify.Event{Name: "foo.go", Op: fsnotify.Write}
+	input <- fsnotify.Event{Name: "bar.go", Op: fsnotify.Write}
+	input <- fsnotify.Event{Name: "foo.go", Op: fsnotify.Write}
+
+	select {
+	case paths := <-fired:
+		assert.Equal(t, []string{"foo.go", "bar.go"}, paths)
+	case <-time.After(time.Second):
+		t.Fatal("debounce callback did not fire")
+	}
+
+	input <- fsnotify.Event{Name: "baz.go", Op: fsnotify.Write}
+
+	select {
+	case paths := <-fired:
+		assert.Equal(t, []string{"baz.go"}, paths, "paths should not carry over between bursts")
+	case <-time.After(time.Second):
+		t.Fatal("debounce callback did not fire")
+	}
+}
+
+// TestWatchFiles_ConfigFileChangeRequestsReload tests that writing the config file asks for a reload instead of a run
+func TestWatchFiles_ConfigFileChangeRequestsReload(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := NewTestConfig()
+	ctx, cancel := context.WithTimeout(WithConfig(context.Background(), config), 2*time.Second)
+	defer cancel()
+	ctx = WithConfigReload(ctx, tempDir, config)
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".gotest-watch.yml"), []byte("verbose: true\n"), 0o600))
+
+	select {
+	case <-getConfigReloader(ctx).reloadRequests():
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for config reload request")
+	}
+	select {
+	case msg := <-fileChangeChan:
+		t.Fatalf("config file change should not trigger a run, got %v", msg)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// fuzzContext returns a context for a run that fuzzes the fuzz test named in
+// args, in the package given as the optional second argument or else the
+// package under the configured test path that defines it. Unit tests are
+// skipped so that fuzzing starts straight away.
+func fuzzContext(ctx context.Context, args []string) (context.Context, error) {
+	config := getConfig(ctx)
+	if config == nil {
+		return nil, fmt.Errorf("config not found in context")
+	}
+	if err := validateFuzzArgs(args); err != nil {
+		return nil, err
+	}
+
+	var pkg string
+	if len(args) == 2 {
+		pkg = args[1]
+	} else {
+		// go test can only fuzz a single package
+		var err error
+		if pkg, err = fuzzPackage(config.WorkingDir, config.GetTestPath(), args[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	config.Lock()
+	defer config.Unlock()
+	config.fuzzTarget = args[0]
+	config.RunPattern = "^$"
+	// Fuzzing needs a single package, which changed packages could replace
+	config.Since = ""
+	config.TestPath = pkg
+	return ctx, nil
+}
+
+// testFilesTemplate prints the import path of a package followed by the
+// paths of its test files, separated by tabs.
+const testFilesTemplate = `{{.ImportPath}}{{range .TestGoFiles}}{{"\t"}}{{$.Dir}}/{{.}}{{end}}` +
+	`{{range .XTestGoFiles}}{{"\t"}}{{$.Dir}}/{{.}}{{end}}`
+
+// fuzzPackage returns the import path of the package under testPath that
+// defines the fuzz test name, resolved with `go list` from workingDir.
+func fuzzPackage(workingDir, testPath, name string) (string, error) {
+	lines, err := goList(workingDir, append([]string{"-f", testFilesTemplate}, strings.Fields(testPath)...))
+	if err != nil {
+		return "", err
+	}
+
+	decl := regexp.MustCompile(`(?m)^func ` + regexp.QuoteMeta(name) + `\(`)
+	var found []string
+	for _, line := range lines {
+		fields := strings.Split(line, "\t")
+		for _, file := range fields[1:] {
+			//nolint:gosec // the file is a test file go list found
+			if content, err := os.ReadFile(filepath.FromSlash(file)); err == nil && decl.Match(content) {
+				found = append(found, fields[0])
+				break
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no fuzz test %s in %s; name its package, as in fuzz %s ./parser", name, testPath, name)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("%s is defined in %s; name the package to fuzz, as in fuzz %s %s",
+			name, strings.Join(found, ", "), name, found[0])
+	}
+}
+
+func validateFuzzArgs(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("usage: fuzz <FuzzName> [package]")
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFuzzContext_BuildsFuzzCommand tests that fuzz runs skip unit tests and fuzz the named target
+func TestFuzzContext_BuildsFuzzCommand(t *testing.T) {
+	config := NewTestConfig()
+	config.SetRunPattern("TestFoo")
+	config.SetFuzzTime("30s")
+
+	runCtx, err := fuzzContext(runContext(context.Background(), config, triggerForceRun), []string{"FuzzParse", "./parser"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "go test ./parser -run=^$ -fuzz=^FuzzParse$ -fuzztime=30s", getConfig(runCtx).BuildCommand())
+	assert.Equal(t, "go test ./... -run=TestFoo", config.BuildCommand(), "shared config should not fuzz")
+	assert.Empty(t, getConfig(runCtx).Snapshot().fuzzTarget, "fuzz target should not outlive the run")
+}
+
+// TestFuzzContext_FindsPackageAndNoFuzzTime tests that the package under the test path defining the fuzz test is fuzzed, unbounded by default
+func TestFuzzContext_FindsPackageAndNoFuzzTime(t *testing.T) {
+	tempDir := setupFuzzModule(t)
+	config := NewTestConfig()
+	config.WorkingDir = tempDir
+
+	runCtx, err := fuzzContext(runContext(context.Background(), config, triggerForceRun), []string{"FuzzParse"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "go test fuzzmodule/parser -run=^$ -fuzz=^FuzzParse$", getConfig(runCtx).BuildCommand())
+}
+
+// TestFuzzPackage_RequiresASinglePackage tests that fuzz tests found in no package, or in several, are reported
+func TestFuzzPackage_RequiresASinglePackage(t *testing.T) {
+	tempDir := setupFuzzModule(t)
+
+	pkg, err := fuzzPackage(tempDir, "./...", "FuzzLex")
+	require.NoError(t, err)
+	assert.Equal(t, "fuzzmodule/lexer", pkg, "external test packages are searched too")
+
+	_, err = fuzzPackage(tempDir, "./...", "FuzzMissing")
+	assert.ErrorContains(t, err, "no fuzz test FuzzMissing")
+
+	_, err = fuzzPackage(tempDir, "./...", "FuzzShared")
+	assert.ErrorContains(t, err, "fuzzmodule/lexer, fuzzmodule/parser")
+
+	pkg, err = fuzzPackage(tempDir, "./parser", "FuzzShared")
+	require.NoError(t, err)
+	assert.Equal(t, "fuzzmodule/parser", pkg)
+}
+
+// setupFuzzModule creates a module whose packages define fuzz tests
+func setupFuzzModule(t *testing.T) string {
+	t.Helper()
+	tempDir := t.TempDir()
+
+	fuzzTests := "import \"testing\"\n\nfunc FuzzShared(f *testing.F) {}\n\n"
+	files := map[string]string{
+		"go.mod":                "module fuzzmodule\n\ngo 1.24\n",
+		"parser/parser.go":      "package parser\n",
+		"parser/parser_test.go": "package parser\n\n" + fuzzTests + "func FuzzParse(f *testing.F) {}\n",
+		"lexer/lexer.go":        "package lexer\n",
+		"lexer/lexer_test.go":   "package lexer_test\n\n" + fuzzTests + "func FuzzLex(f *testing.F) {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return tempDir
+}
+
+// TestHandleFuzz_RequiresTarget tests that fuzz needs a fuzz test name and at most a package
+func TestHandleFuzz_RequiresTarget(t *testing.T) {
+	config := NewTestConfig()
+
+	assert.Error(t, handleFuzz(config, nil))
+	assert.Error(t, handleFuzz(config, []string{"FuzzA", "./a", "extra"}))
+	assert.NoError(t, handleFuzz(config, []string{"FuzzA"}))
+}
+
+// TestDispatcher_FileChangeStopsFuzzing tests that a file change stops a fuzz run and runs the tests
+func TestDispatcher_FileChangeStopsFuzzing(t *testing.T) {
+	initRegistry()
+	runner := &countingRunner{}
+	RegisterRunner("counting", runner)
+	RegisterRunner("fuzzing", commandRunner{"sleep", "30"})
+
+	config := NewTestConfig()
+	config.SetRunner("fuzzing")
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	commandChan := make(chan CommandMessage, 1)
+	helpChan := make(chan HelpMessage, 1)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	outputChan := make(chan string, 1)
+	go func() {
+		outputChan <- captureStdout(t, func() {
+			Dispatcher(ctx, fileChangeChan, commandChan, helpChan, testCompleteChan)
+		})
+	}()
+
+	commandChan <- CommandMessage{Command: FuzzCmd, Args: []string{"FuzzParse", "./parser"}}
+	time.Sleep(100 * time.Millisecond)
+	config.SetRunner("counting")
+	fileChangeChan <- FileChangeMessage{Paths: []string{"parser.go"}}
+	time.Sleep(2 * time.Second)
+	cancel()
+
+	output := <-outputChan
+	assert.Contains(t, output, "stopping fuzzing")
+	assert.Equal(t, 1, runner.Runs(), "tests should run once fuzzing stops")
+	assert.Less(t, strings.Index(output, "stopping fuzzing"), strings.Index(output, "running tests again"))
+}
@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSinceRef is the ref the changed command compares against when no
+// other is given, so that only uncommitted changes are tested.
+const DefaultSinceRef = "HEAD"
+
+// findGitRoot returns the top-level directory of the git repository
+// containing dir.
+func findGitRoot(dir string) (string, error) {
+	cmd := exec.CommandContext(context.Background(), "git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	root := strings.TrimSpace(string(out))
+	if root == "" {
+		return "", fmt.Errorf("git returned an empty repository root")
+	}
+	return root, nil
+}
+
+// ResolveRoot returns the directory to watch for changes. When the config
+// asks for test paths relative to the git root, the root of the repository
+// containing cwd is used, and tests are run from there as well. Outside of a
+// git repository it falls back to cwd.
+func ResolveRoot(config *TestConfig, cwd string) string {
+	if !config.GetGitRootRelative() {
+		return cwd
+	}
+
+	root, err := findGitRoot(cwd)
+	if err != nil {
+		log.Printf("Warning: could not find git root, using %s: %v", cwd, err)
+		return cwd
+	}
+
+	config.SetWorkingDir(root)
+	return root
+}
+
+// gitLines runs git with args in dir and returns its non-empty output lines.
+// Errors include what git printed to stderr.
+func gitLines(dir string, args ...string) ([]string, error) {
+	cmd := exec.CommandContext(context.Background(), "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// verifyGitRef returns an error unless ref names a commit in the repository
+// containing dir.
+func verifyGitRef(dir, ref string) error {
+	if _, err := gitLines(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return fmt.Errorf("%q is not a commit in this repository", ref)
+	}
+	return nil
+}
+
+// changedSince returns the absolute paths of the Go files in the repository
+// containing dir that differ from base, including uncommitted changes and
+// untracked files.
+func changedSince(dir, base string) ([]string, error) {
+	root, err := findGitRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := gitLines(dir, "diff", "--name-only", base, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitLines(dir, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+	return goFiles(root, append(changed, untracked...)), nil
+}
+
+// stagedFiles returns the absolute paths of the Go files staged for commit
+// in the repository containing dir. Deleted files are left out.
+func stagedFiles(dir string) ([]string, error) {
+	root, err := findGitRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	staged, err := gitLines(dir, "diff", "--cached", "--name-only", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, err
+	}
+	return goFiles(root, staged), nil
+}
+
+// goFiles returns the absolute paths of the Go files among names, which are
+// relative to the repository root. Files whose directory no longer holds any
+// Go files, such as those deleted along with their package, are left out.
+func goFiles(root string, names []string) []string {
+	var paths []string
+	for _, name := range names {
+		if filepath.Ext(name) != ".go" {
+			continue
+		}
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.go")); len(matches) == 0 {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// StagedPackages returns the import paths of the packages with Go files
+// staged for commit, and of the packages under config's test path that
+// import them.
+func StagedPackages(config *TestConfig) ([]string, error) {
+	paths, err := stagedFiles(config.GetWorkingDir())
+	if err != nil {
+		return nil, err
+	}
+	return changedPackages(config.GetWorkingDir(), paths, config.GetTestPath(), true)
+}
+
+// changedSinceContext narrows the run in ctx to the packages changed since
+// the ref in its config's since setting, and the packages under the test
+// path that import them. When nothing has changed, or git can't say what
+// has, the whole test path is tested.
+func changedSinceContext(ctx context.Context) context.Context {
+	config := getConfig(ctx)
+	if config == nil || config.Since == "" {
+		return ctx
+	}
+
+	paths, err := changedSince(config.WorkingDir, config.Since)
+	var packages []string
+	if err == nil {
+		packages, err = changedPackages(config.WorkingDir, paths, config.TestPath, true)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not find packages changed since %s, testing %s: %v\n",
+			config.Since, config.TestPath, err)
+		return ctx
+	}
+	if len(packages) == 0 {
+		announce(config, fmt.Sprintf("No packages changed since %s, testing %s", config.Since, config.TestPath))
+		return ctx
+	}
+	config.TestPath = strings.Join(packages, " ")
+	return ctx
+}
@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// installFakeGit puts a fake git executable running script at the front of PATH
+func installFakeGit(t *testing.T, script string) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	//nolint:gosec // test helper needs an executable script
+	err := os.WriteFile(filepath.Join(binDir, "git"), []byte("#!/bin/sh\n"+script+"\n"), 0o700)
+	require.NoError(t, err)
+
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestFindGitRoot_ReturnsTopLevel tests that the output of git rev-parse is used as the root
+func TestFindGitRoot_ReturnsTopLevel(t *testing.T) {
+	installFakeGit(t, `echo /fake/repo`)
+
+	root, err := findGitRoot(t.TempDir())
+
+	require.NoError(t, err)
+	assert.Equal(t, "/fake/repo", root)
+}
+
+// TestFindGitRoot_ErrorsOutsideRepo tests that a failing git command is reported as an error
+func TestFindGitRoot_ErrorsOutsideRepo(t *testing.T) {
+	installFakeGit(t, `echo "fatal: not a git repository" >&2; exit 128`)
+
+	_, err := findGitRoot(t.TempDir())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a git repository")
+}
+
+// TestResolveRoot_UsesGitRootWhenEnabled tests that the watch root and working dir become the repo root
+func TestResolveRoot_UsesGitRootWhenEnabled(t *testing.T) {
+	installFakeGit(t, `echo /fake/repo`)
+	config := NewTestConfig()
+	config.SetGitRootRelative(true)
+
+	root := ResolveRoot(config, t.TempDir())
+
+	assert.Equal(t, "/fake/repo", root, "watch root should be the git root")
+	assert.Equal(t, "/fake/repo", config.GetWorkingDir(), "tests should run from the git root")
+}
+
+// TestResolveRoot_UsesCwdWhenDisabled tests that git is not consulted when the option is off
+func TestResolveRoot_UsesCwdWhenDisabled(t *testing.T) {
+	installFakeGit(t, `echo /fake/repo`)
+	config := NewTestConfig()
+
+	cwd := t.TempDir()
+
+	root := ResolveRoot(config, cwd)
+
+	assert.Equal(t, cwd, root)
+	assert.Equal(t, "", config.GetWorkingDir())
+}
+
+// TestResolveRoot_FallsBackToCwdOutsideGit tests graceful handling when not in a git repository
+func TestResolveRoot_FallsBackToCwdOutsideGit(t *testing.T) {
+	installFakeGit(t, `exit 128`)
+	config := NewTestConfig()
+	config.SetGitRootRelative(true)
+
+	cwd := t.TempDir()
+
+	root := ResolveRoot(config, cwd)
+
+	assert.Equal(t, cwd, root)
+	assert.Equal(t, "", config.GetWorkingDir())
+}
+
+// setupDependentRepo commits the module from setupDependentModule to a new
+// git repository
+func setupDependentRepo(t *testing.T) string {
+	t.Helper()
+	dir := setupDependentModule(t)
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "initial"},
+	} {
+		_, err := gitLines(dir, args...)
+		require.NoError(t, err)
+	}
+	return dir
+}
+
+// TestChangedSince_ListsChangedAndUntrackedGoFiles tests that modified and new Go files are found
+func TestChangedSince_ListsChangedAndUntrackedGoFiles(t *testing.T) {
+	dir := setupDependentRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "a.go"), []byte("package a\n\nfunc A() int { return 2 }\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c", "c2.go"), []byte("package c\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not go\n"), 0o600))
+
+	paths, err := changedSince(dir, "HEAD")
+
+	require.NoError(t, err)
+	root, err := findGitRoot(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "a", "a.go"), filepath.Join(root, "c", "c2.go")}, paths)
+}
+
+// TestChangedSince_SkipsDeletedPackages tests that files whose package was deleted are left out
+func TestChangedSince_SkipsDeletedPackages(t *testing.T) {
+	dir := setupDependentRepo(t)
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "c")))
+
+	paths, err := changedSince(dir, "HEAD")
+
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+}
+
+// TestChangedSince_ErrorsForUnknownRef tests that git's complaint about a bad ref is reported
+func TestChangedSince_ErrorsForUnknownRef(t *testing.T) {
+	dir := setupDependentRepo(t)
+
+	_, err := changedSince(dir, "no-such-branch")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no-such-branch")
+}
+
+// TestChangedSinceContext_NarrowsTestPath tests that runs are narrowed to changed packages and their dependents
+func TestChangedSinceContext_NarrowsTestPath(t *testing.T) {
+	dir := setupDependentRepo(t)
+	config := NewTestConfig()
+	config.SetWorkingDir(dir)
+	config.SetQuiet(true)
+
+	runCtx := changedSinceContext(runContext(context.Background(), config, triggerForceRun))
+	assert.Equal(t, "./...", getConfig(runCtx).TestPath, "test path should be unchanged without since")
+
+	config.SetSince("HEAD")
+	runCtx = changedSinceContext(runContext(context.Background(), config, triggerForceRun))
+	assert.Equal(t, "./...", getConfig(runCtx).TestPath, "test path should be unchanged when nothing changed")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "a.go"), []byte("package a\n\nfunc A() int { return 2 }\n"), 0o600))
+	runCtx = changedSinceContext(runContext(context.Background(), config, triggerForceRun))
+	assert.Equal(t, "smartmodule/a smartmodule/b", getConfig(runCtx).TestPath)
+	assert.Equal(t, "./...", config.GetTestPath(), "shared config should not be changed")
+}
+
+// TestHandleChanged tests setting and clearing the ref runs are narrowed by
+func TestHandleChanged(t *testing.T) {
+	dir := setupDependentRepo(t)
+	config := NewTestConfig()
+	config.SetWorkingDir(dir)
+
+	require.NoError(t, handleChanged(config, nil))
+	assert.Equal(t, "HEAD", config.GetSince())
+
+	err := handleChanged(config, []string{"no-such-branch"})
+	require.Error(t, err)
+	assert.Equal(t, "HEAD", config.GetSince(), "an unknown ref should not replace the current one")
+
+	require.NoError(t, handleChanged(config, []string{"off"}))
+	assert.Empty(t, config.GetSince())
+
+	assert.Error(t, handleChanged(config, []string{"main", "extra"}))
+}
+
+// TestStagedPackages tests that only packages with staged Go files, and their dependents, are tested
+func TestStagedPackages(t *testing.T) {
+	dir := setupDependentRepo(t)
+	config := NewTestConfig()
+	config.SetWorkingDir(dir)
+
+	packages, err := StagedPackages(config)
+	require.NoError(t, err)
+	assert.Empty(t, packages, "nothing should be tested when nothing is staged")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "a.go"), []byte("package a\n\nfunc A() int { return 2 }\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c", "c.go"), []byte("package c\n\nfunc C() int { return 4 }\n"), 0o600))
+	_, err = gitLines(dir, "add", filepath.Join("a", "a.go"))
+	require.NoError(t, err)
+
+	packages, err = StagedPackages(config)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"smartmodule/a", "smartmodule/b"}, packages, "unstaged changes should not be tested")
+}
@@ -0,0 +1,288 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runRecord is the config and outcome of a single test run.
+type runRecord struct {
+	config  *TestConfig
+	command string
+	passed  bool
+	elapsed time.Duration
+	summary RunSummary
+	// packages are the per-package results, which are only collected with
+	// the structured summary
+	packages []PackageResult
+	// trigger describes what caused the run, or is empty if it is not known
+	trigger string
+	// logFile is the file the run's output was saved to, if any
+	logFile string
+	// results maps the name of each test that ran to its result
+	results map[string]string
+	// durations are how long each test that ran took
+	durations []testDuration
+	// cancelled is set for runs that were interrupted before they finished
+	cancelled bool
+	// uncounted is set for runs whose passing and skipped tests weren't
+	// counted, as go test only lists them with -v or -json
+	uncounted bool
+}
+
+// maxRunHistory is how many runs the history keeps, so that a long
+// session doesn't hold on to the config and results of every run it made.
+const maxRunHistory = 100
+
+// runHistory records the most recent test runs made during the session.
+type runHistory struct {
+	sync.Mutex
+	runs []runRecord
+	// dropped is how many of the session's earliest runs are no longer
+	// kept, so that runs keep the number they were given
+	dropped int
+}
+
+// history is shared by the test runner and the commands that inspect past
+// runs.
+var history = &runHistory{}
+
+func (h *runHistory) record(run runRecord) {
+	h.Lock()
+	defer h.Unlock()
+	h.runs = append(h.runs, run)
+	if len(h.runs) > maxRunHistory {
+		h.dropped += len(h.runs) - maxRunHistory
+		h.runs = slices.Clone(h.runs[len(h.runs)-maxRunHistory:])
+	}
+}
+
+// get returns the nth run of the session, counting from 1.
+func (h *runHistory) get(n int) (runRecord, bool) {
+	h.Lock()
+	defer h.Unlock()
+	i := n - h.dropped - 1
+	if i < 0 || i >= len(h.runs) {
+		return runRecord{}, false
+	}
+	return h.runs[i], true
+}
+
+// missing returns the error for the nth run of the session, which get
+// didn't find.
+func (h *runHistory) missing(n int) error {
+	h.Lock()
+	defer h.Unlock()
+	if n >= 1 && n <= h.dropped {
+		return fmt.Errorf("run %d is no longer in history (only the last %d runs are kept)", n, maxRunHistory)
+	}
+	return fmt.Errorf("no run %d in history (%d runs recorded)", n, h.dropped+len(h.runs))
+}
+
+// last returns the most recent run of the session, if there is one.
+func (h *runHistory) last() (runRecord, bool) {
+	h.Lock()
+	defer h.Unlock()
+	if len(h.runs) == 0 {
+		return runRecord{}, false
+	}
+	return h.runs[len(h.runs)-1], true
+}
+
+// lastFinished returns the most recent run of the session that wasn't
+// cancelled, if there is one, to compare the next run's results with.
+func (h *runHistory) lastFinished() (runRecord, bool) {
+	h.Lock()
+	defer h.Unlock()
+	for i := len(h.runs) - 1; i >= 0; i-- {
+		if !h.runs[i].cancelled {
+			return h.runs[i], true
+		}
+	}
+	return runRecord{}, false
+}
+
+// len returns how many runs the session has made, including those that are
+// no longer kept.
+func (h *runHistory) len() int {
+	h.Lock()
+	defer h.Unlock()
+	return h.dropped + len(h.runs)
+}
+
+// all returns the runs that are kept, in order, and the number of the first.
+func (h *runHistory) all() (int, []runRecord) {
+	h.Lock()
+	defer h.Unlock()
+	return h.dropped + 1, append([]runRecord(nil), h.runs...)
+}
+
+// maxReasonFiles is how many changed files runReason names before
+// summarizing the rest.
+const maxReasonFiles = 3
+
+// runReason describes what triggered the run using ctx, naming the changed
+// files relative to workingDir, or returns "" if that is not known.
+func runReason(ctx context.Context, workingDir string) string {
+	trigger, ok := getRunTrigger(ctx)
+	if !ok {
+		return ""
+	}
+	reason := trigger.String()
+	if paths := getChangedFiles(ctx); len(paths) > 0 {
+		names := changedFileNames(paths, workingDir)
+		if len(names) > maxReasonFiles {
+			more := len(names) - maxReasonFiles
+			names = names[:maxReasonFiles]
+			names[maxReasonFiles-1] += fmt.Sprintf(" and %d more", more)
+		}
+		reason += ": " + strings.Join(names, ", ")
+	}
+	return reason
+}
+
+// formatRunEntry renders the nth run as a line of the history list, e.g.
+// "  2  FAIL  ✓ 12 passed ✗ 1 failed ⊘ 0 skipped in 2.3s  file change: a.go  go test ./...".
+func formatRunEntry(n int, run runRecord) string {
+	result := "PASS"
+	if !run.passed {
+		result = "FAIL"
+	}
+	fields := []string{fmt.Sprintf("%3d", n), result, formatRunSummary(run)}
+	if run.trigger != "" {
+		fields = append(fields, run.trigger)
+	}
+	return strings.Join(append(fields, run.command), "  ")
+}
+
+// formatRunDetails renders the summary of the nth run shown by history <n>.
+func formatRunDetails(n int, run runRecord) []string {
+	lines := []string{fmt.Sprintf("Run %d: %s", n, run.command)}
+	if run.trigger != "" {
+		lines = append(lines, "Triggered by "+run.trigger)
+	}
+	lines = append(lines, formatPackageResults(run.packages)...)
+	lines = append(lines, formatRunSummary(run))
+	if run.logFile != "" {
+		lines = append(lines, "Output saved to "+run.logFile)
+	}
+	return lines
+}
+
+// formatRunSummary renders the counts of run as formatSummaryLine does, or
+// only its failures, or that it was ok, if the rest weren't counted.
+func formatRunSummary(run runRecord) string {
+	summary := run.summary
+	switch {
+	case !run.uncounted:
+		return formatSummaryLine(summary)
+	case summary.Fail > 0:
+		return fmt.Sprintf("✗ %d failed in %.1fs", summary.Fail, summary.Elapsed)
+	case summary.OK:
+		return fmt.Sprintf("ok in %.1fs", summary.Elapsed)
+	}
+	return fmt.Sprintf("run failed in %.1fs", summary.Elapsed)
+}
+
+// diffResults compares the test results of a run with those of the run
+// before it, returning the sorted names of the tests that fail now but
+// didn't then, and of those that failed then and pass now. Passing tests
+// are only listed with -v, so a test that failed then also counts as fixed
+// if its package, one of passed, passes now.
+func diffResults(previous, current map[string]string, passed []string) ([]string, []string) {
+	var newlyFailing, fixed []string
+	for name, result := range current {
+		switch {
+		case result == "fail" && previous[name] != "fail":
+			newlyFailing = append(newlyFailing, name)
+		case result == "pass" && previous[name] == "fail":
+			fixed = append(fixed, name)
+		}
+	}
+	for name, result := range previous {
+		if result != "fail" || current[name] != "" {
+			continue
+		}
+		if slices.ContainsFunc(passed, func(pkg string) bool { return strings.HasPrefix(name, testKey(pkg, "")) }) {
+			fixed = append(fixed, name)
+		}
+	}
+	slices.Sort(newlyFailing)
+	slices.Sort(fixed)
+	return newlyFailing, fixed
+}
+
+// sameTestFilters reports whether runs with a and b pick the same tests
+// from a package, so that a package passing in one means that the tests
+// that failed in the other now pass. It reports false if either is nil.
+func sameTestFilters(a, b *TestConfig) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	return a.GetRunPattern() == b.GetRunPattern() && a.GetSkipPattern() == b.GetSkipPattern()
+}
+
+// formatResultDiff renders the sections listing the tests that started
+// failing and were fixed since the previous run, or nothing if none did.
+func formatResultDiff(newlyFailing, fixed []string) []string {
+	var lines []string
+	if len(newlyFailing) > 0 {
+		lines = append(lines, "Newly failing:")
+		for _, name := range newlyFailing {
+			lines = append(lines, "  "+name)
+		}
+	}
+	if len(fixed) > 0 {
+		lines = append(lines, "Fixed:")
+		for _, name := range fixed {
+			lines = append(lines, "  "+name)
+		}
+	}
+	return lines
+}
+
+// slowestTests returns the n slowest of durations, slowest first.
+func slowestTests(durations []testDuration, n int) []testDuration {
+	sorted := slices.Clone(durations)
+	slices.SortStableFunc(sorted, func(a, b testDuration) int {
+		if a.Elapsed != b.Elapsed {
+			return cmp.Compare(b.Elapsed, a.Elapsed)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return sorted[:min(n, len(sorted))]
+}
+
+// sessionDurations returns the total time each test took over runs.
+func sessionDurations(runs []runRecord) []testDuration {
+	totals := map[string]float64{}
+	for _, run := range runs {
+		for _, d := range run.durations {
+			totals[d.Name] += d.Elapsed
+		}
+	}
+	durations := make([]testDuration, 0, len(totals))
+	for name, elapsed := range totals {
+		durations = append(durations, testDuration{Name: name, Elapsed: elapsed})
+	}
+	return durations
+}
+
+// formatDurations renders durations as lines of a list, with the names
+// aligned.
+func formatDurations(durations []testDuration) []string {
+	width := 0
+	for _, d := range durations {
+		width = max(width, len(d.Name))
+	}
+	lines := make([]string, 0, len(durations))
+	for _, d := range durations {
+		lines = append(lines, fmt.Sprintf("  %-*s  %.2fs", width, d.Name, d.Elapsed))
+	}
+	return lines
+}
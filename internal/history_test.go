@@ -0,0 +1,293 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunHistory_GetIsOneBased tests that runs are numbered from 1 in the order they were recorded
+func TestRunHistory_GetIsOneBased(t *testing.T) {
+	h := &runHistory{}
+	h.record(runRecord{command: "go test ./..."})
+	h.record(runRecord{command: "go test ./... -v"})
+
+	run, ok := h.get(2)
+	require.True(t, ok)
+	assert.Equal(t, "go test ./... -v", run.command)
+
+	_, ok = h.get(0)
+	assert.False(t, ok)
+	_, ok = h.get(3)
+	assert.False(t, ok)
+	assert.Equal(t, 2, h.len())
+}
+
+// TestRunHistory_KeepsRecentRuns tests that only the last maxRunHistory runs are kept, and that they keep their numbers
+func TestRunHistory_KeepsRecentRuns(t *testing.T) {
+	h := &runHistory{}
+	for i := range maxRunHistory + 5 {
+		h.record(runRecord{config: NewTestConfig(), command: fmt.Sprintf("go test -count=%d", i+1)})
+	}
+
+	first, runs := h.all()
+	assert.Equal(t, 6, first)
+	assert.Len(t, runs, maxRunHistory)
+	assert.Equal(t, maxRunHistory+5, h.len())
+
+	run, ok := h.get(6)
+	require.True(t, ok)
+	assert.Equal(t, "go test -count=6", run.command)
+	last, ok := h.last()
+	require.True(t, ok)
+	assert.Equal(t, fmt.Sprintf("go test -count=%d", maxRunHistory+5), last.command)
+
+	_, ok = h.get(5)
+	assert.False(t, ok)
+	err := replayRun(h, []string{"5"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "run 5 is no longer in history")
+
+	output := captureStdout(t, func() {
+		require.NoError(t, showHistory(h, nil))
+	})
+	assert.True(t, strings.HasPrefix(output, "(runs 1-5 are no longer kept)\n  6  "), output)
+}
+
+// TestReplayContext_UsesHistoricalConfig tests that replaying run n reproduces that run's command without changing the session's config
+func TestReplayContext_UsesHistoricalConfig(t *testing.T) {
+	h := &runHistory{}
+	configs := []*TestConfig{NewTestConfig(), NewTestConfig(), NewTestConfig()}
+	configs[1].SetVerbose(true)
+	configs[1].SetRunPattern("TestFlaky")
+	configs[2].ToggleRace()
+	configs[2].SetCount(5)
+	for _, c := range configs {
+		h.record(runRecord{config: c.Snapshot(), command: c.BuildCommand()})
+	}
+
+	current := NewTestConfig()
+	current.SetSkipPattern("TestOther")
+	ctx := WithConfig(context.Background(), current)
+
+	output := captureStdout(t, func() {
+		require.NoError(t, replayRun(h, []string{"2"}))
+	})
+	assert.Equal(t, "Replaying run 2: go test ./... -v -run=TestFlaky\n", output)
+
+	runCtx, err := replayContext(ctx, h, []string{"2"})
+	require.NoError(t, err)
+	assert.Equal(t, configs[1].BuildCommand(), getConfig(runCtx).BuildCommand(), "replay should reproduce run 2's command")
+
+	runCtx, err = replayContext(ctx, h, []string{"3"})
+	require.NoError(t, err)
+	assert.Equal(t, "go test ./... -race -count=5", getConfig(runCtx).BuildCommand())
+
+	assert.Equal(t, "go test ./... -skip=TestOther", current.BuildCommand(), "the session's config should be left alone")
+}
+
+// TestReplayContext_KeepsColorDelegation tests that replaying a run of gotest reads its output the same way the run did
+func TestReplayContext_KeepsColorDelegation(t *testing.T) {
+	config := NewTestConfig()
+	config.SetColor(true)
+	config.SetColorBackend(ColorBackendGotest)
+	snapshot := config.Snapshot()
+	delegateColor(snapshot)
+	require.True(t, snapshot.colorDelegated)
+
+	h := &runHistory{}
+	h.record(runRecord{config: snapshot.runSnapshot(), command: snapshot.BuildCommand()})
+
+	runCtx, err := replayContext(context.Background(), h, []string{"1"})
+	require.NoError(t, err)
+	replayed := getConfig(runCtx)
+	assert.True(t, replayed.colorDelegated, "gotest colors its own output, which should not be colored again")
+	assert.Equal(t, "gotest ./...", replayed.BuildCommand())
+}
+
+// TestReplayRun_RejectsInvalidRunNumbers tests error handling for missing or unknown runs
+func TestReplayRun_RejectsInvalidRunNumbers(t *testing.T) {
+	h := &runHistory{}
+	h.record(runRecord{config: NewTestConfig(), command: "go test ./..."})
+
+	require.Error(t, replayRun(h, nil))
+	require.Error(t, replayRun(h, []string{"abc"}))
+
+	err := replayRun(h, []string{"4"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no run 4 in history (1 runs recorded)")
+
+	_, err = replayContext(context.Background(), h, []string{"4"})
+	require.Error(t, err)
+}
+
+// TestShowHistory_ListsRuns tests that history lists every run with its result, counts, trigger and command
+func TestShowHistory_ListsRuns(t *testing.T) {
+	h := &runHistory{}
+
+	output := captureStdout(t, func() {
+		require.NoError(t, showHistory(h, nil))
+	})
+	assert.Equal(t, "No runs yet\n", output)
+
+	h.record(runRecord{
+		command: "go test ./...",
+		passed:  true,
+		summary: RunSummary{Pass: 3, Elapsed: 0.4, OK: true},
+		trigger: "startup",
+	})
+	h.record(runRecord{
+		command: "go test ./... -v",
+		summary: RunSummary{Pass: 2, Fail: 1, Skip: 1, Elapsed: 2.31},
+		trigger: "file change: internal/foo.go",
+	})
+	h.record(runRecord{
+		command:   "go test ./...",
+		passed:    true,
+		summary:   RunSummary{Elapsed: 0.5, OK: true},
+		trigger:   "command",
+		uncounted: true,
+	})
+	h.record(runRecord{
+		command:   "go test ./...",
+		summary:   RunSummary{Fail: 2, Elapsed: 0.6},
+		trigger:   "command",
+		uncounted: true,
+	})
+
+	output = captureStdout(t, func() {
+		require.NoError(t, showHistory(h, nil))
+	})
+	assert.Equal(t,
+		"  1  PASS  ✓ 3 passed ✗ 0 failed ⊘ 0 skipped in 0.4s  startup  go test ./...\n"+
+			"  2  FAIL  ✓ 2 passed ✗ 1 failed ⊘ 1 skipped in 2.3s  file change: internal/foo.go  go test ./... -v\n"+
+			"  3  PASS  ok in 0.5s  command  go test ./...\n"+
+			"  4  FAIL  ✗ 2 failed in 0.6s  command  go test ./...\n",
+		output)
+}
+
+// TestShowHistory_ShowsRunSummary tests that history <n> shows a previous run's summary again
+func TestShowHistory_ShowsRunSummary(t *testing.T) {
+	h := &runHistory{}
+	h.record(runRecord{command: "go test ./...", passed: true, summary: RunSummary{OK: true}})
+	h.record(runRecord{
+		command: "go test ./... -json",
+		summary: RunSummary{Pass: 2, Fail: 1, Elapsed: 1.5},
+		packages: []PackageResult{
+			{Package: "example/a", Action: "pass", Pass: 2},
+			{Package: "example/b", Action: "fail", Fail: 1},
+		},
+		trigger: "command",
+		logFile: ".gotest-watch/runs/20261016-093000.000.log",
+	})
+
+	output := captureStdout(t, func() {
+		require.NoError(t, showHistory(h, []string{"2"}))
+	})
+	assert.Equal(t, "Run 2: go test ./... -json\n"+
+		"Triggered by command\n"+
+		"ok    example/a  2 passed, 0 failed, 0 skipped\n"+
+		"FAIL  example/b  0 passed, 1 failed, 0 skipped\n"+
+		"✓ 2 passed ✗ 1 failed ⊘ 0 skipped in 1.5s\n"+
+		"Output saved to .gotest-watch/runs/20261016-093000.000.log\n", output)
+
+	require.Error(t, showHistory(h, []string{"abc"}))
+	err := showHistory(h, []string{"3"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no run 3 in history (2 runs recorded)")
+}
+
+// TestRunReason tests describing what triggered a run
+func TestRunReason(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, runReason(ctx, ""))
+
+	config := NewTestConfig()
+	assert.Equal(t, "manual", runReason(runContext(ctx, config, triggerForceRun), ""))
+	assert.Equal(t, "startup", runReason(runContext(ctx, config, triggerStartup), ""))
+
+	runCtx := changeRunContext(ctx, config, []string{"/work/internal/foo.go", "/work/main.go"})
+	assert.Equal(t, "file change: internal/foo.go, main.go", runReason(runCtx, "/work"))
+
+	runCtx = changeRunContext(ctx, config, []string{"a.go", "b.go", "c.go", "d.go", "e.go"})
+	assert.Equal(t, "file change: a.go, b.go, c.go and 2 more", runReason(runCtx, ""))
+}
+
+// TestDiffResults tests finding the tests that started failing or were fixed since the previous run
+func TestDiffResults(t *testing.T) {
+	previous := map[string]string{
+		"TestStillFails": "fail",
+		"TestFixed":      "fail",
+		"TestBroken":     "pass",
+		"TestNotRun":     "fail",
+		"TestSkipped":    "fail",
+	}
+	current := map[string]string{
+		"TestStillFails": "fail",
+		"TestFixed":      "pass",
+		"TestBroken":     "fail",
+		"TestNew":        "fail",
+		"TestSkipped":    "skip",
+		"TestPasses":     "pass",
+	}
+
+	newlyFailing, fixed := diffResults(previous, current, nil)
+
+	assert.Equal(t, []string{"TestBroken", "TestNew"}, newlyFailing)
+	assert.Equal(t, []string{"TestFixed"}, fixed)
+	assert.Equal(t, []string{"Newly failing:", "  TestBroken", "  TestNew", "Fixed:", "  TestFixed"},
+		formatResultDiff(newlyFailing, fixed))
+	assert.Empty(t, formatResultDiff(nil, nil))
+}
+
+// TestDiffResults_PackagesThatPassed tests that without -v a failing test counts as fixed once its package passes
+func TestDiffResults_PackagesThatPassed(t *testing.T) {
+	previous := map[string]string{
+		"example.com/app/a.TestGet":   "fail",
+		"example.com/app/b.TestGet":   "fail",
+		"example.com/app/ab.TestPut":  "fail",
+		"example.com/app/a.TestOther": "pass",
+	}
+
+	newlyFailing, fixed := diffResults(previous, map[string]string{}, []string{"example.com/app/a"})
+
+	assert.Empty(t, newlyFailing)
+	assert.Equal(t, []string{"example.com/app/a.TestGet"}, fixed, "only tests in the package that passed are fixed")
+}
+
+// TestShowSlowest tests listing the slowest tests of the last run and of the whole session
+func TestShowSlowest(t *testing.T) {
+	h := &runHistory{}
+
+	output := captureStdout(t, func() {
+		require.NoError(t, showSlowest(h, nil))
+	})
+	assert.Equal(t, "No test durations recorded yet (they are read from -v or -json output)\n", output)
+
+	h.record(runRecord{durations: []testDuration{
+		{Name: "TestDB", Elapsed: 3},
+		{Name: "TestParse", Elapsed: 0.5},
+	}})
+	h.record(runRecord{durations: []testDuration{
+		{Name: "TestParse", Elapsed: 0.75},
+		{Name: "TestHTTP", Elapsed: 1.25},
+		{Name: "TestFast", Elapsed: 0.01},
+	}})
+
+	output = captureStdout(t, func() {
+		require.NoError(t, showSlowest(h, []string{"2"}))
+	})
+	assert.Equal(t, "Slowest tests in the last run:\n"+
+		"  TestHTTP   1.25s\n"+
+		"  TestParse  0.75s\n"+
+		"Slowest tests over the session (2 runs):\n"+
+		"  TestDB    3.00s\n"+
+		"  TestHTTP  1.25s\n", output)
+
+	assert.EqualError(t, showSlowest(h, []string{"0"}), `invalid number of tests "0"`)
+	assert.EqualError(t, showSlowest(h, []string{"many"}), `invalid number of tests "many"`)
+}
@@ -0,0 +1,233 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHTTPAddr is the address the status endpoint listens on when no
+// other is given.
+const DefaultHTTPAddr = "localhost:7357"
+
+// httpShutdownTimeout limits how long the status endpoint waits for
+// requests in progress when shutting down.
+const httpShutdownTimeout = 2 * time.Second
+
+// runActivity tracks the test run in progress, if any.
+type runActivity struct {
+	sync.Mutex
+	running bool
+	command string
+	started time.Time
+}
+
+// activity is updated by the test runner and read by the status endpoint.
+var activity = &runActivity{}
+
+// begin records that a run of command started.
+func (a *runActivity) begin(command string) {
+	a.Lock()
+	defer a.Unlock()
+	a.running = true
+	a.command = command
+	a.started = time.Now()
+}
+
+// end records that the run in progress finished.
+func (a *runActivity) end() {
+	a.Lock()
+	defer a.Unlock()
+	a.running = false
+}
+
+// statusResponse is the body of /status.
+type statusResponse struct {
+	Running bool `json:"running"`
+	// Command is the command of the run in progress, or the one a run
+	// would execute now
+	Command string `json:"command"`
+	// Started is when the run in progress started
+	Started *time.Time `json:"started,omitempty"`
+	Runs    int        `json:"runs"`
+	// Passed is whether the last run passed, and is left out before the
+	// first run finishes
+	Passed *bool `json:"passed,omitempty"`
+}
+
+// lastRunResponse is the body of /last-run.
+type lastRunResponse struct {
+	Command     string     `json:"command"`
+	Trigger     string     `json:"trigger,omitempty"`
+	Passed      bool       `json:"passed"`
+	Summary     RunSummary `json:"summary"`
+	FailedTests []string   `json:"failedTests"`
+}
+
+// ServeStatus serves the status endpoint on addr until ctx is cancelled.
+// POST /run sends a forced run to cmdChan, as if f had been entered. It
+// returns an error if addr is not a loopback address or can't be listened on.
+// Requests that name a host other than a loopback one are refused, so that
+// web pages can't reach the endpoint by rebinding their domain to it.
+func ServeStatus(ctx context.Context, addr string, cmdChan chan CommandMessage) error {
+	config := getConfig(ctx)
+	if config == nil {
+		return errors.New("config not found in context")
+	}
+	addr, err := loopbackAddr(addr)
+	if err != nil {
+		return err
+	}
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           newStatusHandler(config, history, activity, cmdChan),
+		ReadHeaderTimeout: httpShutdownTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Println(err)
+		}
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println(err)
+		}
+	}()
+	return nil
+}
+
+// loopbackAddr returns addr, with localhost as its host if it names none.
+// Anyone who can reach the endpoint can start runs, so addresses other
+// than loopback ones are rejected.
+func loopbackAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if host == "" {
+		return net.JoinHostPort("localhost", port), nil
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", fmt.Errorf("%s is not a loopback address; anyone who can reach the endpoint can start runs", addr)
+	}
+	return addr, nil
+}
+
+// newStatusHandler returns the handler for the status endpoint, reporting
+// on config, runs and active.
+func newStatusHandler(
+	config *TestConfig,
+	runs *runHistory,
+	active *runActivity,
+	cmdChan chan CommandMessage,
+) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, _ *http.Request) {
+		status := statusResponse{Command: config.BuildCommand(), Runs: runs.len()}
+		active.Lock()
+		if active.running {
+			status.Running = true
+			status.Command = active.command
+			started := active.started
+			status.Started = &started
+		}
+		active.Unlock()
+		if last, ok := runs.last(); ok {
+			status.Passed = &last.passed
+		}
+		writeJSON(w, http.StatusOK, status)
+	})
+
+	mux.HandleFunc("GET /last-run", func(w http.ResponseWriter, _ *http.Request) {
+		last, ok := runs.last()
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no test run has finished yet"})
+			return
+		}
+		failed := []string{}
+		for name, result := range last.results {
+			if result == "fail" {
+				failed = append(failed, name)
+			}
+		}
+		slices.Sort(failed)
+		writeJSON(w, http.StatusOK, lastRunResponse{
+			Command:     last.command,
+			Trigger:     last.trigger,
+			Passed:      last.passed,
+			Summary:     last.summary,
+			FailedTests: failed,
+		})
+	})
+
+	mux.HandleFunc("POST /run", func(w http.ResponseWriter, r *http.Request) {
+		// Web pages can only send JSON to another origin after asking
+		// whether it may, which the endpoint never allows, so requiring it
+		// keeps them from starting runs
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil ||
+			mediaType != "application/json" {
+			writeJSON(w, http.StatusUnsupportedMediaType,
+				map[string]string{"error": "requests to start a run must have Content-Type: application/json"})
+			return
+		}
+		active.Lock()
+		running := active.running
+		active.Unlock()
+		if running {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "a test run is in progress"})
+			return
+		}
+		select {
+		case cmdChan <- CommandMessage{Command: ForceRunCmd}:
+			writeJSON(w, http.StatusAccepted, map[string]string{"status": "started"})
+		case <-r.Context().Done():
+		}
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "only requests to a loopback host are served"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether host, the Host of a request with or
+// without its port, is localhost or a loopback address.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// writeJSON writes v as the JSON body of a response with status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println(err)
+	}
+}
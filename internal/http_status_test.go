@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveStatus sends req to a status handler for config, runs and active,
+// and returns the response.
+func serveStatus(
+	config *TestConfig,
+	runs *runHistory,
+	active *runActivity,
+	cmdChan chan CommandMessage,
+	method, path string,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Host = DefaultHTTPAddr
+	req.Header.Set("Content-Type", "application/json")
+	return serveRequest(config, runs, active, cmdChan, req)
+}
+
+// serveRequest sends req to a status handler for config, runs and active,
+// and returns the response.
+func serveRequest(
+	config *TestConfig,
+	runs *runHistory,
+	active *runActivity,
+	cmdChan chan CommandMessage,
+	req *http.Request,
+) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	newStatusHandler(config, runs, active, cmdChan).ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestStatusHandler_Status(t *testing.T) {
+	t.Run("reports the command a run would execute when idle", func(t *testing.T) {
+		config := NewTestConfig()
+		config.SetVerbose(true)
+
+		resp := serveStatus(config, &runHistory{}, &runActivity{}, nil, http.MethodGet, "/status")
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"running":false,"command":"go test ./... -v","runs":0}`, resp.Body.String())
+	})
+
+	t.Run("reports the run in progress and the last result", func(t *testing.T) {
+		runs := &runHistory{}
+		runs.record(runRecord{command: "go test ./...", passed: false})
+		active := &runActivity{}
+		active.begin("go test ./... -race")
+
+		resp := serveStatus(NewTestConfig(), runs, active, nil, http.MethodGet, "/status")
+
+		var status statusResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		assert.True(t, status.Running)
+		assert.Equal(t, "go test ./... -race", status.Command)
+		require.NotNil(t, status.Started)
+		assert.WithinDuration(t, time.Now(), *status.Started, time.Minute)
+		assert.Equal(t, 1, status.Runs)
+		require.NotNil(t, status.Passed)
+		assert.False(t, *status.Passed)
+	})
+}
+
+func TestStatusHandler_LastRun(t *testing.T) {
+	t.Run("is not found before the first run", func(t *testing.T) {
+		resp := serveStatus(NewTestConfig(), &runHistory{}, &runActivity{}, nil, http.MethodGet, "/last-run")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Contains(t, resp.Body.String(), "error")
+	})
+
+	t.Run("reports the last run", func(t *testing.T) {
+		runs := &runHistory{}
+		runs.record(runRecord{command: "go test ./...", passed: true})
+		runs.record(runRecord{
+			command: "go test ./... -v",
+			trigger: "manual",
+			summary: RunSummary{Pass: 1, Fail: 2, Elapsed: 0.5},
+			results: map[string]string{"app.TestB": "fail", "app.TestA": "fail", "app.TestC": "pass"},
+		})
+
+		resp := serveStatus(NewTestConfig(), runs, &runActivity{}, nil, http.MethodGet, "/last-run")
+
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{
+			"command": "go test ./... -v",
+			"trigger": "manual",
+			"passed": false,
+			"summary": {"pass": 1, "fail": 2, "skip": 0, "elapsed": 0.5, "ok": false},
+			"failedTests": ["app.TestA", "app.TestB"]
+		}`, resp.Body.String())
+	})
+}
+
+func TestStatusHandler_Run(t *testing.T) {
+	t.Run("starts a run", func(t *testing.T) {
+		cmdChan := make(chan CommandMessage, 1)
+
+		resp := serveStatus(NewTestConfig(), &runHistory{}, &runActivity{}, cmdChan, http.MethodPost, "/run")
+
+		assert.Equal(t, http.StatusAccepted, resp.Code)
+		require.Len(t, cmdChan, 1)
+		assert.Equal(t, ForceRunCmd, (<-cmdChan).Command)
+	})
+
+	t.Run("refuses while a run is in progress", func(t *testing.T) {
+		cmdChan := make(chan CommandMessage, 1)
+		active := &runActivity{}
+		active.begin("go test ./...")
+
+		resp := serveStatus(NewTestConfig(), &runHistory{}, active, cmdChan, http.MethodPost, "/run")
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Empty(t, cmdChan)
+	})
+
+	t.Run("only accepts POST", func(t *testing.T) {
+		resp := serveStatus(NewTestConfig(), &runHistory{}, &runActivity{}, nil, http.MethodGet, "/run")
+
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	})
+
+	t.Run("requires JSON, which web pages can't send to it", func(t *testing.T) {
+		cmdChan := make(chan CommandMessage, 1)
+		for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+			req := httptest.NewRequest(http.MethodPost, "/run", nil)
+			req.Host = DefaultHTTPAddr
+			if contentType != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+
+			resp := serveRequest(NewTestConfig(), &runHistory{}, &runActivity{}, cmdChan, req)
+
+			assert.Equal(t, http.StatusUnsupportedMediaType, resp.Code, contentType)
+			assert.Empty(t, cmdChan)
+		}
+	})
+}
+
+func TestStatusHandler_RefusesOtherHosts(t *testing.T) {
+	for host, want := range map[string]int{
+		"localhost:7357":    http.StatusOK,
+		"localhost":         http.StatusOK,
+		"127.0.0.1:8080":    http.StatusOK,
+		"[::1]:8080":        http.StatusOK,
+		"[::1]":             http.StatusOK,
+		"attacker.example":  http.StatusForbidden,
+		"rebound.test:7357": http.StatusForbidden,
+		"192.168.1.5:7357":  http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		req.Host = host
+
+		resp := serveRequest(NewTestConfig(), &runHistory{}, &runActivity{}, nil, req)
+
+		assert.Equal(t, want, resp.Code, host)
+	}
+}
+
+func TestLoopbackAddr(t *testing.T) {
+	for addr, want := range map[string]string{
+		"localhost:7357": "localhost:7357",
+		"127.0.0.1:8080": "127.0.0.1:8080",
+		"[::1]:8080":     "[::1]:8080",
+		":8080":          "localhost:8080",
+	} {
+		got, err := loopbackAddr(addr)
+		require.NoError(t, err, addr)
+		assert.Equal(t, want, got)
+	}
+
+	for _, addr := range []string{"0.0.0.0:8080", "[::]:8080", "192.168.1.5:8080", "devbox:8080", "8080"} {
+		_, err := loopbackAddr(addr)
+		assert.Error(t, err, addr)
+	}
+}
+
+func TestServeStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), NewTestConfig()))
+	defer cancel()
+
+	require.NoError(t, ServeStatus(ctx, "127.0.0.1:0", make(chan CommandMessage, 1)))
+
+	t.Run("fails for addresses other than loopback ones", func(t *testing.T) {
+		err := ServeStatus(ctx, "0.0.0.0:0", nil)
+		assert.ErrorContains(t, err, "not a loopback address")
+	})
+
+	t.Run("fails when the address is taken", func(t *testing.T) {
+		listener := httptest.NewServer(http.NotFoundHandler())
+		defer listener.Close()
+
+		assert.Error(t, ServeStatus(ctx, listener.Listener.Addr().String(), nil))
+	})
+}
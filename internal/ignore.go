@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// gitignoreFile is the name of the files whose patterns the watcher honors.
+const gitignoreFile = ".gitignore"
+
+// ignoreRule is a single pattern from a .gitignore file or the `ignore`
+// config list.
+type ignoreRule struct {
+	// base is the directory the pattern is relative to, as slash-separated
+	// segments relative to the watched root
+	base    []string
+	pattern []string
+	// anchored patterns are matched against the path relative to base,
+	// others against the final path element only
+	anchored bool
+	dirOnly  bool
+	negate   bool
+}
+
+// ignoreMatcher reports which paths under a watched root should neither be
+// watched nor trigger runs. It follows .gitignore semantics: later rules
+// override earlier ones, `!` re-includes a path, a trailing `/` matches only
+// directories, and `**` matches any number of directories. It is safe for
+// concurrent use.
+type ignoreMatcher struct {
+	root string
+
+	mu    sync.RWMutex
+	rules []ignoreRule
+	// loaded records the directories whose .gitignore has been read
+	loaded map[string]bool
+}
+
+// newIgnoreMatcher returns a matcher for paths under root that ignores
+// paths matching patterns, which are relative to root and may use the
+// platform's path separator, as in `internal\gen\` on Windows. Rules from
+// .gitignore files, which always use `/`, are added with loadGitignore as
+// directories are walked.
+func newIgnoreMatcher(root string, patterns []string) *ignoreMatcher {
+	m := &ignoreMatcher{root: root, loaded: map[string]bool{}}
+	for _, pattern := range patterns {
+		if rule, ok := parseIgnoreRule(filepath.ToSlash(pattern), nil); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+	return m
+}
+
+// loadGitignore adds the rules from the .gitignore file in dir, if there is
+// one. Each directory's file is only read once.
+func (m *ignoreMatcher) loadGitignore(dir string) error {
+	if m == nil {
+		return nil
+	}
+	base, ok := m.relative(dir)
+	if !ok {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.loaded[dir] {
+		return nil
+	}
+	m.loaded[dir] = true
+
+	f, err := os.Open(filepath.Join(dir, gitignoreFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), base); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+	return scanner.Err()
+}
+
+// ignored reports whether p, a directory if isDir is set, is ignored. A path
+// inside an ignored directory is always ignored, as with git.
+func (m *ignoreMatcher) ignored(p string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.rules) == 0 {
+		return false
+	}
+	segments, ok := m.relative(p)
+	if !ok || len(segments) == 0 {
+		return false
+	}
+
+	for i := 1; i <= len(segments); i++ {
+		if m.matches(segments[:i], i < len(segments) || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the last rule matching segments ignores it. The
+// caller must hold m.mu.
+func (m *ignoreMatcher) matches(segments []string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.match(segments, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// relative returns p as slash-separated segments relative to the root, or
+// false if p is outside it.
+func (m *ignoreMatcher) relative(p string) ([]string, bool) {
+	rel, err := filepath.Rel(m.root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, false
+	}
+	if rel == "." {
+		return nil, true
+	}
+	return strings.Split(filepath.ToSlash(rel), "/"), true
+}
+
+// parseIgnoreRule parses a line of a .gitignore file in the directory base.
+// It reports false for blank lines and comments.
+func parseIgnoreRule(line string, base []string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	rule.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.pattern = strings.Split(line, "/")
+	return rule, true
+}
+
+func (r ignoreRule) match(segments []string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if len(segments) <= len(r.base) {
+		return false
+	}
+	for i, dir := range r.base {
+		if segments[i] != dir {
+			return false
+		}
+	}
+	rest := segments[len(r.base):]
+
+	if !r.anchored {
+		ok, _ := path.Match(r.pattern[0], rest[len(rest)-1])
+		return ok
+	}
+	return matchSegments(r.pattern, rest)
+}
+
+// matchSegments reports whether the path segments match the glob pattern
+// segments, where a `**` segment matches any number of path segments.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], segments[0])
+	return ok && matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIgnoreMatcher_ConfigPatterns tests the glob forms accepted by the ignore config list
+func TestIgnoreMatcher_ConfigPatterns(t *testing.T) {
+	root := t.TempDir()
+	m := newIgnoreMatcher(root, []string{"**/mocks/**", "*_gen.go", "/build/", "tools/*.go"})
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"mocks", true, true},
+		{"internal/mocks", true, true},
+		{"internal/mocks/store.go", false, true},
+		{"internal/store.go", false, false},
+		{"model_gen.go", false, true},
+		{"internal/api/client_gen.go", false, true},
+		{"build", true, true},
+		{"build/main.go", false, true},
+		{"internal/build", true, false},
+		{"tools/tools.go", false, true},
+		{"tools/lint/lint.go", false, false},
+		{"internal/tools/tools.go", false, false},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.ignored, m.ignored(filepath.Join(root, tc.path), tc.isDir), tc.path)
+	}
+}
+
+// TestIgnoreMatcher_PlatformSeparators tests that config patterns may use the platform's path separator
+func TestIgnoreMatcher_PlatformSeparators(t *testing.T) {
+	root := t.TempDir()
+	m := newIgnoreMatcher(root, []string{filepath.FromSlash("internal/gen/"), filepath.FromSlash("/tools/*.go")})
+
+	assert.True(t, m.ignored(filepath.Join(root, "internal", "gen", "types.go"), false))
+	assert.True(t, m.ignored(filepath.Join(root, "tools", "tools.go"), false))
+	assert.False(t, m.ignored(filepath.Join(root, "internal", "types.go"), false))
+}
+
+// TestIgnoreMatcher_Gitignore tests that .gitignore rules are scoped to their directory and honor negation
+func TestIgnoreMatcher_Gitignore(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, gitignoreFile), []byte(`# generated code
+*.pb.go
+!keep.pb.go
+vendor/
+`), 0o600))
+	sub := filepath.Join(root, "pkg")
+	require.NoError(t, os.MkdirAll(sub, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, gitignoreFile), []byte("/local.go\n"), 0o600))
+
+	m := newIgnoreMatcher(root, nil)
+	require.NoError(t, m.loadGitignore(root))
+	require.NoError(t, m.loadGitignore(sub))
+
+	assert.True(t, m.ignored(filepath.Join(root, "api", "api.pb.go"), false))
+	assert.False(t, m.ignored(filepath.Join(root, "api", "keep.pb.go"), false), "negated pattern should re-include")
+	assert.True(t, m.ignored(filepath.Join(root, "vendor"), true))
+	assert.True(t, m.ignored(filepath.Join(root, "vendor", "lib", "lib.go"), false), "files in ignored dirs are ignored")
+	assert.False(t, m.ignored(filepath.Join(root, "vendor"), false), "dir-only pattern should not match a file")
+	assert.True(t, m.ignored(filepath.Join(sub, "local.go"), false))
+	assert.False(t, m.ignored(filepath.Join(root, "local.go"), false), "nested .gitignore should not apply above its directory")
+	assert.False(t, m.ignored(filepath.Join(sub, "inner", "local.go"), false), "anchored pattern should only match at its directory")
+}
+
+// TestIgnoreMatcher_NilAndOutsideRoot tests that a nil matcher and paths outside the root ignore nothing
+func TestIgnoreMatcher_NilAndOutsideRoot(t *testing.T) {
+	var m *ignoreMatcher
+	assert.False(t, m.ignored("/anything/foo.go", false))
+	require.NoError(t, m.loadGitignore("/anything"))
+
+	root := t.TempDir()
+	m = newIgnoreMatcher(filepath.Join(root, "project"), []string{"*.go"})
+	assert.False(t, m.ignored(filepath.Join(root, "other", "foo.go"), false))
+	assert.True(t, m.ignored(filepath.Join(root, "project", "foo.go"), false))
+}
@@ -41,7 +41,9 @@ func TestIntegration_StartSignalShutdown(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 
 	// Send SIGINT signal
-	err := syscall.Kill(os.Getpid(), syscall.SIGINT)
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	err = process.Signal(os.Interrupt)
 	require.NoError(t, err, "should be able to send SIGINT")
 
 	// Dispatcher should exit cleanly
@@ -92,7 +94,9 @@ func TestIntegration_SignalDuringTestRun(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 
 	// Send signal while test is running
-	err := syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	err = process.Signal(syscall.SIGTERM)
 	require.NoError(t, err, "should be able to send SIGTERM")
 
 	// Dispatcher should NOT exit yet
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// The kinds of event written to the JSON event stream.
+const (
+	eventRunStarted    = "run-started"
+	eventTestFailed    = "test-failed"
+	eventRunFinished   = "run-finished"
+	eventConfigChanged = "config-changed"
+)
+
+// jsonEvent is a single line of the JSON event stream. Fields that don't
+// apply to an event's kind are left out.
+type jsonEvent struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	// Command is the test command of a run, or the one the config builds
+	// after it changed
+	Command string `json:"command,omitempty"`
+	// Trigger is what started a run, such as "file change: foo.go"
+	Trigger string `json:"trigger,omitempty"`
+
+	Package string `json:"package,omitempty"`
+	Test    string `json:"test,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	Passed   *bool       `json:"passed,omitempty"`
+	ExitCode *int        `json:"exitCode,omitempty"`
+	Summary  *RunSummary `json:"summary,omitempty"`
+
+	// Settings names the settings that changed, as in .gotest-watch.yml,
+	// and Source is what changed them: "command" or "config file"
+	Settings []string `json:"settings,omitempty"`
+	Source   string   `json:"source,omitempty"`
+}
+
+// eventStream writes events as newline-delimited JSON, for editor plugins
+// and wrappers to follow. Events are dropped until it is opened.
+type eventStream struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// jsonEvents is the stream that runs, commands and config reloads report
+// their events to.
+var jsonEvents = &eventStream{}
+
+// OpenJSONEvents starts writing events to w.
+func OpenJSONEvents(w io.Writer) {
+	jsonEvents.mu.Lock()
+	defer jsonEvents.mu.Unlock()
+	jsonEvents.w = w
+}
+
+// enabled reports whether events are being written.
+func (s *eventStream) enabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w != nil
+}
+
+// emit writes event, stamped with the current time, as a line of JSON.
+func (s *eventStream) emit(event jsonEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return
+	}
+	event.Time = time.Now()
+	line, err := json.Marshal(event)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		log.Println(err)
+	}
+}
+
+// failureEvent returns the event reporting the failure at location.
+func failureEvent(location failureLocation) jsonEvent {
+	return jsonEvent{
+		Event:   eventTestFailed,
+		Package: location.Package,
+		Test:    location.Test,
+		File:    location.File,
+		Line:    location.Line,
+		Message: location.Message,
+	}
+}
+
+// finishedEvent returns the event reporting that a run finished with
+// result, and summary if it got far enough to have one.
+func finishedEvent(result TestCompleteMessage, summary *RunSummary) jsonEvent {
+	return jsonEvent{
+		Event:    eventRunFinished,
+		Passed:   &result.Passed,
+		ExitCode: &result.ExitCode,
+		Summary:  summary,
+	}
+}
+
+// configChangedEvent returns the event reporting that source changed the
+// settings named settings, leaving config as it is.
+func configChangedEvent(config *TestConfig, settings []string, source string) jsonEvent {
+	return jsonEvent{
+		Event:    eventConfigChanged,
+		Command:  config.BuildCommand(),
+		Settings: settings,
+		Source:   source,
+	}
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureJSONEvents sends JSON events to a buffer for the rest of the test.
+func captureJSONEvents(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	OpenJSONEvents(&buf)
+	t.Cleanup(func() { OpenJSONEvents(nil) })
+	return &buf
+}
+
+// decodeJSONEvents returns the events written to buf.
+func decodeJSONEvents(t *testing.T, buf *bytes.Buffer) []jsonEvent {
+	t.Helper()
+	var events []jsonEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var event jsonEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event), line)
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestEventStream(t *testing.T) {
+	t.Run("writes each event as a line of JSON", func(t *testing.T) {
+		buf := captureJSONEvents(t)
+
+		jsonEvents.emit(jsonEvent{Event: eventRunStarted, Command: "go test ./...", Trigger: "startup"})
+		jsonEvents.emit(finishedEvent(TestCompleteMessage{Passed: false, ExitCode: 1}, nil))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], `"event":"run-started"`)
+		assert.Contains(t, lines[0], `"trigger":"startup"`)
+		assert.NotContains(t, lines[0], `"passed"`)
+		assert.Contains(t, lines[1], `"passed":false`)
+		assert.Contains(t, lines[1], `"exitCode":1`)
+
+		events := decodeJSONEvents(t, buf)
+		assert.False(t, events[0].Time.IsZero())
+	})
+
+	t.Run("drops events until opened", func(t *testing.T) {
+		assert.False(t, jsonEvents.enabled())
+		jsonEvents.emit(jsonEvent{Event: eventRunStarted})
+	})
+}
+
+func TestConfigChangedEvent(t *testing.T) {
+	before := NewTestConfig()
+	after := before.Snapshot()
+	after.Verbose = true
+	after.RunPattern = "TestFoo"
+
+	event := configChangedEvent(after, changedSettings(before, after), "command")
+
+	assert.Equal(t, eventConfigChanged, event.Event)
+	assert.Equal(t, []string{"verbose", "runPattern"}, event.Settings)
+	assert.Equal(t, "command", event.Source)
+	assert.Equal(t, after.BuildCommand(), event.Command)
+}
+
+// TestRunTests_EmitsJSONEvents tests that a run reports its start, each
+// failure with its location, and its result to the JSON event stream
+func TestRunTests_EmitsJSONEvents(t *testing.T) {
+	testContent := `package events
+
+import "testing"
+
+func TestPasses(t *testing.T) {}
+
+func TestAlsoPasses(t *testing.T) {}
+
+func TestSkips(t *testing.T) {
+	t.Skip("not today")
+}
+
+func TestFails(t *testing.T) {
+	t.Error("intentional failure")
+}
+`
+	tempDir := setupTestModule(t, testContent)
+	buf := captureJSONEvents(t)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	events := decodeJSONEvents(t, buf)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, eventRunStarted, events[0].Event)
+	assert.Equal(t, "go test . -json", events[0].Command, "the summary's counts should come from -json output")
+
+	assert.Equal(t, eventTestFailed, events[1].Event)
+	assert.Equal(t, "TestFails", events[1].Test)
+	assert.Equal(t, "example_test.go", events[1].File)
+	assert.Equal(t, 14, events[1].Line)
+	assert.Equal(t, "intentional failure", events[1].Message)
+	assert.NotEmpty(t, events[1].Package)
+
+	assert.Equal(t, eventRunFinished, events[2].Event)
+	require.NotNil(t, events[2].Passed)
+	assert.False(t, *events[2].Passed)
+	require.NotNil(t, events[2].ExitCode)
+	assert.Equal(t, 1, *events[2].ExitCode)
+	require.NotNil(t, events[2].Summary)
+	assert.Equal(t, 2, events[2].Summary.Pass)
+	assert.Equal(t, 1, events[2].Summary.Fail)
+	assert.Equal(t, 1, events[2].Summary.Skip)
+}
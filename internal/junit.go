@@ -0,0 +1,216 @@
+package internal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// packageFailedCase names the test case added for a package that failed
+// without any of its tests failing, such as one that didn't build.
+const packageFailedCase = "[package failed]"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Output  string `xml:",chardata"`
+}
+
+// junitPackage collects the results of one package's tests.
+type junitPackage struct {
+	name    string
+	elapsed float64
+	action  string
+	// output is the package's own output, such as a panic outside a test
+	output []string
+	cases  []*junitCase
+}
+
+type junitCase struct {
+	name    string
+	action  string
+	elapsed float64
+	output  []string
+}
+
+// junitCollector collects per-test results and output from a `go test
+// -json` event stream to report as JUnit XML. It is safe to feed from both
+// the stdout and stderr streamers at once.
+type junitCollector struct {
+	mu       sync.Mutex
+	packages []*junitPackage
+}
+
+func newJUnitCollector() *junitCollector {
+	return &junitCollector{}
+}
+
+func (c *junitCollector) parseLine(line string) {
+	event, ok := decodeTestEvent(line)
+	if !ok || event.Package == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pkg := c.packageNamed(event.Package)
+	if event.Test == "" {
+		switch event.Action {
+		case "output":
+			pkg.output = append(pkg.output, event.Output)
+		case "pass", "fail", "skip":
+			pkg.action = event.Action
+			pkg.elapsed = event.Elapsed
+		}
+		return
+	}
+
+	test := pkg.caseNamed(event.Test)
+	switch event.Action {
+	case "output":
+		test.output = append(test.output, event.Output)
+	case "pass", "fail", "skip":
+		test.action = event.Action
+		test.elapsed = event.Elapsed
+	}
+}
+
+// packageNamed returns the results for the package named name, adding it
+// if it has not been seen yet. Callers must hold c.mu.
+func (c *junitCollector) packageNamed(name string) *junitPackage {
+	for _, pkg := range c.packages {
+		if pkg.name == name {
+			return pkg
+		}
+	}
+	pkg := &junitPackage{name: name}
+	c.packages = append(c.packages, pkg)
+	return pkg
+}
+
+func (p *junitPackage) caseNamed(name string) *junitCase {
+	for _, test := range p.cases {
+		if test.name == name {
+			return test
+		}
+	}
+	test := &junitCase{name: name}
+	p.cases = append(p.cases, test)
+	return test
+}
+
+// report returns the results collected so far as JUnit test suites, one
+// per package, stamped with the time the run started.
+func (c *junitCollector) report(started time.Time) junitTestSuites {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var report junitTestSuites
+	var total float64
+	for _, pkg := range c.packages {
+		suite := junitTestSuite{
+			Name:      pkg.name,
+			Time:      junitSeconds(pkg.elapsed),
+			Timestamp: started.Format("2006-01-02T15:04:05"),
+		}
+		testFailed := false
+		for _, test := range pkg.cases {
+			if test.action == "" {
+				// Tests that never finished, such as those interrupted by
+				// a panic elsewhere, are reported with the package
+				continue
+			}
+			testCase := junitTestCase{Name: test.name, Classname: pkg.name, Time: junitSeconds(test.elapsed)}
+			switch test.action {
+			case "fail":
+				testFailed = true
+				testCase.Failure = &junitMessage{Message: "Failed", Output: strings.Join(test.output, "")}
+				suite.Failures++
+			case "skip":
+				testCase.Skipped = &junitMessage{Message: "Skipped", Output: strings.Join(test.output, "")}
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		if pkg.action == "fail" && !testFailed {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      packageFailedCase,
+				Classname: pkg.name,
+				Time:      junitSeconds(pkg.elapsed),
+				Failure:   &junitMessage{Message: "Failed", Output: strings.Join(pkg.output, "")},
+			})
+			suite.Failures++
+		}
+		suite.Tests = len(suite.Cases)
+
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		total += pkg.elapsed
+	}
+	report.Time = junitSeconds(total)
+	return report
+}
+
+func junitSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+// junitFile returns the file to write the JUnit report of a run that
+// started at started to. A path that is a directory, or ends in a path
+// separator, gets a new timestamped file for each run; any other path is
+// overwritten.
+func junitFile(path string, started time.Time) string {
+	info, err := os.Stat(path)
+	isDir := err == nil && info.IsDir()
+	if isDir || strings.HasSuffix(path, string(filepath.Separator)) || strings.HasSuffix(path, "/") {
+		return filepath.Join(path, "junit-"+started.Format("20060102-150405")+".xml")
+	}
+	return path
+}
+
+// writeJUnit writes report as JUnit XML to file, creating its directory if
+// needed.
+func writeJUnit(file string, report junitTestSuites) error {
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), data...)
+	return os.WriteFile(file, append(data, '\n'), 0o600)
+}
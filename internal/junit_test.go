@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJUnitCollector_Report tests converting a -json event stream to JUnit test suites
+func TestJUnitCollector_Report(t *testing.T) {
+	c := newJUnitCollector()
+	lines := []string{
+		`{"Action":"run","Package":"example/a","Test":"TestPasses"}`,
+		`{"Action":"output","Package":"example/a","Test":"TestPasses","Output":"=== RUN   TestPasses\n"}`,
+		`{"Action":"pass","Package":"example/a","Test":"TestPasses","Elapsed":0.5}`,
+		`{"Action":"output","Package":"example/a","Test":"TestFails","Output":"    a_test.go:9: boom\n"}`,
+		`{"Action":"output","Package":"example/a","Test":"TestFails","Output":"--- FAIL: TestFails (0.10s)\n"}`,
+		`{"Action":"fail","Package":"example/a","Test":"TestFails","Elapsed":0.1}`,
+		`{"Action":"output","Package":"example/a","Test":"TestSkipped","Output":"    a_test.go:12: not today\n"}`,
+		`{"Action":"skip","Package":"example/a","Test":"TestSkipped"}`,
+		`{"Action":"fail","Package":"example/a","Elapsed":0.75}`,
+		`{"Action":"output","Package":"example/b","Output":"panic: init failed\n"}`,
+		`{"Action":"fail","Package":"example/b","Elapsed":0.01}`,
+		"not an event",
+	}
+	for _, line := range lines {
+		c.parseLine(line)
+	}
+
+	started := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	report := c.report(started)
+
+	assert.Equal(t, junitTestSuites{
+		Tests:    4,
+		Failures: 2,
+		Skipped:  1,
+		Time:     "0.760",
+		Suites: []junitTestSuite{
+			{
+				Name: "example/a", Tests: 3, Failures: 1, Skipped: 1, Time: "0.750", Timestamp: "2026-10-16T09:30:00",
+				Cases: []junitTestCase{
+					{Name: "TestPasses", Classname: "example/a", Time: "0.500"},
+					{
+						Name: "TestFails", Classname: "example/a", Time: "0.100",
+						Failure: &junitMessage{Message: "Failed", Output: "    a_test.go:9: boom\n--- FAIL: TestFails (0.10s)\n"},
+					},
+					{
+						Name: "TestSkipped", Classname: "example/a", Time: "0.000",
+						Skipped: &junitMessage{Message: "Skipped", Output: "    a_test.go:12: not today\n"},
+					},
+				},
+			},
+			{
+				Name: "example/b", Tests: 1, Failures: 1, Time: "0.010", Timestamp: "2026-10-16T09:30:00",
+				Cases: []junitTestCase{{
+					Name: packageFailedCase, Classname: "example/b", Time: "0.010",
+					Failure: &junitMessage{Message: "Failed", Output: "panic: init failed\n"},
+				}},
+			},
+		},
+	}, report)
+}
+
+// TestJUnitFile tests that directories get timestamped reports and files are overwritten
+func TestJUnitFile(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Date(2026, 10, 16, 9, 30, 5, 0, time.UTC)
+
+	assert.Equal(t, filepath.Join(dir, "junit-20261016-093005.xml"), junitFile(dir, started))
+	assert.Equal(t, filepath.Join("reports", "junit-20261016-093005.xml"), junitFile("reports/", started))
+	assert.Equal(t, filepath.Join(dir, "report.xml"), junitFile(filepath.Join(dir, "report.xml"), started))
+}
+
+// TestRunTests_WritesJUnitReport tests that a run writes its results as JUnit XML
+func TestRunTests_WritesJUnitReport(t *testing.T) {
+	testContent := `package junit
+
+import "testing"
+
+func TestPasses(t *testing.T) {}
+
+func TestFails(t *testing.T) {
+	t.Fatal("intentional failure")
+}
+`
+	tempDir := setupTestModule(t, testContent)
+	reportFile := filepath.Join(t.TempDir(), "reports", "junit.xml")
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetJUnitPath(reportFile)
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.NotContains(t, stdoutBuf.String(), `"Action"`, "raw JSON events should not be displayed")
+	assert.Contains(t, stdoutBuf.String(), "--- FAIL: TestFails")
+
+	data, err := os.ReadFile(reportFile)
+	require.NoError(t, err)
+	var report junitTestSuites
+	require.NoError(t, xml.Unmarshal(data, &report))
+	assert.Equal(t, 2, report.Tests)
+	assert.Equal(t, 1, report.Failures)
+	require.Len(t, report.Suites, 1)
+	assert.Equal(t, "testmodule", report.Suites[0].Name)
+	require.Len(t, report.Suites[0].Cases, 2)
+	assert.Equal(t, "TestPasses", report.Suites[0].Cases[0].Name)
+	assert.Equal(t, "TestFails", report.Suites[0].Cases[1].Name)
+	require.NotNil(t, report.Suites[0].Cases[1].Failure)
+	assert.Contains(t, report.Suites[0].Cases[1].Failure.Output, "intentional failure")
+}
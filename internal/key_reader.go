@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// commandLineKey opens a command line in single-key mode, for typing
+// commands that have no key of their own.
+const commandLineKey = ':'
+
+// singleKeys maps the keys that run a command as soon as they are pressed in
+// single-key mode to that command.
+var singleKeys = map[rune]string{
+	'v': "v",
+	'f': "f",
+	'x': "x",
+	'h': "h",
+	'?': "h",
+}
+
+// patternKeys maps the keys that open a command line in single-key mode to
+// the start of the command they open it with, so that only the pattern or
+// path has to be typed.
+var patternKeys = map[rune]string{
+	'r': "r ",
+	's': "s ",
+	'p': "p ",
+}
+
+// ReadKeys reads keypresses from r, a terminal in single-key mode, and sends
+// the commands they make up to the appropriate channels, echoing them to w.
+// The keys in singleKeys act immediately; those in patternKeys and
+// commandLineKey open a line that is sent when Enter is pressed and can be
+// edited, recalled and completed as with ReadTerminal. Escape, or Backspace
+// on an empty line, abandons it.
+func ReadKeys(
+	ctx context.Context,
+	r io.Reader,
+	w io.Writer,
+	cmdChan chan CommandMessage,
+	helpChan chan HelpMessage,
+) {
+	editor := newLineEditor(bufio.NewReader(r), w)
+	editor.abandonWhenEmpty = true
+	editor.complete = func(before string) (int, []string) {
+		return completeInput(getConfig(ctx), before)
+	}
+	var prompt string
+	editor.prompt = func() string { return shownPrompt() + prompt }
+
+	for {
+		key, err := editor.readKey()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Print(err)
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if cmd, ok := singleKeys[key]; ok {
+			fmt.Fprintf(w, "%c\n", key)
+			if !sendLine(ctx, cmd, cmdChan, helpChan) {
+				return
+			}
+			continue
+		}
+
+		var start string
+		prompt = ""
+		if s, ok := patternKeys[key]; ok {
+			start = s
+		} else if key == commandLineKey {
+			prompt = string(commandLineKey)
+		} else {
+			continue
+		}
+
+		fmt.Fprint(w, prompt)
+		line, err := editor.edit(start)
+		switch {
+		case errors.Is(err, errLineAbandoned) || (err == nil && strings.TrimSpace(line) == ""):
+			editor.clear()
+			fmt.Fprint(w, strings.Repeat("\b \b", len(prompt)))
+		case err != nil:
+			if !errors.Is(err, io.EOF) {
+				log.Print(err)
+			}
+			return
+		default:
+			fmt.Fprintln(w)
+			if !sendLine(ctx, line, cmdChan, helpChan) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readKeys runs ReadKeys over input and returns the commands it sent, with
+// help requests recorded as "help", and what it echoed.
+func readKeys(t *testing.T, input string) ([]string, string) {
+	t.Helper()
+
+	cmdChan := make(chan CommandMessage, 10)
+	helpChan := make(chan HelpMessage, 10)
+	var echoed bytes.Buffer
+
+	ReadKeys(context.Background(), strings.NewReader(input), &echoed, cmdChan, helpChan)
+	close(cmdChan)
+	close(helpChan)
+
+	var sent []string
+	for msg := range cmdChan {
+		sent = append(sent, strings.Join(append([]string{string(msg.Command)}, msg.Args...), " "))
+	}
+	for range helpChan {
+		sent = append(sent, "help")
+	}
+	return sent, echoed.String()
+}
+
+func TestReadKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"single key acts immediately", "v", []string{"v"}},
+		{"several single keys", "vfx", []string{"v", "f", "x"}},
+		{"help key", "?", []string{"help"}},
+		{"unmapped keys are ignored", "zq\r", nil},
+		{"pattern key opens a command line", "rTestFoo\r", []string{"r TestFoo"}},
+		{"pattern key with newline", "sTestSlow\n", []string{"s TestSlow"}},
+		{"pattern key with no pattern clears it", "r\r", []string{"r"}},
+		{"command line key", ":race\r", []string{"race"}},
+		{"command line with arguments", ":count 3\r", []string{"count 3"}},
+		{"keys on a command line are typed", ":vet\rv", []string{"vet", "v"}},
+		{"empty command line sends nothing", ":\rv", []string{"v"}},
+		{"backspace edits the line", ":racf\x7fe\r", []string{"race"}},
+		{"backspace on an empty line closes it", "r\x7f\x7f\x7fv", []string{"v"}},
+		{"ctrl+u clears the line", ":cover\x15race\r", []string{"race"}},
+		{"escape abandons the line", ":rac\x1bv", []string{"v"}},
+		{"unfinished line is not sent", ":race", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent, _ := readKeys(t, tt.input)
+			assert.Equal(t, tt.expected, sent)
+		})
+	}
+}
+
+func TestReadKeys_Echo(t *testing.T) {
+	t.Run("single key is echoed on its own line", func(t *testing.T) {
+		_, echoed := readKeys(t, "v")
+		assert.Equal(t, "v\n", echoed)
+	})
+
+	t.Run("command line is echoed as it is typed", func(t *testing.T) {
+		_, echoed := readKeys(t, "rFoo\r")
+		assert.Equal(t, "r Foo\n", echoed)
+	})
+
+	t.Run("backspace erases a character", func(t *testing.T) {
+		_, echoed := readKeys(t, ":ab\x7f")
+		assert.Equal(t, ":ab\b \b", echoed)
+	})
+
+	t.Run("escape erases the prompt and line", func(t *testing.T) {
+		_, echoed := readKeys(t, ":ab\x1b")
+		line, cursor := displayed(echoed)
+		assert.Equal(t, "", line)
+		assert.Equal(t, 0, cursor)
+	})
+}
+
+func TestReadKeys_StopsOnCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cmdChan := make(chan CommandMessage, 10)
+	helpChan := make(chan HelpMessage, 10)
+	ReadKeys(ctx, strings.NewReader("vf"), &bytes.Buffer{}, cmdChan, helpChan)
+
+	assert.Empty(t, cmdChan)
+}
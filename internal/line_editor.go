@@ -0,0 +1,472 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxLineHistory is how many previously entered lines the line editor keeps.
+const maxLineHistory = 500
+
+// Control keys understood by the line editor.
+const (
+	keyCtrlA     = 0x01
+	keyCtrlB     = 0x02
+	keyCtrlD     = 0x04
+	keyCtrlE     = 0x05
+	keyCtrlF     = 0x06
+	keyCtrlG     = 0x07
+	keyCtrlH     = '\b'
+	keyTab       = '\t'
+	keyCtrlK     = 0x0b
+	keyCtrlN     = 0x0e
+	keyCtrlP     = 0x10
+	keyCtrlR     = 0x12
+	keyCtrlU     = 0x15
+	keyCtrlW     = 0x17
+	keyEscape    = 0x1b
+	keyBackspace = 0x7f
+)
+
+// Keys that the terminal sends as escape sequences. They are negative so
+// that they cannot be mistaken for typed characters.
+const (
+	keyUp rune = -1 - iota
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+// errLineAbandoned is returned by lineEditor.edit when Escape is pressed.
+var errLineAbandoned = errors.New("line abandoned")
+
+// lineSource is a source of input lines, such as a bufio.Scanner or a
+// lineEditor.
+type lineSource interface {
+	Scan() bool
+	Text() string
+	Err() error
+}
+
+// lineEditor reads lines typed at a terminal that is not echoing or
+// buffering input, echoing them to w as they are edited. It supports moving
+// around the line with the arrow keys, Home, End, Ctrl+A and Ctrl+E,
+// deleting with Backspace, Delete, Ctrl+D, Ctrl+U, Ctrl+K and Ctrl+W,
+// recalling earlier lines with the Up and Down arrows, Ctrl+P and Ctrl+N,
+// searching them with Ctrl+R, and completing words with Tab. It implements
+// lineSource.
+type lineEditor struct {
+	r *bufio.Reader
+	w io.Writer
+	// abandonWhenEmpty makes Backspace and Ctrl+D on an empty line abandon
+	// it, as if Escape had been pressed
+	abandonWhenEmpty bool
+	// complete returns the byte offset of the start of the word at the end
+	// of the text before the cursor, and the ways it could be completed
+	complete func(before string) (int, []string)
+	// prompt returns what precedes the line on the terminal, which is
+	// redrawn after completions are listed
+	prompt func() string
+
+	history []string
+
+	text   []rune
+	cursor int
+	// shown is what is displayed on the terminal for the line, and col the
+	// column of the terminal's cursor within it
+	shown []rune
+	col   int
+
+	line string
+	err  error
+}
+
+func newLineEditor(r *bufio.Reader, w io.Writer) *lineEditor {
+	return &lineEditor{r: r, w: w}
+}
+
+// Scan reads the next line, leaving the terminal's cursor at the start of
+// the following line. Lines abandoned with Escape are erased and skipped.
+func (e *lineEditor) Scan() bool {
+	for {
+		line, err := e.edit("")
+		if errors.Is(err, errLineAbandoned) {
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				e.err = err
+			}
+			return false
+		}
+		fmt.Fprintln(e.w)
+		e.line = line
+		return true
+	}
+}
+
+func (e *lineEditor) Text() string {
+	return e.line
+}
+
+func (e *lineEditor) Err() error {
+	return e.err
+}
+
+// edit reads a line starting with initial, which can be edited like the
+// rest of it, until Enter is pressed. The line is added to the history, and
+// the terminal's cursor is left at its end.
+func (e *lineEditor) edit(initial string) (string, error) {
+	e.text = []rune(initial)
+	e.cursor = len(e.text)
+	e.shown = nil
+	e.col = 0
+	e.render()
+
+	// browsing is the index in the history of the line being shown, and
+	// draft the line that was being typed before browsing began
+	browsing := len(e.history)
+	var draft []rune
+	var pending rune
+
+	for {
+		key := pending
+		pending = 0
+		if key == 0 {
+			var err error
+			if key, err = e.readKey(); err != nil {
+				return "", err
+			}
+		}
+
+		switch key {
+		case '\r', '\n':
+			line := string(e.text)
+			e.remember(line)
+			return line, nil
+		case keyEscape:
+			e.clear()
+			return "", errLineAbandoned
+		case keyBackspace, keyCtrlH:
+			if len(e.text) == 0 && e.abandonWhenEmpty {
+				return "", errLineAbandoned
+			}
+			if e.cursor > 0 {
+				e.text = slices.Delete(e.text, e.cursor-1, e.cursor)
+				e.cursor--
+			}
+		case keyCtrlD:
+			if len(e.text) == 0 {
+				if e.abandonWhenEmpty {
+					return "", errLineAbandoned
+				}
+				return "", io.EOF
+			}
+			fallthrough
+		case keyDelete:
+			if e.cursor < len(e.text) {
+				e.text = slices.Delete(e.text, e.cursor, e.cursor+1)
+			}
+		case keyCtrlA, keyHome:
+			e.cursor = 0
+		case keyCtrlE, keyEnd:
+			e.cursor = len(e.text)
+		case keyCtrlB, keyLeft:
+			e.cursor = max(e.cursor-1, 0)
+		case keyCtrlF, keyRight:
+			e.cursor = min(e.cursor+1, len(e.text))
+		case keyCtrlU:
+			e.text = slices.Delete(e.text, 0, e.cursor)
+			e.cursor = 0
+		case keyCtrlK:
+			e.text = e.text[:e.cursor]
+		case keyCtrlW:
+			start := e.cursor
+			for start > 0 && unicode.IsSpace(e.text[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(e.text[start-1]) {
+				start--
+			}
+			e.text = slices.Delete(e.text, start, e.cursor)
+			e.cursor = start
+		case keyUp, keyCtrlP:
+			if browsing > 0 {
+				if browsing == len(e.history) {
+					draft = slices.Clone(e.text)
+				}
+				browsing--
+				e.setText([]rune(e.history[browsing]))
+			}
+		case keyDown, keyCtrlN:
+			if browsing < len(e.history) {
+				browsing++
+				if browsing == len(e.history) {
+					e.setText(draft)
+				} else {
+					e.setText([]rune(e.history[browsing]))
+				}
+			}
+		case keyTab:
+			e.completeWord()
+		case keyCtrlR:
+			next, err := e.search()
+			if err != nil {
+				return "", err
+			}
+			pending = next
+			browsing = len(e.history)
+		default:
+			if unicode.IsPrint(key) {
+				e.text = slices.Insert(e.text, e.cursor, key)
+				e.cursor++
+			}
+		}
+		e.render()
+	}
+}
+
+// search runs a reverse incremental search of the history, showing the most
+// recent line containing what has been typed so far. Ctrl+R moves on to an
+// older match, and Ctrl+G abandons the search. Any other key leaves the
+// match on the line and is returned so that it can be acted on as usual,
+// except for Escape, which only ends the search.
+func (e *lineEditor) search() (rune, error) {
+	original := slices.Clone(e.text)
+	var query []rune
+	match := len(e.history)
+	failed := false
+
+	// find shows the most recent match at or before from, if there is one
+	find := func(from int) {
+		for i := min(from, len(e.history)-1); i >= 0; i-- {
+			if strings.Contains(e.history[i], string(query)) {
+				match = i
+				failed = false
+				return
+			}
+		}
+		failed = true
+	}
+
+	for {
+		status := "reverse-i-search"
+		if failed {
+			status = "failed " + status
+		}
+		shown := ""
+		if match < len(e.history) {
+			shown = e.history[match]
+		}
+		prompt := []rune(fmt.Sprintf("(%s)`%s': %s", status, string(query), shown))
+		e.draw(prompt, len(prompt))
+
+		key, err := e.readKey()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case key == keyCtrlR:
+			if len(query) > 0 {
+				find(match - 1)
+			}
+		case key == keyBackspace || key == keyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				match = len(e.history)
+				if len(query) > 0 {
+					find(match)
+				}
+			}
+		case key == keyCtrlG:
+			e.setText(original)
+			return 0, nil
+		case unicode.IsPrint(key):
+			query = append(query, key)
+			find(match)
+		default:
+			if match < len(e.history) {
+				e.setText([]rune(e.history[match]))
+			} else {
+				e.setText(original)
+			}
+			if key == keyEscape {
+				return 0, nil
+			}
+			return key, nil
+		}
+	}
+}
+
+// completeWord completes the word before the cursor as far as all of its
+// completions agree, and lists them if that does not add anything.
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+	before := string(e.text[:e.cursor])
+	start, candidates := e.complete(before)
+	if len(candidates) == 0 {
+		return
+	}
+
+	word := []rune(before[start:])
+	common := []rune(candidates[0])
+	for _, candidate := range candidates[1:] {
+		c := []rune(candidate)
+		n := 0
+		for n < len(common) && n < len(c) && common[n] == c[n] {
+			n++
+		}
+		common = common[:n]
+	}
+
+	if len(common) > len(word) {
+		wordStart := e.cursor - len(word)
+		e.text = slices.Concat(e.text[:wordStart], common, e.text[e.cursor:])
+		e.cursor = wordStart + len(common)
+		return
+	}
+	if len(candidates) == 1 {
+		return
+	}
+
+	listed := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		listed[i] = strings.TrimSpace(candidate)
+	}
+	// The line is drawn again in full below the list
+	fmt.Fprint(e.w, string(e.shown[e.col:]), "\n", strings.Join(listed, "  "), "\n")
+	if e.prompt != nil {
+		fmt.Fprint(e.w, e.prompt())
+	}
+	e.shown = nil
+	e.col = 0
+}
+
+// remember adds line to the history, unless it is blank or repeats the most
+// recent line.
+func (e *lineEditor) remember(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxLineHistory {
+		e.history = e.history[len(e.history)-maxLineHistory:]
+	}
+}
+
+func (e *lineEditor) setText(text []rune) {
+	e.text = slices.Clone(text)
+	e.cursor = len(e.text)
+}
+
+// clear erases the line from the terminal, leaving its cursor where the
+// line started.
+func (e *lineEditor) clear() {
+	e.draw(nil, 0)
+}
+
+func (e *lineEditor) render() {
+	e.draw(e.text, e.cursor)
+}
+
+// draw updates the terminal to show s in place of what it shows for the
+// line, with its cursor at column cursor. Only the part that has changed is
+// redrawn, and the cursor is moved with backspaces and by rewriting
+// characters, so no terminal escape sequences are needed.
+func (e *lineEditor) draw(s []rune, cursor int) {
+	same := 0
+	for same < len(s) && same < len(e.shown) && s[same] == e.shown[same] {
+		same++
+	}
+
+	var b strings.Builder
+	if e.col > same {
+		b.WriteString(strings.Repeat("\b", e.col-same))
+	} else {
+		b.WriteString(string(e.shown[e.col:same]))
+	}
+	b.WriteString(string(s[same:]))
+	end := len(s)
+	if extra := len(e.shown) - len(s); extra > 0 {
+		b.WriteString(strings.Repeat(" ", extra))
+		end += extra
+	}
+	b.WriteString(strings.Repeat("\b", end-cursor))
+
+	fmt.Fprint(e.w, b.String())
+	e.shown = slices.Clone(s)
+	e.col = cursor
+}
+
+// readKey reads a keypress, decoding the escape sequences sent by the arrow
+// keys, Home, End and Delete.
+func (e *lineEditor) readKey() (rune, error) {
+	key, _, err := e.r.ReadRune()
+	if err != nil || key != keyEscape {
+		return key, err
+	}
+
+	// Terminals send escape sequences all at once, so an Escape with nothing
+	// after it, or followed by an ordinary key, was pressed on its own
+	if e.r.Buffered() == 0 {
+		return keyEscape, nil
+	}
+	next, err := e.r.Peek(1)
+	if err != nil || (next[0] != '[' && next[0] != 'O') {
+		return keyEscape, nil
+	}
+	if _, err := e.r.ReadByte(); err != nil {
+		return 0, err
+	}
+
+	var params []byte
+	for {
+		b, err := e.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b < 0x40 || b > 0x7e {
+			params = append(params, b)
+			continue
+		}
+
+		switch b {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			return keyRight, nil
+		case 'D':
+			return keyLeft, nil
+		case 'H':
+			return keyHome, nil
+		case 'F':
+			return keyEnd, nil
+		case '~':
+			switch string(params) {
+			case "1", "7":
+				return keyHome, nil
+			case "4", "8":
+				return keyEnd, nil
+			case "3":
+				return keyDelete, nil
+			}
+		}
+		return keyUnknown, nil
+	}
+}
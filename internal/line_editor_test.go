@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// displayed returns the last line a terminal would show after echoing
+// output, without trailing spaces, and the column its cursor would be in.
+func displayed(output string) (string, int) {
+	var line []rune
+	cursor := 0
+	for _, r := range output {
+		switch r {
+		case '\n':
+			line, cursor = nil, 0
+		case '\b':
+			cursor = max(cursor-1, 0)
+		default:
+			if cursor < len(line) {
+				line[cursor] = r
+			} else {
+				line = append(line, r)
+			}
+			cursor++
+		}
+	}
+	return strings.TrimRight(string(line), " "), cursor
+}
+
+// scanLines returns the lines a lineEditor reads from input.
+func scanLines(t *testing.T, input string, history ...string) []string {
+	t.Helper()
+
+	e := newLineEditor(bufio.NewReader(strings.NewReader(input)), io.Discard)
+	e.history = history
+	var lines []string
+	for e.Scan() {
+		lines = append(lines, e.Text())
+	}
+	require.NoError(t, e.Err())
+	return lines
+}
+
+func TestLineEditor_Editing(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"plain lines", "v\nr TestFoo\n", []string{"v", "r TestFoo"}},
+		{"carriage return ends a line", "race\r", []string{"race"}},
+		{"backspace", "racf\x7fe\n", []string{"race"}},
+		{"ctrl+h", "racf\be\n", []string{"race"}},
+		{"ctrl+a inserts at the start", "TestFoo\x01r \n", []string{"r TestFoo"}},
+		{"ctrl+e returns to the end", "r Foo\x01\x05Bar\n", []string{"r FooBar"}},
+		{"home and end keys", "ace\x1b[Hr\x1b[F!\n", []string{"race!"}},
+		{"home and end sequences", "ace\x1b[1~r\x1b[4~!\n", []string{"race!"}},
+		{"arrow keys move the cursor", "rae\x1b[D\x1b[Dc\x1b[C\x1b[C!\n", []string{"rcae!"}},
+		{"application mode arrow keys", "rc\x1bODa\n", []string{"rac"}},
+		{"ctrl+b and ctrl+f", "rce\x02\x02a\x06\x06!\n", []string{"race!"}},
+		{"delete key", "rxace\x01\x06\x1b[3~\n", []string{"race"}},
+		{"ctrl+d deletes under the cursor", "rxace\x01\x06\x04\n", []string{"race"}},
+		{"ctrl+u deletes to the start", "cover\x15race\n", []string{"race"}},
+		{"ctrl+k deletes to the end", "race cover\x01\x06\x06\x06\x06\x0b\n", []string{"race"}},
+		{"ctrl+w deletes a word", "r TestFoo  \x17Bar\n", []string{"r Bar"}},
+		{"unknown escape sequences are ignored", "ra\x1b[5~ce\n", []string{"race"}},
+		{"escape abandons the line", "cover\x1bv\n", []string{"v"}},
+		{"ctrl+d on an empty line ends input", "v\n\x04f\n", []string{"v"}},
+		{"unfinished line is not returned", "v\nrace", []string{"v"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, scanLines(t, tt.input))
+		})
+	}
+}
+
+func TestLineEditor_History(t *testing.T) {
+	history := []string{"v", "r TestSomethingLong", "race"}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"up recalls the last line", "\x1b[A\n", []string{"race"}},
+		{"up twice recalls an earlier line", "\x1b[A\x1b[A\n", []string{"r TestSomethingLong"}},
+		{"up stops at the oldest line", "\x1b[A\x1b[A\x1b[A\x1b[A\n", []string{"v"}},
+		{"down returns to a later line", "\x1b[A\x1b[A\x1b[B\n", []string{"race"}},
+		{"down restores the typed line", "cov\x1b[A\x1b[Ber\n", []string{"cover"}},
+		{"ctrl+p and ctrl+n", "\x10\x10\x10\x0e\n", []string{"r TestSomethingLong"}},
+		{"recalled lines can be edited", "\x1b[A\x1b[A\x7f\x7f\x7f\x7fBar\n", []string{"r TestSomethingBar"}},
+		{"entered lines are added", "cover\n\x1b[A\n", []string{"cover", "cover"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, scanLines(t, tt.input, history...))
+		})
+	}
+}
+
+func TestLineEditor_Search(t *testing.T) {
+	history := []string{"r TestFoo", "v", "r TestSomethingLong", "race"}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"finds the most recent match", "\x12r \n", []string{"r TestSomethingLong"}},
+		{"ctrl+r finds an older match", "\x12r \x12\n", []string{"r TestFoo"}},
+		{"ctrl+r stays on the oldest match", "\x12r \x12\x12\n", []string{"r TestFoo"}},
+		{"typing narrows the match", "\x12Test\n", []string{"r TestSomethingLong"}},
+		{"backspace widens the match", "\x12rac\x7f\x7f\x7fFoo\n", []string{"r TestFoo"}},
+		{"other keys edit the match", "\x12Foo\x05Bar\n", []string{"r TestFooBar"}},
+		{"escape leaves the match to edit", "\x12Foo\x1b!\n", []string{"r TestFoo!"}},
+		{"ctrl+g restores the line", "cover\x12Foo\x07\n", []string{"cover"}},
+		{"no match keeps the line", "cover\x12zzz\n", []string{"cover"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, scanLines(t, tt.input, history...))
+		})
+	}
+}
+
+func TestLineEditor_SearchPrompt(t *testing.T) {
+	var echoed bytes.Buffer
+	e := newLineEditor(bufio.NewReader(strings.NewReader("\x12Long")), &echoed)
+	e.history = []string{"r TestSomethingLong", "race"}
+
+	assert.False(t, e.Scan())
+	line, _ := displayed(echoed.String())
+	assert.Equal(t, "(reverse-i-search)`Long': r TestSomethingLong", line)
+
+	echoed.Reset()
+	e = newLineEditor(bufio.NewReader(strings.NewReader("\x12zzz")), &echoed)
+	e.history = []string{"race"}
+
+	assert.False(t, e.Scan())
+	line, _ = displayed(echoed.String())
+	assert.Equal(t, "(failed reverse-i-search)`zzz':", line)
+}
+
+func TestLineEditor_Remember(t *testing.T) {
+	e := newLineEditor(nil, io.Discard)
+	e.remember("v")
+	e.remember("v")
+	e.remember("  ")
+	e.remember("race")
+	assert.Equal(t, []string{"v", "race"}, e.history)
+
+	for i := range maxLineHistory + 10 {
+		e.remember(fmt.Sprint(i))
+	}
+	assert.Len(t, e.history, maxLineHistory)
+	assert.Equal(t, fmt.Sprint(maxLineHistory+9), e.history[len(e.history)-1])
+}
+
+func TestLineEditor_Echo(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		line   string
+		cursor int
+	}{
+		{"typed text", "race", "race", 4},
+		{"backspace", "racf\x7fe", "race", 4},
+		{"insert in the middle", "rce\x1b[D\x1b[Da", "race", 2},
+		{"ctrl+a", "race\x01", "race", 0},
+		{"history replaces a longer line", "something long\x1b[A", "v", 1},
+		{"escape erases the line", "race\x1b", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var echoed bytes.Buffer
+			e := newLineEditor(bufio.NewReader(strings.NewReader(tt.input)), &echoed)
+			e.history = []string{"v"}
+			for e.Scan() {
+			}
+
+			line, cursor := displayed(echoed.String())
+			assert.Equal(t, tt.line, line)
+			assert.Equal(t, tt.cursor, cursor)
+		})
+	}
+}
+
+func TestLineEditor_ReadError(t *testing.T) {
+	e := newLineEditor(bufio.NewReader(io.MultiReader(strings.NewReader("v\n"), errReader{})), io.Discard)
+
+	assert.True(t, e.Scan())
+	assert.Equal(t, "v", e.Text())
+	assert.False(t, e.Scan())
+	assert.True(t, errors.Is(e.Err(), errRead))
+}
+
+var errRead = errors.New("read failed")
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errRead
+}
@@ -1,5 +1,7 @@
 package internal
 
+import "strings"
+
 type MessageType string
 
 const (
@@ -23,9 +25,41 @@ const (
 	RaceCmd           Command = "race"
 	FailFastCmd       Command = "ff"
 	CountCmd          Command = "count"
+	TimeoutCmd        Command = "timeout"
+	ParallelCmd       Command = "par"
+	CPUCmd            Command = "cpu"
+	TestArgsCmd       Command = "args"
 	SetCommandBaseCmd Command = "cmd"
 	CoverCmd          Command = "cover"
+	ShortCmd          Command = "short"
+	ShuffleCmd        Command = "shuffle"
 	ColorCmd          Command = "color"
+	MetricsCmd        Command = "metrics"
+	LiteralCmd        Command = "literal"
+	ReplayRunCmd      Command = "replay-run"
+	PushPatternCmd    Command = "push"
+	PopPatternCmd     Command = "pop"
+	InterruptCmd      Command = "x"
+	SmartCmd          Command = "smart"
+	CoverHTMLCmd      Command = "coverhtml"
+	EnvCmd            Command = "env"
+	FuzzCmd           Command = "fuzz"
+	VetCmd            Command = "vet"
+	WatchCmd          Command = "watch"
+	UnwatchCmd        Command = "unwatch"
+	RescanCmd         Command = "rescan"
+	StatusCmd         Command = "status"
+	QuietPassCmd      Command = "quietpass"
+	CollapseCmd       Command = "collapse"
+	ShowCmd           Command = "show"
+	HistoryCmd        Command = "history"
+	NotifyCmd         Command = "notify"
+	SlowCmd           Command = "slow"
+	GroupCmd          Command = "group"
+	NoCacheCmd        Command = "nocache"
+	ChangedCmd        Command = "changed"
+	FuncsCmd          Command = "funcs"
+	CoverPkgCmd       Command = "coverpkg"
 )
 
 type Message interface {
@@ -33,13 +67,31 @@ type Message interface {
 }
 
 type (
-	FileChangeMessage struct{}
-	CommandMessage    struct {
+	FileChangeMessage struct {
+		// Pending is set when changes have been seen but are still being
+		// debounced, so no run should start yet
+		Pending bool
+		// Paths lists the files that changed, in the order they were first seen
+		Paths []string
+	}
+	CommandMessage struct {
 		Command Command
 		Args    []string
+		// Then holds the commands that followed this one on the same line,
+		// to be run in order once it, and any test run it starts, completes
+		Then []CommandMessage
 	}
 	HelpMessage         struct{}
-	TestCompleteMessage struct{}
+	TestCompleteMessage struct {
+		// Passed is set when the test command exited successfully
+		Passed bool
+		// ExitCode is the test command's exit status, or 1 if it could not
+		// be run
+		ExitCode int
+		// Packages holds the per-package results of the run when a
+		// structured summary was requested
+		Packages []PackageResult
+	}
 )
 
 func (m *FileChangeMessage) Type() MessageType {
@@ -57,6 +109,21 @@ func (m *CommandMessage) Type() MessageType {
 	return MessageTypeCommand
 }
 
+// Sequence returns the command and those in Then, in the order they run.
+func (m *CommandMessage) Sequence() []CommandMessage {
+	return append([]CommandMessage{{Command: m.Command, Args: m.Args}}, m.Then...)
+}
+
+// String returns the commands as they would be typed, separated by
+// commandSeparator.
+func (m *CommandMessage) String() string {
+	commands := make([]string, 0, len(m.Then)+1)
+	for _, cmd := range m.Sequence() {
+		commands = append(commands, strings.Join(append([]string{string(cmd.Command)}, cmd.Args...), " "))
+	}
+	return strings.Join(commands, commandSeparator+" ")
+}
+
 func (m *HelpMessage) Type() MessageType {
 	return MessageTypeHelp
 }
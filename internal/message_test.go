@@ -69,3 +69,19 @@ func TestNewCommandMessage(t *testing.T) {
 		assert.Equal(t, m.Args, []string{"MyTest"})
 	})
 }
+
+func TestCommandMessage_String(t *testing.T) {
+	single := CommandMessage{Command: SetPatternCmd, Args: []string{"TestFoo"}}
+	assert.Equal(t, "r TestFoo", single.String())
+
+	chained := CommandMessage{Command: VerboseCmd, Then: []CommandMessage{
+		{Command: CountCmd, Args: []string{"3"}},
+		{Command: ForceRunCmd},
+	}}
+	assert.Equal(t, "v; count 3; f", chained.String())
+	assert.Equal(t, []CommandMessage{
+		{Command: VerboseCmd},
+		{Command: CountCmd, Args: []string{"3"}},
+		{Command: ForceRunCmd},
+	}, chained.Sequence())
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// watchMetrics counts watcher and test runner activity, to help tune the
+// watch setup.
+type watchMetrics struct {
+	sync.Mutex
+	eventsSeen      int
+	eventsDebounced int
+	runsLaunched    int
+	totalRunTime    time.Duration
+}
+
+// metrics is shared by the watcher, the test runner and the metrics command.
+var metrics = &watchMetrics{}
+
+// recordEvent counts a file change event that passed the watcher's filters.
+func (m *watchMetrics) recordEvent() {
+	m.Lock()
+	defer m.Unlock()
+	m.eventsSeen++
+}
+
+// recordDebounced counts an event that was folded into a pending run rather
+// than triggering one of its own.
+func (m *watchMetrics) recordDebounced() {
+	m.Lock()
+	defer m.Unlock()
+	m.eventsDebounced++
+}
+
+// recordRun counts a completed test run and how long it took.
+func (m *watchMetrics) recordRun(elapsed time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	m.runsLaunched++
+	m.totalRunTime += elapsed
+}
+
+func (m *watchMetrics) averageRunTime() time.Duration {
+	if m.runsLaunched == 0 {
+		return 0
+	}
+	return m.totalRunTime / time.Duration(m.runsLaunched)
+}
+
+func (m *watchMetrics) String() string {
+	m.Lock()
+	defer m.Unlock()
+	return fmt.Sprintf(
+		"Events seen: %d\nEvents debounced: %d\nRuns launched: %d\nAverage run duration: %.2fs",
+		m.eventsSeen, m.eventsDebounced, m.runsLaunched, m.averageRunTime().Seconds(),
+	)
+}
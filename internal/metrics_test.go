@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWatchMetrics_ReflectsSimulatedActivity tests the counters after a sequence of events and runs
+func TestWatchMetrics_ReflectsSimulatedActivity(t *testing.T) {
+	m := &watchMetrics{}
+
+	for i := 0; i < 5; i++ {
+		m.recordEvent()
+	}
+	for i := 0; i < 3; i++ {
+		m.recordDebounced()
+	}
+	m.recordRun(1 * time.Second)
+	m.recordRun(2 * time.Second)
+
+	assert.Equal(t,
+		"Events seen: 5\nEvents debounced: 3\nRuns launched: 2\nAverage run duration: 1.50s",
+		m.String(),
+	)
+}
+
+// TestWatchMetrics_NoRunsHasZeroAverage tests that the average is zero before any run
+func TestWatchMetrics_NoRunsHasZeroAverage(t *testing.T) {
+	m := &watchMetrics{}
+
+	assert.Contains(t, m.String(), "Average run duration: 0.00s")
+}
+
+// TestDebounceLoop_RecordsDebouncedEvents tests that coalesced events are counted as debounced
+func TestDebounceLoop_RecordsDebouncedEvents(t *testing.T) {
+	before := debouncedCount()
+
+	input := make(chan fsnotify.Event, 10)
+	fired := make(chan struct{}, 10)
+	go debounceLoop(50*time.Millisecond, input, nil, func(_ []string) {
+		fired <- struct{}{}
+	})
+
+	for i := 0; i < 3; i++ {
+		input <- fsnotify.Event{Name: "main.go", Op: fsnotify.Write}
+	}
+
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("debounce callback did not fire")
+	}
+
+	require.GreaterOrEqual(t, debouncedCount()-before, 2, "all but one event of the burst should be debounced")
+}
+
+// TestHandleMetrics_PrintsCounters tests that the metrics command prints the counters
+func TestHandleMetrics_PrintsCounters(t *testing.T) {
+	output := captureStdout(t, func() {
+		require.NoError(t, handleMetrics(NewTestConfig(), nil))
+	})
+
+	assert.Contains(t, output, "Events seen:")
+	assert.Contains(t, output, "Runs launched:")
+}
+
+func debouncedCount() int {
+	metrics.Lock()
+	defer metrics.Unlock()
+	return metrics.eventsDebounced
+}
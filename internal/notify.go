@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// notifyTitle is the title of the desktop notifications sent after runs.
+const notifyTitle = "gotest-watch"
+
+// maxFailedTestsNotified limits how many failed tests are named in a
+// desktop notification, which has little room.
+const maxFailedTestsNotified = 3
+
+// runMessage is what is said about a finished run, both in desktop
+// notifications and in chat messages.
+type runMessage struct {
+	title string
+	body  string
+	// failed are the names of the tests that failed
+	failed    []string
+	passed    bool
+	recovered bool
+}
+
+// newRunMessage returns the message about a run with summary, in which the
+// failed tests failed. A run that passed after the previous run failed is
+// announced as a recovery.
+func newRunMessage(summary RunSummary, failed []string, recovered bool) runMessage {
+	status := "PASS"
+	switch {
+	case !summary.OK:
+		status = "FAIL"
+	case recovered:
+		status = "PASS (recovered)"
+	}
+	return runMessage{
+		title:     notifyTitle + ": " + status,
+		body:      formatSummaryLine(summary),
+		failed:    failed,
+		passed:    summary.OK,
+		recovered: summary.OK && recovered,
+	}
+}
+
+// text renders the message below its title: its summary, followed by up to
+// maxFailed of the tests that failed. Desktop notifications and chat
+// messages are both rendered with it.
+func (m runMessage) text(maxFailed int) string {
+	lines := []string{m.body}
+	for i, name := range m.failed {
+		if i == maxFailed {
+			lines = append(lines, fmt.Sprintf("and %d more", len(m.failed)-i))
+			break
+		}
+		lines = append(lines, "- "+name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// notifyRun sends a desktop notification with message, without waiting for
+// it to be shown.
+func notifyRun(message runMessage) {
+	args, err := notificationCommand(runtime.GOOS, message.title, message.text(maxFailedTestsNotified))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+
+	deliveries.Add(1)
+	go func() {
+		defer deliveries.Done()
+		//nolint:gosec // the command is one of the fixed notifiers below
+		cmd := exec.CommandContext(context.Background(), args[0], args[1:]...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not send notification: %v %s\n", err,
+				strings.TrimSpace(string(output)))
+		}
+	}()
+}
+
+// notificationCommand returns the command that shows a desktop notification
+// with title and body on goos.
+func notificationCommand(goos, title, body string) ([]string, error) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s",
+			appleScriptString(body), appleScriptString(title))
+		return []string{"osascript", "-e", script}, nil
+	case "windows":
+		script := fmt.Sprintf(windowsToastScript, powerShellString(title), powerShellString(body))
+		return []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", script}, nil
+	case "linux", "freebsd", "netbsd", "openbsd", "dragonfly":
+		return []string{"notify-send", title, body}, nil
+	}
+	return nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+}
+
+// windowsToastScript shows a toast notification with the title and body
+// substituted into it.
+//
+//nolint:lll // the script's lines can't be broken up, and its type names are long
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode(%s)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode(%s)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('gotest-watch').Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// powerShellString quotes s as a PowerShell string literal, in which
+// nothing is expanded.
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNotificationCommand tests the notifier used on each platform
+func TestNotificationCommand(t *testing.T) {
+	args, err := notificationCommand("linux", "gotest-watch: FAIL", "✓ 1 passed ✗ 1 failed ⊘ 0 skipped in 0.1s")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notify-send", "gotest-watch: FAIL", "✓ 1 passed ✗ 1 failed ⊘ 0 skipped in 0.1s"}, args)
+
+	args, err = notificationCommand("darwin", "gotest-watch: PASS", `say "hi" \o/`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"osascript", "-e", `display notification "say \"hi\" \\o/" with title "gotest-watch: PASS"`,
+	}, args)
+
+	args, err = notificationCommand("windows", "gotest-watch: PASS", "it's done")
+	require.NoError(t, err)
+	require.Len(t, args, 5)
+	assert.Equal(t, "powershell", args[0])
+	assert.Contains(t, args[4], "CreateTextNode('gotest-watch: PASS')")
+	assert.Contains(t, args[4], "CreateTextNode('it''s done')")
+
+	_, err = notificationCommand("plan9", "title", "body")
+	assert.EqualError(t, err, "desktop notifications are not supported on plan9")
+}
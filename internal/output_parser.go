@@ -0,0 +1,429 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RunSummary tallies the results of a single test run.
+type RunSummary struct {
+	Pass    int     `json:"pass"`
+	Fail    int     `json:"fail"`
+	Skip    int     `json:"skip"`
+	Elapsed float64 `json:"elapsed"`
+	OK      bool    `json:"ok"`
+	// Coverage is the percentage of statements covered across every
+	// package, for runs that keep a coverage profile
+	Coverage *float64 `json:"coverage,omitempty"`
+}
+
+// outputParser collects test results from the lines of a test run. It
+// understands both the plain text output of `go test -v` and the event
+// stream of `go test -json`, and is safe to feed from both the stdout and
+// stderr streamers at once.
+type outputParser struct {
+	mu        sync.Mutex
+	summary   RunSummary
+	durations []testDuration
+	// seeds lists the -shuffle seeds reported by the test binaries, in the
+	// order they were seen
+	seeds []string
+	// failed lists the names of the tests that failed, in the order they
+	// finished
+	failed []string
+	// coverage maps each package that reported coverage to its percentage
+	coverage map[string]float64
+	// results maps each test seen, as its package and name joined by a dot,
+	// to its last result: "pass", "fail" or "skip"
+	results map[string]string
+	// pending holds the results of tests in plain text output until the
+	// line ending their package's output says which package it is
+	pending []testResult
+	// passed lists the packages that passed, including those whose tests
+	// were only counted, without -v
+	passed []string
+	// cached lists the packages whose results came from the test cache
+	cached []string
+	// totalCoverage is the coverage across every package, once it is known
+	totalCoverage *float64
+}
+
+// testResult is the outcome of a single test as reported in test output.
+type testResult struct {
+	action string
+	// pkg is the test's package, which only -json output reports with it
+	pkg     string
+	name    string
+	elapsed float64
+}
+
+// testDuration records how long a single test took, in seconds.
+type testDuration struct {
+	Name    string
+	Elapsed float64
+}
+
+func newOutputParser() *outputParser {
+	return &outputParser{}
+}
+
+func (p *outputParser) parseLine(line string) {
+	if pkg, passed, ok := parsePackageResult(line); ok {
+		p.mu.Lock()
+		for _, result := range p.pending {
+			p.results[testKey(pkg, result.name)] = result.action
+		}
+		p.pending = nil
+		if passed && !slices.Contains(p.passed, pkg) {
+			p.passed = append(p.passed, pkg)
+		}
+		p.mu.Unlock()
+	}
+
+	if seed, ok := parseShuffleSeed(line); ok {
+		p.mu.Lock()
+		p.seeds = append(p.seeds, seed)
+		p.mu.Unlock()
+		return
+	}
+
+	// A cached package's trailer may also report its coverage
+	if pkg, ok := parseCachedPackage(line); ok {
+		p.mu.Lock()
+		if !slices.Contains(p.cached, pkg) {
+			p.cached = append(p.cached, pkg)
+		}
+		p.mu.Unlock()
+	}
+
+	if pkg, percent, ok := parseCoverage(line); ok {
+		p.mu.Lock()
+		if p.coverage == nil {
+			p.coverage = map[string]float64{}
+		}
+		p.coverage[pkg] = percent
+		p.mu.Unlock()
+		return
+	}
+
+	result, ok := parseTestResult(line)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if result.action != "skip" {
+		p.durations = append(p.durations, testDuration{Name: result.name, Elapsed: result.elapsed})
+	}
+	if p.results == nil {
+		p.results = map[string]string{}
+	}
+	if result.pkg != "" {
+		p.results[testKey(result.pkg, result.name)] = result.action
+	} else {
+		p.pending = append(p.pending, result)
+	}
+
+	switch result.action {
+	case "pass":
+		p.summary.Pass++
+	case "fail":
+		p.summary.Fail++
+		p.failed = append(p.failed, result.name)
+	case "skip":
+		p.summary.Skip++
+	}
+}
+
+// Summary returns the results collected so far, stamped with the elapsed
+// time and overall outcome of the run.
+func (p *outputParser) Summary(elapsed time.Duration, ok bool) RunSummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	summary := p.summary
+	summary.Elapsed = math.Round(elapsed.Seconds()*100) / 100
+	summary.OK = ok
+	summary.Coverage = p.totalCoverage
+	return summary
+}
+
+// SetTotalCoverage records the coverage across every package, read from the
+// run's coverage profile, for its summary.
+func (p *outputParser) SetTotalCoverage(percent float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.totalCoverage = &percent
+}
+
+// Slowest returns the n slowest tests seen so far, slowest first.
+func (p *outputParser) Slowest(n int) []testDuration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slowestTests(p.durations, n)
+}
+
+// Failed returns the names of the tests that failed so far.
+func (p *outputParser) Failed() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.failed...)
+}
+
+// Durations returns how long each test seen so far took, in the order they
+// finished.
+func (p *outputParser) Durations() []testDuration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.durations)
+}
+
+// Results returns the last result seen for each test, keyed by its package
+// and name joined by a dot. Tests whose package never finished, such as in
+// a run that was interrupted, are keyed by name alone.
+func (p *outputParser) Results() map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	results := maps.Clone(p.results)
+	for _, result := range p.pending {
+		if results == nil {
+			results = map[string]string{}
+		}
+		results[result.name] = result.action
+	}
+	return results
+}
+
+// Passed returns the packages that passed so far.
+func (p *outputParser) Passed() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.passed)
+}
+
+// testKey returns the key a test's results are recorded under, e.g.
+// "example.com/app/store.TestGet", so that tests with the same name in
+// different packages are told apart.
+func testKey(pkg, name string) string {
+	return pkg + "." + name
+}
+
+// Coverage returns the statement coverage percentage reported for each
+// package so far.
+func (p *outputParser) Coverage() map[string]float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return maps.Clone(p.coverage)
+}
+
+// Cached returns the packages whose results came from the test cache so
+// far.
+func (p *outputParser) Cached() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.cached)
+}
+
+// Seeds returns the -shuffle seeds seen so far, without duplicates.
+func (p *outputParser) Seeds() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var seeds []string
+	for _, seed := range p.seeds {
+		if !slices.Contains(seeds, seed) {
+			seeds = append(seeds, seed)
+		}
+	}
+	return seeds
+}
+
+// formatSeeds renders seeds as a single report line telling the user how to
+// reproduce the ordering of a shuffled run, e.g.
+// "Shuffle seed: 1700000000 (run `shuffle 1700000000` to reproduce)".
+func formatSeeds(seeds []string) string {
+	if len(seeds) == 1 {
+		return fmt.Sprintf("Shuffle seed: %s (run `shuffle %s` to reproduce)", seeds[0], seeds[0])
+	}
+	return fmt.Sprintf("Shuffle seeds: %s (run `shuffle <seed>` to reproduce)", strings.Join(seeds, ", "))
+}
+
+// parseShuffleSeed reports whether line is the "-test.shuffle <seed>" line a
+// test binary prints when run with -shuffle and, if so, returns the seed.
+func parseShuffleSeed(line string) (string, bool) {
+	if event, ok := decodeTestEvent(line); ok {
+		if event.Action != "output" {
+			return "", false
+		}
+		line = event.Output
+	}
+
+	seed, ok := strings.CutPrefix(strings.TrimSpace(line), "-test.shuffle ")
+	if !ok {
+		return "", false
+	}
+	if _, err := strconv.ParseInt(seed, 10, 64); err != nil {
+		return "", false
+	}
+	return seed, true
+}
+
+// coverageTrailer matches the line `go test -cover` prints when a package
+// passes, e.g. "ok  	pkg	0.01s	coverage: 80.0% of statements".
+var coverageTrailer = regexp.MustCompile(`^ok\s+(\S+)\s.*coverage: ([\d.]+)% of statements`)
+
+// parseCoverage reports whether line is the line that ends a package's
+// output with its coverage and, if so, returns the package and percentage.
+func parseCoverage(line string) (string, float64, bool) {
+	if event, ok := decodeTestEvent(line); ok {
+		if event.Action != "output" {
+			return "", 0, false
+		}
+		line = event.Output
+	}
+
+	m := coverageTrailer.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", 0, false
+	}
+	percent, err := strconv.ParseFloat(m[2], 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return m[1], percent, true
+}
+
+// parsePackageResult reports whether line reports the result of a whole
+// package and, if so, returns the package and whether it passed.
+func parsePackageResult(line string) (string, bool, bool) {
+	if event, ok := decodeTestEvent(line); ok {
+		if event.Test != "" || event.Package == "" {
+			return "", false, false
+		}
+		switch event.Action {
+		case "pass", "fail":
+			return event.Package, event.Action == "pass", true
+		}
+		return "", false, false
+	}
+
+	m := packageTrailer.FindStringSubmatch(line)
+	if m == nil {
+		return "", false, false
+	}
+	return m[2], m[1] == "ok", true
+}
+
+// cachedTrailer matches the line `go test` prints for a package whose
+// result came from the test cache, e.g. "ok  	pkg	(cached)".
+var cachedTrailer = regexp.MustCompile(`^ok\s+(\S+)\s+\(cached\)`)
+
+// parseCachedPackage reports whether line is the line that ends the output
+// of a package whose result was cached and, if so, returns the package.
+func parseCachedPackage(line string) (string, bool) {
+	if event, ok := decodeTestEvent(line); ok {
+		if event.Action != "output" {
+			return "", false
+		}
+		line = event.Output
+	}
+
+	m := cachedTrailer.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// formatCached renders how many packages' results came from the test cache,
+// e.g. " (3 packages cached)", or "" if none did.
+func formatCached(cached []string) string {
+	switch len(cached) {
+	case 0:
+		return ""
+	case 1:
+		return " (1 package cached)"
+	}
+	return fmt.Sprintf(" (%d packages cached)", len(cached))
+}
+
+// formatSlowest renders durations as a single report line, e.g.
+// "Slowest: TestFoo 2.10s, TestBar 1.80s".
+func formatSlowest(durations []testDuration) string {
+	parts := make([]string, 0, len(durations))
+	for _, d := range durations {
+		parts = append(parts, fmt.Sprintf("%s %.2fs", d.Name, d.Elapsed))
+	}
+	return "Slowest: " + strings.Join(parts, ", ")
+}
+
+// parseTestResult reports whether line records the result of a single test
+// and, if so, which test it was, whether it passed, failed or was skipped,
+// and how long it took.
+func parseTestResult(line string) (testResult, bool) {
+	if event, ok := decodeTestEvent(line); ok {
+		if event.Test == "" {
+			return testResult{}, false
+		}
+		switch event.Action {
+		case "pass", "fail", "skip":
+			return testResult{action: event.Action, pkg: event.Package, name: event.Test, elapsed: event.Elapsed}, true
+		}
+		return testResult{}, false
+	}
+
+	trimmed := strings.TrimSpace(line)
+	var result testResult
+	switch {
+	case strings.HasPrefix(trimmed, "--- PASS: "):
+		result.action = "pass"
+	case strings.HasPrefix(trimmed, "--- FAIL: "):
+		result.action = "fail"
+	case strings.HasPrefix(trimmed, "--- SKIP: "):
+		result.action = "skip"
+	default:
+		return testResult{}, false
+	}
+
+	// "--- PASS: TestFoo (0.12s)"
+	rest := trimmed[len("--- PASS: "):]
+	name, duration, _ := strings.Cut(rest, " ")
+	result.name = name
+	duration = strings.TrimSuffix(strings.TrimPrefix(duration, "("), ")")
+	if d, err := time.ParseDuration(duration); err == nil {
+		result.elapsed = d.Seconds()
+	}
+	return result, true
+}
+
+// formatSummaryLine renders summary as the line shown after a run, e.g.
+// "✓ 128 passed ✗ 3 failed ⊘ 2 skipped in 4.2s". A run that failed without
+// any test failing, such as one that didn't build, is marked as failed.
+func formatSummaryLine(summary RunSummary) string {
+	line := fmt.Sprintf("✓ %d passed ✗ %d failed ⊘ %d skipped in %.1fs",
+		summary.Pass, summary.Fail, summary.Skip, summary.Elapsed)
+	if !summary.OK && summary.Fail == 0 {
+		line += " (run failed)"
+	}
+	return line
+}
+
+// String renders the summary as a single line of compact JSON.
+func (s RunSummary) String() string {
+	out, err := json.Marshal(s)
+	if err != nil {
+		return "{}"
+	}
+	return string(out)
+}
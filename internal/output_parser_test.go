@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOutputParser_CountsVerboseResults tests that plain `go test -v` result lines are tallied
+func TestOutputParser_CountsVerboseResults(t *testing.T) {
+	parser := newOutputParser()
+	lines := []string{
+		"=== RUN   TestA",
+		"--- PASS: TestA (0.00s)",
+		"=== RUN   TestB",
+		"    --- PASS: TestB/sub (0.00s)",
+		"--- FAIL: TestB (0.01s)",
+		"--- SKIP: TestC (0.00s)",
+		"FAIL",
+		"FAIL\texample\t0.02s",
+	}
+	for _, line := range lines {
+		parser.parseLine(line)
+	}
+
+	summary := parser.Summary(1234*time.Millisecond, false)
+
+	assert.Equal(t, RunSummary{Pass: 2, Fail: 1, Skip: 1, Elapsed: 1.23, OK: false}, summary)
+}
+
+// TestOutputParser_KeysResultsByPackage tests that tests with the same name in different packages are told apart
+func TestOutputParser_KeysResultsByPackage(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		parser := newOutputParser()
+		for _, line := range []string{
+			`{"Action":"fail","Package":"example/a","Test":"TestGet","Elapsed":0.01}`,
+			`{"Action":"pass","Package":"example/b","Test":"TestGet","Elapsed":0.01}`,
+			`{"Action":"fail","Package":"example/a","Elapsed":0.02}`,
+			`{"Action":"pass","Package":"example/b","Elapsed":0.02}`,
+		} {
+			parser.parseLine(line)
+		}
+
+		assert.Equal(t, map[string]string{"example/a.TestGet": "fail", "example/b.TestGet": "pass"}, parser.Results())
+		assert.Equal(t, []string{"example/b"}, parser.Passed())
+	})
+
+	t.Run("text", func(t *testing.T) {
+		parser := newOutputParser()
+		for _, line := range []string{
+			"--- FAIL: TestGet (0.00s)",
+			"FAIL",
+			"FAIL\texample/a\t0.02s",
+			"--- PASS: TestGet (0.00s)",
+			"PASS",
+			"ok  \texample/b\t0.02s",
+			"ok  \texample/c\t0.01s",
+			"--- FAIL: TestUnfinished (0.00s)",
+		} {
+			parser.parseLine(line)
+		}
+
+		assert.Equal(t, map[string]string{
+			"example/a.TestGet": "fail",
+			"example/b.TestGet": "pass",
+			"TestUnfinished":    "fail",
+		}, parser.Results(), "tests whose package never finished are keyed by name")
+		assert.Equal(t, []string{"example/b", "example/c"}, parser.Passed())
+	})
+}
+
+// TestOutputParser_CountsJSONEvents tests that `go test -json` events are tallied
+func TestOutputParser_CountsJSONEvents(t *testing.T) {
+	parser := newOutputParser()
+	lines := []string{
+		`{"Action":"run","Package":"example","Test":"TestA"}`,
+		`{"Action":"pass","Package":"example","Test":"TestA","Elapsed":0.01}`,
+		`{"Action":"skip","Package":"example","Test":"TestB","Elapsed":0}`,
+		`{"Action":"output","Package":"example","Output":"--- PASS: TestA (0.01s)\n"}`,
+		`{"Action":"pass","Package":"example","Elapsed":0.02}`,
+	}
+	for _, line := range lines {
+		parser.parseLine(line)
+	}
+
+	summary := parser.Summary(0, true)
+
+	assert.Equal(t, 1, summary.Pass, "package-level pass events should not be counted as tests")
+	assert.Equal(t, 1, summary.Skip)
+	assert.Equal(t, 0, summary.Fail)
+}
+
+// TestOutputParser_IgnoresMalformedJSON tests that lines that look like JSON but aren't are ignored
+func TestOutputParser_IgnoresMalformedJSON(t *testing.T) {
+	parser := newOutputParser()
+	parser.parseLine(`{"Action":"pass"`)
+
+	assert.Equal(t, RunSummary{OK: true}, parser.Summary(0, true))
+}
+
+// TestRunSummary_String tests the compact JSON rendering of a summary
+func TestRunSummary_String(t *testing.T) {
+	summary := RunSummary{Pass: 10, Fail: 1, Skip: 0, Elapsed: 1.2, OK: false}
+
+	assert.Equal(t, `{"pass":10,"fail":1,"skip":0,"elapsed":1.2,"ok":false}`, summary.String())
+
+	var decoded RunSummary
+	require.NoError(t, json.Unmarshal([]byte(summary.String()), &decoded))
+	assert.Equal(t, summary, decoded)
+}
+
+// TestOutputParser_SlowestSortsByElapsed tests that the slowest tests are returned in descending order
+func TestOutputParser_SlowestSortsByElapsed(t *testing.T) {
+	parser := newOutputParser()
+	lines := []string{
+		"--- PASS: TestFast (0.01s)",
+		"--- PASS: TestFoo (2.10s)",
+		"--- FAIL: TestBar (1.80s)",
+		"--- SKIP: TestSkipped (5.00s)",
+		`{"Action":"pass","Package":"example","Test":"TestJSON","Elapsed":0.5}`,
+		"--- PASS: TestMedium (0.30s)",
+	}
+	for _, line := range lines {
+		parser.parseLine(line)
+	}
+
+	slowest := parser.Slowest(3)
+
+	assert.Equal(t, []testDuration{
+		{Name: "TestFoo", Elapsed: 2.1},
+		{Name: "TestBar", Elapsed: 1.8},
+		{Name: "TestJSON", Elapsed: 0.5},
+	}, slowest, "skipped tests should not be ranked")
+}
+
+// TestOutputParser_SlowestWithFewerTestsThanN tests that all tests are returned when fewer than n ran
+func TestOutputParser_SlowestWithFewerTestsThanN(t *testing.T) {
+	parser := newOutputParser()
+	parser.parseLine("--- PASS: TestOnly (0.20s)")
+
+	assert.Len(t, parser.Slowest(5), 1)
+}
+
+// TestFormatSlowest tests the rendering of the slowest tests report
+func TestFormatSlowest(t *testing.T) {
+	report := formatSlowest([]testDuration{
+		{Name: "TestFoo", Elapsed: 2.1},
+		{Name: "TestBar", Elapsed: 1.8},
+	})
+
+	assert.Equal(t, "Slowest: TestFoo 2.10s, TestBar 1.80s", report)
+}
+
+// TestOutputParser_CapturesShuffleSeeds tests that the seeds printed by shuffled test binaries are recorded
+func TestOutputParser_CapturesShuffleSeeds(t *testing.T) {
+	parser := newOutputParser()
+	parser.parseLine("-test.shuffle 1700000000000000001")
+	parser.parseLine(`{"Action":"output","Package":"example.com/b","Output":"-test.shuffle 42\n"}`)
+	parser.parseLine("-test.shuffle 1700000000000000001")
+	parser.parseLine("-test.shuffle on")
+	parser.parseLine("--- PASS: TestFoo (0.01s)")
+
+	assert.Equal(t, []string{"1700000000000000001", "42"}, parser.Seeds())
+	assert.Equal(t, 1, parser.Summary(0, true).Pass, "seed lines should not affect test results")
+}
+
+// TestOutputParser_CollectsFailedTestsAndCoverage tests collecting failed test names and coverage from plain and JSON output
+func TestOutputParser_CollectsFailedTestsAndCoverage(t *testing.T) {
+	parser := newOutputParser()
+	lines := []string{
+		"--- FAIL: TestA (0.00s)",
+		"    --- FAIL: TestB/sub (0.00s)",
+		"--- PASS: TestC (0.00s)",
+		"coverage: 80.0% of statements",
+		"ok  \texample.com/a\t0.01s\tcoverage: 80.0% of statements",
+		`{"Action":"fail","Package":"example.com/b","Test":"TestD"}`,
+		`{"Action":"output","Package":"example.com/b","Output":"ok  \texample.com/b\t(cached)\tcoverage: 12.5% of statements\n"}`,
+		"ok  \texample.com/c\t0.01s",
+	}
+	for _, line := range lines {
+		parser.parseLine(line)
+	}
+
+	assert.Equal(t, []string{"TestA", "TestB/sub", "TestD"}, parser.Failed())
+	assert.Equal(t, map[string]float64{"example.com/a": 80, "example.com/b": 12.5}, parser.Coverage())
+	assert.Equal(t, 1, parser.Summary(0, false).Pass, "coverage lines should not affect test results")
+}
+
+// TestOutputParser_CollectsCachedPackages tests collecting the packages whose results were cached from plain and JSON output
+func TestOutputParser_CollectsCachedPackages(t *testing.T) {
+	parser := newOutputParser()
+	lines := []string{
+		"ok  \texample.com/a\t(cached)",
+		"ok  \texample.com/b\t0.01s",
+		`{"Action":"output","Package":"example.com/c","Output":"ok  \texample.com/c\t(cached)\tcoverage: 12.5% of statements\n"}`,
+		`{"Action":"pass","Package":"example.com/c","Elapsed":0}`,
+		"ok  \texample.com/a\t(cached)",
+	}
+	for _, line := range lines {
+		parser.parseLine(line)
+	}
+
+	assert.Equal(t, []string{"example.com/a", "example.com/c"}, parser.Cached())
+	assert.Equal(t, map[string]float64{"example.com/c": 12.5}, parser.Coverage())
+}
+
+// TestFormatCached tests the annotation for cached packages
+func TestFormatCached(t *testing.T) {
+	assert.Empty(t, formatCached(nil))
+	assert.Equal(t, " (1 package cached)", formatCached([]string{"a"}))
+	assert.Equal(t, " (2 packages cached)", formatCached([]string{"a", "b"}))
+}
+
+// TestFormatSeeds tests the rendering of the shuffle seed report
+func TestFormatSeeds(t *testing.T) {
+	assert.Equal(t, "Shuffle seed: 42 (run `shuffle 42` to reproduce)", formatSeeds([]string{"42"}))
+	assert.Equal(t, "Shuffle seeds: 42, 7 (run `shuffle <seed>` to reproduce)", formatSeeds([]string{"42", "7"}))
+}
+
+// TestFormatSummaryLine tests the line shown after each run with --summary-line
+func TestFormatSummaryLine(t *testing.T) {
+	assert.Equal(t, "✓ 128 passed ✗ 3 failed ⊘ 2 skipped in 4.2s",
+		formatSummaryLine(RunSummary{Pass: 128, Fail: 3, Skip: 2, Elapsed: 4.23}))
+	assert.Equal(t, "✓ 0 passed ✗ 0 failed ⊘ 0 skipped in 0.5s (run failed)",
+		formatSummaryLine(RunSummary{Elapsed: 0.46}))
+	assert.Equal(t, "✓ 2 passed ✗ 0 failed ⊘ 0 skipped in 1.0s",
+		formatSummaryLine(RunSummary{Pass: 2, Elapsed: 1, OK: true}))
+}
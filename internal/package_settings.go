@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PackageSettings maps package paths, such as ./integration/..., to the
+// settings applied to runs that only test packages matching them. The
+// settings are named as in .gotest-watch.yml.
+type PackageSettings map[string]map[string]any
+
+func (ps PackageSettings) clone() PackageSettings {
+	if ps == nil {
+		return nil
+	}
+	cloned := make(PackageSettings, len(ps))
+	for path, settings := range ps {
+		cloned[path] = maps.Clone(settings)
+	}
+	return cloned
+}
+
+// applyPackageSettings applies to snapshot the settings for each of its
+// package paths that matches all of targets, the package paths or
+// directories being tested, in order of path, and records the paths whose
+// settings were applied.
+func applyPackageSettings(snapshot *TestConfig, targets []string) {
+	if len(snapshot.Packages) == 0 || len(targets) == 0 {
+		return
+	}
+
+	base := testDir(snapshot)
+	for _, pattern := range slices.Sorted(maps.Keys(snapshot.Packages)) {
+		matchesAll := !slices.ContainsFunc(targets, func(target string) bool {
+			return !matchPackagePattern(base, pattern, target)
+		})
+		if !matchesAll {
+			continue
+		}
+
+		data, err := yaml.Marshal(snapshot.Packages[pattern])
+		if err == nil {
+			err = yaml.Unmarshal(data, snapshot)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not apply the settings for %s: %v\n", pattern, err)
+			continue
+		}
+		if !slices.Contains(snapshot.packageSettings, pattern) {
+			snapshot.packageSettings = append(snapshot.packageSettings, pattern)
+		}
+	}
+}
+
+// matchPackagePattern reports whether every package in target, a package
+// path given to go test, also matches pattern. A pattern ending in /...
+// matches the packages under it as well. Paths that start with . are
+// resolved from base, so that ./internal and an absolute path to the same
+// directory match.
+func matchPackagePattern(base, pattern, target string) bool {
+	patternDir, patternAll := splitPackagePattern(base, pattern)
+	targetDir, targetAll := splitPackagePattern(base, target)
+	if !patternAll {
+		return !targetAll && targetDir == patternDir
+	}
+	return targetDir == patternDir || strings.HasPrefix(targetDir, patternDir+"/")
+}
+
+// splitPackagePattern returns the path a package pattern starts from, with
+// file system paths made absolute, and whether it ends in /... to match the
+// packages under it.
+func splitPackagePattern(base, pattern string) (string, bool) {
+	path, all := strings.CutSuffix(pattern, "...")
+	path = strings.TrimSuffix(path, "/")
+	if path == "" || strings.HasPrefix(path, ".") || filepath.IsAbs(path) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+		}
+		path = filepath.ToSlash(filepath.Clean(path))
+	}
+	return path, all
+}
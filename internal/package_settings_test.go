@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMatchPackagePattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		target   string
+		expected bool
+	}{
+		{"./integration/...", "./integration/...", true},
+		{"./integration/...", "./integration", true},
+		{"./integration/...", "./integration/db", true},
+		{"./integration/...", "/project/integration/db", true},
+		{"./integration/...", "./integrationtest", false},
+		{"./integration/...", "./...", false},
+		{"./integration", "./integration", true},
+		{"./integration", "integration/", false},
+		{"./integration", "./integration/...", false},
+		{"./integration", "./integration/db", false},
+		{"./...", "./internal", true},
+		{"github.com/example/lib/...", "github.com/example/lib/client", true},
+		{"github.com/example/lib/...", "./lib", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, matchPackagePattern("/project", tt.pattern, tt.target), "%s matching %s", tt.pattern, tt.target)
+	}
+}
+
+func TestApplyPackageSettings(t *testing.T) {
+	newConfig := func(t *testing.T) *TestConfig {
+		t.Helper()
+		config := NewTestConfig()
+		config.SetWorkingDir("/project")
+		require.NoError(t, yaml.Unmarshal([]byte(`
+packages:
+  ./integration/...:
+    tags: integration
+    timeout: 5m
+    env:
+      DB_URL: postgres://localhost/test
+  ./integration/slow:
+    timeout: 30m
+`), config))
+		return config
+	}
+
+	t.Run("applies the settings for paths matching every target", func(t *testing.T) {
+		config := newConfig(t)
+		config.SetEnvVar("DEBUG", "1")
+		snapshot := config.Snapshot()
+
+		applyPackageSettings(snapshot, []string{"./integration/slow"})
+
+		assert.Equal(t, "integration", snapshot.Tags)
+		assert.Equal(t, "30m", snapshot.Timeout, "later paths should override earlier ones")
+		assert.Equal(t, map[string]string{"DEBUG": "1", "DB_URL": "postgres://localhost/test"}, snapshot.Env)
+		assert.Equal(t, []string{"./integration/...", "./integration/slow"}, snapshot.packageSettings)
+		assert.Empty(t, config.GetTags(), "the shared config should not change")
+	})
+
+	t.Run("skips paths that don't match every target", func(t *testing.T) {
+		snapshot := newConfig(t).Snapshot()
+
+		applyPackageSettings(snapshot, []string{"./integration/db", "./internal"})
+
+		assert.Empty(t, snapshot.Tags)
+		assert.Empty(t, snapshot.packageSettings)
+	})
+}
+
+func TestRunContext_AppliesPackageSettingsForTestPath(t *testing.T) {
+	config := NewTestConfig()
+	config.Packages = PackageSettings{"./integration/...": {"tags": "integration"}}
+
+	runCtx := runContext(context.Background(), config, triggerForceRun)
+	assert.Empty(t, getConfig(runCtx).Tags)
+
+	config.SetTestPath("./integration/...")
+	runCtx = runContext(context.Background(), config, triggerForceRun)
+	assert.Equal(t, "integration", getConfig(runCtx).Tags)
+	assert.Contains(t, getConfig(runCtx).BuildCommand(), " -tags=integration")
+}
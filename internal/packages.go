@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// dependentsTemplate prints a package followed by everything its code and
+// tests import, so that packages depending on a changed package can be found.
+const dependentsTemplate = `{{.ImportPath}}{{range .Deps}} {{.}}{{end}}` +
+	`{{range .TestImports}} {{.}}{{end}}{{range .XTestImports}} {{.}}{{end}}`
+
+// changedPackages returns the import paths of the packages containing paths,
+// resolved with `go list` from workingDir. If dependents is set, packages
+// under testPath that import one of them are included as well.
+func changedPackages(workingDir string, paths []string, testPath string, dependents bool) ([]string, error) {
+	var dirs []string
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		if !filepath.IsAbs(dir) {
+			dir = "." + string(filepath.Separator) + dir
+		}
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+
+	packages, err := goList(workingDir, append([]string{"-find", "-f", "{{.ImportPath}}"}, dirs...))
+	if err != nil {
+		return nil, err
+	}
+	if !dependents {
+		return packages, nil
+	}
+
+	lines, err := goList(workingDir, append([]string{"-f", dependentsTemplate}, strings.Fields(testPath)...))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || slices.Contains(packages, fields[0]) {
+			continue
+		}
+		for _, imported := range fields[1:] {
+			if slices.Contains(packages, imported) {
+				packages = append(packages, fields[0])
+				break
+			}
+		}
+	}
+	return packages, nil
+}
+
+// packageDirs returns the directories of the packages with the import paths
+// given, resolved with `go list` from workingDir.
+func packageDirs(workingDir string, packages []string) ([]string, error) {
+	return goList(workingDir, append([]string{"-f", "{{.Dir}}"}, packages...))
+}
+
+// goList runs `go list` with args in workingDir and returns its non-empty
+// output lines.
+func goList(workingDir string, args []string) ([]string, error) {
+	//nolint:gosec // arguments are package paths, not shell input
+	cmd := exec.CommandContext(context.Background(), "go", append([]string{"list"}, args...)...)
+	cmd.Dir = workingDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w", err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
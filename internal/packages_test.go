@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDependentModule creates a module where package b imports package a,
+// and package c stands alone
+func setupDependentModule(t *testing.T) string {
+	t.Helper()
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"go.mod":      "module smartmodule\n\ngo 1.24\n",
+		"a/a.go":      "package a\n\nfunc A() int { return 1 }\n",
+		"b/b.go":      "package b\n\nimport \"smartmodule/a\"\n\nfunc B() int { return a.A() }\n",
+		"c/c.go":      "package c\n\nfunc C() int { return 3 }\n",
+		"c/c_test.go": "package c\n\nimport \"testing\"\n\nfunc TestC(t *testing.T) {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return tempDir
+}
+
+// TestChangedPackages_MapsFilesToPackages tests that changed files resolve to their packages
+func TestChangedPackages_MapsFilesToPackages(t *testing.T) {
+	tempDir := setupDependentModule(t)
+
+	packages, err := changedPackages(tempDir, []string{
+		filepath.Join(tempDir, "a", "a.go"),
+		filepath.Join("c", "c_test.go"),
+		filepath.Join(tempDir, "c", "c.go"),
+	}, "./...", false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"smartmodule/a", "smartmodule/c"}, packages)
+}
+
+// TestChangedPackages_IncludesDependents tests that packages importing a changed package are included when asked
+func TestChangedPackages_IncludesDependents(t *testing.T) {
+	tempDir := setupDependentModule(t)
+
+	packages, err := changedPackages(tempDir, []string{filepath.Join(tempDir, "a", "a.go")}, "./...", true)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"smartmodule/a", "smartmodule/b"}, packages)
+}
+
+// TestChangeRunContext_SmartModeNarrowsTestPath tests that smart mode runs only the changed packages
+func TestChangeRunContext_SmartModeNarrowsTestPath(t *testing.T) {
+	tempDir := setupDependentModule(t)
+
+	config := NewTestConfig()
+	config.SetWorkingDir(tempDir)
+	paths := []string{filepath.Join(tempDir, "c", "c.go")}
+
+	runCtx := changeRunContext(context.Background(), config, paths)
+	assert.Equal(t, "./...", getConfig(runCtx).TestPath, "test path should be unchanged outside smart mode")
+
+	config.SetSmartMode(true)
+	config.SetSince("main")
+	runCtx = changeRunContext(context.Background(), config, paths)
+	assert.Equal(t, "smartmodule/c", getConfig(runCtx).TestPath)
+	assert.Empty(t, getConfig(runCtx).Since, "the changed files should narrow the run instead of since")
+	assert.Equal(t, paths, getChangedFiles(runCtx))
+	assert.Equal(t, "./...", config.GetTestPath(), "shared config should not be changed")
+}
+
+// TestChangeRunContext_SmartModeAppliesSettingsOfTestedPackages tests that package settings match the packages smart mode tests, including dependents
+func TestChangeRunContext_SmartModeAppliesSettingsOfTestedPackages(t *testing.T) {
+	tempDir := setupDependentModule(t)
+
+	config := NewTestConfig()
+	config.SetWorkingDir(tempDir)
+	config.SetSmartMode(true)
+	config.SetSmartDependents(true)
+	config.Packages = PackageSettings{
+		"./a":   {"tags": "onlya"},
+		"./...": {"timeout": "5m"},
+	}
+
+	runCtx := changeRunContext(context.Background(), config, []string{filepath.Join(tempDir, "a", "a.go")})
+	snapshot := getConfig(runCtx)
+
+	assert.Equal(t, "smartmodule/a smartmodule/b", snapshot.TestPath)
+	assert.Equal(t, "5m", snapshot.Timeout)
+	assert.Empty(t, snapshot.Tags, "settings for a alone should not apply when its dependents are tested too")
+
+	config.SetSmartDependents(false)
+	runCtx = changeRunContext(context.Background(), config, []string{filepath.Join(tempDir, "a", "a.go")})
+	assert.Equal(t, "onlya", getConfig(runCtx).Tags)
+}
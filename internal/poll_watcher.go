@@ -0,0 +1,252 @@
+package internal
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fileState is what the poll watcher remembers about a file between scans.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// pollWatcher is a fileWatcher that scans the watched trees for changes to
+// Go files, and checks the config files for changes, every interval. It
+// works where fsnotify events never arrive, such as Docker bind mounts, NFS
+// and WSL paths, at the cost of latency and disk activity.
+type pollWatcher struct {
+	ignore         *ignoreMatcher
+	interval       time.Duration
+	followSymlinks bool
+	// configFiles are checked along with the Go files in the trees
+	configFiles []string
+
+	mu    sync.Mutex
+	roots []string
+
+	events    chan fsnotify.Event
+	errors    chan error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newPollWatcher returns a pollWatcher for the trees under roots, skipping
+// hidden directories and those ignored by ignore, and following symlinks to
+// directories if followSymlinks is set, and for configFiles, that has taken
+// its first scan.
+func newPollWatcher(
+	roots []string,
+	ignore *ignoreMatcher,
+	interval time.Duration,
+	followSymlinks bool,
+	configFiles []string,
+) (*pollWatcher, error) {
+	w := &pollWatcher{
+		roots:          slices.Clone(roots),
+		ignore:         ignore,
+		interval:       interval,
+		followSymlinks: followSymlinks,
+		configFiles:    configFiles,
+		events:         make(chan fsnotify.Event),
+		errors:         make(chan error),
+		done:           make(chan struct{}),
+	}
+
+	files, err := w.scan(w.roots)
+	if err != nil {
+		return nil, err
+	}
+	go w.run(w.roots, files)
+	return w, nil
+}
+
+func (w *pollWatcher) Events() <-chan fsnotify.Event {
+	return w.events
+}
+
+func (w *pollWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+// addDir adds dir to the scanned roots. Directories created inside a root
+// are found by the next scan without it.
+func (w *pollWatcher) addDir(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !slices.Contains(w.roots, dir) {
+		w.roots = append(w.roots, dir)
+	}
+	return nil
+}
+
+func (w *pollWatcher) removeDir(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roots = slices.DeleteFunc(w.roots, func(root string) bool { return root == dir })
+	return nil
+}
+
+func (w *pollWatcher) currentRoots() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.roots)
+}
+
+func (w *pollWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+	})
+	return nil
+}
+
+func (w *pollWatcher) run(roots []string, files map[string]fileState) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		currentRoots := w.currentRoots()
+		current, err := w.scan(currentRoots)
+		if err != nil {
+			select {
+			case w.errors <- err:
+			case <-w.done:
+				return
+			}
+			continue
+		}
+
+		for _, event := range diffFileStates(files, current) {
+			// Files that appear or disappear because their root was added
+			// or removed have not changed
+			if !slices.Contains(w.configFiles, event.Name) &&
+				(!withinAny(event.Name, roots) || !withinAny(event.Name, currentRoots)) {
+				continue
+			}
+			select {
+			case w.events <- event:
+			case <-w.done:
+				return
+			}
+		}
+		files = current
+		roots = currentRoots
+	}
+}
+
+// withinAny reports whether path is within any of dirs.
+func withinAny(path string, dirs []string) bool {
+	return slices.ContainsFunc(dirs, func(dir string) bool { return isWithin(path, dir) })
+}
+
+// scan records the state of every Go file in the trees under roots, and of
+// the config files that exist. Roots that no longer exist are skipped.
+func (w *pollWatcher) scan(roots []string) (map[string]fileState, error) {
+	files := map[string]fileState{}
+	var visited map[string]bool
+	if w.followSymlinks {
+		visited = map[string]bool{}
+	}
+	for i, root := range roots {
+		if err := w.scanRoot(root, files, visited); err != nil {
+			// Only the project root, which comes first, must exist
+			if i > 0 && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+	}
+	for _, path := range w.configFiles {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return files, nil
+}
+
+// scanRoot records the state of every Go file in the tree under root in
+// files. Symlinks are followed as in addWatchRecursive.
+func (w *pollWatcher) scanRoot(root string, files map[string]fileState, visited map[string]bool) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != root && errors.Is(err, fs.ErrNotExist) {
+				// Removed between listing its directory and visiting it
+				return nil
+			}
+			return err
+		}
+
+		if isSymlinkToDir(path, d, visited) {
+			if strings.HasPrefix(d.Name(), ".") || w.ignore.ignored(path, true) {
+				return nil
+			}
+			if err := w.scanRoot(path+string(filepath.Separator), files, visited); err != nil {
+				log.Print(err)
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if (path != root && strings.HasPrefix(d.Name(), ".")) || w.ignore.ignored(path, true) {
+				return filepath.SkipDir
+			}
+			if !markVisited(path, visited) {
+				return filepath.SkipDir
+			}
+			if err := w.ignore.loadGitignore(path); err != nil {
+				log.Print(err)
+			}
+			return nil
+		}
+
+		if !isGoFile(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// Removed between listing its directory and visiting it
+			return nil
+		}
+		files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+}
+
+// diffFileStates returns the events that turn the files in before into those
+// in after, ordered by path.
+func diffFileStates(before, after map[string]fileState) []fsnotify.Event {
+	var events []fsnotify.Event
+	for path, state := range after {
+		prev, ok := before[path]
+		switch {
+		case !ok:
+			events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Create})
+		case prev != state:
+			events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Write})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			events = append(events, fsnotify.Event{Name: path, Op: fsnotify.Remove})
+		}
+	}
+	slices.SortFunc(events, func(a, b fsnotify.Event) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return events
+}
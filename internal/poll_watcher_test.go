@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextEvent waits for the next event from w
+func nextEvent(t *testing.T, w fileWatcher) fsnotify.Event {
+	t.Helper()
+	select {
+	case event := <-w.Events():
+		return event
+	case err := <-w.Errors():
+		t.Fatalf("unexpected watcher error: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+	return fsnotify.Event{}
+}
+
+// TestPollWatcher_ReportsCreateWriteRemove tests that changes between scans are reported as events
+func TestPollWatcher_ReportsCreateWriteRemove(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "pkg", "main.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+
+	w, err := newPollWatcher([]string{tempDir}, nil, 10*time.Millisecond, false, nil)
+	require.NoError(t, err)
+	defer w.Close()
+
+	require.NoError(t, os.WriteFile(path, []byte("package main"), 0o600))
+	assert.Equal(t, fsnotify.Event{Name: path, Op: fsnotify.Create}, nextEvent(t, w))
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	assert.Equal(t, fsnotify.Event{Name: path, Op: fsnotify.Write}, nextEvent(t, w))
+
+	require.NoError(t, os.Remove(path))
+	assert.Equal(t, fsnotify.Event{Name: path, Op: fsnotify.Remove}, nextEvent(t, w))
+}
+
+// TestPollWatcher_ReportsConfigFileChanges tests that the config files are checked along with the Go files
+func TestPollWatcher_ReportsConfigFileChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, ".gotest-watch.yml")
+	reloader := &configReloader{dir: tempDir}
+
+	w, err := newPollWatcher([]string{tempDir}, nil, 10*time.Millisecond, false, reloader.configFiles())
+	require.NoError(t, err)
+	defer w.Close()
+
+	require.NoError(t, os.WriteFile(path, []byte("verbose: true\n"), 0o600))
+	assert.Equal(t, fsnotify.Event{Name: path, Op: fsnotify.Create}, nextEvent(t, w))
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	assert.Equal(t, fsnotify.Event{Name: path, Op: fsnotify.Write}, nextEvent(t, w))
+}
+
+// TestPollWatcher_SkipsHiddenAndIgnoredPaths tests that only watched Go files are scanned
+func TestPollWatcher_SkipsHiddenAndIgnoredPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, dir := range []string{".git", "vendor", "pkg"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, dir), 0o750))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("vendor/\n"), 0o600))
+	for _, file := range []string{".git/hook.go", "vendor/lib.go", "pkg/notes.txt", "pkg/pkg.go"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, file), []byte("package x"), 0o600))
+	}
+
+	w := &pollWatcher{ignore: newIgnoreMatcher(tempDir, nil)}
+	files, err := w.scan([]string{tempDir})
+	require.NoError(t, err)
+
+	assert.Len(t, files, 1)
+	assert.Contains(t, files, filepath.Join(tempDir, "pkg", "pkg.go"))
+}
+
+// TestPollWatcher_AddAndRemoveRoots tests that adding or removing a root does not report its files as changed
+func TestPollWatcher_AddAndRemoveRoots(t *testing.T) {
+	root := t.TempDir()
+	extra := t.TempDir()
+	extraFile := filepath.Join(extra, "lib.go")
+	require.NoError(t, os.WriteFile(extraFile, []byte("package lib"), 0o600))
+
+	w, err := newPollWatcher([]string{root}, nil, 10*time.Millisecond, false, nil)
+	require.NoError(t, err)
+	defer w.Close()
+
+	require.NoError(t, w.addDir(extra))
+	select {
+	case event := <-w.Events():
+		t.Fatalf("adding a root should not report its files, got %v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(extraFile, later, later))
+	assert.Equal(t, fsnotify.Event{Name: extraFile, Op: fsnotify.Write}, nextEvent(t, w))
+
+	require.NoError(t, w.removeDir(extra))
+	select {
+	case event := <-w.Events():
+		t.Fatalf("removing a root should not report its files, got %v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestPollWatcher_FollowsSymlinks tests that files in symlinked directories are scanned once when following symlinks
+func TestPollWatcher_FollowsSymlinks(t *testing.T) {
+	tempDir := t.TempDir()
+	shared := filepath.Join(t.TempDir(), "shared")
+	require.NoError(t, os.MkdirAll(shared, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(shared, "lib.go"), []byte("package shared"), 0o600))
+	require.NoError(t, os.Symlink(shared, filepath.Join(tempDir, "shared")))
+	require.NoError(t, os.Symlink(tempDir, filepath.Join(shared, "project")))
+
+	w := &pollWatcher{}
+	files, err := w.scan([]string{tempDir})
+	require.NoError(t, err)
+	assert.Empty(t, files, "should not follow symlinks by default")
+
+	w.followSymlinks = true
+	files, err = w.scan([]string{tempDir})
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Contains(t, files, filepath.Join(tempDir, "shared", "lib.go"))
+}
+
+// TestDiffFileStates tests that events are derived from two scans in path order
+func TestDiffFileStates(t *testing.T) {
+	now := time.Now()
+	before := map[string]fileState{
+		"/a.go": {modTime: now, size: 1},
+		"/b.go": {modTime: now, size: 1},
+		"/c.go": {modTime: now, size: 1},
+	}
+	after := map[string]fileState{
+		"/a.go": {modTime: now, size: 2},
+		"/c.go": {modTime: now, size: 1},
+		"/d.go": {modTime: now, size: 1},
+	}
+
+	assert.Equal(t, []fsnotify.Event{
+		{Name: "/a.go", Op: fsnotify.Write},
+		{Name: "/b.go", Op: fsnotify.Remove},
+		{Name: "/d.go", Op: fsnotify.Create},
+	}, diffFileStates(before, after))
+}
+
+// TestWatchFiles_PollDetectsChanges tests that the watcher can poll instead of using fsnotify
+func TestWatchFiles_PollDetectsChanges(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := NewTestConfig()
+	config.SetPoll("20ms")
+	ctx, cancel := context.WithTimeout(WithConfig(context.Background(), config), 2*time.Second)
+	defer cancel()
+
+	fileChangeChan := make(chan FileChangeMessage, 10)
+	startWatching := make(chan struct{})
+	close(startWatching)
+
+	go WatchFiles(ctx, tempDir, fileChangeChan, startWatching)
+	time.Sleep(50 * time.Millisecond)
+
+	path := filepath.Join(tempDir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main"), 0o600))
+
+	select {
+	case msg := <-fileChangeChan:
+		assert.Equal(t, []string{path}, msg.Paths)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for FileChangeMessage")
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !unix && !windows
+
+package internal
+
+import "os/exec"
+
+// killProcessGroupOnCancel is a no-op on platforms without process groups;
+// cancelling the command's context kills only the command itself, and
+// there is nothing to wait for afterwards.
+func killProcessGroupOnCancel(_ *exec.Cmd) (waitStopped func()) {
+	return func() {}
+}
@@ -0,0 +1,82 @@
+//go:build unix
+
+package internal
+
+import (
+	"errors"
+	"os/exec"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Grace periods for stopping an interrupted command's process group: how
+// long it has to exit after SIGINT before it is sent SIGTERM, after SIGTERM
+// before it is sent SIGKILL, and after SIGKILL before it is given up on.
+// Together they stay well within the time the dispatcher waits for a run to
+// finish when shutting down.
+const (
+	interruptGracePeriod = 2 * time.Second
+	terminateGracePeriod = 500 * time.Millisecond
+	killGracePeriod      = 500 * time.Millisecond
+)
+
+// processGroupPollInterval is how often a stopping process group is
+// checked for having exited.
+const processGroupPollInterval = 50 * time.Millisecond
+
+// killProcessGroupOnCancel starts cmd in its own process group and makes
+// cancelling its context stop the whole group, so that test binaries started
+// by `go test`, and anything they start in turn, do not outlive an
+// interrupted run. The group is interrupted first so that, for example, a
+// fuzzer can save its progress, then sent SIGTERM if it has not exited after
+// interruptGracePeriod, and SIGKILL if it still has not after
+// terminateGracePeriod. A group that exits on its own is sent nothing more.
+//
+// The returned function waits, once cmd has been waited for, until a group
+// that was stopped is gone, so that nothing is left running when the run
+// is reported finished or gotest-watch exits. Processes that linger after
+// SIGKILL, such as zombies nothing reaps, are waited for up to
+// killGracePeriod.
+func killProcessGroupOnCancel(cmd *exec.Cmd) (waitStopped func()) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var cancelled atomic.Bool
+	stopped := make(chan struct{})
+	cmd.Cancel = func() error {
+		cancelled.Store(true)
+		pgid := cmd.Process.Pid
+		go func() {
+			defer close(stopped)
+			if waitProcessGroup(pgid, interruptGracePeriod) {
+				return
+			}
+			_ = syscall.Kill(-pgid, syscall.SIGTERM)
+			if waitProcessGroup(pgid, terminateGracePeriod) {
+				return
+			}
+			_ = syscall.Kill(-pgid, syscall.SIGKILL)
+			waitProcessGroup(pgid, killGracePeriod)
+		}()
+		return syscall.Kill(-pgid, syscall.SIGINT)
+	}
+	return func() {
+		if cancelled.Load() {
+			<-stopped
+		}
+	}
+}
+
+// waitProcessGroup waits up to timeout for every process in the process
+// group pgid to exit, and reports whether they did.
+func waitProcessGroup(pgid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := syscall.Kill(-pgid, 0); errors.Is(err, syscall.ESRCH) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(processGroupPollInterval)
+	}
+}
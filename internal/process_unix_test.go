@@ -0,0 +1,48 @@
+//go:build unix
+
+package internal
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startStoppable starts argv with killProcessGroupOnCancel and cancels its
+// context once it is running, returning how long it took to stop
+func startStoppable(t *testing.T, argv ...string) time.Duration {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	waitStopped := killProcessGroupOnCancel(cmd)
+	require.NoError(t, cmd.Start())
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	cancel()
+	_ = cmd.Wait()
+	waitStopped()
+	return time.Since(start)
+}
+
+// TestKillProcessGroupOnCancel_StopsEscalatingOnceExited tests that a group that exits when interrupted is sent nothing stronger
+func TestKillProcessGroupOnCancel_StopsEscalatingOnceExited(t *testing.T) {
+	elapsed := startStoppable(t, "sleep", "30")
+
+	assert.Less(t, elapsed, interruptGracePeriod, "the group exited on its own")
+}
+
+// TestKillProcessGroupOnCancel_EscalatesWhenInterruptIsIgnored tests that a group ignoring SIGINT is terminated, well within the shutdown timeout
+func TestKillProcessGroupOnCancel_EscalatesWhenInterruptIsIgnored(t *testing.T) {
+	elapsed := startStoppable(t, "sh", "-c", `trap "" INT; exec sleep 30`)
+
+	assert.GreaterOrEqual(t, elapsed, interruptGracePeriod)
+	assert.Less(t, elapsed, interruptGracePeriod+terminateGracePeriod+killGracePeriod)
+	assert.Less(t, interruptGracePeriod+terminateGracePeriod+killGracePeriod, shutdownTimeout-time.Second)
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package internal
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+)
+
+// killProcessGroupOnCancel makes cancelling cmd's context kill its whole
+// process tree, so that test binaries started by `go test`, and anything
+// they start in turn, do not outlive an interrupted run. Windows has no
+// process groups to signal, and killing only cmd would leave its children
+// running, so the tree is killed with taskkill, which returns once it is
+// gone and leaves nothing to wait for afterwards.
+func killProcessGroupOnCancel(cmd *exec.Cmd) (waitStopped func()) {
+	cmd.Cancel = func() error {
+		pid := strconv.Itoa(cmd.Process.Pid)
+		if err := exec.CommandContext(context.Background(), "taskkill", "/T", "/F", "/PID", pid).Run(); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	return func() {}
+}
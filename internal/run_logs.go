@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// runLogDir is the directory, relative to the directory tests run in, that
+// the output of each run is saved to.
+var runLogDir = filepath.Join(".gotest-watch", "runs")
+
+// runLogSuffix ends the names of run logs, which are otherwise the time the
+// run started, so that they sort in the order the runs were made.
+const runLogSuffix = ".log"
+
+// openRunLog creates the file in dir that the raw output of a run of
+// command started at started is saved to, beginning with the command, and
+// removes the oldest logs so that at most keep remain. Writes to the
+// returned file are serialized so that lines streamed from stdout and
+// stderr are not interleaved.
+func openRunLog(dir, command string, started time.Time, keep int) (*outputFile, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	name := filepath.Join(dir, started.Format("20060102-150405.000")+runLogSuffix)
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(f, "$ %s\n", command); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if err := pruneRunLogs(dir, keep); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not remove old run logs: %v\n", err)
+	}
+	return &outputFile{file: f}, nil
+}
+
+// pruneRunLogs removes all but the newest keep run logs in dir.
+func pruneRunLogs(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var logs []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), runLogSuffix) {
+			logs = append(logs, entry.Name())
+		}
+	}
+	slices.Sort(logs)
+	for len(logs) > keep {
+		if err := os.Remove(filepath.Join(dir, logs[0])); err != nil {
+			return err
+		}
+		logs = logs[1:]
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenRunLog_KeepsNewestLogs tests that each run gets its own log and old logs are removed
+func TestOpenRunLog_KeepsNewestLogs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "runs")
+	started := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	for i := range 4 {
+		runLog, err := openRunLog(dir, "go test ./...", started.Add(time.Duration(i)*time.Minute), 2)
+		require.NoError(t, err)
+		_, err = runLog.Write([]byte("ok  \texample\t0.01s\n"))
+		require.NoError(t, err)
+		require.NoError(t, runLog.Close())
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"20261016-093200.000.log", "20261016-093300.000.log", "notes.txt"}, names)
+
+	data, err := os.ReadFile(filepath.Join(dir, "20261016-093300.000.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "$ go test ./...\nok  \texample\t0.01s\n", string(data))
+}
+
+// TestRunTests_SavesRunLog tests that a run's raw output is saved under the working directory
+func TestRunTests_SavesRunLog(t *testing.T) {
+	testContent := `package logs
+
+import "testing"
+
+func TestFails(t *testing.T) {
+	t.Fatal("intentional failure")
+}
+`
+	tempDir := setupTestModule(t, testContent)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetColor(true)
+	config.SetRunLogs(5)
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	logs, err := filepath.Glob(filepath.Join(tempDir, ".gotest-watch", "runs", "*.log"))
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	data, err := os.ReadFile(logs[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "$ go test .\n")
+	assert.Contains(t, string(data), "intentional failure")
+	assert.NotContains(t, string(data), "\x1b[", "the raw output should not be colorized")
+}
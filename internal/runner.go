@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultRunnerName is the runner used when none is configured.
+const defaultRunnerName = "go"
+
+// Runner customizes how a test run is executed, so that the command can be
+// transformed or its output post-processed without forking gotest-watch.
+type Runner interface {
+	// Command returns the program and arguments to execute for config.
+	Command(config *TestConfig) []string
+	// ProcessLine transforms a line of output before it is displayed.
+	ProcessLine(line string) string
+}
+
+// preparingRunner is a Runner with work to do before each run's command
+// starts, such as copying the project to where the command runs.
+type preparingRunner interface {
+	Runner
+	// Prepare readies a run with config, writing any output to out.
+	Prepare(ctx context.Context, config *TestConfig, out io.Writer) error
+}
+
+// stoppingRunner is a Runner whose command isn't stopped by interrupting
+// the process that started it, such as one run in a container, so that an
+// interrupted run has to be stopped where it runs.
+type stoppingRunner interface {
+	Runner
+	// Stop stops the command of an interrupted run with config.
+	Stop(config *TestConfig) error
+}
+
+// goTestRunner runs the configured command as it is, so that a command base
+// such as `richgo test` or `gotestsum --` executes its own program.
+type goTestRunner struct{}
+
+func (goTestRunner) Command(config *TestConfig) []string {
+	return strings.Fields(config.BuildCommand())
+}
+
+func (goTestRunner) ProcessLine(line string) string {
+	return line
+}
+
+var (
+	runnersMu sync.RWMutex
+	runners   = map[string]Runner{
+		defaultRunnerName: goTestRunner{},
+		dockerRunnerName:  dockerRunner{},
+	}
+)
+
+// RegisterRunner makes runner selectable by name with --runner.
+func RegisterRunner(name string, runner Runner) {
+	runnersMu.Lock()
+	defer runnersMu.Unlock()
+	runners[name] = runner
+}
+
+// lookupRunner returns the runner registered as name, or the default runner
+// if name is empty. A name of the form "ssh host:path" returns a runner for
+// that host.
+func lookupRunner(name string) (Runner, error) {
+	if name == "" {
+		name = defaultRunnerName
+	}
+	if target, ok := strings.CutPrefix(name, sshRunnerPrefix); ok {
+		return newSSHRunner(target)
+	}
+
+	runnersMu.RLock()
+	defer runnersMu.RUnlock()
+	runner, ok := runners[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown runner %q (available: %s, or ssh host:path)", name, strings.Join(runnerNames(), ", "))
+	}
+	return runner, nil
+}
+
+// runnerNames returns the names of all registered runners. Callers must hold
+// runnersMu.
+func runnerNames() []string {
+	names := make([]string, 0, len(runners))
+	for name := range runners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkExecutable returns an error unless name, the program a run executes,
+// can be found in PATH. Paths to programs are left for the run to check, as
+// they are resolved from its working directory.
+func checkExecutable(name string) error {
+	if strings.ContainsAny(name, `/\`) {
+		return nil
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("%s not found in PATH", name)
+	}
+	return nil
+}
+
+// absWorkingDir returns the absolute path of the directory tests run in.
+func absWorkingDir(config *TestConfig) string {
+	dir, err := filepath.Abs(config.GetWorkingDir())
+	if err != nil {
+		log.Println(err)
+		return "."
+	}
+	return dir
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRunner records how it was invoked and tags every line of output
+type fakeRunner struct {
+	mu       sync.Mutex
+	commands int
+	lines    int
+}
+
+func (r *fakeRunner) Command(_ *TestConfig) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands++
+	return []string{"go", "version"}
+}
+
+func (r *fakeRunner) ProcessLine(line string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines++
+	return "FAKE: " + line
+}
+
+// TestLookupRunner_DefaultsToGoRunner tests that an empty name selects the go runner
+func TestLookupRunner_DefaultsToGoRunner(t *testing.T) {
+	runner, err := lookupRunner("")
+
+	require.NoError(t, err)
+	assert.Equal(t, goTestRunner{}, runner)
+}
+
+// TestLookupRunner_UnknownRunnerErrors tests that an unregistered name is rejected
+func TestLookupRunner_UnknownRunnerErrors(t *testing.T) {
+	_, err := lookupRunner("does-not-exist")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown runner "does-not-exist"`)
+}
+
+// TestGoTestRunner_Command tests that the default runner executes the command base's own program
+func TestGoTestRunner_Command(t *testing.T) {
+	config := NewTestConfig()
+	config.SetCommandBase([]string{"richgo", "test"})
+	config.SetVerbose(true)
+
+	assert.Equal(t, []string{"richgo", "test", "./...", "-v"}, goTestRunner{}.Command(config))
+}
+
+// TestCheckExecutable tests that programs are looked up in PATH unless given as paths
+func TestCheckExecutable(t *testing.T) {
+	assert.NoError(t, checkExecutable("go"))
+	assert.NoError(t, checkExecutable("./scripts/test"), "paths are checked when the run starts")
+
+	err := checkExecutable("no-such-binary-for-gotest-watch")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no-such-binary-for-gotest-watch not found")
+}
+
+// TestRunTests_UsesRegisteredRunnerByName tests that a registered runner is selected and invoked by name
+func TestRunTests_UsesRegisteredRunnerByName(t *testing.T) {
+	fake := &fakeRunner{}
+	RegisterRunner("fake", fake)
+
+	config := NewTestConfig()
+	config.SetRunner("fake")
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdout bytes.Buffer
+	output := captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdout, &bytes.Buffer{})
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.Equal(t, 1, fake.commands, "runner should build the command")
+	assert.Positive(t, fake.lines, "runner should process output")
+	assert.Contains(t, output, "go version", "the runner's command should be displayed")
+	assert.True(t, strings.HasPrefix(stdout.String(), "FAKE: go version"), "output should be processed by the runner")
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package internal
+
+import (
+	"os"
+	"syscall"
+)
+
+// shutdownSignals are the signals that make gotest-watch shut down. On
+// Windows, os.Interrupt is delivered for Ctrl+C and Ctrl+Break, and
+// syscall.SIGTERM when the console is closed or the user logs off.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
@@ -57,7 +57,9 @@ func TestSetupSignalHandler_RespondsToSIGINT(t *testing.T) {
 	defer cancel()
 
 	// Send SIGINT to current process
-	err := syscall.Kill(os.Getpid(), syscall.SIGINT)
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	err = process.Signal(os.Interrupt)
 	require.NoError(t, err, "should be able to send SIGINT")
 
 	// Context should be cancelled shortly after signal
@@ -76,7 +78,9 @@ func TestSetupSignalHandler_RespondsToSIGTERM(t *testing.T) {
 	defer cancel()
 
 	// Send SIGTERM to current process
-	err := syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	err = process.Signal(syscall.SIGTERM)
 	require.NoError(t, err, "should be able to send SIGTERM")
 
 	// Context should be cancelled shortly after signal
@@ -88,6 +92,12 @@ func TestSetupSignalHandler_RespondsToSIGTERM(t *testing.T) {
 	}
 }
 
+// TestShutdownSignals tests that both Ctrl+C and requests to terminate shut gotest-watch down
+func TestShutdownSignals(t *testing.T) {
+	require.Contains(t, shutdownSignals, os.Interrupt)
+	require.Contains(t, shutdownSignals, os.Signal(syscall.SIGTERM))
+}
+
 // TestSetupSignalHandler_MultipleCallsIndependent tests that multiple setupSignalHandler calls are independent
 func TestSetupSignalHandler_MultipleCallsIndependent(t *testing.T) {
 	ctx1, cancel1 := setupSignalHandler()
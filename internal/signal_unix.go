@@ -0,0 +1,12 @@
+//go:build unix
+
+package internal
+
+import (
+	"os"
+	"syscall"
+)
+
+// shutdownSignals are the signals that make gotest-watch shut down: Ctrl+C,
+// a request to terminate, and the terminal being closed.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
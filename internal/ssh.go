@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// sshRunnerPrefix starts the runner setting that runs tests on another
+// machine, as in "ssh devbox:src/project".
+const sshRunnerPrefix = "ssh "
+
+// shellSafe matches arguments that need no quoting in a POSIX shell.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// sshRunner copies the project to path on host with rsync before each run,
+// then runs the configured command there over ssh, streaming its output
+// back.
+type sshRunner struct {
+	host string
+	path string
+}
+
+// newSSHRunner returns the runner for target, given as host:path.
+func newSSHRunner(target string) (sshRunner, error) {
+	host, path, ok := strings.Cut(strings.TrimSpace(target), ":")
+	if !ok || host == "" || path == "" {
+		return sshRunner{}, fmt.Errorf("the ssh runner needs a host:path to run tests in, got %q", target)
+	}
+	return sshRunner{host: host, path: path}, nil
+}
+
+// Prepare copies the project to the remote path, leaving out what git
+// ignores, and removes files that were deleted locally.
+func (r sshRunner) Prepare(ctx context.Context, config *TestConfig, out io.Writer) error {
+	//nolint:gosec // the host and path come from the user's own config
+	cmd := exec.CommandContext(ctx, "rsync", "-az", "--delete",
+		"--exclude=.git", "--exclude=.gotest-watch", "--filter=:- .gitignore",
+		absWorkingDir(config)+"/", r.host+":"+strings.TrimSuffix(r.path, "/")+"/")
+	waitStopped := killProcessGroupOnCancel(cmd)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	waitStopped()
+	if err != nil {
+		return fmt.Errorf("could not copy the project to %s:%s: %w", r.host, r.path, err)
+	}
+	return nil
+}
+
+// Command runs the test command on a remote terminal, which hangs it up when
+// ssh is stopped, so that interrupted runs don't keep running there. The
+// terminal is told to leave line endings alone, and merges the command's
+// stderr into its stdout. Only errors are logged, leaving out the
+// "Connection closed" message ssh prints for terminal sessions.
+func (r sshRunner) Command(config *TestConfig) []string {
+	words := []string{"stty", "-onlcr", "2>/dev/null;", "cd", remotePath(r.path), "&&"}
+	if env := formatEnv(config.GetEnv()); len(env) > 0 {
+		words = append(words, "env")
+		for _, pair := range env {
+			words = append(words, shellQuote(pair))
+		}
+	}
+	for _, arg := range strings.Fields(config.BuildCommand()) {
+		words = append(words, shellQuote(arg))
+	}
+	return []string{"ssh", "-tt", "-o", "LogLevel=ERROR", r.host, strings.Join(words, " ")}
+}
+
+func (sshRunner) ProcessLine(line string) string {
+	return line
+}
+
+// remotePath quotes path for the remote shell, leaving a leading ~/ for the
+// shell to expand to the home directory.
+func remotePath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if rest == "" {
+			return "~"
+		}
+		return "~/" + shellQuote(rest)
+	}
+	return shellQuote(path)
+}
+
+// shellQuote quotes s as a single word for a POSIX shell, if it needs it.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// installFakeCommands puts fake executables running the given scripts at
+// the front of PATH
+func installFakeCommands(t *testing.T, scripts map[string]string) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	for name, script := range scripts {
+		//nolint:gosec // test helper needs an executable script
+		err := os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"+script+"\n"), 0o700)
+		require.NoError(t, err)
+	}
+
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// TestLookupRunner_SSH tests that an ssh runner is made for the host and path given
+func TestLookupRunner_SSH(t *testing.T) {
+	runner, err := lookupRunner("ssh me@devbox:~/src/app")
+
+	require.NoError(t, err)
+	assert.Equal(t, sshRunner{host: "me@devbox", path: "~/src/app"}, runner)
+
+	for _, name := range []string{"ssh devbox", "ssh :src/app", "ssh devbox:"} {
+		_, err := lookupRunner(name)
+		assert.Error(t, err, name)
+	}
+}
+
+// TestSSHRunner_Command tests that the command runs in the remote path, quoted for the remote shell
+func TestSSHRunner_Command(t *testing.T) {
+	config := NewTestConfig()
+	config.SetRunPattern("TestA|TestB")
+	config.SetEnvVar("GOFLAGS", "-tags=integration slow")
+
+	argv := sshRunner{host: "devbox", path: "~/src/my app"}.Command(config)
+
+	assert.Equal(t, []string{
+		"ssh", "-tt", "-o", "LogLevel=ERROR", "devbox",
+		`stty -onlcr 2>/dev/null; cd ~/'src/my app' && env 'GOFLAGS=-tags=integration slow' go test ./... '-run=TestA|TestB'`,
+	}, argv)
+}
+
+// TestShellQuote tests that only words that need it are quoted
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "./...", shellQuote("./..."))
+	assert.Equal(t, "'-run=^Test$'", shellQuote("-run=^Test$"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
+
+// TestRunTests_SSHRunnerCopiesThenRunsRemotely tests that the project is copied before the command runs over ssh
+func TestRunTests_SSHRunnerCopiesThenRunsRemotely(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "calls")
+	installFakeCommands(t, map[string]string{
+		"rsync": `echo "rsync $*" >> ` + log,
+		"ssh":   `echo "ssh $*" >> ` + log + `; echo "ok  	remote/pkg	0.01s"`,
+	})
+
+	dir := t.TempDir()
+	config := NewTestConfig()
+	config.SetRunner("ssh devbox:/srv/app")
+	config.WorkingDir = dir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		select {
+		case result := <-testCompleteChan:
+			assert.True(t, result.Passed)
+		case <-time.After(30 * time.Second):
+			t.Fatal("TestCompleteMessage was not sent within timeout")
+		}
+	})
+
+	calls, err := os.ReadFile(log)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "rsync -az --delete"), lines[0])
+	assert.True(t, strings.HasSuffix(lines[0], dir+"/ devbox:/srv/app/"), lines[0])
+	assert.Equal(t, "ssh -tt -o LogLevel=ERROR devbox stty -onlcr 2>/dev/null; cd /srv/app && go test ./...", lines[1])
+	assert.Contains(t, stdoutBuf.String(), "remote/pkg")
+}
+
+// TestRunTests_SSHRunnerStopsWhenCopyFails tests that a failed copy fails the run without running the tests
+func TestRunTests_SSHRunnerStopsWhenCopyFails(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "calls")
+	installFakeCommands(t, map[string]string{
+		"rsync": `echo "connection refused" >&2; exit 255`,
+		"ssh":   `echo "ssh $*" >> ` + log,
+	})
+
+	config := NewTestConfig()
+	config.SetRunner("ssh devbox:/srv/app")
+	config.WorkingDir = t.TempDir()
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		select {
+		case result := <-testCompleteChan:
+			assert.False(t, result.Passed)
+			assert.Equal(t, 1, result.ExitCode)
+		case <-time.After(30 * time.Second):
+			t.Fatal("TestCompleteMessage was not sent within timeout")
+		}
+	})
+
+	assert.Contains(t, stderrBuf.String(), "connection refused")
+	assert.NoFileExists(t, log, "the tests should not run")
+}
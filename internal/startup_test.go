@@ -310,3 +310,39 @@ func TestWatchFiles_ContextCancellationWhileBlocked(t *testing.T) {
 		t.Fatal("watcher should exit when context is cancelled, even while blocked on startWatching")
 	}
 }
+
+// TestStartupContext_FirstRunSkipsCache tests that only the initial run is forced to -count=1
+func TestStartupContext_FirstRunSkipsCache(t *testing.T) {
+	config := NewTestConfig()
+	config.SetFirstRunSkipCache(true)
+	ctx := WithConfig(context.Background(), config)
+
+	startupCmd := getConfig(StartupContext(ctx)).BuildCommand()
+	fileChangeCmd := getConfig(runContext(ctx, config, triggerFileChange)).BuildCommand()
+	forceRunCmd := getConfig(runContext(ctx, config, triggerForceRun)).BuildCommand()
+
+	assert.Contains(t, startupCmd, "-count=1", "first run should bypass the test cache")
+	assert.NotContains(t, fileChangeCmd, "-count", "subsequent runs should use the cache")
+	assert.NotContains(t, forceRunCmd, "-count", "subsequent runs should use the cache")
+	assert.Equal(t, 0, config.GetCount(), "shared config should not be modified")
+}
+
+// TestStartupContext_KeepsExplicitCount tests that a user-set count is not overridden
+func TestStartupContext_KeepsExplicitCount(t *testing.T) {
+	config := NewTestConfig()
+	config.SetFirstRunSkipCache(true)
+	config.SetCount(3)
+
+	startupCmd := getConfig(StartupContext(WithConfig(context.Background(), config))).BuildCommand()
+
+	assert.Contains(t, startupCmd, "-count=3")
+}
+
+// TestStartupContext_DisabledByDefault tests that the first run is unchanged without the option
+func TestStartupContext_DisabledByDefault(t *testing.T) {
+	config := NewTestConfig()
+
+	startupCmd := getConfig(StartupContext(WithConfig(context.Background(), config))).BuildCommand()
+
+	assert.NotContains(t, startupCmd, "-count")
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// configSettings returns a "key: value" line for each setting in config,
+// named as in .gotest-watch.yml and in the order they are declared.
+func configSettings(config *TestConfig) []string {
+	snapshot := config.Snapshot()
+	v := reflect.ValueOf(snapshot).Elem()
+	t := v.Type()
+
+	var lines []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || key == "" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", key, formatSetting(v.Field(i))))
+	}
+	return lines
+}
+
+// formatSetting formats the value of a setting for display, showing empty
+// strings as "" so that they are visible.
+func formatSetting(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		if v.String() == "" {
+			return `""`
+		}
+		return v.String()
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range v.Len() {
+			items[i] = formatSetting(v.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Map:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+		pairs := make([]string, 0, len(keys))
+		for _, key := range keys {
+			value := v.MapIndex(key)
+			if value.Kind() == reflect.String {
+				pairs = append(pairs, key.String()+"="+value.String())
+			} else {
+				pairs = append(pairs, key.String()+"="+formatSetting(value))
+			}
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	case reflect.Interface:
+		if v.IsNil() {
+			return "null"
+		}
+		return formatSetting(v.Elem())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// statusCommand returns the command a forced run with config would execute.
+func statusCommand(config *TestConfig) (string, error) {
+	runner, err := lookupRunner(config.GetRunner())
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(runner.Command(config.Snapshot()), " "), nil
+}
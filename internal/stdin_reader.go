@@ -3,11 +3,21 @@ package internal
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"log"
 	"strings"
+	"time"
 )
 
+// pasteBurstWindow is the longest gap between two lines for them to be
+// considered part of the same pasted block.
+const pasteBurstWindow = 10 * time.Millisecond
+
+// commandSeparator separates several commands typed on one line, as in
+// `v; race; f`.
+const commandSeparator = ";"
+
 func parseCommand(input string) (Command, []string) {
 	input = strings.TrimSpace(input)
 	inputs := strings.Fields(input)
@@ -20,6 +30,30 @@ func parseCommand(input string) (Command, []string) {
 	return Command(inputs[0]), inputs[1:]
 }
 
+// parseCommands parses a line of one or more commands separated by
+// commandSeparator. A command named after one of macros, that is not a
+// built-in command, is replaced by the commands in the macro, which are not
+// themselves expanded.
+func parseCommands(line string, macros map[string]string) []CommandMessage {
+	var commands []CommandMessage
+	for _, part := range strings.Split(line, commandSeparator) {
+		cmd, args := parseCommand(part)
+		if cmd == Command("") {
+			continue
+		}
+		if macro, ok := macros[string(cmd)]; ok && commandRegistry[cmd] == nil {
+			for _, macroPart := range strings.Split(macro, commandSeparator) {
+				if cmd, args := parseCommand(macroPart); cmd != Command("") {
+					commands = append(commands, CommandMessage{Command: cmd, Args: args})
+				}
+			}
+			continue
+		}
+		commands = append(commands, CommandMessage{Command: cmd, Args: args})
+	}
+	return commands
+}
+
 // readStdin reads commands from stdin and sends them to the appropriate channels.
 // It runs continuously in a goroutine, and the dispatcher decides whether to
 // process or ignore commands based on whether tests are running.
@@ -29,9 +63,41 @@ func ReadStdin(
 	cmdChan chan CommandMessage,
 	helpChan chan HelpMessage,
 ) {
-	scanner := bufio.NewScanner(r)
+	readLines(ctx, bufio.NewScanner(r), cmdChan, helpChan)
+}
+
+// ReadTerminal reads commands like ReadStdin from r, a terminal that is not
+// echoing or buffering input, with the line editing, history and completion
+// of a lineEditor. What is typed is echoed to w.
+func ReadTerminal(
+	ctx context.Context,
+	r io.Reader,
+	w io.Writer,
+	cmdChan chan CommandMessage,
+	helpChan chan HelpMessage,
+) {
+	editor := newLineEditor(bufio.NewReader(r), w)
+	editor.complete = func(before string) (int, []string) {
+		return completeInput(getConfig(ctx), before)
+	}
+	editor.prompt = shownPrompt
+	readLines(ctx, editor, cmdChan, helpChan)
+}
 
-	for scanner.Scan() {
+// readLines sends each line from lines as a command until lines runs out or
+// the context is cancelled.
+func readLines(
+	ctx context.Context,
+	lines lineSource,
+	cmdChan chan CommandMessage,
+	helpChan chan HelpMessage,
+) {
+	if config := getConfig(ctx); config != nil && config.GetPasteGuard() {
+		readStdinGuarded(ctx, lines, cmdChan, helpChan, pasteBurstWindow)
+		return
+	}
+
+	for lines.Scan() {
 		// Check if context was cancelled
 		select {
 		case <-ctx.Done():
@@ -39,29 +105,117 @@ func ReadStdin(
 		default:
 		}
 
-		line := scanner.Text()
-		cmd, args := parseCommand(line)
-
-		if cmd == Command("") {
-			continue
+		if !sendLine(ctx, lines.Text(), cmdChan, helpChan) {
+			return
 		}
+	}
 
-		if cmd == HelpCmd {
+	if err := lines.Err(); err != nil {
+		log.Print(err)
+	}
+}
+
+// readStdinGuarded groups lines that arrive within window of each other into
+// bursts. A single line is dispatched once the window passes without another
+// line arriving. A multi-line burst is assumed to be pasted and is only
+// dispatched if it ends with a blank line; otherwise it is discarded so that
+// partial or garbled commands are never executed.
+func readStdinGuarded(
+	ctx context.Context,
+	scanner lineSource,
+	cmdChan chan CommandMessage,
+	helpChan chan HelpMessage,
+	window time.Duration,
+) {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
 			select {
-			case helpChan <- HelpMessage{}:
+			case lines <- scanner.Text():
 			case <-ctx.Done():
 				return
 			}
-		} else {
-			select {
-			case cmdChan <- CommandMessage{Command: cmd, Args: args}:
-			case <-ctx.Done():
+		}
+		if err := scanner.Err(); err != nil {
+			log.Print(err)
+		}
+	}()
+
+	var burst []string
+	timer := time.NewTimer(window)
+	timer.Stop()
+	defer timer.Stop()
+
+	flush := func() bool {
+		defer func() { burst = nil }()
+		if len(burst) > 1 && strings.TrimSpace(burst[len(burst)-1]) != "" {
+			fmt.Printf("\n(Ignored pasted input: %d lines)\n", len(burst))
+			return true
+		}
+		for _, line := range burst {
+			if !sendLine(ctx, line, cmdChan, helpChan) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				flush()
+				return
+			}
+			burst = append(burst, line)
+			timer.Reset(window)
+		case <-timer.C:
+			if !flush() {
 				return
 			}
 		}
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		log.Print(err)
+// sendLine parses a line of input and sends it to the matching channel. A
+// line of several commands is sent as one message, with the commands after
+// the first in its Then. It returns false if the context was cancelled
+// before the message was sent.
+func sendLine(
+	ctx context.Context,
+	line string,
+	cmdChan chan CommandMessage,
+	helpChan chan HelpMessage,
+) bool {
+	var macros map[string]string
+	if config := getConfig(ctx); config != nil {
+		macros = config.GetMacros()
+	}
+	commands := parseCommands(line, macros)
+
+	if len(commands) == 0 {
+		return true
+	}
+
+	if len(commands) == 1 && commands[0].Command == HelpCmd {
+		select {
+		case helpChan <- HelpMessage{}:
+		case <-ctx.Done():
+			return false
+		}
+	} else {
+		msg := commands[0]
+		if len(commands) > 1 {
+			msg.Then = commands[1:]
+		}
+		select {
+		case cmdChan <- msg:
+		case <-ctx.Done():
+			return false
+		}
 	}
+	return true
 }
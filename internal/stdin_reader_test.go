@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"bufio"
 	"context"
 	"io"
 	"strings"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestParseCommand tests the parseCommand helper function with various inputs
@@ -430,3 +432,168 @@ func TestReadStdin_ContextCancellation(t *testing.T) {
 		// Expected - goroutine stopped
 	}
 }
+
+// ============================================================================
+// Paste guard Tests
+// ============================================================================
+
+// TestReadStdinGuarded_IgnoresRapidMultiLinePaste tests that a pasted burst is not dispatched
+func TestReadStdinGuarded_IgnoresRapidMultiLinePaste(t *testing.T) {
+	commandChan := make(chan CommandMessage, 10)
+	helpChan := make(chan HelpMessage, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	output := captureStdout(t, func() {
+		scanner := bufio.NewScanner(strings.NewReader("r TestFoo\ncount 3\nclear\n"))
+		readStdinGuarded(ctx, scanner, commandChan, helpChan, 50*time.Millisecond)
+	})
+
+	assert.Empty(t, commandChan, "pasted lines should not be dispatched")
+	assert.Empty(t, helpChan)
+	assert.Contains(t, output, "Ignored pasted input: 3 lines")
+}
+
+// TestReadStdinGuarded_DispatchesPasteEndingWithBlankLine tests that a burst terminated by a blank line is dispatched
+func TestReadStdinGuarded_DispatchesPasteEndingWithBlankLine(t *testing.T) {
+	commandChan := make(chan CommandMessage, 10)
+	helpChan := make(chan HelpMessage, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scanner := bufio.NewScanner(strings.NewReader("r TestFoo\ncount 3\n\n"))
+	readStdinGuarded(ctx, scanner, commandChan, helpChan, 50*time.Millisecond)
+
+	require.Len(t, commandChan, 2)
+	assert.Equal(t, CommandMessage{Command: SetPatternCmd, Args: []string{"TestFoo"}}, <-commandChan)
+	assert.Equal(t, CommandMessage{Command: CountCmd, Args: []string{"3"}}, <-commandChan)
+}
+
+// TestReadStdinGuarded_DispatchesLinesSeparatedByPause tests that typed lines are dispatched individually
+func TestReadStdinGuarded_DispatchesLinesSeparatedByPause(t *testing.T) {
+	commandChan := make(chan CommandMessage, 10)
+	helpChan := make(chan HelpMessage, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, w := io.Pipe()
+	go readStdinGuarded(ctx, bufio.NewScanner(r), commandChan, helpChan, 20*time.Millisecond)
+
+	_, _ = w.Write([]byte("v\n"))
+	select {
+	case msg := <-commandChan:
+		assert.Equal(t, VerboseCmd, msg.Command)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for first command")
+	}
+
+	_, _ = w.Write([]byte("h\n"))
+	select {
+	case <-helpChan:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for help message")
+	}
+
+	_ = w.Close()
+}
+
+// TestReadStdin_UsesPasteGuardFromConfig tests that ReadStdin enables the guard when configured
+func TestReadStdin_UsesPasteGuardFromConfig(t *testing.T) {
+	config := NewTestConfig()
+	config.SetPasteGuard(true)
+
+	commandChan := make(chan CommandMessage, 10)
+	helpChan := make(chan HelpMessage, 10)
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	defer cancel()
+
+	captureStdout(t, func() {
+		ReadStdin(ctx, strings.NewReader("v\nf\n"), commandChan, helpChan)
+	})
+
+	assert.Empty(t, commandChan, "pasted lines should not be dispatched when the guard is on")
+}
+
+// TestReadTerminal_SendsEditedLines tests that ReadTerminal sends lines as
+// edited, including those recalled from the history
+func TestReadTerminal_SendsEditedLines(t *testing.T) {
+	commandChan := make(chan CommandMessage, 10)
+	helpChan := make(chan HelpMessage, 10)
+
+	input := strings.NewReader("r TestFoo\n\x1b[A\x17TestBar\nh\n")
+	ReadTerminal(context.Background(), input, io.Discard, commandChan, helpChan)
+
+	require.Len(t, commandChan, 2)
+	assert.Equal(t, CommandMessage{Command: SetPatternCmd, Args: []string{"TestFoo"}}, <-commandChan)
+	assert.Equal(t, CommandMessage{Command: SetPatternCmd, Args: []string{"TestBar"}}, <-commandChan)
+	assert.Len(t, helpChan, 1)
+}
+
+func TestParseCommands(t *testing.T) {
+	initRegistry()
+	macros := map[string]string{
+		"setup": "v; race ;r TestFoo",
+		"f":     "v",
+		"self":  "self; f",
+	}
+
+	tests := []struct {
+		name     string
+		line     string
+		expected []CommandMessage
+	}{
+		{"single command", "r TestFoo", []CommandMessage{{Command: SetPatternCmd, Args: []string{"TestFoo"}}}},
+		{"chained commands", "v; race;f", []CommandMessage{{Command: VerboseCmd}, {Command: RaceCmd}, {Command: ForceRunCmd}}},
+		{"empty commands are skipped", ";v;; ;f;", []CommandMessage{{Command: VerboseCmd}, {Command: ForceRunCmd}}},
+		{"blank line", "  ", nil},
+		{"macro", "setup", []CommandMessage{
+			{Command: VerboseCmd},
+			{Command: RaceCmd},
+			{Command: SetPatternCmd, Args: []string{"TestFoo"}},
+		}},
+		{"macro in a chain", "clear; setup; f", []CommandMessage{
+			{Command: ClearCmd},
+			{Command: VerboseCmd},
+			{Command: RaceCmd},
+			{Command: SetPatternCmd, Args: []string{"TestFoo"}},
+			{Command: ForceRunCmd},
+		}},
+		{"built-in commands are not overridden", "f", []CommandMessage{{Command: ForceRunCmd}}},
+		{"macros are not expanded within macros", "self", []CommandMessage{{Command: "self"}, {Command: ForceRunCmd}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseCommands(tt.line, macros))
+		})
+	}
+}
+
+// TestReadStdin_SendsChainedCommandsAsOneMessage tests that a line of several commands, or a macro, is sent as one message
+func TestReadStdin_SendsChainedCommandsAsOneMessage(t *testing.T) {
+	initRegistry()
+	config := NewTestConfig()
+	config.SetMacros(map[string]string{"fast": "count 1; f"})
+
+	commandChan := make(chan CommandMessage, 10)
+	helpChan := make(chan HelpMessage, 10)
+	ctx := WithConfig(context.Background(), config)
+
+	ReadStdin(ctx, strings.NewReader("v; h; f\nfast\nh\n"), commandChan, helpChan)
+
+	require.Len(t, commandChan, 2)
+	assert.Equal(t, CommandMessage{
+		Command: VerboseCmd,
+		Then:    []CommandMessage{{Command: HelpCmd}, {Command: ForceRunCmd}},
+	}, <-commandChan)
+	assert.Equal(t, CommandMessage{
+		Command: CountCmd,
+		Args:    []string{"1"},
+		Then:    []CommandMessage{{Command: ForceRunCmd}},
+	}, <-commandChan)
+	assert.Len(t, helpChan, 1, "help on its own line is sent as usual")
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// EnableTerminalInput switches the terminal on f to passing on keypresses as
+// they are typed, without echoing them, so that ReadTerminal can edit lines
+// and ReadKeys can act on single keys. It reports whether f is a terminal,
+// and returns a function that restores the terminal's previous settings,
+// which must be called before exiting. If single-key mode is configured but
+// f is not a terminal, a warning is written to w, as commands will be read
+// line by line.
+func EnableTerminalInput(f *os.File, w io.Writer, config *TestConfig) (func(), bool) {
+	restore, err := makeCbreak(int(f.Fd()))
+	if err != nil {
+		if config.GetSingleKey() {
+			fmt.Fprintf(w, "Warning: single-key mode is unavailable (%v); commands must be followed by Enter\n", err)
+		}
+		return func() {}, false
+	}
+	return func() {
+		if err := restore(); err != nil {
+			log.Println(err)
+		}
+	}, true
+}
+
+// EnableVirtualTerminal switches a Windows console f to interpreting escape
+// sequences, so that colors and clearing the screen work even when they are
+// forced on. It does nothing elsewhere, or if f is not a console.
+func EnableVirtualTerminal(f *os.File) {
+	if isTerminal(f) {
+		_ = enableVirtualTerminal(f)
+	}
+}
+
+// AdaptToOutput turns off colorization when the NO_COLOR environment
+// variable is set or out is not a terminal, and clearing the screen before
+// each run when out is not a terminal, so that output piped to a file or
+// another program is not cluttered with escape sequences. Windows consoles
+// are switched to interpreting escape sequences, and those too old to be are
+// treated like files.
+func AdaptToOutput(config *TestConfig, out *os.File) {
+	terminal := isTerminal(out) && enableVirtualTerminal(out) == nil
+	adaptToOutput(config, terminal, os.Getenv("NO_COLOR") != "")
+}
+
+func adaptToOutput(config *TestConfig, terminal, noColor bool) {
+	if !terminal || noColor {
+		config.SetColor(false)
+	}
+	if !terminal {
+		config.SetClearScreen(ClearNever)
+	}
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// openPty returns the terminal end of a new pseudo-terminal.
+func openPty(t *testing.T) *os.File {
+	t.Helper()
+
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals unavailable: %v", err)
+	}
+	t.Cleanup(func() { ptmx.Close() })
+
+	fd := int(ptmx.Fd())
+	require.NoError(t, unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0))
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	require.NoError(t, err)
+
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { tty.Close() })
+	return tty
+}
+
+func TestEnableTerminalInput_RestoresTerminal(t *testing.T) {
+	tty := openPty(t)
+	fd := int(tty.Fd())
+
+	before, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	require.NoError(t, err)
+	require.NotZero(t, before.Lflag&unix.ICANON)
+
+	var w bytes.Buffer
+	restore, isTerminal := EnableTerminalInput(tty, &w, NewTestConfig())
+	require.True(t, isTerminal)
+	assert.Empty(t, w.String())
+
+	during, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	require.NoError(t, err)
+	assert.Zero(t, during.Lflag&(unix.ICANON|unix.ECHO), "line buffering and echo are off")
+	assert.NotZero(t, during.Lflag&unix.ISIG, "Ctrl+C still interrupts")
+
+	restore()
+
+	after, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	require.NoError(t, err)
+	assert.Equal(t, *before, *after)
+}
@@ -0,0 +1,32 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package internal
+
+import (
+	"errors"
+	"os"
+)
+
+// isTerminal reports whether f is a character device, which is as close as
+// platforms without termios get to telling whether it is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalRows returns defaultTerminalRows, as the terminal's size can't be
+// read on platforms without termios.
+func terminalRows(_ *os.File) int {
+	return defaultTerminalRows
+}
+
+// enableVirtualTerminal does nothing, as terminals on platforms without
+// termios are assumed to interpret escape sequences.
+func enableVirtualTerminal(_ *os.File) error {
+	return nil
+}
+
+// makeCbreak is unsupported on platforms without termios.
+func makeCbreak(_ int) (func() error, error) {
+	return nil, errors.New("not supported on this platform")
+}
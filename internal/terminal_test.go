@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableTerminalInput(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdin"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	t.Run("reads lines when not a terminal", func(t *testing.T) {
+		var w bytes.Buffer
+		restore, isTerminal := EnableTerminalInput(f, &w, NewTestConfig())
+		defer restore()
+
+		assert.False(t, isTerminal)
+		assert.Empty(t, w.String())
+	})
+
+	t.Run("warns when single-key mode is unavailable", func(t *testing.T) {
+		config := NewTestConfig()
+		config.SetSingleKey(true)
+
+		var w bytes.Buffer
+		restore, isTerminal := EnableTerminalInput(f, &w, config)
+		defer restore()
+
+		assert.False(t, isTerminal)
+		assert.Contains(t, w.String(), "single-key mode is unavailable")
+	})
+}
+
+func TestTerminalRows_DefaultsWhenNotATerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	assert.Equal(t, defaultTerminalRows, terminalRows(f))
+}
+
+func TestAdaptToOutput(t *testing.T) {
+	newConfig := func() *TestConfig {
+		config := NewTestConfig()
+		config.SetColor(true)
+		config.SetClearScreen(ClearAlways)
+		return config
+	}
+
+	t.Run("keeps settings for a terminal", func(t *testing.T) {
+		config := newConfig()
+		adaptToOutput(config, true, false)
+		assert.True(t, config.GetColor())
+		assert.Equal(t, ClearAlways, config.GetClearScreen())
+	})
+
+	t.Run("turns off color when NO_COLOR is set", func(t *testing.T) {
+		config := newConfig()
+		adaptToOutput(config, true, true)
+		assert.False(t, config.GetColor())
+		assert.Equal(t, ClearAlways, config.GetClearScreen())
+	})
+
+	t.Run("turns off color and clearing when output is not a terminal", func(t *testing.T) {
+		f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+		require.NoError(t, err)
+		defer f.Close()
+
+		config := newConfig()
+		AdaptToOutput(config, f)
+		assert.False(t, config.GetColor())
+		assert.Equal(t, ClearNever, config.GetClearScreen())
+	})
+}
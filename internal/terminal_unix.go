@@ -0,0 +1,53 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package internal
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalRows returns the number of rows of the terminal f, or
+// defaultTerminalRows if it can't be read.
+func terminalRows(f *os.File) int {
+	ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Row == 0 {
+		return defaultTerminalRows
+	}
+	return int(ws.Row)
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetTermios(int(f.Fd()), ioctlGetTermios)
+	return err == nil
+}
+
+// enableVirtualTerminal does nothing, as Unix terminals interpret escape
+// sequences already.
+func enableVirtualTerminal(_ *os.File) error {
+	return nil
+}
+
+// makeCbreak turns off line buffering and echo on the terminal fd, leaving
+// output processing and signal keys such as Ctrl+C alone, and returns a
+// function that restores its previous settings.
+func makeCbreak(fd int) (func() error, error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+	saved := *termios
+
+	termios.Lflag &^= unix.ICANON | unix.ECHO
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, termios); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return unix.IoctlSetTermios(fd, ioctlSetTermios, &saved)
+	}, nil
+}
@@ -0,0 +1,66 @@
+//go:build windows
+
+package internal
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// terminalRows returns the number of rows of the console window f, or
+// defaultTerminalRows if it can't be read.
+func terminalRows(f *os.File) int {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(f.Fd()), &info); err != nil {
+		return defaultTerminalRows
+	}
+	rows := int(info.Window.Bottom-info.Window.Top) + 1
+	if rows <= 0 {
+		return defaultTerminalRows
+	}
+	return rows
+}
+
+// isTerminal reports whether f is a console.
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(f.Fd()), &mode) == nil
+}
+
+// enableVirtualTerminal has the console f interpret escape sequences, which
+// colorization and clearing the screen rely on. It fails on consoles that
+// predate Windows 10, which can't.
+func enableVirtualTerminal(f *os.File) error {
+	handle := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return err
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return nil
+	}
+	return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+}
+
+// makeCbreak turns off line buffering and echo on the console input fd,
+// leaving Ctrl+C to be handled as a signal, and has keys such as the arrows
+// sent as the escape sequences a Unix terminal would send. It returns a
+// function that restores the console's previous mode.
+func makeCbreak(fd int) (func() error, error) {
+	handle := windows.Handle(fd)
+	var saved uint32
+	if err := windows.GetConsoleMode(handle, &saved); err != nil {
+		return nil, err
+	}
+
+	mode := saved&^(windows.ENABLE_LINE_INPUT|windows.ENABLE_ECHO_INPUT) |
+		windows.ENABLE_PROCESSED_INPUT | windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(handle, mode); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return windows.SetConsoleMode(handle, saved)
+	}, nil
+}
@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,16 +18,156 @@ type TestConfig struct {
 	Race        bool     `yaml:"race"`
 	FailFast    bool     `yaml:"failfast"`
 	Count       int      `yaml:"count"`
-	ClearScreen bool     `yaml:"clearScreen"`
-	Cover       bool     `yaml:"cover"`
-	Color       bool     `yaml:"color"`
-	WorkingDir  string   `yaml:"workingDir"` // Optional: if set, tests will run in this directory
+	// Add -count=1 to bypass the test cache, unless count is set
+	NoCache bool   `yaml:"noCache"`
+	Timeout string `yaml:"timeout"`
+	// "on", or a seed to reproduce a previous ordering
+	Shuffle  string `yaml:"shuffle"`
+	Parallel int    `yaml:"parallel"`
+	CPU      string `yaml:"cpu"`
+	// Build tags passed to go test with -tags
+	Tags string `yaml:"tags"`
+	// Arguments passed to the test binary after -args
+	TestArgs    []string  `yaml:"testArgs"`
+	ClearScreen ClearMode `yaml:"clearScreen"`
+	// Scroll earlier runs into the scrollback rather than erasing them when clearing the screen
+	KeepScrollback bool `yaml:"keepScrollback"`
+	Cover          bool `yaml:"cover"`
+	// Packages -coverpkg measures the coverage of, e.g. ./internal/...
+	CoverPkg string `yaml:"coverPkg"`
+	Short    bool   `yaml:"short"`
+	Color    bool   `yaml:"color"`
+	// Built-in color theme: default, colorblind or monochrome
+	ColorTheme string `yaml:"colorTheme"`
+	// Colors for pass, fail, skip, location and text lines, replacing the theme's
+	Colors     map[string]string `yaml:"colors"`
+	LinePrefix string            `yaml:"linePrefix"`
+	// Leave out the command echo, prompts and status messages
+	Quiet      bool `yaml:"quiet"`
+	AltScreen  bool `yaml:"altScreen"`
+	AutoShort  bool `yaml:"autoSkipLongTests"`
+	PasteGuard bool `yaml:"pasteGuard"`
+	// Act on single keypresses without waiting for Enter
+	SingleKey bool `yaml:"singleKey"`
+	// Never read commands or show the prompt
+	NoInput         bool `yaml:"noInput"`
+	GitRootRelative bool `yaml:"testPathRelativeToGitRoot"`
+	SummaryJSON     bool `yaml:"summaryJSON"`
+	// Number of slowest tests to report after each run
+	ProfileSummary int `yaml:"profileSummary"`
+	// Size in bytes below which created/written files are ignored
+	WatchMinFileSize  int  `yaml:"watchMinFileSize"`
+	FirstRunSkipCache bool `yaml:"firstRunSkipCache"`
+	LiteralPatterns   bool `yaml:"literalPatterns"`
+	// Seconds without progress during a run before goroutines are dumped to the log
+	DeadlockTimeout int    `yaml:"deadlockTimeout"`
+	PromptPending   bool   `yaml:"promptShowsPendingChanges"`
+	Runner          string `yaml:"runner"`
+	ExitOnFirstPass bool   `yaml:"exitOnFirstPass"`
+	// Run the tests once, end with a summary line and exit with their exit code
+	CI bool `yaml:"ci"`
+	// Milliseconds the watched tree must be quiet before a run starts
+	WatchQuietPeriod int `yaml:"watchQuietPeriod"`
+	// List the files that triggered a run after it finishes
+	ReportChangedFiles bool `yaml:"reportChangedFilesInSummary"`
+	// Optional: if set, test output is also appended to this file
+	OutputFile string `yaml:"outputFile"`
+	// Save the output of each run under .gotest-watch/runs, keeping this many runs
+	RunLogs int `yaml:"runLogs"`
+	// Run with -json and summarize the results of each package
+	StructuredSummary bool `yaml:"structuredSummary"`
+	// Print a count of passed, failed and skipped tests after each run
+	SummaryLine bool `yaml:"summaryLine"`
+	// Optional: warn when a run takes longer than this duration (e.g. 30s)
+	RunBudget string `yaml:"runBudget"`
+	// Only show the output of failing tests and packages, and a count of the results
+	QuietPass bool `yaml:"quietPass"`
+	// Show passing packages as their ok line; show <pkg> expands them
+	CollapsePassing bool `yaml:"collapsePassing"`
+	// With -v, show each package's output under a header once it finishes
+	GroupByPackage bool `yaml:"groupByPackage"`
+	// Send a desktop notification when each run finishes
+	Notify bool `yaml:"notify"`
+	// Optional: if set, each run's result is posted to this URL as JSON
+	WebhookURL string `yaml:"webhookURL"`
+	// Optional: if set, a JUnit XML report of each run is written to this file or directory
+	JUnitPath string `yaml:"junit"`
+	// Optional: if set, each run's coverage profile is written to this file with -coverprofile
+	CoverageFile string `yaml:"coverageFile"`
+	// Optional: if set, failures are written as file:line: message to this file, or stderr
+	FailureLocations string `yaml:"failureLocations"`
+	// Optional: if set, commands are also read from this unix socket
+	ControlSocket string `yaml:"controlSocket"`
+	// Optional: if set, a JSON status endpoint is served on this address
+	HTTPAddr string `yaml:"httpAddr"`
+	// Optional: if set, runs only test the packages changed since this git ref and those importing them
+	Since string `yaml:"since"`
+	// Optional: if set, the docker runner runs tests in this docker compose service
+	DockerService string `yaml:"dockerService"`
+	// Optional: the image the docker runner runs tests in without a service (default: golang)
+	DockerImage string `yaml:"dockerImage"`
+	// Optional: the project's directory in the container (default: /src with docker run)
+	DockerWorkdir string `yaml:"dockerWorkdir"`
+	// Optional: what colors test output: internal (default), richgo, gotest, or auto to use richgo when installed
+	ColorBackend string `yaml:"colorBackend"`
+	// Optional: Slack or Discord webhooks to post the results of runs to
+	Notifications []Notification `yaml:"notifications"`
+	// Only test the packages containing changed files
+	SmartMode bool `yaml:"smartMode"`
+	// In smart mode, also test packages that import the changed packages
+	SmartDependents bool `yaml:"smartIncludeDependents"`
+	// Extra environment variables set for each test run
+	Env map[string]string `yaml:"env"`
+	// Named commands that run a list of commands separated by ;
+	Macros map[string]string `yaml:"macros"`
+	// How long the fuzz command fuzzes for; empty fuzzes until interrupted
+	FuzzTime string `yaml:"fuzzTime"`
+	// Run go vet on the test path before each run
+	Vet bool `yaml:"vet"`
+	// Skip the test run when go vet reports problems
+	VetSkipsTests bool `yaml:"vetFailureSkipsTests"`
+	// Globs of paths that are neither watched nor trigger runs
+	Ignore []string `yaml:"ignore"`
+	// How often to scan for changes instead of using fsnotify; empty uses fsnotify
+	Poll string `yaml:"poll"`
+	// Directories that are never watched, by name or path relative to the watched root
+	Exclude []string `yaml:"exclude"`
+	// Directories watched alongside the project root, relative to it unless absolute
+	WatchDirs []string `yaml:"watchDirs"`
+	// Watch directories reached through symlinks
+	FollowSymlinks bool `yaml:"followSymlinks"`
+	// Settings applied to runs that only test packages matching each path
+	Packages   PackageSettings `yaml:"packages"`
+	WorkingDir string          `yaml:"workingDir"` // Optional: if set, tests will run in this directory
+
+	// runPatternStack holds the run patterns saved by PushRunPattern
+	runPatternStack []string
+	// coverProfile, if set, is passed as -coverprofile for a single run. It
+	// is not copied by Snapshot, so it never outlives the run it was set for.
+	coverProfile string
+	// fuzzTarget, if set, is the fuzz test to run with -fuzz for a single
+	// run. Like coverProfile, it is not copied by Snapshot.
+	fuzzTarget string
+	// colorDelegated is set for a run whose command colors its own output,
+	// so that it isn't colored again. It is not copied by Snapshot either.
+	colorDelegated bool
+	// jsonFile, if set, is where gotestsum writes the go test -json output
+	// of a single run for it to be read from. Like coverProfile, it is not
+	// copied by Snapshot.
+	jsonFile string
+	// colorFilter, if set, is the command a run's output is piped through
+	// to color it. Like colorDelegated, it is not copied by Snapshot.
+	colorFilter []string
+	// packageSettings lists the paths whose PackageSettings were applied
+	// for a single run. Like coverProfile, it is not copied by Snapshot.
+	packageSettings []string
 }
 
 func NewTestConfig() *TestConfig {
 	return &TestConfig{
 		TestPath:    "./...",
 		CommandBase: []string{"go", "test"},
+		ClearScreen: ClearNever,
 	}
 }
 
@@ -33,9 +175,13 @@ func (tc *TestConfig) BuildCommand() string {
 	tc.RLock()
 	defer tc.RUnlock()
 
+	return strings.Join(builderFor(tc.CommandBase).build(tc), " ")
+}
+
+// goTestArgs returns the arguments to go test for the settings in tc: the
+// test path, then the flags. Callers must hold tc's lock.
+func (tc *TestConfig) goTestArgs() []string {
 	var b strings.Builder
-	b.WriteString(strings.Join(tc.CommandBase, " "))
-	b.WriteString(" ")
 	b.WriteString(tc.TestPath)
 	if tc.Verbose {
 		b.WriteString(" -v")
@@ -49,9 +195,38 @@ func (tc *TestConfig) BuildCommand() string {
 	if tc.Cover {
 		b.WriteString(" -cover")
 	}
+	if tc.CoverPkg != "" {
+		b.WriteString(" -coverpkg=")
+		b.WriteString(tc.CoverPkg)
+	}
+	if tc.Short {
+		b.WriteString(" -short")
+	}
 	if tc.Count > 0 {
 		b.WriteString(" -count=")
 		b.WriteString(strconv.Itoa(tc.Count))
+	} else if tc.NoCache {
+		b.WriteString(" -count=1")
+	}
+	if tc.Timeout != "" {
+		b.WriteString(" -timeout=")
+		b.WriteString(tc.Timeout)
+	}
+	if tc.Shuffle != "" {
+		b.WriteString(" -shuffle=")
+		b.WriteString(tc.Shuffle)
+	}
+	if tc.Parallel > 0 {
+		b.WriteString(" -parallel=")
+		b.WriteString(strconv.Itoa(tc.Parallel))
+	}
+	if tc.CPU != "" {
+		b.WriteString(" -cpu=")
+		b.WriteString(tc.CPU)
+	}
+	if tc.Tags != "" {
+		b.WriteString(" -tags=")
+		b.WriteString(tc.Tags)
 	}
 	if tc.RunPattern != "" {
 		b.WriteString(" -run=")
@@ -61,7 +236,155 @@ func (tc *TestConfig) BuildCommand() string {
 		b.WriteString(" -skip=")
 		b.WriteString(tc.SkipPattern)
 	}
-	return b.String()
+	if tc.fuzzTarget != "" {
+		b.WriteString(" -fuzz=^")
+		b.WriteString(tc.fuzzTarget)
+		b.WriteString("$")
+		if tc.FuzzTime != "" {
+			b.WriteString(" -fuzztime=")
+			b.WriteString(tc.FuzzTime)
+		}
+	}
+	if tc.coverProfile != "" {
+		b.WriteString(" -coverprofile=")
+		b.WriteString(tc.coverProfile)
+	} else if tc.CoverageFile != "" {
+		b.WriteString(" -coverprofile=")
+		b.WriteString(tc.CoverageFile)
+	}
+	if tc.wantsJSON() {
+		b.WriteString(" -json")
+	}
+	// -args must come last, as everything after it goes to the test binary
+	if len(tc.TestArgs) > 0 {
+		b.WriteString(" -args ")
+		b.WriteString(strings.Join(tc.TestArgs, " "))
+	}
+	return strings.Fields(b.String())
+}
+
+// wantsJSON reports whether tc's settings, or the JSON event stream, need
+// the output of go test -json. Callers must hold tc's lock.
+func (tc *TestConfig) wantsJSON() bool {
+	return jsonEvents.enabled() || tc.StructuredSummary || tc.SummaryLine || tc.QuietPass || tc.JUnitPath != "" ||
+		tc.SummaryJSON || tc.Notify || tc.WebhookURL != "" || len(tc.Notifications) > 0 || tc.ProfileSummary > 0 ||
+		(tc.GroupByPackage && tc.Verbose)
+}
+
+// runsWithJSON reports whether runs with tc's settings use go test -json.
+func (tc *TestConfig) runsWithJSON() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.wantsJSON()
+}
+
+// Snapshot returns a copy of the config that can be adjusted for a single
+// test run without affecting the shared config.
+func (tc *TestConfig) Snapshot() *TestConfig {
+	tc.RLock()
+	defer tc.RUnlock()
+
+	snapshot := &TestConfig{}
+	snapshot.copyFrom(tc)
+	return snapshot
+}
+
+// runSnapshot returns a copy of the config for a single test run that,
+// unlike Snapshot, keeps how the run's command colors its output and where
+// gotestsum writes its -json output, so that the run can be repeated.
+func (tc *TestConfig) runSnapshot() *TestConfig {
+	tc.RLock()
+	defer tc.RUnlock()
+
+	snapshot := &TestConfig{}
+	snapshot.copyFrom(tc)
+	snapshot.colorDelegated = tc.colorDelegated
+	snapshot.colorFilter = tc.colorFilter
+	snapshot.jsonFile = tc.jsonFile
+	return snapshot
+}
+
+// copyFrom copies every setting from other into tc. Callers must hold the
+// appropriate locks.
+func (tc *TestConfig) copyFrom(other *TestConfig) {
+	tc.TestPath = other.TestPath
+	tc.Verbose = other.Verbose
+	tc.RunPattern = other.RunPattern
+	tc.SkipPattern = other.SkipPattern
+	tc.CommandBase = append([]string(nil), other.CommandBase...)
+	tc.Race = other.Race
+	tc.FailFast = other.FailFast
+	tc.Count = other.Count
+	tc.NoCache = other.NoCache
+	tc.Timeout = other.Timeout
+	tc.Shuffle = other.Shuffle
+	tc.Parallel = other.Parallel
+	tc.CPU = other.CPU
+	tc.Tags = other.Tags
+	tc.TestArgs = append([]string(nil), other.TestArgs...)
+	tc.ClearScreen = other.ClearScreen
+	tc.KeepScrollback = other.KeepScrollback
+	tc.Cover = other.Cover
+	tc.CoverPkg = other.CoverPkg
+	tc.Short = other.Short
+	tc.Color = other.Color
+	tc.ColorTheme = other.ColorTheme
+	tc.Colors = maps.Clone(other.Colors)
+	tc.LinePrefix = other.LinePrefix
+	tc.Quiet = other.Quiet
+	tc.AltScreen = other.AltScreen
+	tc.AutoShort = other.AutoShort
+	tc.PasteGuard = other.PasteGuard
+	tc.SingleKey = other.SingleKey
+	tc.NoInput = other.NoInput
+	tc.GitRootRelative = other.GitRootRelative
+	tc.SummaryJSON = other.SummaryJSON
+	tc.WatchMinFileSize = other.WatchMinFileSize
+	tc.ProfileSummary = other.ProfileSummary
+	tc.FirstRunSkipCache = other.FirstRunSkipCache
+	tc.LiteralPatterns = other.LiteralPatterns
+	tc.DeadlockTimeout = other.DeadlockTimeout
+	tc.PromptPending = other.PromptPending
+	tc.Runner = other.Runner
+	tc.ExitOnFirstPass = other.ExitOnFirstPass
+	tc.CI = other.CI
+	tc.WatchQuietPeriod = other.WatchQuietPeriod
+	tc.ReportChangedFiles = other.ReportChangedFiles
+	tc.OutputFile = other.OutputFile
+	tc.RunLogs = other.RunLogs
+	tc.StructuredSummary = other.StructuredSummary
+	tc.SummaryLine = other.SummaryLine
+	tc.RunBudget = other.RunBudget
+	tc.QuietPass = other.QuietPass
+	tc.CollapsePassing = other.CollapsePassing
+	tc.GroupByPackage = other.GroupByPackage
+	tc.Notify = other.Notify
+	tc.WebhookURL = other.WebhookURL
+	tc.JUnitPath = other.JUnitPath
+	tc.CoverageFile = other.CoverageFile
+	tc.FailureLocations = other.FailureLocations
+	tc.ControlSocket = other.ControlSocket
+	tc.HTTPAddr = other.HTTPAddr
+	tc.Since = other.Since
+	tc.DockerService = other.DockerService
+	tc.DockerImage = other.DockerImage
+	tc.DockerWorkdir = other.DockerWorkdir
+	tc.ColorBackend = other.ColorBackend
+	tc.Notifications = slices.Clone(other.Notifications)
+	tc.SmartMode = other.SmartMode
+	tc.SmartDependents = other.SmartDependents
+	tc.Env = maps.Clone(other.Env)
+	tc.Macros = maps.Clone(other.Macros)
+	tc.FuzzTime = other.FuzzTime
+	tc.Vet = other.Vet
+	tc.VetSkipsTests = other.VetSkipsTests
+	tc.Ignore = append([]string(nil), other.Ignore...)
+	tc.Poll = other.Poll
+	tc.Exclude = append([]string(nil), other.Exclude...)
+	tc.WatchDirs = append([]string(nil), other.WatchDirs...)
+	tc.FollowSymlinks = other.FollowSymlinks
+	tc.Packages = other.Packages.clone()
+	tc.WorkingDir = other.WorkingDir
 }
 
 func (tc *TestConfig) GetVerbose() bool {
@@ -70,7 +393,7 @@ func (tc *TestConfig) GetVerbose() bool {
 	return tc.Verbose
 }
 
-func (tc *TestConfig) GetClearScreen() bool {
+func (tc *TestConfig) GetClearScreen() ClearMode {
 	tc.RLock()
 	defer tc.RUnlock()
 	return tc.ClearScreen
@@ -124,95 +447,1052 @@ func (tc *TestConfig) GetCover() bool {
 	return tc.Cover
 }
 
+func (tc *TestConfig) GetCoverPkg() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.CoverPkg
+}
+
 func (tc *TestConfig) GetColor() bool {
 	tc.RLock()
 	defer tc.RUnlock()
 	return tc.Color
 }
 
-// Safe setters
-func (tc *TestConfig) SetVerbose(v bool) {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.Verbose = v
+func (tc *TestConfig) GetShort() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Short
 }
 
-func (tc *TestConfig) SetTestPath(path string) {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.TestPath = path
+func (tc *TestConfig) GetAutoShort() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.AutoShort
 }
 
-func (tc *TestConfig) SetRunPattern(pattern string) {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.RunPattern = pattern
+func (tc *TestConfig) GetPasteGuard() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.PasteGuard
 }
 
-func (tc *TestConfig) SetSkipPattern(pattern string) {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.SkipPattern = pattern
+func (tc *TestConfig) GetGitRootRelative() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.GitRootRelative
 }
 
-func (tc *TestConfig) SetCommandBase(commandBase []string) {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.CommandBase = commandBase
+func (tc *TestConfig) GetSummaryJSON() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.SummaryJSON
 }
 
-func (tc *TestConfig) SetCount(count int) {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.Count = count
+func (tc *TestConfig) GetWatchMinFileSize() int {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.WatchMinFileSize
 }
 
-func (tc *TestConfig) SetClearScreen(cls bool) {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.ClearScreen = cls
+func (tc *TestConfig) GetProfileSummary() int {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.ProfileSummary
 }
 
-func (tc *TestConfig) SetColor(color bool) {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.Color = color
+func (tc *TestConfig) GetFirstRunSkipCache() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.FirstRunSkipCache
 }
 
-func (tc *TestConfig) ToggleVerbose() {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.Verbose = !tc.Verbose
+func (tc *TestConfig) GetLiteralPatterns() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.LiteralPatterns
 }
 
-func (tc *TestConfig) ToggleClearScreen() {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.ClearScreen = !tc.ClearScreen
+func (tc *TestConfig) GetDeadlockTimeout() int {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.DeadlockTimeout
 }
 
-func (tc *TestConfig) ToggleRace() {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.Race = !tc.Race
+func (tc *TestConfig) GetPromptPending() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.PromptPending
 }
 
-func (tc *TestConfig) ToggleFailFast() {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.FailFast = !tc.FailFast
+func (tc *TestConfig) GetRunner() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Runner
 }
 
-func (tc *TestConfig) ToggleCover() {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.Cover = !tc.Cover
+func (tc *TestConfig) GetExitOnFirstPass() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.ExitOnFirstPass
 }
 
-func (tc *TestConfig) ToggleColor() {
-	tc.Lock()
-	defer tc.Unlock()
-	tc.Color = !tc.Color
+func (tc *TestConfig) GetCI() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.CI
+}
+
+func (tc *TestConfig) GetWatchQuietPeriod() int {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.WatchQuietPeriod
+}
+
+func (tc *TestConfig) GetReportChangedFiles() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.ReportChangedFiles
+}
+
+func (tc *TestConfig) GetOutputFile() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.OutputFile
+}
+
+func (tc *TestConfig) GetStructuredSummary() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.StructuredSummary
+}
+
+func (tc *TestConfig) GetSmartMode() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.SmartMode
+}
+
+func (tc *TestConfig) GetSmartDependents() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.SmartDependents
+}
+
+func (tc *TestConfig) GetTimeout() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Timeout
+}
+
+// GetEnv returns a copy of the extra environment variables for test runs.
+func (tc *TestConfig) GetEnv() map[string]string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return maps.Clone(tc.Env)
+}
+
+func (tc *TestConfig) GetFuzzTime() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.FuzzTime
+}
+
+func (tc *TestConfig) GetVet() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Vet
+}
+
+func (tc *TestConfig) GetVetSkipsTests() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.VetSkipsTests
+}
+
+func (tc *TestConfig) GetShuffle() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Shuffle
+}
+
+func (tc *TestConfig) GetParallel() int {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Parallel
+}
+
+func (tc *TestConfig) GetCPU() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.CPU
+}
+
+func (tc *TestConfig) GetTestArgs() []string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.TestArgs
+}
+
+func (tc *TestConfig) GetIgnore() []string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Ignore
+}
+
+func (tc *TestConfig) GetPoll() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Poll
+}
+
+func (tc *TestConfig) GetExclude() []string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Exclude
+}
+
+// GetWatchDirs returns a copy of the extra directories to watch.
+func (tc *TestConfig) GetWatchDirs() []string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return slices.Clone(tc.WatchDirs)
+}
+
+func (tc *TestConfig) GetFollowSymlinks() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.FollowSymlinks
+}
+
+func (tc *TestConfig) GetSingleKey() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.SingleKey
+}
+
+func (tc *TestConfig) GetMacros() map[string]string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return maps.Clone(tc.Macros)
+}
+
+func (tc *TestConfig) GetTags() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Tags
+}
+
+func (tc *TestConfig) GetSummaryLine() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.SummaryLine
+}
+
+func (tc *TestConfig) GetQuietPass() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.QuietPass
+}
+
+func (tc *TestConfig) GetCollapsePassing() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.CollapsePassing
+}
+
+func (tc *TestConfig) GetNotify() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Notify
+}
+
+func (tc *TestConfig) GetWebhookURL() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.WebhookURL
+}
+
+func (tc *TestConfig) GetJUnitPath() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.JUnitPath
+}
+
+func (tc *TestConfig) GetRunLogs() int {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.RunLogs
+}
+
+func (tc *TestConfig) GetRunBudget() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.RunBudget
+}
+
+func (tc *TestConfig) GetColorTheme() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.ColorTheme
+}
+
+func (tc *TestConfig) GetColors() map[string]string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return maps.Clone(tc.Colors)
+}
+
+func (tc *TestConfig) GetQuiet() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Quiet
+}
+
+func (tc *TestConfig) GetGroupByPackage() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.GroupByPackage
+}
+
+func (tc *TestConfig) GetNoCache() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.NoCache
+}
+
+func (tc *TestConfig) GetKeepScrollback() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.KeepScrollback
+}
+
+func (tc *TestConfig) GetCoverageFile() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.CoverageFile
+}
+
+func (tc *TestConfig) GetNoInput() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.NoInput
+}
+
+func (tc *TestConfig) GetControlSocket() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.ControlSocket
+}
+
+func (tc *TestConfig) GetHTTPAddr() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.HTTPAddr
+}
+
+func (tc *TestConfig) GetFailureLocations() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.FailureLocations
+}
+
+func (tc *TestConfig) GetSince() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.Since
+}
+
+func (tc *TestConfig) GetDockerService() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.DockerService
+}
+
+func (tc *TestConfig) GetDockerImage() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.DockerImage
+}
+
+func (tc *TestConfig) GetDockerWorkdir() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.DockerWorkdir
+}
+
+func (tc *TestConfig) GetColorBackend() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.ColorBackend
+}
+
+func (tc *TestConfig) GetNotifications() []Notification {
+	tc.RLock()
+	defer tc.RUnlock()
+	return slices.Clone(tc.Notifications)
+}
+
+func (tc *TestConfig) GetWorkingDir() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.WorkingDir
+}
+
+func (tc *TestConfig) GetAltScreen() bool {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.AltScreen
+}
+
+func (tc *TestConfig) GetLinePrefix() string {
+	tc.RLock()
+	defer tc.RUnlock()
+	return tc.LinePrefix
+}
+
+// Safe setters
+func (tc *TestConfig) SetVerbose(v bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Verbose = v
+}
+
+func (tc *TestConfig) SetTestPath(path string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.TestPath = path
+}
+
+func (tc *TestConfig) SetRunPattern(pattern string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.RunPattern = pattern
+}
+
+func (tc *TestConfig) SetSkipPattern(pattern string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.SkipPattern = pattern
+}
+
+func (tc *TestConfig) SetCommandBase(commandBase []string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.CommandBase = commandBase
+}
+
+func (tc *TestConfig) SetCount(count int) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Count = count
+}
+
+func (tc *TestConfig) SetClearScreen(cls ClearMode) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.ClearScreen = cls
+}
+
+func (tc *TestConfig) SetColor(color bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Color = color
+}
+
+func (tc *TestConfig) SetRace(race bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Race = race
+}
+
+func (tc *TestConfig) SetFailFast(failFast bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.FailFast = failFast
+}
+
+func (tc *TestConfig) SetCover(cover bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Cover = cover
+}
+
+func (tc *TestConfig) SetCoverPkg(coverPkg string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.CoverPkg = coverPkg
+}
+
+func (tc *TestConfig) SetShort(short bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Short = short
+}
+
+func (tc *TestConfig) SetAutoShort(autoShort bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.AutoShort = autoShort
+}
+
+func (tc *TestConfig) SetPasteGuard(pasteGuard bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.PasteGuard = pasteGuard
+}
+
+func (tc *TestConfig) SetGitRootRelative(gitRootRelative bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.GitRootRelative = gitRootRelative
+}
+
+func (tc *TestConfig) SetSummaryJSON(summaryJSON bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.SummaryJSON = summaryJSON
+}
+
+func (tc *TestConfig) SetWatchMinFileSize(size int) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.WatchMinFileSize = size
+}
+
+func (tc *TestConfig) SetProfileSummary(n int) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.ProfileSummary = n
+}
+
+func (tc *TestConfig) SetFirstRunSkipCache(firstRunSkipCache bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.FirstRunSkipCache = firstRunSkipCache
+}
+
+func (tc *TestConfig) SetLiteralPatterns(literalPatterns bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.LiteralPatterns = literalPatterns
+}
+
+func (tc *TestConfig) SetDeadlockTimeout(deadlockTimeout int) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.DeadlockTimeout = deadlockTimeout
+}
+
+func (tc *TestConfig) SetPromptPending(promptPending bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.PromptPending = promptPending
+}
+
+func (tc *TestConfig) SetRunner(runner string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Runner = runner
+}
+
+func (tc *TestConfig) SetExitOnFirstPass(exitOnFirstPass bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.ExitOnFirstPass = exitOnFirstPass
+}
+
+func (tc *TestConfig) SetCI(ci bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.CI = ci
+}
+
+func (tc *TestConfig) SetWatchQuietPeriod(watchQuietPeriod int) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.WatchQuietPeriod = watchQuietPeriod
+}
+
+func (tc *TestConfig) SetReportChangedFiles(reportChangedFiles bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.ReportChangedFiles = reportChangedFiles
+}
+
+func (tc *TestConfig) SetOutputFile(path string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.OutputFile = path
+}
+
+func (tc *TestConfig) SetStructuredSummary(structuredSummary bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.StructuredSummary = structuredSummary
+}
+
+func (tc *TestConfig) SetSmartMode(smartMode bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.SmartMode = smartMode
+}
+
+func (tc *TestConfig) SetSmartDependents(smartDependents bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.SmartDependents = smartDependents
+}
+
+func (tc *TestConfig) SetTimeout(timeout string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Timeout = timeout
+}
+
+func (tc *TestConfig) SetFuzzTime(fuzzTime string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.FuzzTime = fuzzTime
+}
+
+func (tc *TestConfig) SetVet(vet bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Vet = vet
+}
+
+func (tc *TestConfig) SetVetSkipsTests(vetSkipsTests bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.VetSkipsTests = vetSkipsTests
+}
+
+func (tc *TestConfig) SetShuffle(shuffle string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Shuffle = shuffle
+}
+
+func (tc *TestConfig) SetParallel(parallel int) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Parallel = parallel
+}
+
+func (tc *TestConfig) SetCPU(cpu string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.CPU = cpu
+}
+
+func (tc *TestConfig) SetTestArgs(testArgs []string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.TestArgs = testArgs
+}
+
+func (tc *TestConfig) SetIgnore(ignore []string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Ignore = ignore
+}
+
+func (tc *TestConfig) SetPoll(poll string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Poll = poll
+}
+
+func (tc *TestConfig) SetExclude(exclude []string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Exclude = exclude
+}
+
+func (tc *TestConfig) SetWatchDirs(watchDirs []string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.WatchDirs = watchDirs
+}
+
+func (tc *TestConfig) SetFollowSymlinks(followSymlinks bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.FollowSymlinks = followSymlinks
+}
+
+func (tc *TestConfig) SetSingleKey(singleKey bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.SingleKey = singleKey
+}
+
+func (tc *TestConfig) SetMacros(macros map[string]string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Macros = maps.Clone(macros)
+}
+
+func (tc *TestConfig) SetTags(tags string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Tags = tags
+}
+
+func (tc *TestConfig) SetSummaryLine(summaryLine bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.SummaryLine = summaryLine
+}
+
+func (tc *TestConfig) SetQuietPass(quietPass bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.QuietPass = quietPass
+}
+
+func (tc *TestConfig) SetCollapsePassing(collapsePassing bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.CollapsePassing = collapsePassing
+}
+
+func (tc *TestConfig) SetNotify(notify bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Notify = notify
+}
+
+func (tc *TestConfig) SetWebhookURL(webhookURL string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.WebhookURL = webhookURL
+}
+
+func (tc *TestConfig) SetJUnitPath(path string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.JUnitPath = path
+}
+
+func (tc *TestConfig) SetRunLogs(runLogs int) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.RunLogs = runLogs
+}
+
+func (tc *TestConfig) SetRunBudget(runBudget string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.RunBudget = runBudget
+}
+
+func (tc *TestConfig) SetColorTheme(colorTheme string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.ColorTheme = colorTheme
+}
+
+func (tc *TestConfig) SetColors(colors map[string]string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Colors = colors
+}
+
+func (tc *TestConfig) SetQuiet(quiet bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Quiet = quiet
+}
+
+func (tc *TestConfig) SetGroupByPackage(groupByPackage bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.GroupByPackage = groupByPackage
+}
+
+func (tc *TestConfig) SetNoCache(noCache bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.NoCache = noCache
+}
+
+func (tc *TestConfig) SetKeepScrollback(keepScrollback bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.KeepScrollback = keepScrollback
+}
+
+func (tc *TestConfig) SetCoverageFile(coverageFile string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.CoverageFile = coverageFile
+}
+
+func (tc *TestConfig) SetNoInput(noInput bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.NoInput = noInput
+}
+
+func (tc *TestConfig) SetControlSocket(controlSocket string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.ControlSocket = controlSocket
+}
+
+func (tc *TestConfig) SetHTTPAddr(addr string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.HTTPAddr = addr
+}
+
+func (tc *TestConfig) SetFailureLocations(failureLocations string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.FailureLocations = failureLocations
+}
+
+func (tc *TestConfig) SetSince(since string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Since = since
+}
+
+func (tc *TestConfig) SetDockerService(dockerService string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.DockerService = dockerService
+}
+
+func (tc *TestConfig) SetDockerImage(dockerImage string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.DockerImage = dockerImage
+}
+
+func (tc *TestConfig) SetDockerWorkdir(dockerWorkdir string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.DockerWorkdir = dockerWorkdir
+}
+
+func (tc *TestConfig) SetColorBackend(colorBackend string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.ColorBackend = colorBackend
+}
+
+func (tc *TestConfig) SetNotifications(notifications []Notification) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Notifications = notifications
+}
+
+func (tc *TestConfig) SetWorkingDir(dir string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.WorkingDir = dir
+}
+
+func (tc *TestConfig) SetAltScreen(altScreen bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.AltScreen = altScreen
+}
+
+func (tc *TestConfig) SetLinePrefix(prefix string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.LinePrefix = prefix
+}
+
+func (tc *TestConfig) ToggleVerbose() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Verbose = !tc.Verbose
+}
+
+// CycleClearScreen moves to the next clear screen mode, from never to
+// always to auto and back to never.
+func (tc *TestConfig) CycleClearScreen() {
+	tc.Lock()
+	defer tc.Unlock()
+	switch tc.ClearScreen {
+	case ClearAlways:
+		tc.ClearScreen = ClearAuto
+	case ClearAuto:
+		tc.ClearScreen = ClearNever
+	default:
+		tc.ClearScreen = ClearAlways
+	}
+}
+
+func (tc *TestConfig) ToggleRace() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Race = !tc.Race
+}
+
+func (tc *TestConfig) ToggleFailFast() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.FailFast = !tc.FailFast
+}
+
+func (tc *TestConfig) ToggleCover() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Cover = !tc.Cover
+}
+
+func (tc *TestConfig) ToggleShort() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Short = !tc.Short
+}
+
+func (tc *TestConfig) ToggleColor() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Color = !tc.Color
+}
+
+func (tc *TestConfig) ToggleLiteralPatterns() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.LiteralPatterns = !tc.LiteralPatterns
+}
+
+func (tc *TestConfig) ToggleSmartMode() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.SmartMode = !tc.SmartMode
+}
+
+func (tc *TestConfig) ToggleSmartDependents() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.SmartDependents = !tc.SmartDependents
+}
+
+// SetEnvVar sets an extra environment variable for test runs.
+func (tc *TestConfig) SetEnvVar(key, value string) {
+	tc.Lock()
+	defer tc.Unlock()
+	if tc.Env == nil {
+		tc.Env = make(map[string]string)
+	}
+	tc.Env[key] = value
+}
+
+// UnsetEnvVar removes an extra environment variable. It reports false if
+// key was not set.
+func (tc *TestConfig) UnsetEnvVar(key string) bool {
+	tc.Lock()
+	defer tc.Unlock()
+	if _, ok := tc.Env[key]; !ok {
+		return false
+	}
+	delete(tc.Env, key)
+	return true
+}
+
+// AddWatchDir adds an extra directory to watch. It reports false if dir was
+// already being watched.
+func (tc *TestConfig) AddWatchDir(dir string) bool {
+	tc.Lock()
+	defer tc.Unlock()
+	if slices.Contains(tc.WatchDirs, dir) {
+		return false
+	}
+	tc.WatchDirs = append(tc.WatchDirs, dir)
+	return true
+}
+
+// RemoveWatchDir stops watching an extra directory. It reports false if dir
+// was not being watched.
+func (tc *TestConfig) RemoveWatchDir(dir string) bool {
+	tc.Lock()
+	defer tc.Unlock()
+	i := slices.Index(tc.WatchDirs, dir)
+	if i < 0 {
+		return false
+	}
+	tc.WatchDirs = slices.Delete(slices.Clone(tc.WatchDirs), i, i+1)
+	return true
+}
+
+// ClearEnv removes every extra environment variable.
+func (tc *TestConfig) ClearEnv() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Env = nil
+}
+
+func (tc *TestConfig) ToggleVet() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Vet = !tc.Vet
+}
+
+func (tc *TestConfig) ToggleVetSkipsTests() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.VetSkipsTests = !tc.VetSkipsTests
+}
+
+func (tc *TestConfig) ToggleQuietPass() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.QuietPass = !tc.QuietPass
+}
+
+func (tc *TestConfig) ToggleCollapsePassing() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.CollapsePassing = !tc.CollapsePassing
+}
+
+func (tc *TestConfig) ToggleNotify() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.Notify = !tc.Notify
+}
+
+func (tc *TestConfig) ToggleGroupByPackage() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.GroupByPackage = !tc.GroupByPackage
+}
+
+func (tc *TestConfig) ToggleNoCache() {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.NoCache = !tc.NoCache
+}
+
+// PushRunPattern saves the current run pattern and replaces it with pattern.
+func (tc *TestConfig) PushRunPattern(pattern string) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.runPatternStack = append(tc.runPatternStack, tc.RunPattern)
+	tc.RunPattern = pattern
+}
+
+// PopRunPattern restores the most recently saved run pattern. It reports
+// false, leaving the run pattern unchanged, if there is nothing to restore.
+func (tc *TestConfig) PopRunPattern() (string, bool) {
+	tc.Lock()
+	defer tc.Unlock()
+	if len(tc.runPatternStack) == 0 {
+		return tc.RunPattern, false
+	}
+	last := len(tc.runPatternStack) - 1
+	tc.RunPattern = tc.runPatternStack[last]
+	tc.runPatternStack = tc.runPatternStack[:last]
+	return tc.RunPattern, true
 }
 
 func (tc *TestConfig) Clear() {
@@ -226,6 +1506,16 @@ func (tc *TestConfig) Clear() {
 	tc.Race = false
 	tc.FailFast = false
 	tc.Count = 0
+	tc.NoCache = false
+	tc.Timeout = ""
+	tc.Shuffle = ""
+	tc.Parallel = 0
+	tc.CPU = ""
+	tc.Tags = ""
+	tc.TestArgs = nil
 	tc.Cover = false
+	tc.CoverPkg = ""
+	tc.Short = false
 	tc.Color = false
+	tc.Since = ""
 }
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -84,6 +85,20 @@ func TestBuildCommand_CoverWithOtherFlags(t *testing.T) {
 	assert.Equal(t, "go test ./... -v -race -cover", cmd)
 }
 
+func TestBuildCommand_CoverPkg(t *testing.T) {
+	config := TestConfig{
+		TestPath:    "./integration/...",
+		CommandBase: []string{"go", "test"},
+		Cover:       true,
+		CoverPkg:    "./internal/...,./pkg/...",
+		Short:       true,
+	}
+
+	cmd := config.BuildCommand()
+
+	assert.Equal(t, "go test ./integration/... -cover -coverpkg=./internal/...,./pkg/... -short", cmd)
+}
+
 func TestGetCover(t *testing.T) {
 	config := &TestConfig{
 		Cover: true,
@@ -142,3 +157,168 @@ func TestBuildCommand_DoesNotIncludeColor(t *testing.T) {
 	assert.Equal(t, "go test ./...", cmd, "Color should not affect command output")
 	assert.NotContains(t, cmd, "color", "Command should not contain color flag")
 }
+
+func TestBuildCommand_WithShort(t *testing.T) {
+	config := TestConfig{
+		TestPath:    "./...",
+		CommandBase: []string{"go", "test"},
+		Short:       true,
+	}
+
+	assert.Equal(t, "go test ./... -short", config.BuildCommand())
+}
+
+func TestBuildCommand_TestArgsComeLast(t *testing.T) {
+	config := TestConfig{
+		TestPath:          "./...",
+		CommandBase:       []string{"go", "test"},
+		RunPattern:        "MyTest",
+		StructuredSummary: true,
+		TestArgs:          []string{"-update"},
+	}
+
+	assert.Equal(t, "go test ./... -run=MyTest -json -args -update", config.BuildCommand())
+}
+
+func TestSnapshot_CopiesAllFields(t *testing.T) {
+	config := &TestConfig{}
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !v.Type().Field(i).IsExported() || v.Type().Field(i).Anonymous {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString("value")
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.Int:
+			field.SetInt(3)
+		case reflect.Slice:
+			field.Set(reflect.Append(reflect.MakeSlice(field.Type(), 0, 1), reflect.Zero(field.Type().Elem())))
+		case reflect.Map:
+			field.Set(reflect.MakeMap(field.Type()))
+			field.SetMapIndex(reflect.Zero(field.Type().Key()), reflect.Zero(field.Type().Elem()))
+		default:
+			t.Fatalf("unhandled field kind %s for %s", field.Kind(), v.Type().Field(i).Name)
+		}
+	}
+
+	snapshot := config.Snapshot()
+	sv := reflect.ValueOf(snapshot).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Type().Field(i).IsExported() || v.Type().Field(i).Anonymous {
+			continue
+		}
+		assert.Equal(t, v.Field(i).Interface(), sv.Field(i).Interface(), "field %s should be copied", v.Type().Field(i).Name)
+	}
+}
+
+func TestSnapshot_IsIndependentOfOriginal(t *testing.T) {
+	config := NewTestConfig()
+	config.SetEnvVar("KEY", "original")
+	config.SetColors(map[string]string{"fail": "red"})
+	snapshot := config.Snapshot()
+
+	snapshot.SetVerbose(true)
+	snapshot.CommandBase[0] = "richgo"
+	snapshot.SetEnvVar("KEY", "changed")
+	snapshot.Colors["fail"] = "magenta"
+	config.GetColors()["pass"] = "green"
+
+	assert.False(t, config.GetVerbose())
+	assert.Equal(t, []string{"go", "test"}, config.GetCommandBase())
+	assert.Equal(t, map[string]string{"KEY": "original"}, config.GetEnv())
+	assert.Equal(t, map[string]string{"fail": "red"}, config.GetColors())
+}
+
+func TestBuildCommand_WithStructuredSummary(t *testing.T) {
+	config := TestConfig{
+		TestPath:          "./...",
+		CommandBase:       []string{"go", "test"},
+		RunPattern:        "MyTest",
+		StructuredSummary: true,
+	}
+
+	assert.Equal(t, "go test ./... -run=MyTest -json", config.BuildCommand())
+}
+
+func TestBuildCommand_WithQuietPass(t *testing.T) {
+	config := TestConfig{
+		TestPath:    "./...",
+		CommandBase: []string{"go", "test"},
+		Verbose:     true,
+		QuietPass:   true,
+	}
+
+	assert.Equal(t, "go test ./... -v -json", config.BuildCommand())
+}
+
+func TestBuildCommand_WithCoverageFile(t *testing.T) {
+	config := TestConfig{
+		TestPath:     "./...",
+		CommandBase:  []string{"go", "test"},
+		CoverageFile: "cover.out",
+	}
+	assert.Equal(t, "go test ./... -coverprofile=cover.out", config.BuildCommand())
+
+	config.coverProfile = "/tmp/coverhtml.out"
+	assert.Equal(t, "go test ./... -coverprofile=/tmp/coverhtml.out", config.BuildCommand(),
+		"coverhtml's profile should replace the coverage file")
+}
+
+func TestBuildCommand_WithNoCache(t *testing.T) {
+	config := TestConfig{
+		TestPath:    "./...",
+		CommandBase: []string{"go", "test"},
+		NoCache:     true,
+	}
+	assert.Equal(t, "go test ./... -count=1", config.BuildCommand())
+
+	config.Count = 3
+	assert.Equal(t, "go test ./... -count=3", config.BuildCommand(), "an explicit count should win")
+}
+
+func TestBuildCommand_WithGroupByPackage(t *testing.T) {
+	config := TestConfig{
+		TestPath:       "./...",
+		CommandBase:    []string{"go", "test"},
+		GroupByPackage: true,
+	}
+	assert.Equal(t, "go test ./...", config.BuildCommand(), "grouping only applies to verbose runs")
+
+	config.Verbose = true
+	assert.Equal(t, "go test ./... -v -json", config.BuildCommand())
+}
+
+func TestBuildCommand_WithNotify(t *testing.T) {
+	config := TestConfig{
+		TestPath:    "./...",
+		CommandBase: []string{"go", "test"},
+		Notify:      true,
+	}
+
+	assert.Equal(t, "go test ./... -json", config.BuildCommand(), "notifications count the tests that passed")
+}
+
+func TestBuildCommand_WithProfileSummary(t *testing.T) {
+	config := TestConfig{
+		TestPath:       "./...",
+		CommandBase:    []string{"go", "test"},
+		ProfileSummary: 5,
+	}
+
+	assert.Equal(t, "go test ./... -json", config.BuildCommand(), "the slowest tests are timed without -v")
+}
+
+func TestBuildCommand_WithSummaryLine(t *testing.T) {
+	config := TestConfig{
+		TestPath:    "./...",
+		CommandBase: []string{"go", "test"},
+		SummaryLine: true,
+		TestArgs:    []string{"-update"},
+	}
+
+	assert.Equal(t, "go test ./... -json -args -update", config.BuildCommand())
+}
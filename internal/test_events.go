@@ -0,0 +1,270 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// testEvent is a single event emitted by `go test -json`.
+type testEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// decodeTestEvent reports whether line is a `go test -json` event and, if
+// so, returns it.
+func decodeTestEvent(line string) (testEvent, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return testEvent{}, false
+	}
+	var event testEvent
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Action == "" {
+		return testEvent{}, false
+	}
+	return event, true
+}
+
+// eventOutput returns the text to display for a line of `go test -json`
+// output. Events that carry no output are dropped, and lines that are not
+// events, such as build errors, are passed through unchanged.
+func eventOutput(line string) (string, bool) {
+	event, ok := decodeTestEvent(line)
+	if !ok {
+		return line, true
+	}
+	if event.Action != "output" {
+		return "", false
+	}
+	return strings.TrimSuffix(event.Output, "\n"), true
+}
+
+// testResultLine matches the line `go test` prints when a test finishes,
+// such as "--- FAIL: TestFoo (0.01s)", indented for subtests.
+var testResultLine = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): `)
+
+// packageResultLine matches the lines `go test` prints for a package as a
+// whole: the PASS or FAIL after its tests, and the line naming it with its
+// result, such as "ok  	pkg	0.01s".
+var packageResultLine = regexp.MustCompile(`^(PASS|FAIL|ok|\?)(\s|$)`)
+
+// eventColorizer returns the color for line, which was decoded from event
+// or from output held back until event. Only the lines that report results
+// are colored as results, so that a test's own output is never colored as a
+// result because it happens to contain "PASS" or "FAIL".
+func eventColorizer(event testEvent, line string) string {
+	if event.Test == "" && event.Package != "" && line == packageHeader(event.Package) {
+		return Bold
+	}
+	result := ""
+	if m := testResultLine.FindStringSubmatch(line); m != nil {
+		result = m[1]
+	} else if m := packageResultLine.FindStringSubmatch(line); m != nil && event.Test == "" {
+		result = m[1]
+	}
+
+	switch result {
+	case "PASS", "ok":
+		return Green
+	case "FAIL":
+		return Red
+	case "SKIP", "?":
+		return Yellow
+	}
+	if strings.Contains(line, ".go:") {
+		return Magenta
+	}
+	return White
+}
+
+// quietEventOutput returns a decoder for `go test -json` output that shows
+// what `go test` without -v would: the output of each test is held back
+// until it finishes, and only shown if it failed. If hidePassingPackages is
+// set, the output of each package, such as its ok line, is held back the
+// same way, so only failures are shown.
+func quietEventOutput(hidePassingPackages bool) func(line string) (string, bool) {
+	var mu sync.Mutex
+	held := map[[2]string][]string{}
+
+	return func(line string) (string, bool) {
+		event, ok := decodeTestEvent(line)
+		if !ok {
+			return line, true
+		}
+		output := strings.TrimSuffix(event.Output, "\n")
+		if event.Test == "" && (event.Package == "" || !hidePassingPackages) {
+			// Build errors and the per-package results, except for the
+			// PASS that only -v prints
+			if (event.Action != "output" && event.Action != "build-output") || output == "PASS" {
+				return "", false
+			}
+			return output, true
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		key := [2]string{event.Package, event.Test}
+		switch event.Action {
+		case "output":
+			if !strings.HasPrefix(output, "=== ") {
+				held[key] = append(held[key], output)
+			}
+		case "fail":
+			lines := held[key]
+			delete(held, key)
+			if len(lines) > 0 {
+				return strings.Join(lines, "\n"), true
+			}
+		case "pass", "skip":
+			delete(held, key)
+		}
+		return "", false
+	}
+}
+
+// groupedEventOutput returns a decoder for `go test -json` output that holds
+// back each package's output until the package finishes, then shows it all
+// at once under a header naming the package, so that the output of packages
+// tested in parallel is not interleaved. Packages are shown in the order
+// they finish.
+func groupedEventOutput() func(line string) (string, bool) {
+	var mu sync.Mutex
+	held := map[string][]string{}
+
+	return func(line string) (string, bool) {
+		event, ok := decodeTestEvent(line)
+		if !ok {
+			return line, true
+		}
+		if event.Package == "" {
+			// Build errors belong to no package until it fails
+			if event.Action != "output" && event.Action != "build-output" {
+				return "", false
+			}
+			return strings.TrimSuffix(event.Output, "\n"), true
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case event.Action == "output":
+			held[event.Package] = append(held[event.Package], strings.TrimSuffix(event.Output, "\n"))
+		case event.Test == "" && (event.Action == "pass" || event.Action == "fail" || event.Action == "skip"):
+			lines := held[event.Package]
+			delete(held, event.Package)
+			return strings.Join(append([]string{packageHeader(event.Package)}, lines...), "\n"), true
+		}
+		return "", false
+	}
+}
+
+// packageHeader is the line shown above a package's grouped output.
+func packageHeader(pkg string) string {
+	return "# " + pkg
+}
+
+// PackageResult tallies the tests of a single package in a test run.
+type PackageResult struct {
+	Package string
+	// Action is the package's final action: "pass", "fail" or "skip", or
+	// empty if the package did not finish
+	Action string
+	Pass   int
+	Fail   int
+	Skip   int
+}
+
+// eventParser collects per-package results from a `go test -json` event
+// stream. It is safe to feed from both the stdout and stderr streamers at
+// once.
+type eventParser struct {
+	mu       sync.Mutex
+	packages []*PackageResult
+}
+
+func newEventParser() *eventParser {
+	return &eventParser{}
+}
+
+func (p *eventParser) parseLine(line string) {
+	event, ok := decodeTestEvent(line)
+	if !ok || event.Package == "" {
+		return
+	}
+	switch event.Action {
+	case "pass", "fail", "skip":
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result := p.packageResult(event.Package)
+	if event.Test == "" {
+		result.Action = event.Action
+		return
+	}
+	switch event.Action {
+	case "pass":
+		result.Pass++
+	case "fail":
+		result.Fail++
+	case "skip":
+		result.Skip++
+	}
+}
+
+// packageResult returns the result for pkg, adding it if it has not been
+// seen yet. Callers must hold p.mu.
+func (p *eventParser) packageResult(pkg string) *PackageResult {
+	for _, result := range p.packages {
+		if result.Package == pkg {
+			return result
+		}
+	}
+	result := &PackageResult{Package: pkg}
+	p.packages = append(p.packages, result)
+	return result
+}
+
+// Results returns the results collected so far, in the order the packages
+// were first seen.
+func (p *eventParser) Results() []PackageResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	results := make([]PackageResult, 0, len(p.packages))
+	for _, result := range p.packages {
+		results = append(results, *result)
+	}
+	return results
+}
+
+// formatPackageResults renders one line per package, led by the same status
+// markers `go test` uses, e.g. "ok    example  2 passed, 0 failed, 1 skipped".
+func formatPackageResults(results []PackageResult) []string {
+	width := 0
+	for _, result := range results {
+		width = max(width, len(result.Package))
+	}
+
+	lines := make([]string, 0, len(results))
+	for _, result := range results {
+		status := "?"
+		switch result.Action {
+		case "pass":
+			status = "ok"
+		case "fail":
+			status = "FAIL"
+		}
+		lines = append(lines, fmt.Sprintf("%-5s %-*s  %d passed, %d failed, %d skipped",
+			status, width, result.Package, result.Pass, result.Fail, result.Skip))
+	}
+	return lines
+}
@@ -0,0 +1,216 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEventOutput_DisplaysOutputEventsOnly tests that only output events and non-JSON lines are displayed
+func TestEventOutput_DisplaysOutputEventsOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+		display  bool
+	}{
+		{"output event", `{"Action":"output","Package":"example","Output":"--- PASS: TestA (0.00s)\n"}`, "--- PASS: TestA (0.00s)", true},
+		{"run event", `{"Action":"run","Package":"example","Test":"TestA"}`, "", false},
+		{"pass event", `{"Action":"pass","Package":"example","Test":"TestA","Elapsed":0.01}`, "", false},
+		{"build error", "./example_test.go:5:2: undefined: foo", "./example_test.go:5:2: undefined: foo", true},
+		{"malformed JSON", "{not json", "{not json", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			output, display := eventOutput(tc.line)
+
+			assert.Equal(t, tc.display, display)
+			assert.Equal(t, tc.expected, output)
+		})
+	}
+}
+
+// TestEventParser_TalliesResultsPerPackage tests that test results are counted against their package
+func TestEventParser_TalliesResultsPerPackage(t *testing.T) {
+	parser := newEventParser()
+	lines := []string{
+		`{"Action":"start","Package":"example/a"}`,
+		`{"Action":"run","Package":"example/a","Test":"TestA"}`,
+		`{"Action":"pass","Package":"example/a","Test":"TestA","Elapsed":0.01}`,
+		`{"Action":"skip","Package":"example/a","Test":"TestB","Elapsed":0}`,
+		`{"Action":"fail","Package":"example/b","Test":"TestC","Elapsed":0.02}`,
+		`{"Action":"pass","Package":"example/b","Test":"TestC/sub","Elapsed":0.01}`,
+		`{"Action":"output","Package":"example/a","Output":"ok  \texample/a\t0.01s\n"}`,
+		`{"Action":"pass","Package":"example/a","Elapsed":0.03}`,
+		`{"Action":"fail","Package":"example/b","Elapsed":0.04}`,
+		`{"Action":"skip","Package":"example/c"}`,
+		"not an event",
+	}
+	for _, line := range lines {
+		parser.parseLine(line)
+	}
+
+	assert.Equal(t, []PackageResult{
+		{Package: "example/a", Action: "pass", Pass: 1, Skip: 1},
+		{Package: "example/b", Action: "fail", Pass: 1, Fail: 1},
+		{Package: "example/c", Action: "skip"},
+	}, parser.Results())
+}
+
+// TestFormatPackageResults tests that each package is reported on its own aligned line
+func TestFormatPackageResults(t *testing.T) {
+	results := []PackageResult{
+		{Package: "example/a", Action: "pass", Pass: 2, Skip: 1},
+		{Package: "example/bb", Action: "fail", Pass: 1, Fail: 1},
+		{Package: "example/c", Action: "skip"},
+	}
+
+	assert.Equal(t, []string{
+		"ok    example/a   2 passed, 0 failed, 1 skipped",
+		"FAIL  example/bb  1 passed, 1 failed, 0 skipped",
+		"?     example/c   0 passed, 0 failed, 0 skipped",
+	}, formatPackageResults(results))
+}
+
+// TestQuietEventOutput tests that only the output of failed tests and of packages is shown
+func TestQuietEventOutput(t *testing.T) {
+	lines := []string{
+		`{"Action":"start","Package":"example"}`,
+		`{"Action":"run","Package":"example","Test":"TestPasses"}`,
+		`{"Action":"output","Package":"example","Test":"TestPasses","Output":"=== RUN   TestPasses\n"}`,
+		`{"Action":"output","Package":"example","Test":"TestPasses","Output":"    a_test.go:5: log\n"}`,
+		`{"Action":"output","Package":"example","Test":"TestPasses","Output":"--- PASS: TestPasses (0.00s)\n"}`,
+		`{"Action":"pass","Package":"example","Test":"TestPasses"}`,
+		`{"Action":"output","Package":"example","Test":"TestFails","Output":"=== RUN   TestFails\n"}`,
+		`{"Action":"output","Package":"example","Test":"TestFails","Output":"    a_test.go:9: boom\n"}`,
+		`{"Action":"output","Package":"example","Test":"TestFails","Output":"--- FAIL: TestFails (0.00s)\n"}`,
+		`{"Action":"fail","Package":"example","Test":"TestFails"}`,
+		`{"Action":"output","Package":"example","Output":"FAIL\n"}`,
+		`{"Action":"output","Package":"example","Output":"FAIL\texample\t0.01s\n"}`,
+		`{"Action":"fail","Package":"example"}`,
+		`{"Action":"output","Package":"other","Output":"PASS\n"}`,
+		`{"Action":"output","Package":"other","Output":"ok  \tother\t0.01s\n"}`,
+		`{"ImportPath":"broken","Action":"build-output","Output":"broken/a.go:3:1: syntax error\n"}`,
+		"not an event",
+	}
+
+	decode := quietEventOutput(false)
+	var shown []string
+	for _, line := range lines {
+		if output, ok := decode(line); ok {
+			shown = append(shown, output)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"    a_test.go:9: boom\n--- FAIL: TestFails (0.00s)",
+		"FAIL",
+		"FAIL\texample\t0.01s",
+		"ok  \tother\t0.01s",
+		"broken/a.go:3:1: syntax error",
+		"not an event",
+	}, shown)
+}
+
+func TestQuietEventOutput_HidesPassingPackages(t *testing.T) {
+	lines := []string{
+		`{"Action":"output","Package":"example","Test":"TestFails","Output":"=== RUN   TestFails\n"}`,
+		`{"Action":"output","Package":"example","Test":"TestFails","Output":"--- FAIL: TestFails (0.00s)\n"}`,
+		`{"Action":"fail","Package":"example","Test":"TestFails"}`,
+		`{"Action":"output","Package":"example","Output":"FAIL\n"}`,
+		`{"Action":"output","Package":"other","Test":"TestPasses","Output":"--- PASS: TestPasses (0.00s)\n"}`,
+		`{"Action":"pass","Package":"other","Test":"TestPasses"}`,
+		`{"Action":"output","Package":"other","Output":"PASS\n"}`,
+		`{"Action":"output","Package":"other","Output":"coverage: 80.0% of statements\n"}`,
+		`{"Action":"output","Package":"other","Output":"ok  \tother\t0.01s\n"}`,
+		`{"Action":"pass","Package":"other"}`,
+		`{"Action":"output","Package":"empty","Output":"?   \tempty\t[no test files]\n"}`,
+		`{"Action":"skip","Package":"empty"}`,
+		`{"Action":"output","Package":"example","Output":"FAIL\texample\t0.01s\n"}`,
+		`{"Action":"fail","Package":"example"}`,
+		`{"ImportPath":"broken","Action":"build-output","Output":"broken/a.go:3:1: syntax error\n"}`,
+	}
+
+	decode := quietEventOutput(true)
+	var shown []string
+	for _, line := range lines {
+		if output, ok := decode(line); ok {
+			shown = append(shown, output)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"--- FAIL: TestFails (0.00s)",
+		"FAIL\nFAIL\texample\t0.01s",
+		"broken/a.go:3:1: syntax error",
+	}, shown)
+}
+
+func TestGroupedEventOutput(t *testing.T) {
+	lines := []string{
+		`{"Action":"start","Package":"slow"}`,
+		`{"Action":"output","Package":"slow","Test":"TestSlow","Output":"=== RUN   TestSlow\n"}`,
+		`{"Action":"output","Package":"fast","Test":"TestFast","Output":"=== RUN   TestFast\n"}`,
+		`{"Action":"output","Package":"slow","Test":"TestSlow","Output":"    a_test.go:5: waiting\n"}`,
+		`{"Action":"output","Package":"fast","Test":"TestFast","Output":"--- PASS: TestFast (0.00s)\n"}`,
+		`{"Action":"pass","Package":"fast","Test":"TestFast"}`,
+		`{"Action":"output","Package":"fast","Output":"PASS\n"}`,
+		`{"Action":"output","Package":"fast","Output":"ok  \tfast\t0.01s\n"}`,
+		`{"Action":"pass","Package":"fast"}`,
+		`{"ImportPath":"broken","Action":"build-output","Output":"broken/a.go:3:1: syntax error\n"}`,
+		`{"Action":"output","Package":"slow","Test":"TestSlow","Output":"--- FAIL: TestSlow (1.00s)\n"}`,
+		`{"Action":"fail","Package":"slow","Test":"TestSlow"}`,
+		`{"Action":"output","Package":"slow","Output":"FAIL\tslow\t1.01s\n"}`,
+		`{"Action":"fail","Package":"slow"}`,
+		"not an event",
+	}
+
+	decode := groupedEventOutput()
+	var shown []string
+	for _, line := range lines {
+		if output, ok := decode(line); ok {
+			shown = append(shown, output)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"# fast\n=== RUN   TestFast\n--- PASS: TestFast (0.00s)\nPASS\nok  \tfast\t0.01s",
+		"broken/a.go:3:1: syntax error",
+		"# slow\n=== RUN   TestSlow\n    a_test.go:5: waiting\n--- FAIL: TestSlow (1.00s)\nFAIL\tslow\t1.01s",
+		"not an event",
+	}, shown)
+}
+
+// TestEventColorizer tests that only result lines are colored as results
+func TestEventColorizer(t *testing.T) {
+	inTest := testEvent{Action: "output", Package: "example", Test: "TestFoo"}
+	packageEvent := testEvent{Action: "output", Package: "example"}
+
+	tests := []struct {
+		name     string
+		event    testEvent
+		line     string
+		expected string
+	}{
+		{"test pass", inTest, "--- PASS: TestFoo (0.00s)", Green},
+		{"subtest fail", inTest, "    --- FAIL: TestFoo/bar (0.00s)", Red},
+		{"test skip", inTest, "--- SKIP: TestFoo (0.00s)", Yellow},
+		{"log mentioning PASS", inTest, "    foo_test.go:12: expected PASS, got FAIL", Magenta},
+		{"test printing PASS", inTest, "PASS", White},
+		{"run line", inTest, "=== RUN   TestFoo", White},
+		{"package pass", packageEvent, "PASS", Green},
+		{"package ok", packageEvent, "ok  \texample\t0.01s", Green},
+		{"package fail", packageEvent, "FAIL\texample\t0.01s", Red},
+		{"no test files", packageEvent, "?   \texample\t[no test files]", Yellow},
+		{"package output", packageEvent, "okay then", White},
+		{"package header", packageEvent, "# example", Bold},
+		{"test output like a header", inTest, "# example", White},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, eventColorizer(tt.event, tt.line))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"bytes"
 	"io"
 	"os"
 	"sync"
@@ -37,3 +38,57 @@ func captureStdout(t *testing.T, f func()) string {
 	}
 	return string(out)
 }
+
+var stderrMu sync.Mutex
+
+// captureStderr captures stderr during test execution
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+
+	stderrMu.Lock()
+	defer stderrMu.Unlock()
+
+	old := os.Stderr
+	defer func() { os.Stderr = old }()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		panic(err)
+	}
+
+	os.Stderr = w
+
+	f()
+
+	os.Stderr = old
+	_ = w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		panic(err)
+	}
+	return string(out)
+}
+
+// syncBuffer is a bytes.Buffer that is safe to write from one goroutine
+// while reading from another
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+func (b *syncBuffer) String() string {
+	return string(b.Bytes())
+}
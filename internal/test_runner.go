@@ -3,13 +3,18 @@ package internal
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -18,9 +23,27 @@ const (
 	Yellow  = "33;1"
 	Magenta = "35;1"
 	White   = "37;1"
+	// Bold is used for headings, such as package headers, in every theme
+	Bold = "1"
 )
 
-func streamOutput(r *bufio.Scanner, w io.Writer, wg *sync.WaitGroup, colorize bool) {
+// streamOptions controls how each line of test output is decorated before
+// it is written.
+type streamOptions struct {
+	colorize bool
+	// theme replaces the default colors when colorize is set
+	theme  colorTheme
+	prefix string
+	// observe, if set, is called with every raw line before it is decorated
+	observe func(line string)
+	// decode, if set, extracts the text to display from a raw line, which
+	// may be several lines; lines it rejects are not written
+	decode func(line string) (string, bool)
+	// process, if set, transforms every line before it is decorated
+	process func(line string) string
+}
+
+func streamOutput(r *bufio.Scanner, w io.Writer, wg *sync.WaitGroup, opts streamOptions) {
 	defer wg.Done()
 
 	for r.Scan() {
@@ -31,20 +54,47 @@ func streamOutput(r *bufio.Scanner, w io.Writer, wg *sync.WaitGroup, colorize bo
 		}
 
 		output := r.Text()
-		if colorize {
-			output = colorizeOutput(output)
+		if opts.observe != nil {
+			opts.observe(output)
 		}
-		_, err = w.Write([]byte(output))
-		if err != nil {
-			log.Println(err)
+		// Lines decoded from test2json events are colored by what the
+		// event says they are, rather than by what they contain
+		event, isEvent := testEvent{}, false
+		if opts.decode != nil && opts.colorize {
+			event, isEvent = decodeTestEvent(output)
 		}
-		_, err = w.Write([]byte("\n"))
-		if err != nil {
-			log.Println(err)
+		if opts.decode != nil {
+			var ok bool
+			if output, ok = opts.decode(output); !ok {
+				continue
+			}
+		}
+		for _, line := range strings.Split(output, "\n") {
+			if opts.process != nil {
+				line = opts.process(line)
+			}
+			if isEvent {
+				line = opts.theme.paint(line, eventColorizer(event, line))
+			} else if opts.colorize {
+				line = opts.theme.colorize(line)
+			}
+			line = opts.prefix + line + "\n"
+			_, err = w.Write([]byte(line))
+			if err != nil {
+				log.Println(err)
+			}
 		}
 	}
 }
 
+// writeLine writes line, which gotest-watch adds to the output of a run, to
+// w with the same prefix as the output's own lines.
+func (opts streamOptions) writeLine(w io.Writer, line string) {
+	if _, err := fmt.Fprintln(w, opts.prefix+line); err != nil {
+		log.Println(err)
+	}
+}
+
 //nolint:funlen
 func RunTests(
 	ctx context.Context,
@@ -66,63 +116,574 @@ func RunTests(
 		return
 	}
 
-	if config.GetClearScreen() {
-		fmt.Print("\x1b[H\x1b[2J")
+	if config.GetClearScreen() == ClearAlways {
+		fmt.Print(clearSequence(config.GetKeepScrollback(), terminalRows(os.Stdout)))
 	}
+	runner, err := lookupRunner(config.GetRunner())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		completeChan <- TestCompleteMessage{ExitCode: 1}
+		return
+	}
+
 	testCommand := config.BuildCommand()
-	fields := strings.Fields(testCommand)
+	argv := runner.Command(config)
+	if len(argv) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: the test command is empty")
+		completeChan <- TestCompleteMessage{ExitCode: 1}
+		return
+	}
+	if err := checkExecutable(argv[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		completeChan <- TestCompleteMessage{ExitCode: 1}
+		return
+	}
+	jsonEvents.emit(jsonEvent{Event: eventRunStarted, Command: testCommand, Trigger: runReason(ctx, config.WorkingDir)})
+	activity.begin(testCommand)
+	complete := func(result TestCompleteMessage, summary *RunSummary) {
+		activity.end()
+		jsonEvents.emit(finishedEvent(result, summary))
+		completeChan <- result
+	}
 
-	displayCommand(fields)
+	if path := config.GetOutputFile(); path != "" {
+		mirror, err := openOutputFile(path, testCommand)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not open output file: %v\n", err)
+		} else {
+			defer func() {
+				if err := mirror.Close(); err != nil {
+					log.Println(err)
+				}
+			}()
+			stdoutWriter = io.MultiWriter(stdoutWriter, mirror)
+			stderrWriter = io.MultiWriter(stderrWriter, mirror)
+		}
+	}
+
+	var runLog *outputFile
+	if keep := config.GetRunLogs(); keep > 0 {
+		var logErr error
+		runLog, logErr = openRunLog(filepath.Join(config.WorkingDir, runLogDir), testCommand, time.Now(), keep)
+		if logErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save the run's output: %v\n", logErr)
+		} else {
+			defer func() {
+				if err := runLog.Close(); err != nil {
+					log.Println(err)
+				}
+			}()
+		}
+	}
+
+	if !config.GetQuiet() {
+		// Written once the output file is open so that it records what
+		// started the run, and with which settings
+		headerOpts := streamOptions{prefix: config.GetLinePrefix()}
+		if reason := runReason(ctx, config.WorkingDir); reason != "" {
+			headerOpts.writeLine(stdoutWriter, fmt.Sprintf("[%s] %s", time.Now().Format(time.TimeOnly), reason))
+		}
+		if len(config.packageSettings) > 0 {
+			headerOpts.writeLine(stdoutWriter, "Using the settings for "+strings.Join(config.packageSettings, ", "))
+		}
+	}
+
+	if config.GetVet() {
+		vetOpts := streamOptions{colorize: config.GetColor(), theme: config.colorTheme(), prefix: config.GetLinePrefix()}
+		if !runVet(ctx, config, stdoutWriter, vetOpts) && config.GetVetSkipsTests() {
+			vetOpts.writeLine(stdoutWriter, "go vet reported problems, skipping tests")
+			complete(TestCompleteMessage{ExitCode: 1}, nil)
+			return
+		}
+	}
+
+	if preparing, ok := runner.(preparingRunner); ok {
+		if err := preparing.Prepare(ctx, config, stderrWriter); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			complete(TestCompleteMessage{ExitCode: 1}, nil)
+			return
+		}
+	}
+
+	switch {
+	case config.GetQuiet():
+	case config.GetRunner() == "" || config.GetRunner() == defaultRunnerName:
+		displayCommand(strings.Fields(testCommand))
+	default:
+		displayCommand(argv)
+	}
 
 	// Use CommandContext to support cancellation via context
 	//nolint:gosec // TODO: sanitize input
-	cmd := exec.CommandContext(ctx, "go", fields[1:]...)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	waitStopped := killProcessGroupOnCancel(cmd)
 
 	// Set working directory if specified
 	if config.WorkingDir != "" {
 		cmd.Dir = config.WorkingDir
 	}
+	if env := config.GetEnv(); len(env) > 0 {
+		cmd.Env = append(os.Environ(), formatEnv(env)...)
+	}
+
+	parser := newOutputParser()
+	previousCoverage := lastCoverage.snapshot()
+	opts := streamOptions{
+		colorize: config.GetColor() && !config.colorDelegated,
+		theme:    config.colorTheme(),
+		prefix:   config.GetLinePrefix(),
+		observe:  parser.parseLine,
+		process: func(line string) string {
+			return annotateCoverage(runner.ProcessLine(line), previousCoverage)
+		},
+	}
+
+	grouped := config.GetGroupByPackage() && config.GetVerbose()
+	var events *eventParser
+	if config.GetStructuredSummary() {
+		events = newEventParser()
+		opts.observe = func(line string) {
+			parser.parseLine(line)
+			events.parseLine(line)
+		}
+		switch {
+		case grouped:
+			opts.decode = groupedEventOutput()
+		case config.GetVerbose():
+			opts.decode = eventOutput
+		default:
+			opts.decode = quietEventOutput(false)
+		}
+	} else if config.GetQuietPass() {
+		opts.decode = quietEventOutput(true)
+	} else if grouped {
+		opts.decode = groupedEventOutput()
+	} else if config.runsWithJSON() {
+		// The tests are only run with -json to count or report them, so
+		// show the output -v would have shown only if it was asked for
+		opts.decode = eventOutput
+		if !config.GetVerbose() {
+			opts.decode = quietEventOutput(false)
+		}
+	}
+
+	if runLog != nil {
+		observe := opts.observe
+		opts.observe = func(line string) {
+			observe(line)
+			if _, werr := runLog.Write([]byte(line + "\n")); werr != nil {
+				log.Println(werr)
+			}
+		}
+	}
+
+	var junit *junitCollector
+	if config.GetJUnitPath() != "" {
+		junit = newJUnitCollector()
+		observe := opts.observe
+		opts.observe = func(line string) {
+			observe(line)
+			junit.parseLine(line)
+		}
+	}
+
+	// failures is only appended to by the collector, which serializes its
+	// reports
+	var failures []failureLocation
+	if jsonEvents.enabled() || config.GetFailureLocations() != "" {
+		collector := newFailureCollector(func(location failureLocation) {
+			failures = append(failures, location)
+			jsonEvents.emit(failureEvent(location))
+		})
+		observe := opts.observe
+		opts.observe = func(line string) {
+			observe(line)
+			collector.parseLine(line)
+		}
+	}
 
-	colorize := config.GetColor()
+	if config.colorDelegated {
+		// Lines are read as go test wrote them, not as colored by gotest
+		observe := opts.observe
+		opts.observe = func(line string) {
+			observe(stripColor(line))
+		}
+	}
+
+	// gotestsum prints its own output, so the -json output it writes is read
+	// once it has finished instead
+	var readJSON func(line string)
+	if config.jsonFile != "" {
+		readJSON = opts.observe
+		opts.observe, opts.decode = nil, nil
+		jsonFile := filepath.Join(config.WorkingDir, config.jsonFile)
+		if rerr := os.Remove(jsonFile); rerr != nil && !os.IsNotExist(rerr) {
+			log.Println(rerr)
+		}
+	}
+
+	collapsedOutput.reset()
+	stdoutOpts := opts
+	if config.GetCollapsePassing() {
+		// Only stdout carries the package trailers; build errors on stderr
+		// are shown as they arrive
+		decode := opts.decode
+		stdoutOpts.decode = func(line string) (string, bool) {
+			if decode != nil {
+				var ok bool
+				if line, ok = decode(line); !ok {
+					return "", false
+				}
+			}
+			return collapsedOutput.collapse(line)
+		}
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		fmt.Println(err)
+		complete(TestCompleteMessage{ExitCode: 1}, nil)
 		return
 	}
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		fmt.Println(err)
+		complete(TestCompleteMessage{ExitCode: 1}, nil)
 		return
 	}
 
+	start := time.Now()
 	err = cmd.Start()
 	if err != nil {
 		fmt.Println(err)
+		complete(TestCompleteMessage{ExitCode: 1}, nil)
 		return
 	}
 
+	// The runner's Stop is waited for before the run finishes, so that it
+	// can't stop the run that follows
+	stopDone := func() {}
+	if stopping, ok := runner.(stoppingRunner); ok {
+		finished, stopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(stopped)
+			select {
+			case <-ctx.Done():
+				if serr := stopping.Stop(config); serr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: could not stop the run: %v\n", serr)
+				}
+			case <-finished:
+			}
+		}()
+		stopDone = func() {
+			close(finished)
+			<-stopped
+		}
+	}
+
+	runOutput := stdoutWriter
+	var filter *colorFilter
+	if argv := config.colorFilter; len(argv) > 0 {
+		if filter, err = startColorFilter(argv, stdoutWriter); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not color output with %s: %v\n", argv[0], err)
+		} else {
+			runOutput = filter
+			stdoutOpts.colorize = false
+		}
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(2)
 
 	go func() {
 		r := bufio.NewScanner(stdout)
-		streamOutput(r, stdoutWriter, &wg, colorize)
+		streamOutput(r, runOutput, &wg, stdoutOpts)
 	}()
 
 	go func() {
 		r := bufio.NewScanner(stderr)
-		streamOutput(r, stderrWriter, &wg, colorize)
+		streamOutput(r, stderrWriter, &wg, opts)
 	}()
 
 	wg.Wait()
+	for _, line := range collapsedOutput.flush() {
+		if stdoutOpts.colorize {
+			line = opts.theme.colorize(line)
+		}
+		if _, werr := fmt.Fprintln(runOutput, opts.prefix+line); werr != nil {
+			log.Println(werr)
+		}
+	}
+	if filter != nil {
+		if ferr := filter.Close(); ferr != nil {
+			log.Println(ferr)
+		}
+	}
 	err = cmd.Wait()
+	waitStopped()
+	stopDone()
 	if err != nil {
 		log.Println(err)
 	}
+	if readJSON != nil {
+		if rerr := readJSONFile(filepath.Join(config.WorkingDir, config.jsonFile), readJSON); rerr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not read the results of gotestsum: %v\n", rerr)
+		}
+	}
+	// Runs interrupted with x, stopped fuzzing or cut short by shutting
+	// down didn't finish, so they aren't reported as failing
+	cancelled := ctx.Err() != nil
+	elapsed := time.Since(start)
+	metrics.recordRun(elapsed)
+	if profile := keptCoverProfile(config); profile != "" {
+		total, ok, cerr := totalCoverage(profile, start)
+		if cerr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not total the coverage: %v\n", cerr)
+		} else if ok {
+			parser.SetTotalCoverage(total)
+		}
+	}
+
+	var packages []PackageResult
+	if events != nil {
+		packages = events.Results()
+		for _, line := range formatPackageResults(packages) {
+			if opts.colorize {
+				line = opts.theme.colorize(line)
+			}
+			opts.writeLine(stdoutWriter, line)
+		}
+	}
+
+	results := parser.Results()
+	previous, hasPrevious := history.lastFinished()
+	if hasPrevious && !config.GetQuiet() {
+		var passed []string
+		if sameTestFilters(previous.config, config) {
+			passed = parser.Passed()
+		}
+		for _, line := range formatResultDiff(diffResults(previous.results, results, passed)) {
+			if opts.colorize {
+				line = opts.theme.colorize(line)
+			}
+			opts.writeLine(stdoutWriter, line)
+		}
+	}
+
+	if junit != nil {
+		file := junitFile(config.GetJUnitPath(), start)
+		if werr := writeJUnit(file, junit.report(start)); werr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not write JUnit report: %v\n", werr)
+		}
+	}
+
+	if target := config.GetFailureLocations(); target != "" {
+		lines := formatFailureLocations(config.WorkingDir, failures)
+		if werr := writeFailureLocations(target, stderrWriter, lines); werr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not write failure locations: %v\n", werr)
+		}
+	}
+
+	lastCoverage.update(parser.Coverage())
+	if profile := keptCoverProfile(config); profile != "" {
+		lastCoverProfile.set(profile)
+	}
+	history.record(runRecord{
+		config:    config.runSnapshot(),
+		command:   testCommand,
+		passed:    err == nil,
+		elapsed:   elapsed,
+		summary:   parser.Summary(elapsed, err == nil),
+		packages:  packages,
+		trigger:   runReason(ctx, config.WorkingDir),
+		logFile:   runLog.name(),
+		results:   results,
+		durations: parser.Durations(),
+		cancelled: cancelled,
+		uncounted: !config.GetVerbose() && !config.runsWithJSON(),
+	})
+
+	if n := config.GetProfileSummary(); n > 0 {
+		if slowest := parser.Slowest(n); len(slowest) > 0 {
+			opts.writeLine(stdoutWriter, formatSlowest(slowest))
+		}
+	}
+
+	if config.GetShuffle() != "" {
+		if seeds := parser.Seeds(); len(seeds) > 0 {
+			opts.writeLine(stdoutWriter, formatSeeds(seeds))
+		}
+	}
+
+	if config.GetReportChangedFiles() {
+		if paths := getChangedFiles(ctx); len(paths) > 0 {
+			opts.writeLine(stdoutWriter, formatChangedFiles(paths, config.WorkingDir))
+		}
+	}
+
+	footer := fmt.Sprintf("Finished in %.1fs", parser.Summary(elapsed, err == nil).Elapsed)
+	if config.GetSummaryLine() || config.GetQuietPass() {
+		footer = formatSummaryLine(parser.Summary(elapsed, err == nil))
+	}
+	footer += formatTotalCoverage(parser.Summary(elapsed, err == nil))
+	footer += formatCached(parser.Cached())
+	if opts.colorize {
+		color := Green
+		if err != nil {
+			color = Red
+		}
+		footer = opts.theme.paint(footer, color)
+	}
+	opts.writeLine(stdoutWriter, footer)
+
+	if budget, berr := time.ParseDuration(config.GetRunBudget()); berr == nil && budget > 0 && elapsed > budget {
+		warning := fmt.Sprintf("Warning: the run took %.1fs, over its %s budget", elapsed.Seconds(), config.GetRunBudget())
+		if opts.colorize {
+			warning = opts.theme.paint(warning, Yellow)
+		}
+		opts.writeLine(stdoutWriter, warning)
+	}
+
+	if config.GetSummaryJSON() {
+		summary := parser.Summary(elapsed, err == nil)
+		if _, werr := fmt.Fprintln(stderrWriter, summary.String()); werr != nil {
+			log.Println(werr)
+		}
+	}
+
+	if url := config.GetWebhookURL(); url != "" && !cancelled {
+		payload := newWebhookPayload(testCommand, parser.Summary(elapsed, err == nil), parser.Failed(), parser.Coverage())
+		deliveries.Add(1)
+		go func() {
+			defer deliveries.Done()
+			// The run's context is cancelled once it completes, so the
+			// post must not use it
+			if werr := postWebhook(context.Background(), url, payload); werr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not post to webhook: %v\n", werr)
+			}
+		}()
+	}
+
+	notifications := config.GetNotifications()
+	if (config.GetNotify() || len(notifications) > 0) && !cancelled {
+		message := newRunMessage(parser.Summary(elapsed, err == nil), parser.Failed(), hasPrevious && !previous.passed)
+		if config.GetNotify() {
+			notifyRun(message)
+		}
+		postNotifications(notifications, message)
+	}
+
+	if action := getPostRun(ctx); action != nil {
+		action(config, err == nil)
+	}
+
+	summary := parser.Summary(elapsed, err == nil)
+	complete(TestCompleteMessage{Passed: err == nil, ExitCode: exitCode(err), Packages: packages}, &summary)
+}
+
+// exitCode returns the exit status of a test command that finished with
+// err, or 1 if it did not exit by itself, such as when it was interrupted.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
 
-	completeChan <- TestCompleteMessage{}
+// deliveries tracks the webhook posts, chat messages and desktop
+// notifications still being sent for finished runs.
+var deliveries sync.WaitGroup
+
+// WaitForDeliveries waits until the webhook posts and desktop notifications
+// of finished runs have been sent, so that exiting after a single run does
+// not drop them.
+func WaitForDeliveries() {
+	deliveries.Wait()
+}
+
+// openOutputFile opens path for appending and writes a separator marking
+// the start of a run of command. Writes to the returned file are serialized
+// so that lines streamed from stdout and stderr are not interleaved.
+func openOutputFile(path, command string) (*outputFile, error) {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	separator := fmt.Sprintf("%s %s: %s\n", outputFileSeparator, time.Now().Format(time.RFC3339), command)
+	if _, err := f.WriteString(separator); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &outputFile{file: f}, nil
+}
+
+// outputFileSeparator begins the line written to the output file before
+// each run.
+const outputFileSeparator = "=== gotest-watch run"
+
+type outputFile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (o *outputFile) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.file.Write(p)
+}
+
+// name returns the name of the file, or "" if o is nil.
+func (o *outputFile) name() string {
+	if o == nil {
+		return ""
+	}
+	return o.file.Name()
+}
+
+func (o *outputFile) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.file.Close()
+}
+
+// formatEnv renders env as KEY=VALUE pairs, sorted by key.
+func formatEnv(env map[string]string) []string {
+	pairs := make([]string, 0, len(env))
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		pairs = append(pairs, key+"="+env[key])
+	}
+	return pairs
+}
+
+// formatChangedFiles lists paths, named as changedFileNames names them, as
+// the files that triggered a run.
+func formatChangedFiles(paths []string, workingDir string) string {
+	return "Triggered by: " + strings.Join(changedFileNames(paths, workingDir), ", ")
+}
+
+// changedFileNames returns paths relative to workingDir where possible, or
+// to the directory tests run in, the current one, if workingDir is unset.
+func changedFileNames(paths []string, workingDir string) []string {
+	if workingDir == "" {
+		if wd, err := os.Getwd(); err == nil {
+			workingDir = wd
+		}
+	}
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if workingDir != "" && filepath.IsAbs(path) {
+			if rel, err := filepath.Rel(workingDir, path); err == nil {
+				path = rel
+			}
+		}
+		names = append(names, filepath.ToSlash(path))
+	}
+	return names
 }
 
 func selectColorizer(line string) string {
@@ -142,7 +703,10 @@ func selectColorizer(line string) string {
 }
 
 func colorizeOutput(output string) string {
+	return colorizeWith(output, selectColorizer(output))
+}
+
+func colorizeWith(output, colorizer string) string {
 	reset := "\033[0m"
-	colorizer := selectColorizer(output)
 	return fmt.Sprintf("\033[%sm%s%s", colorizer, output, reset)
 }
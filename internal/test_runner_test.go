@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -64,7 +66,7 @@ func TestStreamOutput_ReadsAllLines(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	streamOutput(scanner, &output, &wg, false)
+	streamOutput(scanner, &output, &wg, streamOptions{})
 
 	assert.Equal(t, "line1\nline2\nline3\n", output.String(), "should write all lines to output")
 }
@@ -78,7 +80,7 @@ func TestStreamOutput_CallsWaitGroupDone(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	streamOutput(scanner, &output, &wg, false)
+	streamOutput(scanner, &output, &wg, streamOptions{})
 
 	// This should not block if wg.Done() was called
 	done := make(chan struct{})
@@ -104,7 +106,7 @@ func TestStreamOutput_HandlesEmptyInput(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	streamOutput(scanner, &output, &wg, false)
+	streamOutput(scanner, &output, &wg, streamOptions{})
 
 	assert.Equal(t, "", output.String(), "should handle empty input")
 }
@@ -119,11 +121,84 @@ func TestStreamOutput_PreservesLineContent(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	streamOutput(scanner, &output, &wg, false)
+	streamOutput(scanner, &output, &wg, streamOptions{})
 
 	assert.Equal(t, input, output.String(), "should preserve exact line content including special characters")
 }
 
+// TestStreamOutput_AppliesLinePrefix tests that the prefix is written before every line
+func TestStreamOutput_AppliesLinePrefix(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("line1\nline2\nline3\n"))
+
+	var output bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	streamOutput(scanner, &output, &wg, streamOptions{prefix: "[api] "})
+
+	assert.Equal(t, "[api] line1\n[api] line2\n[api] line3\n", output.String(), "should prefix each line")
+}
+
+// TestStreamOutput_EmptyPrefixLeavesOutputUnchanged tests that an empty prefix is a no-op
+func TestStreamOutput_EmptyPrefixLeavesOutputUnchanged(t *testing.T) {
+	input := "ok  \tpkg\t0.01s\n--- FAIL: TestBar\n"
+	scanner := bufio.NewScanner(strings.NewReader(input))
+
+	var output bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	streamOutput(scanner, &output, &wg, streamOptions{prefix: ""})
+
+	assert.Equal(t, input, output.String(), "empty prefix should not change output")
+}
+
+// TestStreamOutput_PrefixComposesWithColor tests that the prefix is left uncolored ahead of colorized output
+func TestStreamOutput_PrefixComposesWithColor(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("ok  \tpkg\n"))
+
+	var output bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	streamOutput(scanner, &output, &wg, streamOptions{colorize: true, prefix: "[api] "})
+
+	assert.Equal(t, "[api] "+colorizeOutput("ok  \tpkg")+"\n", output.String())
+}
+
+// TestStreamOutput_UsesTheme tests that colorized output uses the theme's colors
+func TestStreamOutput_UsesTheme(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("FAIL\tpkg\n"))
+
+	var output bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	theme := newColorTheme("default", map[string]string{"fail": "208"})
+	streamOutput(scanner, &output, &wg, streamOptions{colorize: true, theme: theme})
+
+	assert.Equal(t, "\033[1;38;5;208mFAIL\tpkg\033[0m\n", output.String())
+}
+
+// TestStreamOutput_ColorsEventsByStructure tests that decoded test2json events are colored by what they report
+func TestStreamOutput_ColorsEventsByStructure(t *testing.T) {
+	input := `{"Action":"output","Package":"example","Test":"TestFoo","Output":"    foo_test.go:9: PASS expected\n"}
+{"Action":"output","Package":"example","Test":"TestFoo","Output":"--- FAIL: TestFoo (0.00s)\n"}
+not an event PASS
+`
+	scanner := bufio.NewScanner(strings.NewReader(input))
+
+	var output bytes.Buffer
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	streamOutput(scanner, &output, &wg, streamOptions{colorize: true, decode: eventOutput})
+
+	assert.Equal(t, colorizeWith("    foo_test.go:9: PASS expected", Magenta)+"\n"+
+		colorizeWith("--- FAIL: TestFoo (0.00s)", Red)+"\n"+
+		colorizeWith("not an event PASS", Green)+"\n", output.String())
+}
+
 // TestRunTests_SendsTestCompleteMessage tests that runTests sends completion message
 func TestRunTests_SendsTestCompleteMessage(t *testing.T) {
 	testContent := `package example
@@ -241,7 +316,51 @@ func TestFailure(t *testing.T) {
 	testCompleteChan := make(chan TestCompleteMessage, 1)
 	go RunTests(ctx, testCompleteChan, nil, nil)
 
-	waitForTestCompletion(t, testCompleteChan)
+	select {
+	case result := <-testCompleteChan:
+		assert.False(t, result.Passed)
+		assert.Equal(t, 1, result.ExitCode, "the exit code of go test should be reported")
+	case <-time.After(30 * time.Second):
+		t.Fatal("TestCompleteMessage was not sent within timeout")
+	}
+}
+
+// TestRunTests_ReportsStartFailure tests that a command that cannot be started still completes the run
+func TestRunTests_ReportsStartFailure(t *testing.T) {
+	config := NewTestConfig()
+	config.SetCommandBase([]string{"no-such-binary-for-gotest-watch", "test"})
+	config.WorkingDir = t.TempDir()
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, nil, nil)
+		select {
+		case result := <-testCompleteChan:
+			assert.False(t, result.Passed)
+			assert.Equal(t, 1, result.ExitCode)
+		case <-time.After(30 * time.Second):
+			t.Fatal("TestCompleteMessage was not sent within timeout")
+		}
+	})
+}
+
+// TestRunTests_ExecutesCommandBaseProgram tests that the first word of the command base is the program run
+func TestRunTests_ExecutesCommandBaseProgram(t *testing.T) {
+	config := NewTestConfig()
+	config.SetCommandBase([]string{"echo", "from", "echo"})
+	config.WorkingDir = t.TempDir()
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.Contains(t, stdoutBuf.String(), "from echo ./...")
 }
 
 // TestRunTests_WaitsForBothStreamers tests that WaitGroup properly waits for both goroutines
@@ -388,7 +507,7 @@ func TestStreamOutput_HandlesScannerError(t *testing.T) {
 	_ = pw.Close()
 
 	// Should complete without panic even with error
-	streamOutput(scanner, &output, &wg, false)
+	streamOutput(scanner, &output, &wg, streamOptions{})
 
 	// Should still call wg.Done()
 	done := make(chan struct{})
@@ -415,7 +534,7 @@ func TestStreamOutput_WritesLineByLine(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	streamOutput(scanner, &output, &wg, false)
+	streamOutput(scanner, &output, &wg, streamOptions{})
 
 	lines := strings.Split(output.String(), "\n")
 	// Should have at least 3 lines (plus possible empty line at end)
@@ -592,9 +711,9 @@ func TestStreamOutput_ConcurrentSafety(t *testing.T) {
 	scanner3 := bufio.NewScanner(reader3)
 
 	// Run multiple streamOutput calls concurrently
-	go streamOutput(scanner1, &output1, &wg, false)
-	go streamOutput(scanner2, &output2, &wg, false)
-	go streamOutput(scanner3, &output3, &wg, false)
+	go streamOutput(scanner1, &output1, &wg, streamOptions{})
+	go streamOutput(scanner2, &output2, &wg, streamOptions{})
+	go streamOutput(scanner3, &output3, &wg, streamOptions{})
 
 	// Wait for all to complete
 	done := make(chan struct{})
@@ -613,3 +732,764 @@ func TestStreamOutput_ConcurrentSafety(t *testing.T) {
 		t.Fatal("concurrent streamOutput calls did not complete")
 	}
 }
+
+// TestRunTests_EmitsSummaryJSONOncePerRun tests that --summary-json writes a single summary line to stderr
+func TestRunTests_EmitsSummaryJSONOncePerRun(t *testing.T) {
+	testContent := `package summary
+
+import "testing"
+
+func TestPassOne(t *testing.T) {}
+
+func TestPassTwo(t *testing.T) {}
+
+func TestSkipped(t *testing.T) {
+	t.Skip("skipping")
+}
+
+func TestFailing(t *testing.T) {
+	t.Fatal("intentional failure")
+}
+`
+	tempDir := setupTestModule(t, testContent)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetVerbose(true)
+	config.SetSummaryJSON(true)
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	var summaries []RunSummary
+	for _, line := range strings.Split(stderrBuf.String(), "\n") {
+		if strings.HasPrefix(line, `{"pass"`) {
+			var summary RunSummary
+			require.NoError(t, json.Unmarshal([]byte(line), &summary))
+			summaries = append(summaries, summary)
+		}
+	}
+
+	require.Len(t, summaries, 1, "summary should be emitted exactly once")
+	assert.Equal(t, 2, summaries[0].Pass)
+	assert.Equal(t, 1, summaries[0].Fail)
+	assert.Equal(t, 1, summaries[0].Skip)
+	assert.False(t, summaries[0].OK)
+	assert.NotContains(t, stdoutBuf.String(), `{"pass"`, "summary should not be written to stdout")
+}
+
+// TestRunTests_SummaryJSONCountsWithoutVerbose tests that --summary-json counts passing tests that go test only lists with -v
+func TestRunTests_SummaryJSONCountsWithoutVerbose(t *testing.T) {
+	testContent := `package summary
+
+import "testing"
+
+func TestPassOne(t *testing.T) {}
+
+func TestPassTwo(t *testing.T) {
+	t.Log("only shown with -v")
+}
+`
+	tempDir := setupTestModule(t, testContent)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetSummaryJSON(true)
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	var summary RunSummary
+	line, _, _ := strings.Cut(strings.TrimSpace(stderrBuf.String()), "\n")
+	require.NoError(t, json.Unmarshal([]byte(line), &summary))
+	assert.Equal(t, 2, summary.Pass)
+	assert.True(t, summary.OK)
+	assert.NotContains(t, stdoutBuf.String(), `"Action"`, "events should be decoded")
+	assert.NotContains(t, stdoutBuf.String(), "only shown with -v")
+	assert.Contains(t, stdoutBuf.String(), "ok")
+}
+
+// TestRunTests_ProfileSummaryWithoutVerbose tests that --profile-summary times passing tests that go test only lists with -v
+func TestRunTests_ProfileSummaryWithoutVerbose(t *testing.T) {
+	testContent := `package profile
+
+import "testing"
+
+func TestQuick(t *testing.T) {}
+`
+	tempDir := setupTestModule(t, testContent)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetProfileSummary(1)
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	output := stdoutBuf.String()
+	assert.Contains(t, output, "Slowest: TestQuick")
+	assert.NotContains(t, output, "=== RUN", "output should be shown as without -v")
+}
+
+// TestFormatChangedFiles tests that changed files are listed relative to the working directory
+func TestFormatChangedFiles(t *testing.T) {
+	workingDir := filepath.Join(string(filepath.Separator), "repo")
+	paths := []string{
+		filepath.Join(workingDir, "internal", "foo.go"),
+		filepath.Join(workingDir, "internal", "bar.go"),
+	}
+
+	assert.Equal(t, "Triggered by: internal/foo.go, internal/bar.go", formatChangedFiles(paths, workingDir))
+	assert.Equal(t, "Triggered by: main.go", formatChangedFiles([]string{"main.go"}, ""))
+}
+
+// TestChangedFileNames_DefaultsToCurrentDirectory tests that without a working directory paths are named relative to the current one, where tests run
+func TestChangedFileNames_DefaultsToCurrentDirectory(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	paths := []string{filepath.Join(wd, "internal", "foo.go"), filepath.Join(wd, "main.go")}
+
+	assert.Equal(t, []string{"internal/foo.go", "main.go"}, changedFileNames(paths, ""))
+}
+
+// TestRunTests_MirrorsOutputToFile tests that --output-file receives the streamed output, appending across runs
+func TestRunTests_MirrorsOutputToFile(t *testing.T) {
+	testContent := `package mirror
+
+import "testing"
+
+func TestMirrored(t *testing.T) {}
+`
+	tempDir := setupTestModule(t, testContent)
+	outputPath := filepath.Join(t.TempDir(), "gotest-watch.out")
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetVerbose(true)
+	config.SetOutputFile(outputPath)
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var expected strings.Builder
+	for range 2 {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		captureStdout(t, func() {
+			go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+			waitForTestCompletion(t, testCompleteChan)
+		})
+		require.Contains(t, stdoutBuf.String(), "TestMirrored")
+		expected.WriteString(stdoutBuf.String())
+		expected.WriteString(stderrBuf.String())
+	}
+
+	contents, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	var separators int
+	var mirrored strings.Builder
+	for _, line := range strings.SplitAfter(string(contents), "\n") {
+		if strings.HasPrefix(line, outputFileSeparator) {
+			separators++
+			assert.Contains(t, line, "go test . -v", "separator should name the command that was run")
+			continue
+		}
+		mirrored.WriteString(line)
+	}
+
+	assert.Equal(t, 2, separators, "each run should begin with a separator")
+	assert.True(t, strings.HasPrefix(string(contents), outputFileSeparator), "file should begin with a separator")
+	assert.Equal(t, expected.String(), mirrored.String(), "file should contain the same output as the writers")
+}
+
+// TestRunTests_StructuredSummary tests that --structured-summary decodes -json output and reports each package
+func TestRunTests_StructuredSummary(t *testing.T) {
+	testContent := `package structured
+
+import "testing"
+
+func TestPasses(t *testing.T) {}
+
+func TestSkipped(t *testing.T) {
+	t.Skip("skipping")
+}
+
+func TestFails(t *testing.T) {
+	t.Fatal("intentional failure")
+}
+`
+	tempDir := setupTestModule(t, testContent)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetStructuredSummary(true)
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	var result TestCompleteMessage
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		select {
+		case result = <-testCompleteChan:
+		case <-time.After(30 * time.Second):
+			t.Fatal("TestCompleteMessage was not sent within timeout")
+		}
+	})
+
+	output := stdoutBuf.String()
+	assert.NotContains(t, output, `"Action"`, "raw JSON events should not be displayed")
+	assert.Contains(t, output, "--- FAIL: TestFails", "test output should be displayed")
+	assert.NotContains(t, output, "--- PASS: TestPasses", "only failures are shown without -v")
+	assert.NotContains(t, output, "=== RUN", "only failures are shown without -v")
+	assert.Contains(t, output, "FAIL  testmodule  1 passed, 1 failed, 1 skipped")
+
+	assert.False(t, result.Passed)
+	assert.Equal(t, []PackageResult{
+		{Package: "testmodule", Action: "fail", Pass: 1, Fail: 1, Skip: 1},
+	}, result.Packages)
+}
+
+// TestRunTests_SummaryLine tests that --summary-line counts the tests of a run without showing verbose output
+func TestRunTests_SummaryLine(t *testing.T) {
+	testContent := `package summary
+
+import "testing"
+
+func TestPasses(t *testing.T) {
+	t.Log("only shown with -v")
+}
+
+func TestSkipped(t *testing.T) {
+	t.Skip("skipping")
+}
+
+func TestFails(t *testing.T) {
+	t.Fatal("intentional failure")
+}
+`
+	tempDir := setupTestModule(t, testContent)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetSummaryLine(true)
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	output := stdoutBuf.String()
+	assert.NotContains(t, output, `"Action"`, "raw JSON events should not be displayed")
+	assert.NotContains(t, output, "=== RUN", "verbose output should not be displayed")
+	assert.NotContains(t, output, "only shown with -v")
+	assert.NotContains(t, output, "--- PASS")
+	assert.Contains(t, output, "--- FAIL: TestFails")
+	assert.Contains(t, output, "intentional failure")
+	assert.Regexp(t, `(?m)^✓ 1 passed ✗ 1 failed ⊘ 1 skipped in \d+\.\ds\n$`, output)
+}
+
+// TestRunTests_QuietPass tests that quietpass only shows failures and the result counts, even with -v
+func TestRunTests_QuietPass(t *testing.T) {
+	testContent := `package quiet
+
+import "testing"
+
+func TestPasses(t *testing.T) {
+	t.Log("passing noise")
+}
+
+func TestFails(t *testing.T) {
+	t.Fatal("intentional failure")
+}
+`
+	tempDir := setupTestModule(t, testContent)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.ToggleVerbose()
+	config.ToggleQuietPass()
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	output := stdoutBuf.String()
+	assert.NotContains(t, output, `"Action"`, "raw JSON events should not be displayed")
+	assert.NotContains(t, output, "=== RUN")
+	assert.NotContains(t, output, "passing noise")
+	assert.NotContains(t, output, "--- PASS")
+	assert.Contains(t, output, "--- FAIL: TestFails")
+	assert.Contains(t, output, "intentional failure")
+	assert.Regexp(t, `(?m)^FAIL\s+testmodule`, output)
+	assert.Regexp(t, `(?m)^✓ 1 passed ✗ 1 failed ⊘ 0 skipped in \d+\.\ds\n$`, output)
+}
+
+// TestRunTests_CollapsePassing tests that a passing package is shown as its ok line and its output kept for show
+func TestRunTests_CollapsePassing(t *testing.T) {
+	testContent := `package collapse
+
+import "testing"
+
+func TestPasses(t *testing.T) {
+	t.Log("passing noise")
+}
+`
+	tempDir := setupTestModule(t, testContent)
+	t.Cleanup(collapsedOutput.reset)
+	// Earlier runs of other tests in the same module would list their
+	// failures as fixed
+	saved := history
+	history = &runHistory{}
+	t.Cleanup(func() { history = saved })
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.ToggleVerbose()
+	config.ToggleCollapsePassing()
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.Regexp(t, `^ok\s+testmodule\s+\S+\nFinished in \d+\.\ds\n$`, stdoutBuf.String())
+
+	collapsed, err := collapsedOutput.get("testmodule")
+	require.NoError(t, err)
+	assert.Contains(t, collapsed, "=== RUN   TestPasses")
+	assert.Contains(t, strings.Join(collapsed, "\n"), "passing noise")
+}
+
+// TestRunTests_PrintsRunHeader tests that a run starts with the time and what triggered it
+func TestRunTests_PrintsRunHeader(t *testing.T) {
+	tempDir := setupTestModule(t, "package header\n")
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.WorkingDir = tempDir
+
+	ctx := changeRunContext(WithConfig(context.Background(), config), config,
+		[]string{filepath.Join(tempDir, "header.go")})
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.Regexp(t, `^\[\d\d:\d\d:\d\d\] file change: header\.go\n`, stdoutBuf.String())
+}
+
+// TestRunTests_Quiet tests that quiet runs leave out the header and command, but not the test output
+func TestRunTests_Quiet(t *testing.T) {
+	tempDir := setupTestModule(t, "package quiet\n")
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetQuiet(true)
+	config.WorkingDir = tempDir
+
+	ctx := changeRunContext(WithConfig(context.Background(), config), config,
+		[]string{filepath.Join(tempDir, "quiet.go")})
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	echoed := captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.Empty(t, echoed)
+	assert.Regexp(t, `^ok\s+testmodule\b.*\nFinished in \d+\.\ds( \(1 package cached\))?\n$`, stdoutBuf.String())
+}
+
+// TestRunTests_WarnsOverBudget tests that a run taking longer than its budget is reported after its duration
+func TestRunTests_WarnsOverBudget(t *testing.T) {
+	tempDir := setupTestModule(t, "package budget\n")
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetRunBudget("1ns")
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.Regexp(t, `(?m)^Finished in \d+\.\ds( \(1 package cached\))?\nWarning: the run took \d+\.\ds, over its 1ns budget\n$`, stdoutBuf.String())
+
+	config.SetRunBudget("1h")
+	stdoutBuf.Reset()
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.NotContains(t, stdoutBuf.String(), "Warning")
+}
+
+// TestRunTests_PrefixesFooter tests that the line ending a run and the budget warning get the line prefix
+func TestRunTests_PrefixesFooter(t *testing.T) {
+	tempDir := setupTestModule(t, "package budget\n")
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetRunBudget("1ns")
+	config.SetLinePrefix("[api] ")
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.Regexp(t, `(?m)^\[api\] Finished in \d+\.\ds.*\n\[api\] Warning: the run took`, stdoutBuf.String())
+}
+
+// TestRunTests_PrefixesHeader tests that the lines naming what started a run and the package settings it used get the line prefix and are mirrored to the output file
+func TestRunTests_PrefixesHeader(t *testing.T) {
+	tempDir := setupTestModule(t, "package header\n")
+	outputPath := filepath.Join(t.TempDir(), "gotest-watch.out")
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetLinePrefix("[api] ")
+	config.SetOutputFile(outputPath)
+	config.WorkingDir = tempDir
+
+	ctx := runContext(context.Background(), config, triggerForceRun)
+	getConfig(ctx).packageSettings = []string{"./..."}
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	contents, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	header := `(?m)^\[api\] \[\d{2}:\d{2}:\d{2}\] manual\n\[api\] Using the settings for \./\.\.\.\n`
+	assert.Regexp(t, header, stdoutBuf.String())
+	assert.Regexp(t, header, string(contents))
+}
+
+// TestRunTests_ReportsChangedResults tests that a run lists the tests that started failing or were fixed since the last run
+func TestRunTests_ReportsChangedResults(t *testing.T) {
+	failing := `package flip
+
+import "testing"
+
+func TestFlips(t *testing.T) {
+	t.Fatal("broken")
+}
+
+func TestSteady(t *testing.T) {}
+`
+	for _, verbose := range []bool{true, false} {
+		t.Run(fmt.Sprintf("verbose=%v", verbose), func(t *testing.T) {
+			tempDir := setupTestModule(t, failing)
+
+			config := NewTestConfig()
+			config.SetTestPath(".")
+			config.SetVerbose(verbose)
+			config.WorkingDir = tempDir
+			history.record(runRecord{
+				config:  config.Snapshot(),
+				results: map[string]string{"testmodule.TestFlips": "pass", "testmodule.TestSteady": "pass"},
+			})
+
+			ctx := WithConfig(context.Background(), config)
+			testCompleteChan := make(chan TestCompleteMessage, 1)
+
+			var stdoutBuf, stderrBuf bytes.Buffer
+			captureStdout(t, func() {
+				go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+				waitForTestCompletion(t, testCompleteChan)
+			})
+			assert.Contains(t, stdoutBuf.String(), "Newly failing:\n  testmodule.TestFlips\n")
+
+			fixed := strings.Replace(failing, `t.Fatal("broken")`, "", 1)
+			require.NoError(t, os.WriteFile(filepath.Join(tempDir, "example_test.go"), []byte(fixed), 0o600))
+			stdoutBuf.Reset()
+			captureStdout(t, func() {
+				go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+				waitForTestCompletion(t, testCompleteChan)
+			})
+			assert.Contains(t, stdoutBuf.String(), "Fixed:\n  testmodule.TestFlips\nFinished in",
+				"unchanged results should not be listed")
+			assert.NotContains(t, stdoutBuf.String(), "Newly failing")
+		})
+	}
+}
+
+// commandRunner always runs the same command
+type commandRunner []string
+
+func (r commandRunner) Command(_ *TestConfig) []string {
+	return r
+}
+
+func (r commandRunner) ProcessLine(line string) string {
+	return line
+}
+
+// TestRunTests_CancelKillsChildProcesses tests that cancelling a run kills processes started by the command too
+func TestRunTests_CancelKillsChildProcesses(t *testing.T) {
+	initRegistry()
+	// The backgrounded sleep holds stdout open, so the run only finishes
+	// promptly if it is killed along with the shell
+	RegisterRunner("orphaning", commandRunner{"sh", "-c", "sleep 30 & wait"})
+
+	config := NewTestConfig()
+	config.SetRunner("orphaning")
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+
+		select {
+		case result := <-testCompleteChan:
+			assert.False(t, result.Passed, "an interrupted run should not pass")
+		case <-time.After(5 * time.Second):
+			t.Fatal("cancelled run did not complete; child processes were left running")
+		}
+	})
+}
+
+// TestRunTests_CancelStopsProcessesIgnoringInterrupt tests that processes ignoring the interrupt are terminated before the run finishes
+func TestRunTests_CancelStopsProcessesIgnoringInterrupt(t *testing.T) {
+	initRegistry()
+	marker := filepath.Join(t.TempDir(), "terminated")
+	// The background loop ignores SIGINT and doesn't hold stdout open, so
+	// the shell exits on the interrupt and leaves it running unless the
+	// group is terminated before the run finishes
+	script := `(trap "" INT; trap "echo > ` + marker + `; exit" TERM; while :; do sleep 0.1; done) >/dev/null 2>&1 & wait`
+	RegisterRunner("lingering", commandRunner{"sh", "-c", script})
+
+	config := NewTestConfig()
+	config.SetRunner("lingering")
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+
+		select {
+		case <-testCompleteChan:
+			assert.FileExists(t, marker, "the process group should be terminated before the run finishes")
+		case <-time.After(10 * time.Second):
+			t.Fatal("cancelled run did not complete")
+		}
+	})
+}
+
+// stoppedRunner is a commandRunner that records being stopped in stops.
+type stoppedRunner struct {
+	commandRunner
+	stops chan<- string
+}
+
+func (r stoppedRunner) Stop(config *TestConfig) error {
+	r.stops <- config.GetRunner()
+	return nil
+}
+
+// TestRunTests_CancelStopsRunnerCommand tests that a runner whose command outlives its process is stopped before an interrupted run finishes, and only then
+func TestRunTests_CancelStopsRunnerCommand(t *testing.T) {
+	initRegistry()
+	stops := make(chan string, 2)
+	RegisterRunner("stopped-slow", stoppedRunner{commandRunner{"sleep", "30"}, stops})
+	RegisterRunner("stopped-quick", stoppedRunner{commandRunner{"go", "version"}, stops})
+
+	config := NewTestConfig()
+	config.SetRunner("stopped-slow")
+
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+		waitForTestCompletion(t, testCompleteChan)
+		require.Len(t, stops, 1, "the run should be stopped before it finishes")
+		assert.Equal(t, "stopped-slow", <-stops)
+
+		config.SetRunner("stopped-quick")
+		go RunTests(WithConfig(context.Background(), config), testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+		assert.Empty(t, stops, "a run that finishes on its own should not be stopped")
+	})
+}
+
+// TestRunTests_RunsPostRunAction tests that an action attached to the run's context runs once it finishes
+func TestRunTests_RunsPostRunAction(t *testing.T) {
+	initRegistry()
+	RegisterRunner("passing", commandRunner{"go", "version"})
+
+	config := NewTestConfig()
+	config.SetRunner("passing")
+
+	var called int
+	var passed bool
+	ctx := withPostRun(WithConfig(context.Background(), config), func(_ *TestConfig, ok bool) {
+		called++
+		passed = ok
+	})
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+
+	assert.Equal(t, 1, called, "post-run action should run exactly once")
+	assert.True(t, passed, "post-run action should be told the run passed")
+}
+
+// TestRunTests_SetsEnv tests that env vars from the config reach the test process
+func TestRunTests_SetsEnv(t *testing.T) {
+	testContent := `package env
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	if got := os.Getenv("GOTEST_WATCH_DB_URL"); got != "postgres://localhost/test" {
+		t.Fatalf("GOTEST_WATCH_DB_URL = %q", got)
+	}
+}
+`
+	tempDir := setupTestModule(t, testContent)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetCount(1)
+	config.SetEnvVar("GOTEST_WATCH_DB_URL", "postgres://localhost/test")
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	var result TestCompleteMessage
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		select {
+		case result = <-testCompleteChan:
+		case <-time.After(30 * time.Second):
+			t.Fatal("TestCompleteMessage was not sent within timeout")
+		}
+	})
+
+	assert.True(t, result.Passed, "test should see the env var: %s", stdoutBuf.String())
+}
+
+// TestFormatEnv tests that env vars are rendered sorted by key
+func TestFormatEnv(t *testing.T) {
+	assert.Equal(t, []string{"A=1", "B=", "C=x=y"}, formatEnv(map[string]string{"C": "x=y", "A": "1", "B": ""}))
+}
+
+// TestRunTests_ReportsShuffleSeed tests that the seed of a shuffled run is reported so it can be reproduced
+func TestRunTests_ReportsShuffleSeed(t *testing.T) {
+	testContent := `package shuffle
+
+import "testing"
+
+func TestOne(t *testing.T) {}
+
+func TestTwo(t *testing.T) {}
+`
+	tempDir := setupTestModule(t, testContent)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetVerbose(true)
+	config.SetCount(1)
+	config.SetShuffle("12345")
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		select {
+		case <-testCompleteChan:
+		case <-time.After(30 * time.Second):
+			t.Fatal("TestCompleteMessage was not sent within timeout")
+		}
+	})
+
+	assert.Contains(t, stdoutBuf.String(), "Shuffle seed: 12345 (run `shuffle 12345` to reproduce)")
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// runVet runs `go vet` on the configured test path, writing its findings to
+// w decorated like test output. It reports whether vet found no problems.
+func runVet(ctx context.Context, config *TestConfig, w io.Writer, opts streamOptions) bool {
+	argv := append([]string{"go", "vet"}, strings.Fields(config.GetTestPath())...)
+	if !config.GetQuiet() {
+		displayCommand(argv)
+	}
+
+	//nolint:gosec // TODO: sanitize input
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	waitStopped := killProcessGroupOnCancel(cmd)
+	if config.WorkingDir != "" {
+		cmd.Dir = config.WorkingDir
+	}
+	if env := config.GetEnv(); len(env) > 0 {
+		cmd.Env = append(os.Environ(), formatEnv(env)...)
+	}
+
+	out, err := cmd.CombinedOutput()
+	waitStopped()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	streamOutput(bufio.NewScanner(bytes.NewReader(out)), w, &wg, opts)
+	return err == nil
+}
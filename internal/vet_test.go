@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// vetProblemTest has unreachable code, which go vet reports but the subset
+// of vet checks run by go test does not
+const vetProblemTest = `package vetted
+
+import "testing"
+
+func TestVetted(t *testing.T) {
+	return
+	t.Log("unreachable")
+}
+`
+
+// runVetted runs the tests of a module with a vet problem and returns the run's output and result
+func runVetted(t *testing.T, strict bool) (string, TestCompleteMessage) {
+	t.Helper()
+	tempDir := setupTestModule(t, vetProblemTest)
+
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetVerbose(true)
+	config.SetVet(true)
+	config.SetVetSkipsTests(strict)
+	config.WorkingDir = tempDir
+
+	ctx := WithConfig(context.Background(), config)
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	var result TestCompleteMessage
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		select {
+		case result = <-testCompleteChan:
+		case <-time.After(30 * time.Second):
+			t.Fatal("TestCompleteMessage was not sent within timeout")
+		}
+	})
+	return stdoutBuf.String(), result
+}
+
+// TestRunTests_VetReportsProblemsThenTests tests that vet findings are shown before the tests run
+func TestRunTests_VetReportsProblemsThenTests(t *testing.T) {
+	output, _ := runVetted(t, false)
+
+	assert.Contains(t, output, "example_test.go:7:", "vet findings should be displayed")
+	assert.Contains(t, output, "--- PASS: TestVetted", "tests should still run")
+	assert.Less(t, strings.Index(output, "example_test.go:7:"), strings.Index(output, "--- PASS: TestVetted"))
+}
+
+// TestRunTests_VetFailureSkipsTestsWhenStrict tests that strict vet mode does not run the tests
+func TestRunTests_VetFailureSkipsTestsWhenStrict(t *testing.T) {
+	output, result := runVetted(t, true)
+
+	assert.Contains(t, output, "go vet reported problems, skipping tests")
+	assert.NotContains(t, output, "TestVetted", "tests should not run")
+	assert.False(t, result.Passed)
+}
+
+// TestHandleVet_TogglesModes tests that vet toggles vet and vet strict toggles skipping tests
+func TestHandleVet_TogglesModes(t *testing.T) {
+	config := NewTestConfig()
+
+	output := captureStdout(t, func() {
+		assert.NoError(t, handleVet(config, nil))
+		assert.NoError(t, handleVet(config, []string{"strict"}))
+	})
+
+	assert.True(t, config.GetVet())
+	assert.True(t, config.GetVetSkipsTests())
+	assert.Equal(t, "Vet: enabled\nVet strict: enabled\n", output)
+
+	output = captureStdout(t, func() {
+		assert.NoError(t, handleVet(config, []string{"loud"}))
+	})
+	assert.Equal(t, "Error: vet accepts no arguments or \"strict\", got \"loud\"\n", output)
+	assert.True(t, config.GetVet(), "an invalid argument should change nothing")
+	assert.True(t, config.GetVetSkipsTests())
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// watchdog detects when the dispatcher stops making progress while a test
+// run is in flight, which usually means one of the goroutines is stuck on a
+// blocked channel send.
+type watchdog struct {
+	mu           sync.Mutex
+	now          func() time.Time
+	timeout      time.Duration
+	armed        bool
+	reported     bool
+	lastProgress time.Time
+}
+
+func newWatchdog(timeout time.Duration, now func() time.Time) *watchdog {
+	return &watchdog{
+		now:          now,
+		timeout:      timeout,
+		lastProgress: now(),
+	}
+}
+
+// kick records that progress was made.
+func (w *watchdog) kick() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastProgress = w.now()
+	w.reported = false
+}
+
+// arm starts watching for stalls, e.g. when a test run starts.
+func (w *watchdog) arm() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.armed = true
+	w.lastProgress = w.now()
+	w.reported = false
+}
+
+// disarm stops watching for stalls, e.g. while idle waiting for input.
+func (w *watchdog) disarm() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.armed = false
+}
+
+// stalled reports whether no progress has been made for the timeout while
+// armed. It reports each stall only once, until progress is made again.
+func (w *watchdog) stalled() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.armed || w.reported || w.now().Sub(w.lastProgress) < w.timeout {
+		return false
+	}
+	w.reported = true
+	return true
+}
+
+// run checks for stalls every interval until ctx is cancelled, calling dump
+// when one is found.
+func (w *watchdog) run(ctx context.Context, interval time.Duration, dump func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.stalled() {
+				dump()
+			}
+		}
+	}
+}
+
+// startWatchdog starts a watchdog if the config enables one, logging a dump
+// of all goroutines when a stall is detected. It returns nil otherwise.
+func startWatchdog(ctx context.Context, config *TestConfig) *watchdog {
+	timeout := time.Duration(config.GetDeadlockTimeout()) * time.Second
+	if timeout <= 0 {
+		return nil
+	}
+
+	w := newWatchdog(timeout, time.Now)
+	logger := getLogger(ctx)
+	go w.run(ctx, timeout/2, func() {
+		buf := make([]byte, 1<<20)
+		n := runtime.Stack(buf, true)
+		logger.Log(ctx, slog.LevelWarn, "no progress detected, dumping goroutines",
+			"timeout", timeout, "goroutines", string(buf[:n]))
+	})
+	return w
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock for watchdog tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// TestWatchdog_NotStalledWhileDisarmed tests that idle periods are never reported
+func TestWatchdog_NotStalledWhileDisarmed(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	w := newWatchdog(time.Minute, clock.Now)
+
+	clock.Advance(time.Hour)
+
+	assert.False(t, w.stalled(), "disarmed watchdog should not report a stall")
+}
+
+// TestWatchdog_StalledAfterTimeoutWhileArmed tests that a stall is reported once the timeout passes
+func TestWatchdog_StalledAfterTimeoutWhileArmed(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	w := newWatchdog(time.Minute, clock.Now)
+	w.arm()
+
+	clock.Advance(59 * time.Second)
+	assert.False(t, w.stalled(), "should not stall before the timeout")
+
+	clock.Advance(time.Second)
+	assert.True(t, w.stalled(), "should stall at the timeout")
+}
+
+// TestWatchdog_ReportsEachStallOnce tests that a stall is only reported once until progress resumes
+func TestWatchdog_ReportsEachStallOnce(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	w := newWatchdog(time.Minute, clock.Now)
+	w.arm()
+
+	clock.Advance(2 * time.Minute)
+	require.True(t, w.stalled())
+	assert.False(t, w.stalled(), "same stall should not be reported twice")
+
+	w.kick()
+	clock.Advance(2 * time.Minute)
+	assert.True(t, w.stalled(), "a new stall after progress should be reported")
+}
+
+// TestWatchdog_KickResetsTimer tests that progress pushes back the stall deadline
+func TestWatchdog_KickResetsTimer(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	w := newWatchdog(time.Minute, clock.Now)
+	w.arm()
+
+	clock.Advance(50 * time.Second)
+	w.kick()
+	clock.Advance(50 * time.Second)
+
+	assert.False(t, w.stalled(), "kick should reset the stall timer")
+}
+
+// TestWatchdog_DisarmStopsReporting tests that disarming after a run completes suppresses stalls
+func TestWatchdog_DisarmStopsReporting(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	w := newWatchdog(time.Minute, clock.Now)
+	w.arm()
+	w.disarm()
+
+	clock.Advance(time.Hour)
+
+	assert.False(t, w.stalled())
+}
+
+// TestWatchdog_NilIsNoOp tests that a disabled (nil) watchdog can be used safely
+func TestWatchdog_NilIsNoOp(t *testing.T) {
+	var w *watchdog
+
+	assert.NotPanics(t, func() {
+		w.kick()
+		w.arm()
+		w.disarm()
+	})
+}
+
+// TestStartWatchdog_DisabledByDefault tests that no watchdog is started without a timeout
+func TestStartWatchdog_DisabledByDefault(t *testing.T) {
+	assert.Nil(t, startWatchdog(context.Background(), NewTestConfig()))
+}
+
+// TestStartWatchdog_LogsGoroutineDump tests that a detected stall is logged with a goroutine dump
+func TestStartWatchdog_LogsGoroutineDump(t *testing.T) {
+	config := NewTestConfig()
+	config.SetDeadlockTimeout(1)
+
+	var logs syncBuffer
+	ctx, cancel := context.WithCancel(WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil))))
+	defer cancel()
+
+	w := startWatchdog(ctx, config)
+	require.NotNil(t, w)
+	w.arm()
+
+	require.Eventually(t, func() bool {
+		return bytes.Contains(logs.Bytes(), []byte("goroutine"))
+	}, 5*time.Second, 50*time.Millisecond, "stall should be logged with a goroutine dump")
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout limits how long posting a run's result may take.
+const webhookTimeout = 10 * time.Second
+
+// webhookPayload is the JSON body posted to the webhook after each run.
+type webhookPayload struct {
+	// Status is "pass" or "fail"
+	Status      string   `json:"status"`
+	Command     string   `json:"command"`
+	Duration    float64  `json:"duration"`
+	Pass        int      `json:"pass"`
+	Fail        int      `json:"fail"`
+	Skip        int      `json:"skip"`
+	FailedTests []string `json:"failedTests"`
+	// Coverage maps each package to its statement coverage percentage, and
+	// is only reported for runs with -cover
+	Coverage map[string]float64 `json:"coverage,omitempty"`
+	// TotalCoverage is the percentage of statements covered across every
+	// package, for runs that keep a coverage profile
+	TotalCoverage *float64 `json:"totalCoverage,omitempty"`
+}
+
+// newWebhookPayload returns the payload describing a run of command.
+func newWebhookPayload(
+	command string,
+	summary RunSummary,
+	failed []string,
+	coverage map[string]float64,
+) webhookPayload {
+	status := "pass"
+	if !summary.OK {
+		status = "fail"
+	}
+	if failed == nil {
+		failed = []string{}
+	}
+	return webhookPayload{
+		Status:        status,
+		Command:       command,
+		Duration:      summary.Elapsed,
+		Pass:          summary.Pass,
+		Fail:          summary.Fail,
+		Skip:          summary.Skip,
+		FailedTests:   failed,
+		Coverage:      coverage,
+		TotalCoverage: summary.Coverage,
+	}
+}
+
+// postWebhook posts payload as JSON to url, failing unless the response
+// has a 2xx status.
+func postWebhook(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPostWebhook_PostsPayload tests that the run's result is posted as JSON
+func TestPostWebhook_PostsPayload(t *testing.T) {
+	var body []byte
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		contentType = r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	total := 78.3
+	payload := newWebhookPayload("go test ./... -cover",
+		RunSummary{Pass: 3, Fail: 1, Skip: 2, Elapsed: 4.2, Coverage: &total},
+		[]string{"TestParse"},
+		map[string]float64{"example.com/app": 81.5})
+	require.NoError(t, postWebhook(context.Background(), server.URL, payload))
+
+	assert.Equal(t, "application/json", contentType)
+	assert.JSONEq(t, `{
+		"status": "fail",
+		"command": "go test ./... -cover",
+		"duration": 4.2,
+		"pass": 3,
+		"fail": 1,
+		"skip": 2,
+		"failedTests": ["TestParse"],
+		"coverage": {"example.com/app": 81.5},
+		"totalCoverage": 78.3
+	}`, string(body))
+}
+
+// TestNewWebhookPayload_PassingRun tests the payload of a passing run without coverage
+func TestNewWebhookPayload_PassingRun(t *testing.T) {
+	payload := newWebhookPayload("go test ./...", RunSummary{Pass: 1, Elapsed: 0.5, OK: true}, nil, nil)
+
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"status": "pass",
+		"command": "go test ./...",
+		"duration": 0.5,
+		"pass": 1,
+		"fail": 0,
+		"skip": 0,
+		"failedTests": []
+	}`, string(data))
+}
+
+// TestPostWebhook_ReportsErrorStatus tests that a non-2xx response is an error
+func TestPostWebhook_ReportsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := postWebhook(context.Background(), server.URL, webhookPayload{})
+	assert.EqualError(t, err, "webhook responded with 500 Internal Server Error")
+}
+
+// TestRunTests_PostsWebhook tests that runs post their counts without -v, and that cancelled runs post nothing
+func TestRunTests_PostsWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var posts []webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		mu.Lock()
+		posts = append(posts, payload)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	tempDir := setupTestModule(t, `package hook
+
+import "testing"
+
+func TestOne(t *testing.T) {}
+
+func TestTwo(t *testing.T) {}
+`)
+	config := NewTestConfig()
+	config.SetTestPath(".")
+	config.SetWebhookURL(server.URL)
+	config.WorkingDir = tempDir
+
+	testCompleteChan := make(chan TestCompleteMessage, 1)
+	var stdoutBuf, stderrBuf bytes.Buffer
+	captureStdout(t, func() {
+		go RunTests(WithConfig(context.Background(), config), testCompleteChan, &stdoutBuf, &stderrBuf)
+		waitForTestCompletion(t, testCompleteChan)
+	})
+	deliveries.Wait()
+
+	mu.Lock()
+	require.Len(t, posts, 1)
+	assert.Equal(t, "pass", posts[0].Status)
+	assert.Equal(t, 2, posts[0].Pass)
+	mu.Unlock()
+
+	initRegistry()
+	RegisterRunner("sleeping", commandRunner{"sleep", "10"})
+	config.SetRunner("sleeping")
+	ctx, cancel := context.WithCancel(WithConfig(context.Background(), config))
+	captureStdout(t, func() {
+		go RunTests(ctx, testCompleteChan, &stdoutBuf, &stderrBuf)
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+		waitForTestCompletion(t, testCompleteChan)
+	})
+	deliveries.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, posts, 1, "a cancelled run should not be posted")
+}